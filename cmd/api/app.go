@@ -18,6 +18,7 @@ var (
 	Server   = ConfSockFile
 	Database = ConfDatabase
 	Verbose  = false
+	Confirm  = 0
 )
 
 type App struct {
@@ -53,6 +54,12 @@ func (a *App) Flags() []cli.Flag {
 				Value:   Url,
 			})
 	}
+	flags = append(flags,
+		&cli.IntFlag{
+			Name:  "confirm",
+			Usage: "rollback network changes unless confirmed in seconds",
+			Value: 0,
+		})
 	flags = append(flags,
 		&cli.BoolFlag{
 			Name:    "verbose",
@@ -76,6 +83,7 @@ func (a *App) New() *cli.App {
 				Verbose = false
 				libol.SetLogger("", libol.INFO)
 			}
+			Confirm = c.Int("confirm")
 			if a.Before == nil {
 				return nil
 			}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/ghodss/yaml"
@@ -78,6 +79,9 @@ func OutTable(data any, tmpl string) error {
 		"pb": func(value uint64) string {
 			return libol.PrettyBytes(value)
 		},
+		"join": func(values []string, sep string) string {
+			return strings.Join(values, sep)
+		},
 		"pc": func(value uint64) string {
 			return libol.PrettyBits(value)
 		},
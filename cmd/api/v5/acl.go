@@ -15,6 +15,7 @@ type ACL struct {
 
 func (u ACL) Commands(app *api.App) {
 	rule := ACLRule{}
+	group := ACLGroup{}
 	app.Command(&cli.Command{
 		Name:  "acl",
 		Usage: "Access control list",
@@ -23,6 +24,7 @@ func (u ACL) Commands(app *api.App) {
 		},
 		Subcommands: []*cli.Command{
 			rule.Commands(),
+			group.Commands(),
 		},
 	})
 }
@@ -48,12 +50,18 @@ func (u ACLRule) Add(c *cli.Context) error {
 	}
 
 	rule := &schema.ACLRule{
-		Proto:   c.String("protocol"),
-		SrcIp:   c.String("srcip"),
-		DstIp:   c.String("dstip"),
-		SrcPort: c.Int("sport"),
-		DstPort: c.Int("dport"),
-		Action:  action,
+		Priority:    c.Int("priority"),
+		Proto:       c.String("protocol"),
+		SrcIp:       c.String("srcip"),
+		DstIp:       c.String("dstip"),
+		SrcIdentity: c.String("srcid"),
+		DstIdentity: c.String("dstid"),
+		SrcPorts:    c.String("sport"),
+		DstPorts:    c.String("dport"),
+		Service:     c.String("service"),
+		IcmpType:    c.String("icmp-type"),
+		Action:      action,
+		Schedule:    c.String("schedule"),
 	}
 
 	clt := u.NewHttp(c.String("token"))
@@ -73,12 +81,18 @@ func (u ACLRule) Remove(c *cli.Context) error {
 	}
 
 	rule := &schema.ACLRule{
-		Proto:   c.String("protocol"),
-		SrcIp:   c.String("srcip"),
-		DstIp:   c.String("dstip"),
-		SrcPort: c.Int("sport"),
-		DstPort: c.Int("dport"),
-		Action:  action,
+		Priority:    c.Int("priority"),
+		Proto:       c.String("protocol"),
+		SrcIp:       c.String("srcip"),
+		DstIp:       c.String("dstip"),
+		SrcIdentity: c.String("srcid"),
+		DstIdentity: c.String("dstid"),
+		SrcPorts:    c.String("sport"),
+		DstPorts:    c.String("dport"),
+		Service:     c.String("service"),
+		IcmpType:    c.String("icmp-type"),
+		Action:      action,
+		Schedule:    c.String("schedule"),
 	}
 
 	clt := u.NewHttp(c.String("token"))
@@ -102,9 +116,9 @@ func (u ACLRule) Action(c *cli.Context) (string, error) {
 
 func (u ACLRule) Tmpl() string {
 	return `# total {{ len . }}
-{{ps -15 "srcip"}} {{ps -15 "dstip"}} {{ps -8 "protocol"}} {{ps -5 "dport"}} {{ps -5 "sport"}} {{ps -8 "action"}}
+{{ps -8 "priority"}} {{ps -18 "source"}} {{ps -18 "destination"}} {{ps -8 "protocol"}} {{ps -11 "dport"}} {{ps -11 "sport"}} {{ps -8 "action"}} {{ps -12 "schedule"}} {{ps -6 "active"}} {{ps -10 "packets"}} {{ps -8 "bytes"}}
 {{- range . }}
-{{ps -15 .SrcIp}} {{ps -15 .DstIp}} {{ps -8 .Proto}} {{pi -5 .DstPort}} {{pi -5 .SrcPort}} {{ps -8 .Action}}
+{{pi -8 .Priority}} {{ps -18 (or .SrcIdentity .SrcIp)}} {{ps -18 (or .DstIdentity .DstIp)}} {{ps -8 (or .Service .Proto)}} {{ps -11 (or .IcmpType .DstPorts)}} {{ps -11 .SrcPorts}} {{ps -8 .Action}} {{ps -12 .Schedule}} {{if .Active}}{{ps -6 "yes"}}{{else}}{{ps -6 "no"}}{{end}} {{pi -10 .Packets}} {{pb .Bytes}}
 {{- end }}
 `
 }
@@ -159,9 +173,15 @@ func (u ACLRule) Commands() *cli.Command {
 				Flags: []cli.Flag{
 					&cli.StringFlag{Name: "srcip", Aliases: []string{"s"}},
 					&cli.StringFlag{Name: "dstip", Aliases: []string{"d"}},
+					&cli.StringFlag{Name: "srcid", Usage: "source of user:NAME, group:NAME, role:NAME or posture:restricted"},
+					&cli.StringFlag{Name: "dstid", Usage: "destination of user:NAME, group:NAME, role:NAME or posture:restricted"},
 					&cli.StringFlag{Name: "protocol", Aliases: []string{"p"}},
-					&cli.IntFlag{Name: "sport", Aliases: []string{"sp"}},
-					&cli.IntFlag{Name: "dport", Aliases: []string{"dp"}},
+					&cli.StringFlag{Name: "sport", Aliases: []string{"sp"}, Usage: "port, MIN-MAX or @group"},
+					&cli.StringFlag{Name: "dport", Aliases: []string{"dp"}, Usage: "port, MIN-MAX or @group"},
+					&cli.StringFlag{Name: "service", Usage: "name of a service group"},
+					&cli.StringFlag{Name: "icmp-type"},
+					&cli.StringFlag{Name: "schedule", Usage: "name of a schedule, and always active if not given"},
+					&cli.IntFlag{Name: "priority", Usage: "matched firstly if lower, default 100"},
 					&cli.StringFlag{Name: "action", Aliases: []string{"a"}, Value: "drop"},
 				},
 				Action: u.Add,
@@ -173,9 +193,14 @@ func (u ACLRule) Commands() *cli.Command {
 				Flags: []cli.Flag{
 					&cli.StringFlag{Name: "srcip", Aliases: []string{"s"}},
 					&cli.StringFlag{Name: "dstip", Aliases: []string{"d"}},
+					&cli.StringFlag{Name: "srcid", Usage: "source of user:NAME, group:NAME, role:NAME or posture:restricted"},
+					&cli.StringFlag{Name: "dstid", Usage: "destination of user:NAME, group:NAME, role:NAME or posture:restricted"},
 					&cli.StringFlag{Name: "protocol", Aliases: []string{"p"}},
-					&cli.IntFlag{Name: "sport", Aliases: []string{"sp"}},
-					&cli.IntFlag{Name: "dport", Aliases: []string{"dp"}},
+					&cli.StringFlag{Name: "sport", Aliases: []string{"sp"}, Usage: "port, MIN-MAX or @group"},
+					&cli.StringFlag{Name: "dport", Aliases: []string{"dp"}, Usage: "port, MIN-MAX or @group"},
+					&cli.StringFlag{Name: "service", Usage: "name of a service group"},
+					&cli.StringFlag{Name: "icmp-type"},
+					&cli.StringFlag{Name: "schedule", Usage: "name of a schedule, and always active if not given"},
 					&cli.StringFlag{Name: "action", Aliases: []string{"a"}, Value: "drop"},
 				},
 				Action: u.Remove,
@@ -200,3 +225,101 @@ func (u ACLRule) Commands() *cli.Command {
 		},
 	}
 }
+
+type ACLGroup struct {
+	Cmd
+}
+
+func (u ACLGroup) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/acl/group"
+}
+
+func (u ACLGroup) Add(c *cli.Context) error {
+	name := c.String("name")
+	url := u.Url(c.String("url"), name)
+
+	group := &schema.ACLGroup{
+		Name: c.String("group"),
+		Type: c.String("type"),
+	}
+	if members := c.String("member"); members != "" {
+		group.Members = strings.Split(members, ",")
+	}
+
+	clt := u.NewHttp(c.String("token"))
+	if err := clt.PostJSON(url, group, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u ACLGroup) Remove(c *cli.Context) error {
+	name := c.String("name")
+	url := u.Url(c.String("url"), name) + "/" + c.String("group")
+
+	clt := u.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, nil, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (u ACLGroup) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -16 "name"}} {{ps -8 "type"}} {{ps -15 "members"}}
+{{- range . }}
+{{ps -16 .Name}} {{ps -8 .Type}} {{ps -15 (join .Members ",")}}
+{{- end }}
+`
+}
+
+func (u ACLGroup) List(c *cli.Context) error {
+	name := c.String("name")
+
+	url := u.Url(c.String("url"), name)
+	clt := u.NewHttp(c.String("token"))
+
+	var items []schema.ACLGroup
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u ACLGroup) Commands() *cli.Command {
+	return &cli.Command{
+		Name:   "group",
+		Usage:  "Object group of addresses, ports or services",
+		Action: u.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:  "add",
+				Usage: "Add or update an object group",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "group", Aliases: []string{"g"}},
+					&cli.StringFlag{Name: "type", Aliases: []string{"t"}, Value: "address", Usage: "address, port or service"},
+					&cli.StringFlag{Name: "member", Aliases: []string{"m"}, Usage: "members separated by comma, like tcp:80,udp:53 for service"},
+				},
+				Action: u.Add,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Remove an object group",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "group", Aliases: []string{"g"}},
+				},
+				Action: u.Remove,
+			},
+			{
+				Name:    "list",
+				Usage:   "Display all object groups",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+		},
+	}
+}
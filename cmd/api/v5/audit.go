@@ -0,0 +1,88 @@
+package v5
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/luscis/openlan/cmd/api"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Audit struct {
+	Cmd
+}
+
+func (u Audit) Url(prefix string) string {
+	return prefix + "/api/audit"
+}
+
+func (u Audit) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -20 "time"}} {{ps -8 "type"}} {{ps -24 "user"}} {{ps -22 "source"}} {{ps -12 "network"}} {{ps -8 "result"}} {{ps -32 "action"}} {{"detail"}}
+{{- range . }}
+{{ut .Time}} {{ps -8 .Type}} {{ps -24 .User}} {{ps -22 .Source}} {{ps -12 .Network}} {{ps -8 .Result}} {{ps -32 .Action}} {{.Detail}}
+{{- end }}
+`
+}
+
+// Time parses a duration ago like 1h, or a local time like 2006-01-02 15:04:05.
+func (u Audit) Time(value string) (string, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return strconv.FormatInt(time.Now().Add(-d).Unix(), 10), nil
+	}
+	t, err := libol.GetLocalTime(libol.SimpleTime, value)
+	if err != nil {
+		return "", libol.NewErr("invalid time %q", value)
+	}
+	return strconv.FormatInt(t.Unix(), 10), nil
+}
+
+func (u Audit) List(c *cli.Context) error {
+	query := url.Values{}
+	for _, name := range []string{"type", "user", "network", "action", "result"} {
+		if value := c.String(name); value != "" {
+			query.Set(name, value)
+		}
+	}
+	for _, name := range []string{"since", "until"} {
+		if value := c.String(name); value != "" {
+			at, err := u.Time(value)
+			if err != nil {
+				return err
+			}
+			query.Set(name, at)
+		}
+	}
+	if limit := c.Int("limit"); limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	url := u.Url(c.String("url")) + "?" + query.Encode()
+	clt := u.NewHttp(c.String("token"))
+
+	var items []schema.Audit
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u Audit) Commands(app *api.App) {
+	app.Command(&cli.Command{
+		Name:   "audit",
+		Usage:  "Audit trail of admin and user actions",
+		Action: u.List,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "type", Usage: "api, login, openvpn, lease or ztrust"},
+			&cli.StringFlag{Name: "user"},
+			&cli.StringFlag{Name: "network"},
+			&cli.StringFlag{Name: "action", Usage: "action or route contains"},
+			&cli.StringFlag{Name: "result", Usage: "success or failure"},
+			&cli.StringFlag{Name: "since", Usage: "duration ago like 1h, or time like '2006-01-02 15:04:05'"},
+			&cli.StringFlag{Name: "until", Usage: "duration ago like 1h, or time like '2006-01-02 15:04:05'"},
+			&cli.IntFlag{Name: "limit", Value: 100},
+		},
+	})
+}
@@ -10,7 +10,8 @@ import (
 // openlan bgp disable
 //
 // openlan bgp neighbor add --address 1.1.1.2 --remote-as 32
-// openlan bgp neighbor add --address 1.1.1.3 --remote-as 33
+// openlan bgp neighbor add --address 1.1.1.3 --remote-as 33 --localpref 200 --prepend 2 --bfd
+// openlan bgp neighbor route --address 1.1.1.2
 //
 // openlan bgp advertis add --neighbor 1.1.1.2 --prefix 192.168.1.0/24
 // openlan bgp receives add --neighbor 1.1.1.2 --prefix 192.168.2.0/24
@@ -113,9 +114,15 @@ func (s Neighbor) Url(prefix string) string {
 
 func (s Neighbor) Add(c *cli.Context) error {
 	data := &schema.BgpNeighbor{
-		RemoteAs: c.Int("remote-as"),
-		Address:  c.String("address"),
-		Password: c.String("password"),
+		RemoteAs:    c.Int("remote-as"),
+		Address:     c.String("address"),
+		Password:    c.String("password"),
+		LocalPref:   c.Int("localpref"),
+		Med:         c.Int("med"),
+		Prepend:     c.Int("prepend"),
+		Communities: c.StringSlice("community"),
+		Bfd:         c.Bool("bfd"),
+		Graceful:    c.Bool("graceful-restart"),
 	}
 	url := s.Url(c.String("url"))
 	clt := s.NewHttp(c.String("token"))
@@ -137,11 +144,69 @@ func (s Neighbor) Remove(c *cli.Context) error {
 	return nil
 }
 
+func (s Neighbor) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -15 "Address"}} {{ps -8 "RemoteAs"}} {{ps -12 "State"}} {{ps -12 "Uptime"}} {{ps -8 "Received"}} {{ps -8 "Sent"}} {{ps -6 "BFD"}} {{"Policy"}}
+{{- range . }}
+{{ps -15 .Address}} {{pi -8 .RemoteAs}} {{ps -12 .State}} {{pt .Uptime | ps -12}} {{pi -8 .Received}} {{pi -8 .Sent}} {{ if .Bfd }}{{ps -6 .BfdState}}{{ else }}{{ps -6 "-"}}{{ end }}
+{{- if .LocalPref }} localpref:{{.LocalPref}}{{ end }}
+{{- if .Med }} med:{{.Med}}{{ end }}
+{{- if .Prepend }} prepend:{{.Prepend}}{{ end }}
+{{- if .Communities }} community:{{.Communities}}{{ end }}
+{{- if .Graceful }} graceful-restart{{ end }}
+{{- end }}
+`
+}
+
+func (s Neighbor) List(c *cli.Context) error {
+	url := BGP{}.Url(c.String("url"))
+	clt := s.NewHttp(c.String("token"))
+	var data schema.Bgp
+	if err := clt.GetJSON(url, &data); err != nil {
+		return err
+	}
+	return s.Out(data.Neighbors, c.String("format"), s.Tmpl())
+}
+
+func (s Neighbor) RouteTmpl() string {
+	return `# total {{ len . }}
+{{ps -2 ""}}{{ps -20 "Prefix"}} {{ps -15 "NextHop"}} {{ps -8 "LocPrf"}} {{ps -8 "Med"}} {{"Path"}}
+{{- range . }}
+{{ if .Best }}{{ps -2 ">"}}{{ else }}{{ps -2 ""}}{{ end }}{{ps -20 .Prefix}} {{ps -15 .NextHop}} {{pi -8 .LocalPref}} {{pi -8 .Med}} {{.Path}}
+{{- end }}
+`
+}
+
+func (s Neighbor) Routes(c *cli.Context) error {
+	url := s.Url(c.String("url")) + "/" + c.String("address") + "/route"
+	clt := s.NewHttp(c.String("token"))
+	var items []schema.BgpRoute
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return s.Out(items, c.String("format"), s.RouteTmpl())
+}
+
 func (s Neighbor) Commands() *cli.Command {
 	return &cli.Command{
-		Name:  "neighbor",
-		Usage: "BGP neighbor",
+		Name:   "neighbor",
+		Usage:  "BGP neighbor",
+		Action: s.List,
 		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display BGP neighbors with session state",
+				Aliases: []string{"ls"},
+				Action:  s.List,
+			},
+			{
+				Name:  "route",
+				Usage: "Display routes received from BGP neighbor",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "address", Required: true},
+				},
+				Action: s.Routes,
+			},
 			{
 				Name:  "add",
 				Usage: "Add BGP neighbor",
@@ -149,6 +214,12 @@ func (s Neighbor) Commands() *cli.Command {
 					&cli.StringFlag{Name: "address", Required: true},
 					&cli.IntFlag{Name: "remote-as", Required: true},
 					&cli.StringFlag{Name: "password"},
+					&cli.IntFlag{Name: "localpref", Usage: "local preference of received routes"},
+					&cli.IntFlag{Name: "med", Usage: "MED of advertised routes"},
+					&cli.IntFlag{Name: "prepend", Usage: "times of local AS prepended to advertised routes"},
+					&cli.StringSliceFlag{Name: "community", Usage: "community of advertised routes, e.g. 65001:100"},
+					&cli.BoolFlag{Name: "bfd", Usage: "enable BFD for neighbor"},
+					&cli.BoolFlag{Name: "graceful-restart", Usage: "enable graceful restart for neighbor"},
 				},
 				Action: s.Add,
 			},
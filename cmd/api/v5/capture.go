@@ -0,0 +1,126 @@
+package v5
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/luscis/openlan/cmd/api"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Capture struct {
+	Cmd
+}
+
+func (u Capture) Url(prefix, name string) string {
+	if name == "" {
+		return prefix + "/api/capture"
+	}
+	return prefix + "/api/capture/" + name
+}
+
+func (u Capture) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -8 "id"}} {{ps -16 "network"}} {{ps -16 "device"}} {{ps -8 "packets"}} {{ps -8 "count"}} {{ps -8 "seconds"}} {{ps -20 "createAt"}} {{"filter"}}
+{{- range . }}
+{{ps -8 .Id}} {{ps -16 .Network}} {{ps -16 .Device}} {{pi -8 .Packets}} {{pi -8 .Count}} {{pi -8 .Seconds}} {{ut .CreateAt}} {{.Filter}}
+{{- end }}
+`
+}
+
+func (u Capture) Add(c *cli.Context) error {
+	data := schema.Capture{
+		Network: c.String("name"),
+		Client:  c.String("client"),
+		Output:  c.String("output"),
+		Filter:  c.String("filter"),
+		Count:   c.Int("count"),
+		Seconds: c.Int("seconds"),
+		File:    c.String("file"),
+	}
+	if data.Network == "" {
+		return libol.NewErr("invalid network")
+	}
+	url := u.Url(c.String("url"), "")
+	clt := u.NewHttp(c.String("token"))
+	if data.File != "" {
+		var state schema.CaptureState
+		if err := clt.PostJSON(url, data, &state); err != nil {
+			return err
+		}
+		return u.Out([]schema.CaptureState{state}, c.String("format"), u.Tmpl())
+	}
+	var out io.Writer = os.Stdout
+	if write := c.String("write"); write != "" && write != "-" {
+		fp, err := os.Create(write)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+		out = fp
+	}
+	// waiting for the capture finished by switch.
+	timeout := time.Duration(data.Seconds+30) * time.Second
+	if data.Seconds <= 0 {
+		timeout = 90 * time.Second
+	}
+	return clt.PostStream(url, data, out, timeout)
+}
+
+func (u Capture) List(c *cli.Context) error {
+	url := u.Url(c.String("url"), "")
+	clt := u.NewHttp(c.String("token"))
+	var items []schema.CaptureState
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u Capture) Remove(c *cli.Context) error {
+	id := c.String("id")
+	if id == "" {
+		return libol.NewErr("invalid id")
+	}
+	url := u.Url(c.String("url"), id)
+	clt := u.NewHttp(c.String("token"))
+	return clt.DeleteJSON(url, nil, nil)
+}
+
+func (u Capture) Commands(app *api.App) {
+	app.Command(&cli.Command{
+		Name:   "capture",
+		Usage:  "Capture packets on a network, an access client or an output",
+		Action: u.Add,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Usage: "network"},
+			&cli.StringFlag{Name: "client", Usage: "alias or address of access client"},
+			&cli.StringFlag{Name: "output", Usage: "device of output"},
+			&cli.StringFlag{Name: "filter", Aliases: []string{"f"}, Usage: "e.g. 'host 10.0.0.1 and udp port 53'"},
+			&cli.IntFlag{Name: "count", Aliases: []string{"c"}, Usage: "stop after packets"},
+			&cli.IntFlag{Name: "seconds", Aliases: []string{"s"}, Usage: "stop after seconds"},
+			&cli.StringFlag{Name: "write", Aliases: []string{"w"}, Usage: "local pcap file, and default is stdout"},
+			&cli.StringFlag{Name: "file", Usage: "pcap file saved on the switch"},
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display running captures",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Stop a running capture",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "id", Required: true},
+				},
+				Action: u.Remove,
+			},
+		},
+	})
+}
@@ -3,9 +3,11 @@ package v5
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/luscis/openlan/cmd/api"
 	"github.com/luscis/openlan/pkg/libol"
@@ -54,6 +56,13 @@ func (cl Client) JSON(client *libol.HttpClient, i, o interface{}) error {
 	out.Debug("Client.JSON -> %s %s", client.Method, client.Url)
 	out.Debug("Client.JSON -> %s", string(data))
 	client.Payload = bytes.NewReader(data)
+	if api.Confirm > 0 && client.Method != "GET" {
+		sep := "?"
+		if strings.Contains(client.Url, "?") {
+			sep = "&"
+		}
+		client.Url += fmt.Sprintf("%sconfirm=%d", sep, api.Confirm)
+	}
 	if r, err := client.Do(); err != nil {
 		return err
 	} else {
@@ -98,6 +107,29 @@ func (cl Client) DeleteJSON(url string, i, o interface{}) error {
 	return cl.JSON(client, i, o)
 }
 
+// PostStream copies the body of response to w, e.g. pcap of capture.
+func (cl Client) PostStream(url string, i interface{}, w io.Writer, timeout time.Duration) error {
+	data, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	client := cl.NewRequest(url)
+	client.Method = "POST"
+	client.Payload = bytes.NewReader(data)
+	client.Timeout = timeout
+	r, err := client.Do()
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(r.Body)
+		return libol.NewErr("%s %s", r.Status, body)
+	}
+	_, err = io.Copy(w, r.Body)
+	return err
+}
+
 func (cl Client) Log() *libol.SubLogger {
 	return libol.NewSubLogger("cli")
 }
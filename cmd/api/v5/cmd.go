@@ -38,6 +38,8 @@ func Commands(app *api.App) {
 	Server{}.Commands(app)
 
 	Log{}.Commands(app)
+	Audit{}.Commands(app)
+	Lockout{}.Commands(app)
 	ZTrust{}.Commands(app)
 	RateLimit{}.Commands(app)
 	Crypt{}.Commands(app)
@@ -48,4 +50,9 @@ func Commands(app *api.App) {
 	IPSec{}.Commands(app)
 	Router{}.Commands(app)
 	Reload{}.Commands(app)
+	Confirm{}.Commands(app)
+	Capture{}.Commands(app)
+	Schedule{}.Commands(app)
+	Lease{}.Commands(app)
+	Traffic{}.Commands(app)
 }
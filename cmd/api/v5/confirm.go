@@ -0,0 +1,94 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/cmd/api"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Confirm struct {
+	Cmd
+}
+
+func (u Confirm) Url(prefix, name string) string {
+	if name == "" {
+		return prefix + "/api/confirm"
+	}
+	return prefix + "/api/network/" + name + "/confirm"
+}
+
+func (u Confirm) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -16 "network"}} {{ps -8 "timeout"}} {{ps -8 "remain"}} {{ps -20 "createAt"}}
+{{- range . }}
+{{ps -16 .Network}} {{pi -8 .Timeout}} {{pi -8 .Remain}} {{ut .CreateAt}}
+{{- end }}
+`
+}
+
+func (u Confirm) List(c *cli.Context) error {
+	url := u.Url(c.String("url"), "")
+	clt := u.NewHttp(c.String("token"))
+
+	var items []schema.Confirm
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u Confirm) Commit(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" {
+		return libol.NewErr("invalid network")
+	}
+	url := u.Url(c.String("url"), name)
+	clt := u.NewHttp(c.String("token"))
+	if err := clt.PostJSON(url, nil, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u Confirm) Rollback(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" {
+		return libol.NewErr("invalid network")
+	}
+	url := u.Url(c.String("url"), name)
+	clt := u.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, nil, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u Confirm) Commands(app *api.App) {
+	app.Command(&cli.Command{
+		Name:   "confirm",
+		Usage:  "Pending changes waiting for a confirm",
+		Action: u.List,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name", Aliases: []string{"n"}},
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display all pending changes",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+			{
+				Name:   "commit",
+				Usage:  "Confirm the pending change of a network",
+				Action: u.Commit,
+			},
+			{
+				Name:   "rollback",
+				Usage:  "Rollback the pending change of a network",
+				Action: u.Rollback,
+			},
+		},
+	})
+}
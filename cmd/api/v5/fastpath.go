@@ -0,0 +1,55 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type FastPath struct {
+	Cmd
+}
+
+func (f FastPath) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/fastpath"
+}
+
+func (f FastPath) Tmpl() string {
+	return `# uplink {{ps -16 .Uplink}} ports {{.Ports}}
+# forwarded {{.Forwarded}} flooded {{.Flooded}} toKernel {{.ToKernel}} dropped {{.Dropped}}
+{{ps -17 "address"}} {{ps -16 "port"}} {{ps -24 "client"}} {{"age"}}
+{{- range .Macs }}
+{{ps -17 .Address}} {{ps -16 .Port}} {{ps -24 .Client}} {{.Age}}
+{{- end }}
+`
+}
+
+func (f FastPath) List(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	url := f.Url(c.String("url"), network)
+	clt := f.NewHttp(c.String("token"))
+	var item schema.FastPath
+	if err := clt.GetJSON(url, &item); err != nil {
+		return err
+	}
+	return f.Out(item, c.String("format"), f.Tmpl())
+}
+
+func (f FastPath) Commands() *cli.Command {
+	return &cli.Command{
+		Name:   "fastpath",
+		Usage:  "Userspace switching of access clients",
+		Action: f.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display MAC table and counters",
+				Aliases: []string{"ls"},
+				Action:  f.List,
+			},
+		},
+	}
+}
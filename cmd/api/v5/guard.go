@@ -0,0 +1,54 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Guard struct {
+	Cmd
+}
+
+func (g Guard) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/guard"
+}
+
+func (g Guard) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -24 "alias"}} {{ps -22 "client"}} {{ps -15 "address"}} {{ps -17 "mac"}} {{ps -20 "lastAt"}} {{"dropped"}}
+{{- range . }}
+{{ps -24 .Alias}} {{ps -22 .Client}} {{ps -15 .Address}} {{ps -17 .Mac}} {{ if .LastAt }}{{ut .LastAt}}{{ else }}{{ps -20 "-"}}{{ end }} {{ range $k, $v := .Dropped }}{{$k}}:{{$v}} {{ end }}
+{{- end }}
+`
+}
+
+func (g Guard) List(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	url := g.Url(c.String("url"), network)
+	clt := g.NewHttp(c.String("token"))
+	var items []schema.GuardBinding
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return g.Out(items, c.String("format"), g.Tmpl())
+}
+
+func (g Guard) Commands() *cli.Command {
+	return &cli.Command{
+		Name:   "guard",
+		Usage:  "Source guard of access clients",
+		Action: g.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display bindings and dropped frames",
+				Aliases: []string{"ls"},
+				Action:  g.List,
+			},
+		},
+	}
+}
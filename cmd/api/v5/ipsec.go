@@ -2,6 +2,7 @@ package v5
 
 import (
 	"github.com/luscis/openlan/cmd/api"
+	"github.com/luscis/openlan/pkg/libol"
 	"github.com/luscis/openlan/pkg/schema"
 	"github.com/urfave/cli/v2"
 )
@@ -72,12 +73,17 @@ func (o IPSecTunnel) Add(c *cli.Context) error {
 	output := &schema.IPSecTunnel{
 		Right:     c.String("remote"),
 		Secret:    c.String("secret"),
+		Cert:      c.String("cert"),
+		Ike:       c.String("ike"),
 		Transport: c.String("protocol"),
 		LeftId:    c.String("localid"),
 		RightId:   c.String("remoteid"),
 		LeftPort:  c.Int("localport"),
 		RightPort: c.Int("remoteport"),
 	}
+	if output.Secret == "" && output.Cert == "" {
+		return libol.NewErr("secret or cert is required")
+	}
 	url := o.Url(c.String("url"), "")
 	clt := o.NewHttp(c.String("token"))
 	if err := clt.PostJSON(url, output, nil); err != nil {
@@ -114,9 +120,9 @@ func (o IPSecTunnel) Restart(c *cli.Context) error {
 
 func (o IPSecTunnel) Tmpl() string {
 	return `# total {{ len . }}
-{{ps -15 "Remote"}} {{ps -15 "Protocol"}} {{ps -15 "Secret"}} {{ps -15 "Connection"}} {{ps -8 "state"}}
+{{ps -15 "Remote"}} {{ps -15 "Protocol"}} {{ps -6 "Ike"}} {{ps -15 "Secret"}} {{ps -15 "Connection"}} {{ps -8 "state"}} {{ps -4 "SAs"}} {{"Error"}}
 {{- range . }}
-{{ps -15 .Right}} {{ps -15 .Transport }} {{ps -15 .Secret}} [{{.LeftId}}]{{.LeftPort}} -> [{{.RightId}}]{{.RightPort}} {{ps -8 .State}}
+{{ps -15 .Right}} {{ps -15 .Transport }} {{ps -6 .Ike}} {{ if .Cert }}{{ps -15 .Cert}}{{ else }}{{ps -15 .Secret}}{{ end }} [{{.LeftId}}]{{.LeftPort}} -> [{{.RightId}}]{{.RightPort}} {{ps -8 .State}} {{pi -4 (len .Sas)}} {{.Error}}
 {{- end }}
 `
 }
@@ -131,6 +137,38 @@ func (o IPSecTunnel) List(c *cli.Context) error {
 	return o.Out(items, c.String("format"), o.Tmpl())
 }
 
+func (o IPSecTunnel) SaTmpl() string {
+	return `# total {{ len . }}
+{{ps -15 "Tunnel"}} {{ps -6 "Serial"}} {{ps -24 "Conn"}} {{ps -6 "Type"}} {{ps -20 "State"}} {{ps -8 "Rekey"}} {{ps -12 "InBytes"}} {{ps -12 "OutBytes"}} {{ps -10 "InPkts"}} {{ps -10 "OutPkts"}}
+{{- range . }}
+{{ps -15 .Name}} {{pi -6 .Serial}} {{ps -24 .Conn}} {{ps -6 .Type}} {{ps -20 .State}} {{pi -8 .Rekey}} {{pi -12 .InBytes}} {{pi -12 .OutBytes}} {{pi -10 .InPackets}} {{pi -10 .OutPackets}}
+{{- end }}
+`
+}
+
+func (o IPSecTunnel) ListSa(c *cli.Context) error {
+	url := o.Url(c.String("url"), "")
+	clt := o.NewHttp(c.String("token"))
+	var items []schema.IPSecTunnel
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	type sa struct {
+		schema.IPSecSA
+		Name string `json:"name"`
+	}
+	sas := make([]sa, 0, 32)
+	for _, tun := range items {
+		if c.String("remote") != "" && tun.Right != c.String("remote") {
+			continue
+		}
+		for _, obj := range tun.Sas {
+			sas = append(sas, sa{IPSecSA: obj, Name: tun.Right + "-" + tun.Transport})
+		}
+	}
+	return o.Out(sas, c.String("format"), o.SaTmpl())
+}
+
 func (o IPSecTunnel) Commands() *cli.Command {
 	return &cli.Command{
 		Name:    "tunnel",
@@ -146,7 +184,9 @@ func (o IPSecTunnel) Commands() *cli.Command {
 					&cli.StringFlag{Name: "remoteid", Required: true},
 					&cli.IntFlag{Name: "remoteport"},
 					&cli.StringFlag{Name: "protocol", Value: "vxlan"},
-					&cli.StringFlag{Name: "secret", Required: true},
+					&cli.StringFlag{Name: "secret"},
+					&cli.StringFlag{Name: "cert", Usage: "nickname of local certificate"},
+					&cli.StringFlag{Name: "ike", Value: "ikev1", Usage: "ikev1 or ikev2"},
 					&cli.StringFlag{Name: "localid", Required: true},
 					&cli.IntFlag{Name: "localport"},
 				},
@@ -178,6 +218,14 @@ func (o IPSecTunnel) Commands() *cli.Command {
 				Flags:   []cli.Flag{},
 				Action:  o.List,
 			},
+			{
+				Name:  "sa",
+				Usage: "Display security associations of ipsec tunnel",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "remote"},
+				},
+				Action: o.ListSa,
+			},
 		},
 	}
 }
@@ -0,0 +1,148 @@
+package v5
+
+import (
+	"net/url"
+
+	"github.com/luscis/openlan/cmd/api"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Lease struct {
+	Cmd
+}
+
+func (u Lease) Url(prefix, name string) string {
+	if name == "" {
+		return prefix + "/api/lease"
+	}
+	return prefix + "/api/lease/" + name
+}
+
+func (u Lease) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -16 "network"}} {{ps -24 "alias"}} {{ps -15 "address"}} {{ps -8 "type"}} {{ps -8 "state"}} {{ps -22 "client"}} {{ps -20 "expireAt"}}
+{{- range . }}
+{{ps -16 .Network}} {{ps -24 .Alias}} {{ps -15 .Address}} {{ps -8 .Type}} {{ps -8 .State}} {{ps -22 .Client}} {{ if .ExpireAt }}{{ut .ExpireAt}}{{ else }}-{{ end }}
+{{- end }}
+`
+}
+
+func (u Lease) List(c *cli.Context) error {
+	url := u.Url(c.String("url"), c.String("name"))
+	clt := u.NewHttp(c.String("token"))
+
+	var items []schema.Lease
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u Lease) Add(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" {
+		return libol.NewErr("invalid network")
+	}
+	value := &schema.Lease{
+		Network: name,
+		Alias:   c.String("alias"),
+		Address: c.String("address"),
+	}
+	url := u.Url(c.String("url"), "")
+	clt := u.NewHttp(c.String("token"))
+	if err := clt.PostJSON(url, value, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u Lease) Remove(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" {
+		return libol.NewErr("invalid network")
+	}
+	value := &schema.Lease{
+		Network: name,
+		Alias:   c.String("alias"),
+	}
+	url := u.Url(c.String("url"), "")
+	clt := u.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, value, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u Lease) HistoryTmpl() string {
+	return `# total {{ len . }}
+{{ps -20 "time"}} {{ps -8 "action"}} {{ps -16 "network"}} {{ps -24 "alias"}} {{ps -15 "address"}} {{ps -22 "client"}} {{"reason"}}
+{{- range . }}
+{{ut .Time}} {{ps -8 .Action}} {{ps -16 .Network}} {{ps -24 .Alias}} {{ps -15 .Address}} {{ps -22 .Client}} {{.Reason}}
+{{- end }}
+`
+}
+
+func (u Lease) History(c *cli.Context) error {
+	query := url.Values{}
+	if name := c.String("name"); name != "" {
+		query.Set("network", name)
+	}
+	if alias := c.String("alias"); alias != "" {
+		query.Set("alias", alias)
+	}
+	url := u.Url(c.String("url"), "history") + "?" + query.Encode()
+	clt := u.NewHttp(c.String("token"))
+
+	var items []schema.LeaseEvent
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.HistoryTmpl())
+}
+
+func (u Lease) Commands(app *api.App) {
+	app.Command(&cli.Command{
+		Name:   "lease",
+		Usage:  "Address leases of networks",
+		Action: u.List,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name", Aliases: []string{"n"}},
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display all leases",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+			{
+				Name:  "add",
+				Usage: "Reserve an address for an alias",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "alias", Required: true},
+					&cli.StringFlag{Name: "address", Required: true},
+				},
+				Action: u.Add,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Remove the lease of an alias",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "alias", Required: true},
+				},
+				Action: u.Remove,
+			},
+			{
+				Name:  "history",
+				Usage: "Display history of leases",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "alias"},
+				},
+				Action: u.History,
+			},
+		},
+	})
+}
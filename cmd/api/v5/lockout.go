@@ -0,0 +1,77 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/cmd/api"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Lockout struct {
+	Cmd
+}
+
+func (u Lockout) Url(prefix, name string) string {
+	if name == "" {
+		return prefix + "/api/lockout"
+	}
+	return prefix + "/api/lockout/" + name
+}
+
+func (u Lockout) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -32 "key"}} {{ps -6 "failed"}} {{ps -7 "blocked"}} {{ps -20 "last"}} {{"until"}}
+{{- range . }}
+{{ps -32 .Key}} {{pi -6 .Failed}} {{ if .Blocked }}{{ps -7 "yes"}}{{ else }}{{ps -7 "no"}}{{ end }} {{ut .Last}} {{ if .Until }}{{ut .Until}}{{ else }}-{{ end }}
+{{- end }}
+`
+}
+
+func (u Lockout) List(c *cli.Context) error {
+	url := u.Url(c.String("url"), "")
+	clt := u.NewHttp(c.String("token"))
+
+	var items []schema.Lockout
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u Lockout) Clear(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" && !c.Bool("all") {
+		return libol.NewErr("invalid name, or clear --all")
+	}
+	url := u.Url(c.String("url"), name)
+	clt := u.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, nil, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u Lockout) Commands(app *api.App) {
+	app.Command(&cli.Command{
+		Name:   "lockout",
+		Usage:  "Lockout of failed logins",
+		Action: u.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display users and sources locked out",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+			{
+				Name:  "clear",
+				Usage: "Clear lockout of an user or source",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Usage: "user like hi@default, or address of source"},
+					&cli.BoolFlag{Name: "all"},
+				},
+				Action: u.Clear,
+			},
+		},
+	})
+}
@@ -0,0 +1,54 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Mesh struct {
+	Cmd
+}
+
+func (m Mesh) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/mesh"
+}
+
+func (m Mesh) Tmpl() string {
+	return `# {{ .Alias }} in {{ .Name }} with root {{ .Root }}
+{{ps -16 "alias"}} {{ps -16 "address"}} {{ps -16 "device"}} {{ps -4 "dir"}} {{ps -12 "state"}} {{ps -16 "status"}} {{ps -8 "uptime"}}
+{{- range .Peers }}
+{{ps -16 .Alias}} {{ps -16 .Address}} {{ps -16 .Device}} {{ps -4 .Direction}} {{ps -12 .State}} {{ps -16 .Status}} {{ut .Uptime}}
+{{- end }}
+`
+}
+
+func (m Mesh) List(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	url := m.Url(c.String("url"), network)
+	clt := m.NewHttp(c.String("token"))
+	var item schema.Mesh
+	if err := clt.GetJSON(url, &item); err != nil {
+		return err
+	}
+	return m.Out(item, c.String("format"), m.Tmpl())
+}
+
+func (m Mesh) Commands() *cli.Command {
+	return &cli.Command{
+		Name:   "mesh",
+		Usage:  "Switch mesh",
+		Action: m.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display peers and forwarding tree",
+				Aliases: []string{"ls"},
+				Action:  m.List,
+			},
+		},
+	}
+}
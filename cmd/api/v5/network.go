@@ -179,6 +179,13 @@ func (u Network) Commands(app *api.App) {
 			SNAT{}.Commands(),
 			DHCP{}.Commands(),
 			DNAT{}.Commands(),
+			VxLAN{}.Commands(),
+			Mesh{}.Commands(),
+			WireGuard{}.Commands(),
+			Guard{}.Commands(),
+			FastPath{}.Commands(),
+			Suppress{}.Commands(),
+			Quota{}.Commands(),
 		},
 	})
 }
@@ -656,6 +663,7 @@ func (q ClientQoS) Commands() *cli.Command {
 		Usage: "QoS for client",
 		Subcommands: []*cli.Command{
 			QosRule{}.Commands(),
+			QosPriority{}.Commands(),
 		},
 	}
 }
@@ -678,8 +686,13 @@ func (qr QosRule) Add(c *cli.Context) error {
 		fullname = client + "@" + name
 	}
 	rule := &schema.Qos{
-		Name:    fullname,
-		InSpeed: c.Float64("inspeed"),
+		Name:     fullname,
+		InSpeed:  c.Float64("inspeed"),
+		InCeil:   c.Float64("inceil"),
+		OutSpeed: c.Float64("outspeed"),
+		OutCeil:  c.Float64("outceil"),
+		Burst:    c.Int("burst"),
+		Schedule: c.String("schedule"),
 	}
 
 	clt := qr.NewHttp(c.String("token"))
@@ -711,9 +724,9 @@ func (qr QosRule) Remove(c *cli.Context) error {
 
 func (qr QosRule) Tmpl() string {
 	return `# total {{ len . }}
-{{ps -28 "Name"}} {{ps -10 "Device"}} {{ps -15 "Ip"}} {{ps -8 "InSpeed"}}
+{{ps -28 "Name"}} {{ps -10 "Device"}} {{ps -15 "Ip"}} {{ps -8 "InSpeed"}} {{ps -8 "InCeil"}} {{ps -8 "OutSpeed"}} {{ps -8 "OutCeil"}} {{ps -8 "Burst"}} {{ps -12 "Schedule"}} {{"Active"}}
 {{- range . }}
-{{ps -28 .Name}} {{ps -10 .Device}} {{ps -15 .Ip}} {{pf -8 2 .InSpeed}}
+{{ps -28 .Name}} {{ps -10 .Device}} {{ps -15 .Ip}} {{pf -8 2 .InSpeed}} {{pf -8 2 .InCeil}} {{pf -8 2 .OutSpeed}} {{pf -8 2 .OutCeil}} {{pi -8 .Burst}} {{ps -12 .Schedule}} {{if .Active}}{{"yes"}}{{else}}{{"no"}}{{end}}
 {{- end }}
 `
 }
@@ -754,7 +767,12 @@ func (qr QosRule) Commands() *cli.Command {
 				Usage: "Add a new qos rule for client",
 				Flags: []cli.Flag{
 					&cli.StringFlag{Name: "client", Aliases: []string{"c"}},
-					&cli.Float64Flag{Name: "inspeed", Aliases: []string{"is"}},
+					&cli.Float64Flag{Name: "inspeed", Aliases: []string{"is"}, Usage: "Mbit of upload"},
+					&cli.Float64Flag{Name: "inceil", Usage: "Mbit of upload borrowed up to"},
+					&cli.Float64Flag{Name: "outspeed", Aliases: []string{"os"}, Usage: "Mbit of download"},
+					&cli.Float64Flag{Name: "outceil", Usage: "Mbit of download borrowed up to"},
+					&cli.IntFlag{Name: "burst", Usage: "KiB"},
+					&cli.StringFlag{Name: "schedule", Usage: "name of a schedule, and always limited if not given"},
 				},
 				Action: qr.Add,
 			},
@@ -782,3 +800,96 @@ func (qr QosRule) Commands() *cli.Command {
 		},
 	}
 }
+
+type QosPriority struct {
+	Cmd
+}
+
+func (qp QosPriority) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/qos/priority"
+}
+
+func (qp QosPriority) Add(c *cli.Context) error {
+	name := c.String("name")
+	url := qp.Url(c.String("url"), name)
+	rule := &schema.QosPriority{
+		Name:     c.String("priority"),
+		Dscp:     c.IntSlice("dscp"),
+		Protocol: c.String("protocol"),
+		Ports:    c.StringSlice("port"),
+	}
+	clt := qp.NewHttp(c.String("token"))
+	if err := clt.PostJSON(url, rule, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (qp QosPriority) Remove(c *cli.Context) error {
+	name := c.String("name")
+	url := qp.Url(c.String("url"), name)
+	rule := &schema.QosPriority{
+		Name: c.String("priority"),
+	}
+	clt := qp.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, rule, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (qp QosPriority) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -16 "Name"}} {{ps -16 "Dscp"}} {{ps -8 "Protocol"}} {{"Ports"}}
+{{- range . }}
+{{ps -16 .Name}} {{ps -16 (printf "%v" .Dscp)}} {{ps -8 .Protocol}} {{printf "%v" .Ports}}
+{{- end }}
+`
+}
+
+func (qp QosPriority) List(c *cli.Context) error {
+	name := c.String("name")
+	url := qp.Url(c.String("url"), name)
+	clt := qp.NewHttp(c.String("token"))
+
+	var items []schema.QosPriority
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return qp.Out(items, c.String("format"), qp.Tmpl())
+}
+
+func (qp QosPriority) Commands() *cli.Command {
+	return &cli.Command{
+		Name:  "priority",
+		Usage: "Traffic preferred by DSCP or ports",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "add",
+				Usage: "Add a priority class",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "priority", Aliases: []string{"p"}, Required: true},
+					&cli.IntSliceFlag{Name: "dscp", Usage: "e.g. 46 for voice"},
+					&cli.StringFlag{Name: "protocol", Usage: "udp or tcp"},
+					&cli.StringSliceFlag{Name: "port", Usage: "e.g. 5060 or 10000-20000"},
+				},
+				Action: qp.Add,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Remove a priority class",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "priority", Aliases: []string{"p"}, Required: true},
+				},
+				Action: qp.Remove,
+			},
+			{
+				Name:    "list",
+				Usage:   "Display all priority classes",
+				Aliases: []string{"ls"},
+				Action:  qp.List,
+			},
+		},
+	}
+}
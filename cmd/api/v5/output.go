@@ -20,14 +20,15 @@ func (o Output) Add(c *cli.Context) error {
 		return libol.NewErr("invalid network")
 	}
 	output := &schema.Output{
-		Network:  network,
-		Remote:   c.String("remote"),
-		Segment:  c.Int("segment"),
-		Protocol: c.String("protocol"),
-		DstPort:  c.Int("dstport"),
-		Secret:   c.String("secret"),
-		Crypt:    c.String("crypt"),
-		Fallback: c.String("fallback"),
+		Network:    network,
+		Remote:     c.String("remote"),
+		Segment:    c.Int("segment"),
+		Protocol:   c.String("protocol"),
+		DstPort:    c.Int("dstport"),
+		Secret:     c.String("secret"),
+		Crypt:      c.String("crypt"),
+		Fallback:   c.String("fallback"),
+		AllowedIPs: c.StringSlice("allowed"),
 	}
 	url := o.Url(c.String("url"), network)
 	clt := o.NewHttp(c.String("token"))
@@ -93,6 +94,7 @@ func (o Output) Commands() *cli.Command {
 					&cli.StringFlag{Name: "dstport"},
 					&cli.StringFlag{Name: "secret"},
 					&cli.StringFlag{Name: "crypt"},
+					&cli.StringSliceFlag{Name: "allowed"},
 				},
 				Action: o.Add,
 			},
@@ -0,0 +1,111 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Quota struct {
+	Cmd
+}
+
+func (q Quota) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/quota"
+}
+
+func (q Quota) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -24 "user"}} {{ps -12 "daily(MiB)"}} {{ps -12 "monthly(MiB)"}} {{ps -10 "action"}} {{"speed(Mbit)"}}
+{{- range . }}
+{{ if .User }}{{ps -24 .User}}{{ else }}{{ps -24 "*"}}{{ end }} {{pi -12 .Daily}} {{pi -12 .Monthly}} {{ps -10 .Action}} {{ if .Speed }}{{.Speed}}{{ else }}-{{ end }}
+{{- end }}
+`
+}
+
+func (q Quota) List(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	url := q.Url(c.String("url"), network)
+	clt := q.NewHttp(c.String("token"))
+	var items []schema.Quota
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return q.Out(items, c.String("format"), q.Tmpl())
+}
+
+func (q Quota) Add(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	data := &schema.Quota{
+		User:    c.String("user"),
+		Daily:   c.Uint64("daily"),
+		Monthly: c.Uint64("monthly"),
+		Action:  c.String("action"),
+		Speed:   c.Float64("speed"),
+	}
+	url := q.Url(c.String("url"), network)
+	clt := q.NewHttp(c.String("token"))
+	if err := clt.PostJSON(url, data, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Quota) Remove(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	data := &schema.Quota{
+		User: c.String("user"),
+	}
+	url := q.Url(c.String("url"), network)
+	clt := q.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, data, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (q Quota) Commands() *cli.Command {
+	return &cli.Command{
+		Name:   "quota",
+		Usage:  "Traffic quota of users",
+		Action: q.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display quota of network and users",
+				Aliases: []string{"ls"},
+				Action:  q.List,
+			},
+			{
+				Name:  "add",
+				Usage: "Set quota of network, or an user if specified",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "user"},
+					&cli.Uint64Flag{Name: "daily", Usage: "MiB per day"},
+					&cli.Uint64Flag{Name: "monthly", Usage: "MiB per month"},
+					&cli.StringFlag{Name: "action", Usage: "throttle or disconnect"},
+					&cli.Float64Flag{Name: "speed", Usage: "Mbit when throttled"},
+				},
+				Action: q.Add,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Remove quota of network, or an user if specified",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "user"},
+				},
+				Action: q.Remove,
+			},
+		},
+	}
+}
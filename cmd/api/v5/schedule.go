@@ -0,0 +1,98 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/cmd/api"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Schedule struct {
+	Cmd
+}
+
+func (u Schedule) Url(prefix, name string) string {
+	if name == "" {
+		return prefix + "/api/schedule"
+	}
+	return prefix + "/api/schedule/" + name
+}
+
+func (u Schedule) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -16 "name"}} {{ps -24 "days"}} {{ps -24 "windows"}} {{ps -16 "timezone"}} {{ps -6 "active"}} {{"next"}}
+{{- range . }}
+{{ps -16 .Name}} {{ps -24 (join .Days ",")}} {{ps -24 (join .Windows ",")}} {{ps -16 .Timezone}} {{if .Active}}{{ps -6 "yes"}}{{else}}{{ps -6 "no"}}{{end}} {{if .NextAt}}{{ut .NextAt}}{{else}}{{"never"}}{{end}}
+{{- end }}
+`
+}
+
+func (u Schedule) Add(c *cli.Context) error {
+	data := schema.Schedule{
+		Name:     c.String("name"),
+		Days:     c.StringSlice("day"),
+		Windows:  c.StringSlice("window"),
+		Timezone: c.String("timezone"),
+	}
+	if data.Name == "" {
+		return libol.NewErr("invalid name")
+	}
+	url := u.Url(c.String("url"), "")
+	clt := u.NewHttp(c.String("token"))
+	return clt.PostJSON(url, data, nil)
+}
+
+func (u Schedule) Remove(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" {
+		return libol.NewErr("invalid name")
+	}
+	url := u.Url(c.String("url"), name)
+	clt := u.NewHttp(c.String("token"))
+	return clt.DeleteJSON(url, nil, nil)
+}
+
+func (u Schedule) List(c *cli.Context) error {
+	url := u.Url(c.String("url"), "")
+	clt := u.NewHttp(c.String("token"))
+	var items []schema.Schedule
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u Schedule) Commands(app *api.App) {
+	app.Command(&cli.Command{
+		Name:  "schedule",
+		Usage: "Schedule of time for acl, guest and qos",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "add",
+				Usage: "Add or update a schedule",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Aliases: []string{"n"}},
+					&cli.StringSliceFlag{Name: "day", Aliases: []string{"d"}, Usage: "mon..sun, weekday or weekend, and all days if not given"},
+					&cli.StringSliceFlag{Name: "window", Aliases: []string{"w"}, Usage: "e.g. 09:00-18:00 or 22:00-06:00, and whole day if not given"},
+					&cli.StringFlag{Name: "timezone", Aliases: []string{"z"}, Usage: "e.g. Asia/Shanghai, and local if not given"},
+				},
+				Action: u.Add,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Remove a schedule",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Aliases: []string{"n"}},
+				},
+				Action: u.Remove,
+			},
+			{
+				Name:    "list",
+				Usage:   "Display all schedules with current state",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+		},
+	})
+}
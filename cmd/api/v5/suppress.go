@@ -0,0 +1,58 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Suppress struct {
+	Cmd
+}
+
+func (s Suppress) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/suppress"
+}
+
+func (s Suppress) Tmpl() string {
+	return `# neighbors {{.Neighbors}} pruned {{.Pruned}}
+{{ps -24 "alias"}} {{ps -24 "client"}} {{ps -16 "device"}} {{ps -10 "arpProxied"}} {{ps -10 "ndProxied"}} {{"rateDropped"}}
+{{- range .Clients }}
+{{ps -24 .Alias}} {{ps -24 .Client}} {{ps -16 .Device}} {{pi -10 .ArpProxied}} {{pi -10 .NdProxied}} {{.RateDropped}}
+{{- end }}
+{{ps -24 "group"}} {{ps -17 "mac"}} {{"members"}}
+{{- range .Groups }}
+{{ps -24 .Group}} {{ps -17 .Mac}} {{.Members}}
+{{- end }}
+`
+}
+
+func (s Suppress) List(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	url := s.Url(c.String("url"), network)
+	clt := s.NewHttp(c.String("token"))
+	var item schema.Suppress
+	if err := clt.GetJSON(url, &item); err != nil {
+		return err
+	}
+	return s.Out(item, c.String("format"), s.Tmpl())
+}
+
+func (s Suppress) Commands() *cli.Command {
+	return &cli.Command{
+		Name:   "suppress",
+		Usage:  "Broadcast and multicast suppression",
+		Action: s.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display suppressed counters and multicast groups",
+				Aliases: []string{"ls"},
+				Action:  s.List,
+			},
+		},
+	}
+}
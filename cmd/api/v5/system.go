@@ -138,8 +138,12 @@ func (v Log) Url(prefix, name string) string {
 }
 
 func (v Log) Tmpl() string {
-	return `File :  {{ .File }}
-Level:  {{ .Level}}
+	return `File  :  {{ .File }}
+Level :  {{ .Level }}
+Format:  {{ .Format }}
+{{- range $name, $level := .Modules }}
+Module:  {{ $name }} {{ $level }}
+{{- end }}
 `
 }
 
@@ -165,6 +169,37 @@ func (v Log) Add(c *cli.Context) error {
 	return nil
 }
 
+func (v Log) AddModule(c *cli.Context) error {
+	url := v.Url(c.String("url"), "") + "/module"
+	value := &schema.LogModule{
+		Name:  c.String("name"),
+		Level: c.Int("level"),
+	}
+	if value.Name == "" {
+		return libol.NewErr("invalid module")
+	}
+	clt := v.NewHttp(c.String("token"))
+	if err := clt.PostJSON(url, value, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v Log) RemoveModule(c *cli.Context) error {
+	url := v.Url(c.String("url"), "") + "/module"
+	value := &schema.LogModule{
+		Name: c.String("name"),
+	}
+	if value.Name == "" {
+		return libol.NewErr("invalid module")
+	}
+	clt := v.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, value, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (v Log) Commands(app *api.App) {
 	app.Command(&cli.Command{
 		Name:   "log",
@@ -179,6 +214,30 @@ func (v Log) Commands(app *api.App) {
 				},
 				Action: v.Add,
 			},
+			{
+				Name:  "module",
+				Usage: "Level of a module",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "set",
+						Usage: "set level of a module",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "name", Usage: "prefix, Type or prefix/Type, like UdpClient or default/WorkerImpl"},
+							&cli.IntFlag{Name: "level"},
+						},
+						Action: v.AddModule,
+					},
+					{
+						Name:    "remove",
+						Usage:   "Remove level of a module",
+						Aliases: []string{"rm"},
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "name"},
+						},
+						Action: v.RemoveModule,
+					},
+				},
+			},
 		},
 	})
 }
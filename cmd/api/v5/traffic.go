@@ -0,0 +1,89 @@
+package v5
+
+import (
+	"net/url"
+
+	"github.com/luscis/openlan/cmd/api"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type Traffic struct {
+	Cmd
+}
+
+func (u Traffic) Url(prefix, name string) string {
+	if name == "" {
+		return prefix + "/api/traffic"
+	}
+	return prefix + "/api/traffic/" + name
+}
+
+func (u Traffic) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -24 "user"}} {{ps -16 "network"}} {{ps -10 "daily"}} {{ps -10 "monthly"}} {{ps -10 "received"}} {{ps -10 "sent"}} {{ps -10 "state"}} {{"updateAt"}}
+{{- range . }}
+{{ps -24 .User}} {{ps -16 .Network}} {{pb .Daily | ps -10}} {{pb .Monthly | ps -10}} {{pb .RxBytes | ps -10}} {{pb .TxBytes | ps -10}} {{ps -10 .State}} {{ if .UpdateAt }}{{ut .UpdateAt}}{{ else }}-{{ end }}
+{{- end }}
+`
+}
+
+func (u Traffic) List(c *cli.Context) error {
+	query := url.Values{}
+	if name := c.String("name"); name != "" {
+		query.Set("network", name)
+	}
+	if user := c.String("user"); user != "" {
+		query.Set("user", user)
+	}
+	url := u.Url(c.String("url"), "") + "?" + query.Encode()
+	clt := u.NewHttp(c.String("token"))
+
+	var items []schema.Traffic
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u Traffic) Reset(c *cli.Context) error {
+	user := c.String("user")
+	if user == "" {
+		return libol.NewErr("invalid user")
+	}
+	url := u.Url(c.String("url"), user)
+	clt := u.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, nil, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u Traffic) Commands(app *api.App) {
+	app.Command(&cli.Command{
+		Name:   "traffic",
+		Usage:  "Traffic accounting of users",
+		Action: u.List,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name", Aliases: []string{"n"}},
+			&cli.StringFlag{Name: "user"},
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display daily and monthly traffic",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+			{
+				Name:  "reset",
+				Usage: "Reset traffic of an user in current period",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "user", Usage: "user@network", Required: true},
+				},
+				Action: u.Reset,
+			},
+		},
+	})
+}
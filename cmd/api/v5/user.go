@@ -37,6 +37,9 @@ func (u User) Add(c *cli.Context) error {
 		Role:     c.String("role"),
 		Lease:    c.String("lease"),
 	}
+	if groups := c.String("group"); groups != "" {
+		user.Groups = strings.Split(groups, ",")
+	}
 	user.Name, user.Network = api.SplitName(username)
 	url := u.Url(c.String("url"), username)
 	clt := u.NewHttp(c.String("token"))
@@ -60,9 +63,9 @@ func (u User) Remove(c *cli.Context) error {
 
 func (u User) Tmpl() string {
 	return `# total {{ len . }}
-{{ps -24 "username"}} {{ps -24 "password"}} {{ps -6 "role"}} {{ps -15 "lease"}}
+{{ps -24 "username"}} {{ps -24 "password"}} {{ps -6 "role"}} {{ps -15 "lease"}} {{ps -15 "groups"}}
 {{- range . }}
-{{p2 -24 "%s@%s" .Name .Network}} {{ps -24 .Password}} {{ps -6 .Role}} {{ps -15 .Lease }}
+{{p2 -24 "%s@%s" .Name .Network}} {{ps -24 .Password}} {{ps -6 .Role}} {{ps -15 .Lease }} {{ps -15 (join .Groups ",")}}
 {{- end }}
 `
 }
@@ -167,6 +170,7 @@ func (u User) Commands(app *api.App) {
 					&cli.StringFlag{Name: "password", Value: libol.GenString(12)},
 					&cli.StringFlag{Name: "role", Value: "guest"},
 					&cli.StringFlag{Name: "lease", Value: lease.Format(libol.LeaseTime)},
+					&cli.StringFlag{Name: "group", Usage: "groups separated by comma"},
 				},
 				Action: u.Add,
 			},
@@ -178,6 +182,7 @@ func (u User) Commands(app *api.App) {
 					&cli.StringFlag{Name: "password"},
 					&cli.StringFlag{Name: "role"},
 					&cli.StringFlag{Name: "lease"},
+					&cli.StringFlag{Name: "group", Usage: "groups separated by comma"},
 				},
 				Action: u.Add,
 			},
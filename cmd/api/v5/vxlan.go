@@ -0,0 +1,103 @@
+package v5
+
+import (
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type VxLAN struct {
+	Cmd
+}
+
+func (v VxLAN) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/vxlan"
+}
+
+func (v VxLAN) Tmpl() string {
+	return `# vni {{ .Vni }} on {{ .Device }} fabric {{ .Fabric }} with {{ .Macs }} macs
+{{ps -8 "vni"}} {{ps -16 "local"}} {{ps -16 "remote"}} {{ps -8 "type"}}
+{{- range .Members }}
+{{pi -8 .Vni}} {{ps -16 .Local}} {{ps -16 .Remote}} {{ps -8 .Type}}
+{{- end }}
+`
+}
+
+func (v VxLAN) List(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	url := v.Url(c.String("url"), network)
+	clt := v.NewHttp(c.String("token"))
+	var item schema.VxLAN
+	if err := clt.GetJSON(url, &item); err != nil {
+		return err
+	}
+	return v.Out(item, c.String("format"), v.Tmpl())
+}
+
+func (v VxLAN) AddPeer(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	data := &schema.VxLANPeer{
+		Remote: c.String("remote"),
+	}
+	url := v.Url(c.String("url"), network) + "/peer"
+	clt := v.NewHttp(c.String("token"))
+	if err := clt.PostJSON(url, data, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v VxLAN) DelPeer(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	data := &schema.VxLANPeer{
+		Remote: c.String("remote"),
+	}
+	url := v.Url(c.String("url"), network) + "/peer"
+	clt := v.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, data, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v VxLAN) Commands() *cli.Command {
+	return &cli.Command{
+		Name:   "vxlan",
+		Usage:  "VxLAN fabric",
+		Action: v.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display VTEPs of the network",
+				Aliases: []string{"ls"},
+				Action:  v.List,
+			},
+			{
+				Name:  "add",
+				Usage: "Add a static VTEP peer",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "remote", Required: true},
+				},
+				Action: v.AddPeer,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Remove a static VTEP peer",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "remote", Required: true},
+				},
+				Action: v.DelPeer,
+			},
+		},
+	}
+}
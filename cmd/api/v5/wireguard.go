@@ -0,0 +1,134 @@
+package v5
+
+import (
+	"fmt"
+
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+type WireGuard struct {
+	Cmd
+}
+
+func (w WireGuard) Url(prefix, name string) string {
+	return prefix + "/api/network/" + name + "/wireguard"
+}
+
+func (w WireGuard) Tmpl() string {
+	return `# {{ .Device }} listen on {{ .Listen }} with {{ .PublicKey }}
+{{ps -16 "name"}} {{ps -16 "address"}} {{ps -22 "endpoint"}} {{ps -8 "handshake"}} {{ps -8 "rx"}} {{ps -8 "tx"}} {{ps -44 "public key"}}
+{{- range .Peers }}
+{{ps -16 .Name}} {{ps -16 .Address}} {{ps -22 .Endpoint}} {{pt .Handshake}} {{pb .RxBytes}} {{pb .TxBytes}} {{ps -44 .PublicKey}}
+{{- end }}
+`
+}
+
+func (w WireGuard) List(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	url := w.Url(c.String("url"), network)
+	clt := w.NewHttp(c.String("token"))
+	var item schema.WireGuard
+	if err := clt.GetJSON(url, &item); err != nil {
+		return err
+	}
+	return w.Out(item, c.String("format"), w.Tmpl())
+}
+
+func (w WireGuard) Add(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	data := &schema.WireGuardPeer{
+		Name:       c.String("peer"),
+		PublicKey:  c.String("pubkey"),
+		Address:    c.String("address"),
+		AllowedIPs: c.StringSlice("allowed"),
+	}
+	url := w.Url(c.String("url"), network) + "/peer"
+	clt := w.NewHttp(c.String("token"))
+	if err := clt.PostJSON(url, data, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w WireGuard) Remove(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	data := &schema.WireGuardPeer{
+		Name: c.String("peer"),
+	}
+	url := w.Url(c.String("url"), network) + "/peer"
+	clt := w.NewHttp(c.String("token"))
+	if err := clt.DeleteJSON(url, data, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w WireGuard) Export(c *cli.Context) error {
+	network := c.String("name")
+	if len(network) == 0 {
+		return libol.NewErr("invalid network")
+	}
+	url := w.Url(c.String("url"), network) + "/peer/" + c.String("peer")
+	clt := w.NewHttp(c.String("token"))
+	data, err := clt.GetBody(url)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+func (w WireGuard) Commands() *cli.Command {
+	return &cli.Command{
+		Name:   "wireguard",
+		Usage:  "WireGuard peers",
+		Action: w.List,
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display all peers",
+				Aliases: []string{"ls"},
+				Action:  w.List,
+			},
+			{
+				Name:  "add",
+				Usage: "Add a peer, and generate keys without pubkey",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "peer", Required: true},
+					&cli.StringFlag{Name: "pubkey"},
+					&cli.StringFlag{Name: "address"},
+					&cli.StringSliceFlag{Name: "allowed"},
+				},
+				Action: w.Add,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Remove a peer",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "peer", Required: true},
+				},
+				Action: w.Remove,
+			},
+			{
+				Name:  "export",
+				Usage: "Export configuration of a peer",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "peer", Required: true},
+				},
+				Action: w.Export,
+			},
+		},
+	}
+}
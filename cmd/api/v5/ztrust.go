@@ -57,6 +57,13 @@ func (z ZTrust) Commands(app *api.App) {
 			},
 			Guest{}.Commands(user),
 			Knock{}.Commands(user),
+			ZPolicy{}.Commands(),
+			ZRequest{}.Commands(),
+			{
+				Name:   "audit",
+				Usage:  "Display audits of knocks",
+				Action: z.Audit,
+			},
 		},
 	})
 }
@@ -79,9 +86,10 @@ func (u Guest) Add(c *cli.Context) error {
 		user, network = api.SplitName(authUser)
 	}
 	guest := &schema.ZGuest{
-		Address: c.String("address"),
-		Name:    user,
-		Network: network,
+		Address:  c.String("address"),
+		Name:     user,
+		Network:  network,
+		Schedule: c.String("schedule"),
 	}
 	url := u.Url(c.String("url"), guest.Network, guest.Name)
 	clt := u.NewHttp(c.String("token"))
@@ -107,9 +115,9 @@ func (u Guest) Remove(c *cli.Context) error {
 
 func (u Guest) Tmpl() string {
 	return `# total {{ len . }}
-{{ps -24 "username"}} {{ps -24 "address"}}
+{{ps -24 "username"}} {{ps -24 "address"}} {{ps -12 "schedule"}} {{"active"}}
 {{- range . }}
-{{p2 -24 "%s@%s" .Name .Network}} {{ps -24 .Address}}
+{{p2 -24 "%s@%s" .Name .Network}} {{ps -24 .Address}} {{ps -12 .Schedule}} {{if .Active}}{{"yes"}}{{else}}{{"no"}}{{end}}
 {{- end }}
 `
 }
@@ -140,6 +148,7 @@ func (u Guest) Commands(user string) *cli.Command {
 				Flags: []cli.Flag{
 					&cli.StringFlag{Name: "user", Value: user},
 					&cli.StringFlag{Name: "address"},
+					&cli.StringFlag{Name: "schedule", Usage: "name of a schedule, and only by admin"},
 				},
 				Action: u.Add,
 			},
@@ -196,17 +205,22 @@ func (u Knock) Add(c *cli.Context) error {
 
 	url := u.Url(c.String("url"), knock.Network, knock.Name)
 	clt := u.NewHttp(c.String("token"))
-	if err := clt.PostJSON(url, knock, nil); err != nil {
+	if api.GetUser(c.String("token")) == "" {
+		return clt.PostJSON(url, knock, nil)
+	}
+	// requested by user, and maybe pending for approval.
+	var obj schema.KnockRule
+	if err := clt.PostJSON(url, knock, &obj); err != nil {
 		return err
 	}
-	return nil
+	return u.Out([]schema.KnockRule{obj}, c.String("format"), u.Tmpl())
 }
 
 func (u Knock) Tmpl() string {
 	return `# total {{ len . }}
-{{ps -24 "username"}} {{ps -8 "protocol"}} {{ps -24 "socket"}} {{ps -4 "age"}} {{ps -24 "createAt"}}
+{{ps -24 "username"}} {{ps -8 "protocol"}} {{ps -24 "socket"}} {{ps -6 "age"}} {{ps -8 "state"}} {{ps -8 "id"}} {{ps -12 "policy"}} {{ps -24 "createAt"}}
 {{- range . }}
-{{p2 -24 "%s@%s" .Name .Network}} {{ps -8 .Protocol}} {{p2 -24 "%s:%s" .Dest .Port}} {{pi -4 .Age}} {{ut .CreateAt}}
+{{p2 -24 "%s@%s" .Name .Network}} {{ps -8 .Protocol}} {{p2 -24 "%s:%s" .Dest .Port}} {{pi -6 .Age}} {{ps -8 .State}} {{ps -8 .Id}} {{ps -12 .Policy}} {{ut .CreateAt}}
 {{- end }}
 `
 }
@@ -252,3 +266,188 @@ func (u Knock) Commands(user string) *cli.Command {
 		},
 	}
 }
+
+func (z ZTrust) AuditTmpl() string {
+	return `# total {{ len . }}
+{{ps -20 "time"}} {{ps -24 "username"}} {{ps -8 "action"}} {{ps -8 "protocol"}} {{ps -24 "socket"}} {{ps -6 "age"}} {{ps -12 "policy"}} {{"by"}}
+{{- range . }}
+{{ut .Time}} {{p2 -24 "%s@%s" .Name .Network}} {{ps -8 .Action}} {{ps -8 .Protocol}} {{p2 -24 "%s:%s" .Dest .Port}} {{pi -6 .Age}} {{ps -12 .Policy}} {{.By}}
+{{- end }}
+`
+}
+
+func (z ZTrust) Audit(c *cli.Context) error {
+	url := z.Url(c.String("url"), c.String("network")) + "/audit"
+	clt := z.NewHttp(c.String("token"))
+	var items []schema.ZTrustEvent
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return z.Out(items, c.String("format"), z.AuditTmpl())
+}
+
+type ZPolicy struct {
+	Cmd
+}
+
+func (u ZPolicy) Url(prefix, network, name string) string {
+	if name == "" {
+		return prefix + "/api/network/" + network + "/ztrust/policy"
+	}
+	return prefix + "/api/network/" + network + "/ztrust/policy/" + name
+}
+
+func (u ZPolicy) Tmpl() string {
+	return `# total {{ len . }}
+{{ps -12 "name"}} {{ps -20 "users"}} {{ps -20 "groups"}} {{ps -6 "maxAge"}} {{ps -8 "approval"}} {{"services"}}
+{{- range . }}
+{{ps -12 .Name}} {{ps -20 (join .Users ",")}} {{ps -20 (join .Groups ",")}} {{pi -6 .MaxAge}} {{if .Approval}}{{ps -8 "yes"}}{{else}}{{ps -8 "no"}}{{end}} {{join .Services ","}}
+{{- end }}
+`
+}
+
+func (u ZPolicy) Add(c *cli.Context) error {
+	data := schema.ZPolicy{
+		Name:     c.String("name"),
+		Users:    c.StringSlice("user"),
+		Groups:   c.StringSlice("group"),
+		Services: c.StringSlice("service"),
+		MaxAge:   c.Int("max-age"),
+		Approval: c.Bool("approval"),
+	}
+	if data.Name == "" {
+		return libol.NewErr("invalid name")
+	}
+	url := u.Url(c.String("url"), c.String("network"), "")
+	clt := u.NewHttp(c.String("token"))
+	return clt.PostJSON(url, data, nil)
+}
+
+func (u ZPolicy) Remove(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" {
+		return libol.NewErr("invalid name")
+	}
+	url := u.Url(c.String("url"), c.String("network"), name)
+	clt := u.NewHttp(c.String("token"))
+	return clt.DeleteJSON(url, nil, nil)
+}
+
+func (u ZPolicy) List(c *cli.Context) error {
+	url := u.Url(c.String("url"), c.String("network"), "")
+	clt := u.NewHttp(c.String("token"))
+	var items []schema.ZPolicy
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), u.Tmpl())
+}
+
+func (u ZPolicy) Commands() *cli.Command {
+	return &cli.Command{
+		Name:  "policy",
+		Usage: "Policies of knocks requested by users",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "add",
+				Usage: "Add or update a policy",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name"},
+					&cli.StringSliceFlag{Name: "user", Usage: "allowed users, and all if no users and groups"},
+					&cli.StringSliceFlag{Name: "group", Usage: "allowed groups of users"},
+					&cli.StringSliceFlag{Name: "service", Usage: "e.g. tcp:10.0.0.0/24:22, udp:10.0.0.1:1000-2000 or any:10.0.0.1"},
+					&cli.IntFlag{Name: "max-age", Usage: "seconds, default 3600"},
+					&cli.BoolFlag{Name: "approval", Usage: "pending until approved by admin"},
+				},
+				Action: u.Add,
+			},
+			{
+				Name:    "remove",
+				Usage:   "Remove a policy",
+				Aliases: []string{"rm"},
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name"},
+				},
+				Action: u.Remove,
+			},
+			{
+				Name:    "list",
+				Usage:   "Display all policies",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+		},
+	}
+}
+
+type ZRequest struct {
+	Cmd
+}
+
+func (u ZRequest) Url(prefix, network, id string) string {
+	if id == "" {
+		return prefix + "/api/network/" + network + "/ztrust/request"
+	}
+	return prefix + "/api/network/" + network + "/ztrust/request/" + id
+}
+
+func (u ZRequest) List(c *cli.Context) error {
+	url := u.Url(c.String("url"), c.String("network"), "")
+	clt := u.NewHttp(c.String("token"))
+	var items []schema.KnockRule
+	if err := clt.GetJSON(url, &items); err != nil {
+		return err
+	}
+	return u.Out(items, c.String("format"), Knock{}.Tmpl())
+}
+
+func (u ZRequest) Approve(c *cli.Context) error {
+	id := c.String("id")
+	if id == "" {
+		return libol.NewErr("invalid id")
+	}
+	url := u.Url(c.String("url"), c.String("network"), id)
+	clt := u.NewHttp(c.String("token"))
+	return clt.PutJSON(url, nil, nil)
+}
+
+func (u ZRequest) Reject(c *cli.Context) error {
+	id := c.String("id")
+	if id == "" {
+		return libol.NewErr("invalid id")
+	}
+	url := u.Url(c.String("url"), c.String("network"), id)
+	clt := u.NewHttp(c.String("token"))
+	return clt.DeleteJSON(url, nil, nil)
+}
+
+func (u ZRequest) Commands() *cli.Command {
+	return &cli.Command{
+		Name:  "request",
+		Usage: "Knocks requested by users and waiting for approval",
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "Display all pending requests",
+				Aliases: []string{"ls"},
+				Action:  u.List,
+			},
+			{
+				Name:  "approve",
+				Usage: "Approve a request",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "id"},
+				},
+				Action: u.Approve,
+			},
+			{
+				Name:  "reject",
+				Usage: "Reject a request",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "id"},
+				},
+				Action: u.Reject,
+			},
+		},
+	}
+}
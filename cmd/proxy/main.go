@@ -8,7 +8,7 @@ import (
 
 func main() {
 	c := config.NewProxy()
-	libol.SetLogger(c.Log.File, c.Log.Verbose)
+	c.Log.Setup()
 
 	p := proxy.NewProxy(c)
 	libol.PreNotify()
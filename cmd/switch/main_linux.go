@@ -11,7 +11,8 @@ func main() {
 	c := config.NewSwitch()
 	config.Update(c)
 
-	libol.SetLogger(c.Log.File, c.Log.Verbose)
+	c.Log.Setup()
+	libol.SetAudit(c.Audit.File, int64(c.Audit.MaxSize)<<20, c.Audit.Backups, c.Audit.Syslog)
 	libol.ShowVersion()
 	libol.WritePid(c.PidFile)
 
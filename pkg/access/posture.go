@@ -0,0 +1,26 @@
+package access
+
+import (
+	"runtime"
+
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/models"
+)
+
+// NewPosture collects state of this device, and agents given are reported
+// if running.
+func NewPosture(agents []string) *models.Posture {
+	p := &models.Posture{
+		System:    runtime.GOOS,
+		OsVersion: osVersion(),
+		Encrypted: diskEncrypted(),
+		Firewall:  firewallOn(),
+		Version:   libol.Version,
+	}
+	for _, agent := range agents {
+		if agentRunning(agent) {
+			p.Agents = append(p.Agents, agent)
+		}
+	}
+	return p
+}
@@ -0,0 +1,31 @@
+package access
+
+import (
+	"strings"
+
+	"github.com/luscis/openlan/pkg/libol"
+)
+
+func osVersion() string {
+	out, err := libol.Exec("sw_vers", "-productVersion")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// diskEncrypted checks FileVault.
+func diskEncrypted() bool {
+	out, err := libol.Exec("fdesetup", "status")
+	return err == nil && strings.Contains(out, "FileVault is On")
+}
+
+func firewallOn() bool {
+	out, err := libol.Exec("/usr/libexec/ApplicationFirewall/socketfilterfw", "--getglobalstate")
+	return err == nil && strings.Contains(out, "enabled")
+}
+
+func agentRunning(name string) bool {
+	_, err := libol.Exec("pgrep", "-x", name)
+	return err == nil
+}
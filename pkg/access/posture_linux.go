@@ -0,0 +1,110 @@
+package access
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/luscis/openlan/pkg/libol"
+)
+
+func osVersion() string {
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	version := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		values := strings.SplitN(line, "=", 2)
+		if len(values) != 2 {
+			continue
+		}
+		value := strings.Trim(values[1], "\"")
+		switch values[0] {
+		case "ID":
+			version = value + " " + version
+		case "VERSION_ID":
+			version += value
+		}
+	}
+	return strings.TrimSpace(version)
+}
+
+// diskEncrypted checks a device mapper of dm-crypt, e.g. LUKS.
+func diskEncrypted() bool {
+	files, _ := filepath.Glob("/sys/block/dm-*/dm/uuid")
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err == nil && strings.HasPrefix(string(data), "CRYPT-") {
+			return true
+		}
+	}
+	return false
+}
+
+// inputFiltered checks whether a chain hooked at input of nft ruleset
+// drops by policy or rejects by rules.
+func inputFiltered(ruleset string) bool {
+	input := false
+	for _, line := range strings.Split(ruleset, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "chain ") {
+			input = false
+			continue
+		}
+		if strings.Contains(line, "hook input") {
+			input = true
+			if strings.Contains(line, "policy drop") {
+				return true
+			}
+			continue
+		}
+		if line == "}" {
+			input = false
+			continue
+		}
+		if !input {
+			continue
+		}
+		for _, verdict := range strings.Fields(line) {
+			if verdict == "drop" || verdict == "reject" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func firewallOn() bool {
+	if out, err := libol.Exec("nft", "list", "ruleset"); err == nil {
+		if inputFiltered(out) {
+			return true
+		}
+	}
+	out, err := libol.Exec("iptables", "-S", "INPUT")
+	if err != nil {
+		return false
+	}
+	// a policy of drop or any rule dropped.
+	if strings.Contains(out, "-P INPUT DROP") {
+		return true
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "-A INPUT") &&
+			(strings.HasSuffix(line, "-j DROP") || strings.Contains(line, "-j REJECT")) {
+			return true
+		}
+	}
+	return false
+}
+
+func agentRunning(name string) bool {
+	files, _ := filepath.Glob("/proc/[0-9]*/comm")
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err == nil && strings.TrimSpace(string(data)) == name {
+			return true
+		}
+	}
+	return false
+}
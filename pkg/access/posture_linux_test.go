@@ -0,0 +1,36 @@
+package access
+
+import "testing"
+
+func TestInputFiltered(t *testing.T) {
+	accept := `table inet filter {
+	chain input {
+		type filter hook input priority filter; policy accept;
+	}
+	chain forward {
+		type filter hook forward priority filter; policy drop;
+	}
+}`
+	if inputFiltered(accept) {
+		t.Errorf("input accepted, but filtered")
+	}
+	drop := `table inet filter {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ct state established,related accept
+	}
+}`
+	if !inputFiltered(drop) {
+		t.Errorf("input dropped by policy, but not filtered")
+	}
+	reject := `table inet filter {
+	chain input {
+		type filter hook input priority filter; policy accept;
+		tcp dport 22 accept
+		reject with icmpx type admin-prohibited
+	}
+}`
+	if !inputFiltered(reject) {
+		t.Errorf("input rejected by rule, but not filtered")
+	}
+}
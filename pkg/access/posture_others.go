@@ -0,0 +1,19 @@
+//go:build !linux && !windows && !darwin
+
+package access
+
+func osVersion() string {
+	return ""
+}
+
+func diskEncrypted() bool {
+	return false
+}
+
+func firewallOn() bool {
+	return false
+}
+
+func agentRunning(name string) bool {
+	return false
+}
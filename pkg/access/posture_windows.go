@@ -0,0 +1,39 @@
+package access
+
+import (
+	"strings"
+
+	"github.com/luscis/openlan/pkg/libol"
+)
+
+func osVersion() string {
+	out, err := libol.Exec("cmd", "/c", "ver")
+	if err != nil {
+		return ""
+	}
+	// e.g. Microsoft Windows [Version 10.0.19045.3803]
+	out = strings.TrimSpace(out)
+	if i := strings.Index(out, "Version "); i >= 0 {
+		return strings.TrimSuffix(out[i+len("Version "):], "]")
+	}
+	return out
+}
+
+// diskEncrypted checks BitLocker of system drive.
+func diskEncrypted() bool {
+	out, err := libol.Exec("manage-bde", "-status", "C:")
+	return err == nil && strings.Contains(out, "Protection On")
+}
+
+func firewallOn() bool {
+	out, err := libol.Exec("netsh", "advfirewall", "show", "allprofiles", "state")
+	return err == nil && strings.Contains(out, "ON")
+}
+
+func agentRunning(name string) bool {
+	if !strings.HasSuffix(strings.ToLower(name), ".exe") {
+		name += ".exe"
+	}
+	out, err := libol.Exec("tasklist", "/NH", "/FI", "IMAGENAME eq "+name)
+	return err == nil && strings.Contains(strings.ToLower(out), strings.ToLower(name))
+}
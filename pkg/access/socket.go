@@ -225,6 +225,8 @@ func (t *SocketWorker) sendLogin(client libsock.SocketClient) error {
 	if client == nil {
 		return libol.NewErr("client is nil")
 	}
+	// collected at each login, because it's changed maybe.
+	t.user.Posture = NewPosture(t.pinCfg.Agents)
 	body, err := json.Marshal(t.user)
 	if err != nil {
 		return err
@@ -356,7 +358,7 @@ func (t *SocketWorker) onInstruct(frame *libsock.FrameMessage) error {
 		return nil
 	}
 	action, resp := frame.CmdAndParams()
-	if libol.HasLog(libol.CMD) {
+	if t.out.Has(libol.CMD) {
 		t.out.Cmd("SocketWorker.onInstruct %s %s", action, resp)
 	}
 	switch action {
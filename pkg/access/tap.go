@@ -35,7 +35,9 @@ type TapWorker struct {
 	pinCfg     *config.Access
 	ifAddr     net.IP
 	writeQueue chan *libsock.FrameMessage
+	writers    []chan *libsock.FrameMessage // pinned by flows for queues.
 	done       chan bool
+	quit       chan struct{}
 	out        *libol.SubLogger
 	eventQueue chan *WorkerEvent
 }
@@ -46,10 +48,17 @@ func NewTapWorker(devCfg network.TapConfig, pinCfg *config.Access) (a *TapWorker
 		devCfg:     devCfg,
 		pinCfg:     pinCfg,
 		done:       make(chan bool, 2),
+		quit:       make(chan struct{}),
 		writeQueue: make(chan *libsock.FrameMessage, pinCfg.Queue.TapWr),
 		out:        libol.NewSubLogger(module),
 		eventQueue: make(chan *WorkerEvent, 32),
 	}
+	if devCfg.Queues > 1 {
+		a.writers = make([]chan *libsock.FrameMessage, devCfg.Queues)
+		for i := range a.writers {
+			a.writers[i] = make(chan *libsock.FrameMessage, pinCfg.Queue.TapWr)
+		}
+	}
 	return
 }
 
@@ -117,11 +126,36 @@ func (a *TapWorker) open() error {
 		return err
 	}
 	device.Up() // up device firstly
-	libol.Go(func() {
-		a.Read(device)
-	})
 	a.out.Info("TapWorker.open: >>> %s <<<", device.Name())
 	a.device = device
+	var once sync.Once
+	failed := func() {
+		once.Do(func() {
+			a.lock.Lock()
+			// closed by reopening.
+			reopen := !a.isStopped() && a.device == device
+			a.lock.Unlock()
+			if reopen {
+				a.eventQueue <- NewEvent(EvTapReadErr, "from read")
+			}
+		})
+	}
+	if mq, ok := device.(network.MultiQueuer); ok && mq.Queues() > 1 {
+		for i := 0; i < mq.Queues(); i++ {
+			index := i
+			libol.Go(func() {
+				a.read(func(p []byte) (int, error) {
+					return mq.ReadQueue(index, p)
+				})
+				failed()
+			})
+		}
+	} else {
+		libol.Go(func() {
+			a.read(device.Read)
+			failed()
+		})
+	}
 	if a.listener.OnOpen != nil {
 		_ = a.listener.OnOpen(a)
 	}
@@ -188,14 +222,15 @@ func (a *TapWorker) onFrame(frame *libsock.FrameMessage, data []byte) int {
 	return size
 }
 
-func (a *TapWorker) Read(device network.Taper) {
+// read frames from kernel until error.
+func (a *TapWorker) read(readAt func(p []byte) (int, error)) {
 	for {
 		frame := libsock.NewFrameMessage(0)
 		data := frame.Frame()
 		if a.IsTun() {
 			data = data[libol.EtherLen:]
 		}
-		if n, err := device.Read(data); err != nil {
+		if n, err := readAt(data); err != nil {
 			a.out.Error("TapWorker.Read: %s", err)
 			break
 		} else {
@@ -210,9 +245,6 @@ func (a *TapWorker) Read(device network.Taper) {
 			}
 		}
 	}
-	if !a.isStopped() {
-		a.eventQueue <- NewEvent(EvTapReadErr, "from read")
-	}
 }
 
 func (a *TapWorker) dispatch(ev *WorkerEvent) {
@@ -244,6 +276,11 @@ func (a *TapWorker) Loop() {
 }
 
 func (a *TapWorker) DoWrite(frame *libsock.FrameMessage) error {
+	return a.write(frame, -1)
+}
+
+// write writes a frame to the queue of index, and the device if index < 0.
+func (a *TapWorker) write(frame *libsock.FrameMessage, index int) error {
 	data := frame.Frame()
 	if a.out.Has(libol.DEBUG) {
 		a.out.Debug("TapWorker.DoWrite: %x", data)
@@ -273,9 +310,16 @@ func (a *TapWorker) DoWrite(frame *libsock.FrameMessage) error {
 			return nil
 		}
 	}
+	device := a.device
 	a.lock.Unlock()
 
-	if _, err := a.device.Write(data); err != nil {
+	var err error
+	if mq, ok := device.(network.MultiQueuer); ok && index >= 0 {
+		_, err = mq.WriteQueue(index, data)
+	} else {
+		_, err = device.Write(data)
+	}
+	if err != nil {
 		a.out.Error("TapWorker.DoWrite: %s", err)
 		return err
 	}
@@ -283,10 +327,27 @@ func (a *TapWorker) DoWrite(frame *libsock.FrameMessage) error {
 }
 
 func (a *TapWorker) Write(frame *libsock.FrameMessage) error {
+	if n := len(a.writers); n > 0 {
+		index := network.FlowHash(frame.Frame(), false) % uint32(n)
+		a.writers[index] <- frame
+		return nil
+	}
 	a.writeQueue <- frame
 	return nil
 }
 
+// writeLoop writes frames pinned to the queue of index.
+func (a *TapWorker) writeLoop(index int, queue chan *libsock.FrameMessage) {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case d := <-queue:
+			_ = a.write(d, index)
+		}
+	}
+}
+
 // learn source from arp
 func (a *TapWorker) toArp(data []byte) bool {
 	a.out.Debug("TapWorker.toArp")
@@ -359,6 +420,12 @@ func (a *TapWorker) Start() {
 	defer a.lock.Unlock()
 	a.out.Info("TapWorker.Start")
 	libol.Go(a.Loop)
+	for i, queue := range a.writers {
+		index, w := i, queue
+		libol.Go(func() {
+			a.writeLoop(index, w)
+		})
+	}
 	libol.Go(a.neighbor.Start)
 }
 
@@ -369,8 +436,14 @@ func (a *TapWorker) isStopped() bool {
 func (a *TapWorker) Stop() {
 	a.lock.Lock()
 	defer a.lock.Unlock()
+	select {
+	case <-a.quit:
+		return // already stopped.
+	default:
+	}
 	a.out.Info("TapWorker.Stop")
 	a.done <- true
+	close(a.quit)
 	a.neighbor.Stop()
 	a.close()
 	a.device = nil
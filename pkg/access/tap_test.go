@@ -16,7 +16,7 @@ func TestTapWrite(t *testing.T) {
 		return
 	}
 	if _, err := network.LinkUp(dev.Name()); err != nil {
-		libol.Warn("KernelTap.Up %s: %s", dev, err)
+		libol.Warn("KernelTap.Up %s: %s", dev.Name(), err)
 		return
 	}
 	frame := make([]byte, 65)
@@ -40,7 +40,7 @@ func BenchmarkTapWrite64(b *testing.B) {
 		return
 	}
 	if _, err := network.LinkUp(dev.Name()); err != nil {
-		libol.Warn("KernelTap.Up %s: %s", dev, err)
+		libol.Warn("KernelTap.Up %s: %s", dev.Name(), err)
 		return
 	}
 	//b.Logf("Tap.write: to %s", dev.Name())
@@ -69,7 +69,7 @@ func BenchmarkTapWrite1500(b *testing.B) {
 		return
 	}
 	if _, err := network.LinkUp(dev.Name()); err != nil {
-		libol.Warn("KernelTap.Up %s: %s", dev, err)
+		libol.Warn("KernelTap.Up %s: %s", dev.Name(), err)
 		return
 	}
 	//b.Logf("Tap.write: to %s", dev.Name())
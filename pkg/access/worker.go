@@ -173,6 +173,9 @@ func GetTapCfg(c *config.Access) network.TapConfig {
 	} else {
 		cfg.Type = network.TAP
 	}
+	if c.Queue != nil {
+		cfg.Queues = c.Queue.TapQus
+	}
 	return cfg
 }
 
@@ -17,6 +17,9 @@ func (h ACL) Router(router *mux.Router) {
 	router.HandleFunc("/api/network/{id}/acl", h.Del).Methods("DELETE")
 	router.HandleFunc("/api/network/{id}/acl", h.Save).Methods("PUT")
 	router.HandleFunc("/api/network/{id}/acl/flush", h.Flush).Methods("PUT")
+	router.HandleFunc("/api/network/{id}/acl/group", h.ListGroup).Methods("GET")
+	router.HandleFunc("/api/network/{id}/acl/group", h.AddGroup).Methods("POST")
+	router.HandleFunc("/api/network/{id}/acl/group/{group}", h.DelGroup).Methods("DELETE")
 }
 
 func (h ACL) List(w http.ResponseWriter, r *http.Request) {
@@ -117,3 +120,64 @@ func (h ACL) Flush(w http.ResponseWriter, r *http.Request) {
 
 	ResponseJson(w, "success")
 }
+
+func (h ACL) ListGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	acl := worker.ACLer()
+
+	groups := make([]schema.ACLGroup, 0, 1024)
+	acl.ListGroups(func(obj schema.ACLGroup) {
+		groups = append(groups, obj)
+	})
+
+	ResponseJson(w, groups)
+}
+
+func (h ACL) AddGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	acl := worker.ACLer()
+
+	group := &schema.ACLGroup{}
+	if err := GetData(r, group); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := acl.AddGroup(group); err == nil {
+		ResponseJson(w, "success")
+	} else {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (h ACL) DelGroup(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	acl := worker.ACLer()
+
+	if err := acl.DelGroup(vars["group"]); err == nil {
+		ResponseJson(w, "success")
+	} else {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"io"
 	"net"
 
 	co "github.com/luscis/openlan/pkg/config"
@@ -14,11 +16,43 @@ type RateLimitApi interface {
 	DelRate(device string) error
 }
 
+type TrafficApi interface {
+	ResetTraffic(user string) error
+	AddQuota(data schema.Quota) error
+	DelQuota(data schema.Quota) error
+	ListQuota(network string, call func(obj schema.Quota)) error
+}
+
 type LdapApi interface {
 	AddLDAP(value schema.LDAP) error
 	DelLDAP()
 }
 
+type ConfirmApi interface {
+	Prepare(network string, timeout int) error
+	Commit(network string) error
+	Rollback(network string) error
+	ListConfirm(call func(obj schema.Confirm))
+}
+
+// CaptureWriter is told the state of a streamed capture before written.
+type CaptureWriter interface {
+	io.Writer
+	Start(state schema.CaptureState)
+}
+
+type CaptureApi interface {
+	AddCapture(ctx context.Context, data schema.Capture, w io.Writer) (schema.CaptureState, error)
+	DelCapture(id string) error
+	ListCapture(call func(obj schema.CaptureState))
+}
+
+type ScheduleApi interface {
+	AddSchedule(data schema.Schedule) error
+	DelSchedule(name string) error
+	ListSchedule(call func(obj schema.Schedule))
+}
+
 type SwitchApi interface {
 	UUID() string
 	UpTime() int64
@@ -30,11 +64,15 @@ type SwitchApi interface {
 	DelNetwork(string)
 	SaveNetwork(string)
 	RateLimitApi
+	TrafficApi
 	UpdateCert(schema.VersionCert)
 	GetCert() schema.VersionCert
 	UpdateCrypt(schema.SwitchCrypt)
 	GetCrypt() schema.SwitchCrypt
 	LdapApi
+	ConfirmApi
+	CaptureApi
+	ScheduleApi
 }
 
 func NewWorkerSchema(s SwitchApi) schema.Worker {
@@ -56,6 +94,9 @@ type ACLApi interface {
 	FlushRules()
 	ListRules(call func(obj schema.ACLRule))
 	SaveRule()
+	AddGroup(group *schema.ACLGroup) error
+	DelGroup(name string) error
+	ListGroups(call func(obj schema.ACLGroup))
 }
 
 type ZTrustApi interface {
@@ -64,6 +105,15 @@ type ZTrustApi interface {
 	Knock(name string, protocol, dest, port string, age int) error
 	ListGuest(call func(obj schema.ZGuest))
 	ListKnock(name string, call func(obj schema.KnockRule))
+	SetSchedule(name, schedule string) error
+	Request(name string, protocol, dest, port string, age int) (schema.KnockRule, error)
+	Approve(id string) error
+	Reject(id string) error
+	ListRequest(call func(obj schema.KnockRule))
+	ListAudit(call func(obj schema.ZTrustEvent))
+	AddPolicy(data schema.ZPolicy) error
+	DelPolicy(name string) error
+	ListPolicy(call func(obj schema.ZPolicy))
 }
 
 type RouteApi interface {
@@ -84,10 +134,13 @@ type VPNApi interface {
 }
 
 type QosApi interface {
-	AddQos(name string, inSpeed float64) error
-	UpdateQos(name string, inSpeed float64) error
+	AddQos(data schema.Qos) error
+	UpdateQos(data schema.Qos) error
 	DelQos(name string) error
 	ListQos(call func(obj schema.Qos))
+	AddPriority(data schema.QosPriority) error
+	DelPriority(name string) error
+	ListPriority(call func(obj schema.QosPriority))
 	SaveQos()
 }
 
@@ -104,6 +157,35 @@ type FindHopApi interface {
 	SaveHop()
 }
 
+type FabricApi interface {
+	Get() *schema.VxLAN
+	AddPeer(remote string) error
+	DelPeer(remote string) error
+}
+
+type WireGuardApi interface {
+	Get() *schema.WireGuard
+	AddPeer(data schema.WireGuardPeer) error
+	DelPeer(name string) error
+	Profile(name, server string) (string, error)
+}
+
+type MeshApi interface {
+	Get() *schema.Mesh
+}
+
+type GuardApi interface {
+	ListGuard(call func(obj schema.GuardBinding))
+}
+
+type FastPathApi interface {
+	Get() schema.FastPath
+}
+
+type SuppressApi interface {
+	Get() schema.Suppress
+}
+
 type NATApi interface {
 	AddDNAT(data schema.DNAT) error
 	DelDNAT(data schema.DNAT) error
@@ -138,6 +220,12 @@ type NetworkApi interface {
 	Qoser() QosApi
 	ACLer() ACLApi
 	FindHoper() FindHopApi
+	Fabricer() FabricApi
+	Mesher() MeshApi
+	WireGuarder() WireGuardApi
+	Guarder() GuardApi
+	FastPather() FastPathApi
+	Suppressor() SuppressApi
 	DoZTrust() error
 	UndoZTrust() error
 	NATApi
@@ -162,6 +250,7 @@ type BgpApi interface {
 	DelReceives(data schema.BgpPrefix)
 	AddAdvertis(data schema.BgpPrefix)
 	DelAdvertis(data schema.BgpPrefix)
+	ListRoutes(neighbor string) ([]schema.BgpRoute, error)
 }
 
 type CeciApi interface {
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Audit struct {
+}
+
+func (h Audit) Router(router *mux.Router) {
+	router.HandleFunc("/api/audit", h.List).Methods("GET")
+}
+
+func (h Audit) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := libol.AuditFilter{
+		Type:    query.Get("type"),
+		User:    query.Get("user"),
+		Network: query.Get("network"),
+		Action:  query.Get("action"),
+		Result:  query.Get("result"),
+		Limit:   100,
+	}
+	var err error
+	if value := query.Get("since"); value != "" {
+		if filter.Since, err = strconv.ParseInt(value, 10, 64); err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("until"); value != "" {
+		if filter.Until, err = strconv.ParseInt(value, 10, 64); err != nil {
+			http.Error(w, "invalid until", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	items := make([]schema.Audit, 0, 128)
+	for _, e := range libol.Auditor.List(filter) {
+		items = append(items, schema.NewAuditSchema(e))
+	}
+	ResponseJson(w, items)
+}
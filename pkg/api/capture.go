@@ -0,0 +1,81 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Capture struct {
+	cs SwitchApi
+}
+
+func (h Capture) Router(router *mux.Router) {
+	router.HandleFunc("/api/capture", h.List).Methods("GET")
+	router.HandleFunc("/api/capture", h.Add).Methods("POST")
+	router.HandleFunc("/api/capture/{id}", h.Del).Methods("DELETE")
+}
+
+func (h Capture) List(w http.ResponseWriter, r *http.Request) {
+	items := make([]schema.CaptureState, 0, 4)
+	h.cs.ListCapture(func(obj schema.CaptureState) {
+		items = append(items, obj)
+	})
+	ResponseJson(w, items)
+}
+
+// flushWriter sends pcap to client as soon as written.
+type flushWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	f.written = true
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// Start sends id of the capture, which could be stopped by it.
+func (f *flushWriter) Start(state schema.CaptureState) {
+	if rw, ok := f.w.(http.ResponseWriter); ok {
+		rw.Header().Set("X-Capture-Id", state.Id)
+	}
+}
+
+func (h Capture) Add(w http.ResponseWriter, r *http.Request) {
+	data := schema.Capture{}
+	if err := GetData(r, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if data.File != "" {
+		state, err := h.cs.AddCapture(r.Context(), data, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ResponseJson(w, state)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+	WriteAttachment(w, data.Network+".pcap")
+	out := &flushWriter{w: w}
+	if _, err := h.cs.AddCapture(r.Context(), data, out); err != nil && !out.written {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (h Capture) Del(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.cs.DelCapture(vars["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Confirm struct {
+	cs SwitchApi
+}
+
+func (h Confirm) Router(router *mux.Router) {
+	router.HandleFunc("/api/confirm", h.List).Methods("GET")
+	router.HandleFunc("/api/network/{id}/confirm", h.Commit).Methods("POST")
+	router.HandleFunc("/api/network/{id}/confirm", h.Rollback).Methods("DELETE")
+}
+
+func (h Confirm) List(w http.ResponseWriter, r *http.Request) {
+	items := make([]schema.Confirm, 0, 32)
+	h.cs.ListConfirm(func(obj schema.Confirm) {
+		items = append(items, obj)
+	})
+	ResponseJson(w, items)
+}
+
+func (h Confirm) Commit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.cs.Commit(id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, "success")
+}
+
+func (h Confirm) Rollback(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.cs.Rollback(id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, "success")
+}
+
+// GetConfirm returns the network and the seconds to wait for a confirm,
+// when a change request carries ?confirm=<seconds>.
+func GetConfirm(r *http.Request) (string, int) {
+	if r.Method == "GET" {
+		return "", 0
+	}
+	timeout, _ := strconv.Atoi(GetQueryOne(r, "confirm"))
+	if timeout <= 0 {
+		return "", 0
+	}
+	// URL: /api/network/<id>/<rest>.
+	elements := strings.SplitN(r.URL.Path, "/", 5)
+	if len(elements) < 4 || elements[1] != "api" || elements[2] != "network" {
+		return "", 0
+	}
+	if len(elements) == 5 && elements[4] == "confirm" {
+		return "", 0
+	}
+	return elements[3], timeout
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type FastPath struct {
+	cs SwitchApi
+}
+
+func (h FastPath) Router(router *mux.Router) {
+	router.HandleFunc("/api/network/{id}/fastpath", h.Get).Methods("GET")
+}
+
+func (h FastPath) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	fast := worker.FastPather()
+	if fast == nil {
+		http.Error(w, "FastPath disabled", http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, fast.Get())
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Guard struct {
+	cs SwitchApi
+}
+
+func (h Guard) Router(router *mux.Router) {
+	router.HandleFunc("/api/network/{id}/guard", h.List).Methods("GET")
+}
+
+func ListGuards() []schema.GuardBinding {
+	items := make([]schema.GuardBinding, 0, 32)
+	Call.ListWorker(func(w NetworkApi) {
+		if guard := w.Guarder(); guard != nil {
+			guard.ListGuard(func(obj schema.GuardBinding) {
+				items = append(items, obj)
+			})
+		}
+	})
+	return items
+}
+
+func (h Guard) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	guard := worker.Guarder()
+	if guard == nil {
+		http.Error(w, "Guard disabled", http.StatusBadRequest)
+		return
+	}
+	items := make([]schema.GuardBinding, 0, 32)
+	guard.ListGuard(func(obj schema.GuardBinding) {
+		items = append(items, obj)
+	})
+	ResponseJson(w, items)
+}
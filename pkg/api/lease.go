@@ -1,10 +1,12 @@
 package api
 
 import (
+	"net"
+	"net/http"
+
 	"github.com/gorilla/mux"
 	"github.com/luscis/openlan/pkg/cache"
 	"github.com/luscis/openlan/pkg/schema"
-	"net/http"
 )
 
 type Lease struct {
@@ -12,16 +14,72 @@ type Lease struct {
 
 func (l Lease) Router(router *mux.Router) {
 	router.HandleFunc("/api/lease", l.List).Methods("GET")
+	router.HandleFunc("/api/lease", l.Post).Methods("POST")
+	router.HandleFunc("/api/lease", l.Delete).Methods("DELETE")
+	router.HandleFunc("/api/lease/history", l.History).Methods("GET")
 	router.HandleFunc("/api/lease/{id}", l.List).Methods("GET")
 }
 
 func (l Lease) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	cache.Network.Expire()
 	nets := make([]schema.Lease, 0, 1024)
 	for u := range cache.Network.ListLease() {
 		if u == nil {
 			break
 		}
+		if id != "" && u.Network != id {
+			continue
+		}
 		nets = append(nets, *u)
 	}
 	ResponseJson(w, nets)
 }
+
+func (l Lease) Post(w http.ResponseWriter, r *http.Request) {
+	value := &schema.Lease{}
+	if err := GetData(r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if cache.Network.Get(value.Network) == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	if net.ParseIP(value.Address) == nil {
+		http.Error(w, "invalid address", http.StatusBadRequest)
+		return
+	}
+	if _, err := cache.Network.Reserve(value.Alias, value.Address, value.Network); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	ResponseJson(w, "success")
+}
+
+func (l Lease) Delete(w http.ResponseWriter, r *http.Request) {
+	value := &schema.Lease{}
+	if err := GetData(r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lease := cache.Network.GetLease(value.Alias, value.Network)
+	if lease == nil {
+		http.Error(w, "Lease not found", http.StatusNotFound)
+		return
+	}
+	if lease.Type == "static" {
+		http.Error(w, "static lease is configured by hosts", http.StatusBadRequest)
+		return
+	}
+	cache.Network.DelLease(value.Alias, value.Network)
+	ResponseJson(w, "success")
+}
+
+func (l Lease) History(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	items := cache.Network.ListHistory(query.Get("network"), query.Get("alias"))
+	ResponseJson(w, items)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Lockout struct {
+}
+
+func (h Lockout) Router(router *mux.Router) {
+	router.HandleFunc("/api/lockout", h.List).Methods("GET")
+	router.HandleFunc("/api/lockout", h.Clear).Methods("DELETE")
+	router.HandleFunc("/api/lockout/{id}", h.Clear).Methods("DELETE")
+}
+
+func (h Lockout) List(w http.ResponseWriter, r *http.Request) {
+	items := make([]schema.Lockout, 0, 32)
+	for _, e := range cache.Lockout.List() {
+		items = append(items, schema.NewLockoutSchema(e))
+	}
+	ResponseJson(w, items)
+}
+
+func (h Lockout) Clear(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := cache.Lockout.Clear(vars["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
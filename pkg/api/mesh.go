@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Mesh struct {
+	cs SwitchApi
+}
+
+func (h Mesh) Router(router *mux.Router) {
+	router.HandleFunc("/api/network/{id}/mesh", h.Get).Methods("GET")
+}
+
+func (h Mesh) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	mesh := worker.Mesher()
+	if mesh == nil {
+		http.Error(w, "Mesh disabled", http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, mesh.Get())
+}
@@ -956,6 +956,7 @@ func (h Bgp) Router(router *mux.Router) {
 	router.HandleFunc("/api/network/bgp/global", h.Remove).Methods("DELETE")
 	router.HandleFunc("/api/network/bgp/neighbor", h.RemoveNeighbor).Methods("DELETE")
 	router.HandleFunc("/api/network/bgp/neighbor", h.AddNeighbor).Methods("POST")
+	router.HandleFunc("/api/network/bgp/neighbor/{address}/route", h.ListRoutes).Methods("GET")
 	router.HandleFunc("/api/network/bgp/advertis", h.RemoveAdvertis).Methods("DELETE")
 	router.HandleFunc("/api/network/bgp/advertis", h.AddAdvertis).Methods("POST")
 	router.HandleFunc("/api/network/bgp/receives", h.RemoveReceivess).Methods("DELETE")
@@ -963,7 +964,7 @@ func (h Bgp) Router(router *mux.Router) {
 }
 
 func (h Bgp) Get(w http.ResponseWriter, r *http.Request) {
-	libol.Debug("Bgp.Get %s")
+	libol.Debug("Bgp.Get")
 	if Call.bgpApi == nil {
 		http.Error(w, "network is nil", http.StatusBadRequest)
 		return
@@ -1019,10 +1020,29 @@ func (h Bgp) AddNeighbor(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "network is nil", http.StatusBadRequest)
 		return
 	}
+	if nei.Prepend < 0 || nei.Prepend > 16 {
+		http.Error(w, "prepend out of range 0-16", http.StatusBadRequest)
+		return
+	}
 	Call.bgpApi.AddNeighbor(nei)
 	ResponseMsg(w, 0, "")
 }
 
+func (h Bgp) ListRoutes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	address := vars["address"]
+	if Call.bgpApi == nil {
+		http.Error(w, "network is nil", http.StatusBadRequest)
+		return
+	}
+	routes, err := Call.bgpApi.ListRoutes(address)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, routes)
+}
+
 func (h Bgp) RemoveAdvertis(w http.ResponseWriter, r *http.Request) {
 	data := schema.BgpPrefix{}
 	if err := GetData(r, &data); err != nil {
@@ -1275,8 +1295,19 @@ func (h IPSec) Router(router *mux.Router) {
 	router.HandleFunc("/api/network/ipsec/tunnel/restart", h.Start).Methods("PUT")
 }
 
+func ListIPSecTunnels() []schema.IPSecTunnel {
+	tunnels := make([]schema.IPSecTunnel, 0, 32)
+	if Call.ipsecApi == nil {
+		return tunnels
+	}
+	Call.ipsecApi.ListTunnels(func(obj schema.IPSecTunnel) {
+		tunnels = append(tunnels, obj)
+	})
+	return tunnels
+}
+
 func (h IPSec) Get(w http.ResponseWriter, r *http.Request) {
-	libol.Debug("IPSec.Get %s")
+	libol.Debug("IPSec.Get")
 	tunnels := make([]schema.IPSecTunnel, 0, 1024)
 	if Call.ipsecApi == nil {
 		http.Error(w, "network is nil", http.StatusBadRequest)
@@ -1294,6 +1325,14 @@ func (h IPSec) Post(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if tun.Secret == "" && tun.Cert == "" {
+		http.Error(w, "secret or cert is required", http.StatusBadRequest)
+		return
+	}
+	if tun.Ike != "" && tun.Ike != "ikev1" && tun.Ike != "ikev2" {
+		http.Error(w, "ike is ikev1 or ikev2", http.StatusBadRequest)
+		return
+	}
 	if Call.ipsecApi == nil {
 		http.Error(w, "network is nil", http.StatusBadRequest)
 		return
@@ -1430,6 +1469,9 @@ func (h ClientQoS) Router(router *mux.Router) {
 	router.HandleFunc("/api/network/{id}/qos", h.Add).Methods("POST")
 	router.HandleFunc("/api/network/{id}/qos", h.Del).Methods("DELETE")
 	router.HandleFunc("/api/network/{id}/qos", h.Save).Methods("PUT")
+	router.HandleFunc("/api/network/{id}/qos/priority", h.ListPriority).Methods("GET")
+	router.HandleFunc("/api/network/{id}/qos/priority", h.AddPriority).Methods("POST")
+	router.HandleFunc("/api/network/{id}/qos/priority", h.DelPriority).Methods("DELETE")
 }
 
 func (h ClientQoS) List(w http.ResponseWriter, r *http.Request) {
@@ -1467,7 +1509,7 @@ func (h ClientQoS) Add(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Network not found", http.StatusBadRequest)
 		return
 	}
-	if err := worker.Qoser().AddQos(qos.Name, qos.InSpeed); err != nil {
+	if err := worker.Qoser().AddQos(*qos); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -1512,3 +1554,63 @@ func (h ClientQoS) Save(w http.ResponseWriter, r *http.Request) {
 
 	ResponseJson(w, "success")
 }
+
+func (h ClientQoS) ListPriority(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	items := make([]schema.QosPriority, 0, 32)
+	worker.Qoser().ListPriority(func(obj schema.QosPriority) {
+		items = append(items, obj)
+	})
+	ResponseJson(w, items)
+}
+
+func (h ClientQoS) AddPriority(w http.ResponseWriter, r *http.Request) {
+	value := &schema.QosPriority{}
+	if err := GetData(r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	if err := worker.Qoser().AddPriority(*value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, true)
+}
+
+func (h ClientQoS) DelPriority(w http.ResponseWriter, r *http.Request) {
+	value := &schema.QosPriority{}
+	if err := GetData(r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	if err := worker.Qoser().DelPriority(value.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, true)
+}
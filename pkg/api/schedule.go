@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Schedule struct {
+	cs SwitchApi
+}
+
+func (h Schedule) Router(router *mux.Router) {
+	router.HandleFunc("/api/schedule", h.List).Methods("GET")
+	router.HandleFunc("/api/schedule", h.Add).Methods("POST")
+	router.HandleFunc("/api/schedule/{name}", h.Del).Methods("DELETE")
+}
+
+func (h Schedule) List(w http.ResponseWriter, r *http.Request) {
+	items := make([]schema.Schedule, 0, 32)
+	h.cs.ListSchedule(func(obj schema.Schedule) {
+		items = append(items, obj)
+	})
+	ResponseJson(w, items)
+}
+
+func (h Schedule) Add(w http.ResponseWriter, r *http.Request) {
+	data := schema.Schedule{}
+	if err := GetData(r, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.cs.AddSchedule(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
+
+func (h Schedule) Del(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.cs.DelSchedule(vars["name"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Suppress struct {
+	cs SwitchApi
+}
+
+func (h Suppress) Router(router *mux.Router) {
+	router.HandleFunc("/api/network/{id}/suppress", h.Get).Methods("GET")
+}
+
+func ListSuppress() []schema.Suppress {
+	items := make([]schema.Suppress, 0, 8)
+	Call.ListWorker(func(w NetworkApi) {
+		if suppress := w.Suppressor(); suppress != nil {
+			items = append(items, suppress.Get())
+		}
+	})
+	return items
+}
+
+func (h Suppress) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return
+	}
+	suppress := worker.Suppressor()
+	if suppress == nil {
+		http.Error(w, "Suppress disabled", http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, suppress.Get())
+}
@@ -261,6 +261,8 @@ type Log struct {
 func (l Log) Router(router *mux.Router) {
 	router.HandleFunc("/api/log", l.List).Methods("GET")
 	router.HandleFunc("/api/log", l.Add).Methods("POST")
+	router.HandleFunc("/api/log/module", l.AddModule).Methods("POST")
+	router.HandleFunc("/api/log/module", l.DelModule).Methods("DELETE")
 }
 
 func (l Log) List(w http.ResponseWriter, r *http.Request) {
@@ -280,6 +282,32 @@ func (l Log) Add(w http.ResponseWriter, r *http.Request) {
 	ResponseMsg(w, 0, "")
 }
 
+func (l Log) AddModule(w http.ResponseWriter, r *http.Request) {
+	value := &schema.LogModule{}
+	if err := GetData(r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if value.Name == "" || value.Level <= 0 {
+		http.Error(w, "invalid module or level", http.StatusBadRequest)
+		return
+	}
+	libol.SetModule(value.Name, value.Level)
+
+	ResponseMsg(w, 0, "")
+}
+
+func (l Log) DelModule(w http.ResponseWriter, r *http.Request) {
+	value := &schema.LogModule{}
+	if err := GetData(r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	libol.SetModule(value.Name, 0)
+
+	ResponseMsg(w, 0, "")
+}
+
 type LDAP struct {
 	cs SwitchApi
 }
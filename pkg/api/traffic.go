@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Traffic struct {
+	cs SwitchApi
+}
+
+func (h Traffic) Router(router *mux.Router) {
+	router.HandleFunc("/api/traffic", h.List).Methods("GET")
+	router.HandleFunc("/api/traffic/{id}", h.Reset).Methods("DELETE")
+	router.HandleFunc("/api/network/{id}/quota", h.ListQuota).Methods("GET")
+	router.HandleFunc("/api/network/{id}/quota", h.AddQuota).Methods("POST")
+	router.HandleFunc("/api/network/{id}/quota", h.DelQuota).Methods("DELETE")
+}
+
+func (h Traffic) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	items := cache.Traffic.List(query.Get("network"), query.Get("user"))
+	ResponseJson(w, items)
+}
+
+func (h Traffic) Reset(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.cs.ResetTraffic(id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
+
+func (h Traffic) ListQuota(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	items := make([]schema.Quota, 0, 32)
+	if err := h.cs.ListQuota(id, func(obj schema.Quota) {
+		items = append(items, obj)
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, items)
+}
+
+func (h Traffic) AddQuota(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	value := &schema.Quota{}
+	if err := GetData(r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	value.Network = id
+	if err := h.cs.AddQuota(*value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
+
+func (h Traffic) DelQuota(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	value := &schema.Quota{}
+	if err := GetData(r, value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	value.Network = id
+	if err := h.cs.DelQuota(*value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
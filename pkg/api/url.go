@@ -17,6 +17,8 @@ func Add(router *mux.Router, cs SwitchApi) {
 	Config{cs: cs}.Router(router)
 	Version{cs: cs}.Router(router)
 	Log{}.Router(router)
+	Audit{}.Router(router)
+	Lockout{}.Router(router)
 	RateLimit{cs: cs}.Router(router)
 	Ceci{cs: cs}.Router(router)
 	Bgp{}.Router(router)
@@ -40,5 +42,15 @@ func Add(router *mux.Router, cs SwitchApi) {
 	RouterPrivate{}.Router(router)
 	RouterInterface{}.Router(router)
 	Routeredirect{}.Router(router)
+	VxLAN{cs: cs}.Router(router)
+	Mesh{cs: cs}.Router(router)
+	Guard{cs: cs}.Router(router)
+	FastPath{cs: cs}.Router(router)
+	Suppress{cs: cs}.Router(router)
+	Traffic{cs: cs}.Router(router)
+	WireGuard{cs: cs}.Router(router)
+	Confirm{cs: cs}.Router(router)
+	Capture{cs: cs}.Router(router)
+	Schedule{cs: cs}.Router(router)
 	Network{cs: cs}.Router(router)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/luscis/openlan/pkg/cache"
@@ -95,9 +96,41 @@ func (h User) Check(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	event := libol.AuditEvent{
+		Type:    "openvpn",
+		User:    user.Name,
+		Source:  user.Alias,
+		Network: GetNetwork(user.Name),
+		Action:  "connect",
+		Result:  "success",
+	}
+	if err := cache.Lockout.Check(user.Name, user.Alias); err != nil {
+		event.Result = "failure"
+		event.Detail = err.Error()
+		libol.Audit(event)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	name := user.Name
+	if !strings.Contains(name, "@") {
+		name += "@" + event.Network
+	}
+	if cache.Traffic.Blocked(name) {
+		event.Result = "failure"
+		event.Detail = "out of quota"
+		libol.Audit(event)
+		http.Error(w, name+" out of quota", http.StatusForbidden)
+		return
+	}
 	if err := UserCheck(user.Name, user.Password); err == nil {
+		cache.Lockout.Success(user.Name)
+		libol.Audit(event)
 		ResponseMsg(w, 0, "success")
 	} else {
+		cache.Lockout.Fail(user.Name, user.Alias)
+		event.Result = "failure"
+		event.Detail = err.Error()
+		libol.Audit(event)
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type VxLAN struct {
+	cs SwitchApi
+}
+
+func (h VxLAN) Router(router *mux.Router) {
+	router.HandleFunc("/api/network/{id}/vxlan", h.Get).Methods("GET")
+	router.HandleFunc("/api/network/{id}/vxlan/peer", h.AddPeer).Methods("POST")
+	router.HandleFunc("/api/network/{id}/vxlan/peer", h.DelPeer).Methods("DELETE")
+}
+
+func (h VxLAN) getFabric(w http.ResponseWriter, r *http.Request) FabricApi {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return nil
+	}
+	fabric := worker.Fabricer()
+	if fabric == nil {
+		http.Error(w, "VxLAN disabled", http.StatusBadRequest)
+		return nil
+	}
+	return fabric
+}
+
+func (h VxLAN) Get(w http.ResponseWriter, r *http.Request) {
+	fabric := h.getFabric(w, r)
+	if fabric == nil {
+		return
+	}
+	ResponseJson(w, fabric.Get())
+}
+
+func (h VxLAN) AddPeer(w http.ResponseWriter, r *http.Request) {
+	fabric := h.getFabric(w, r)
+	if fabric == nil {
+		return
+	}
+	data := schema.VxLANPeer{}
+	if err := GetData(r, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := fabric.AddPeer(data.Remote); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, "success")
+}
+
+func (h VxLAN) DelPeer(w http.ResponseWriter, r *http.Request) {
+	fabric := h.getFabric(w, r)
+	if fabric == nil {
+		return
+	}
+	data := schema.VxLANPeer{}
+	if err := GetData(r, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := fabric.DelPeer(data.Remote); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, "success")
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type WireGuard struct {
+	cs SwitchApi
+}
+
+func (h WireGuard) Router(router *mux.Router) {
+	router.HandleFunc("/api/network/{id}/wireguard", h.Get).Methods("GET")
+	router.HandleFunc("/api/network/{id}/wireguard/peer", h.AddPeer).Methods("POST")
+	router.HandleFunc("/api/network/{id}/wireguard/peer", h.DelPeer).Methods("DELETE")
+	router.HandleFunc("/api/network/{id}/wireguard/peer/{name}", h.Profile).Methods("GET")
+}
+
+func (h WireGuard) getWireGuard(w http.ResponseWriter, r *http.Request) WireGuardApi {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	worker := Call.GetWorker(id)
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return nil
+	}
+	wg := worker.WireGuarder()
+	if wg == nil {
+		http.Error(w, "WireGuard disabled", http.StatusBadRequest)
+		return nil
+	}
+	return wg
+}
+
+func (h WireGuard) Get(w http.ResponseWriter, r *http.Request) {
+	wg := h.getWireGuard(w, r)
+	if wg == nil {
+		return
+	}
+	ResponseJson(w, wg.Get())
+}
+
+func (h WireGuard) AddPeer(w http.ResponseWriter, r *http.Request) {
+	wg := h.getWireGuard(w, r)
+	if wg == nil {
+		return
+	}
+	data := schema.WireGuardPeer{}
+	if err := GetData(r, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := wg.AddPeer(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, "success")
+}
+
+func (h WireGuard) DelPeer(w http.ResponseWriter, r *http.Request) {
+	wg := h.getWireGuard(w, r)
+	if wg == nil {
+		return
+	}
+	data := schema.WireGuardPeer{}
+	if err := GetData(r, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := wg.DelPeer(data.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseJson(w, "success")
+}
+
+func (h WireGuard) Profile(w http.ResponseWriter, r *http.Request) {
+	wg := h.getWireGuard(w, r)
+	if wg == nil {
+		return
+	}
+	vars := mux.Vars(r)
+	name := vars["name"]
+	data, err := wg.Profile(name, GetServer(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	WriteAttachment(w, name+".conf")
+	_, _ = w.Write([]byte(data))
+}
@@ -25,6 +25,13 @@ func (h ZTrust) Router(router *mux.Router) {
 	router.HandleFunc("/api/network/{id}/guest/{user}", h.DelGuest).Methods("DELETE")
 	router.HandleFunc("/api/network/{id}/guest/{user}/knock", h.ListKnock).Methods("GET")
 	router.HandleFunc("/api/network/{id}/guest/{user}/knock", h.AddKnock).Methods("POST")
+	router.HandleFunc("/api/network/{id}/ztrust/policy", h.ListPolicy).Methods("GET")
+	router.HandleFunc("/api/network/{id}/ztrust/policy", h.AddPolicy).Methods("POST")
+	router.HandleFunc("/api/network/{id}/ztrust/policy/{policy}", h.DelPolicy).Methods("DELETE")
+	router.HandleFunc("/api/network/{id}/ztrust/request", h.ListRequest).Methods("GET")
+	router.HandleFunc("/api/network/{id}/ztrust/request/{request}", h.Approve).Methods("PUT")
+	router.HandleFunc("/api/network/{id}/ztrust/request/{request}", h.Reject).Methods("DELETE")
+	router.HandleFunc("/api/network/{id}/ztrust/audit", h.ListAudit).Methods("GET")
 }
 
 func CheckUser(r *http.Request) (bool, string) {
@@ -155,6 +162,13 @@ func (h ZTrust) AddGuest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// only admin limits guests by schedule.
+	if admin {
+		if err := ztrust.SetSchedule(guest.Name, guest.Schedule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 	libol.Debug("ZTrust.AddGuest %s@%s", guest.Name, id)
 	if err := ztrust.AddGuest(guest.Name, guest.Address); err == nil {
 		ResponseJson(w, "success")
@@ -261,6 +275,16 @@ func (h ZTrust) AddKnock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	libol.Debug("ZTrust.AddKnock %s@%s", user, id)
+	if !admin {
+		// requested by the user itself, and allowed by policies.
+		obj, err := ztrust.Request(user, rule.Protocol, rule.Dest, rule.Port, rule.Age)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		ResponseJson(w, obj)
+		return
+	}
 	if err := ztrust.Knock(user, rule.Protocol, rule.Dest, rule.Port, rule.Age); err == nil {
 		ResponseJson(w, "success")
 	} else {
@@ -268,3 +292,110 @@ func (h ZTrust) AddKnock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func (h ZTrust) getZTrust(w http.ResponseWriter, r *http.Request) ZTrustApi {
+	vars := mux.Vars(r)
+	worker := Call.GetWorker(vars["id"])
+	if worker == nil {
+		http.Error(w, "Network not found", http.StatusBadRequest)
+		return nil
+	}
+	ztrust := worker.ZTruster()
+	if ztrust == nil {
+		http.Error(w, "ZTrust disabled", http.StatusBadRequest)
+		return nil
+	}
+	return ztrust
+}
+
+func (h ZTrust) ListPolicy(w http.ResponseWriter, r *http.Request) {
+	ztrust := h.getZTrust(w, r)
+	if ztrust == nil {
+		return
+	}
+	items := make([]schema.ZPolicy, 0, 32)
+	ztrust.ListPolicy(func(obj schema.ZPolicy) {
+		items = append(items, obj)
+	})
+	ResponseJson(w, items)
+}
+
+func (h ZTrust) AddPolicy(w http.ResponseWriter, r *http.Request) {
+	ztrust := h.getZTrust(w, r)
+	if ztrust == nil {
+		return
+	}
+	data := schema.ZPolicy{}
+	if err := GetData(r, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := ztrust.AddPolicy(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
+
+func (h ZTrust) DelPolicy(w http.ResponseWriter, r *http.Request) {
+	ztrust := h.getZTrust(w, r)
+	if ztrust == nil {
+		return
+	}
+	vars := mux.Vars(r)
+	if err := ztrust.DelPolicy(vars["policy"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
+
+func (h ZTrust) ListRequest(w http.ResponseWriter, r *http.Request) {
+	ztrust := h.getZTrust(w, r)
+	if ztrust == nil {
+		return
+	}
+	items := make([]schema.KnockRule, 0, 32)
+	ztrust.ListRequest(func(obj schema.KnockRule) {
+		items = append(items, obj)
+	})
+	ResponseJson(w, items)
+}
+
+func (h ZTrust) Approve(w http.ResponseWriter, r *http.Request) {
+	ztrust := h.getZTrust(w, r)
+	if ztrust == nil {
+		return
+	}
+	vars := mux.Vars(r)
+	if err := ztrust.Approve(vars["request"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
+
+func (h ZTrust) Reject(w http.ResponseWriter, r *http.Request) {
+	ztrust := h.getZTrust(w, r)
+	if ztrust == nil {
+		return
+	}
+	vars := mux.Vars(r)
+	if err := ztrust.Reject(vars["request"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ResponseMsg(w, 0, "")
+}
+
+func (h ZTrust) ListAudit(w http.ResponseWriter, r *http.Request) {
+	ztrust := h.getZTrust(w, r)
+	if ztrust == nil {
+		return
+	}
+	items := make([]schema.ZTrustEvent, 0, 1024)
+	ztrust.ListAudit(func(obj schema.ZTrustEvent) {
+		items = append(items, obj)
+	})
+	ResponseJson(w, items)
+}
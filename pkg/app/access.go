@@ -2,8 +2,10 @@ package app
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
 	"github.com/luscis/openlan/pkg/libol"
 	"github.com/luscis/openlan/pkg/libsock"
 	"github.com/luscis/openlan/pkg/models"
@@ -12,6 +14,7 @@ import (
 type Access struct {
 	success int
 	failed  int
+	locked  int
 	master  Master
 }
 
@@ -67,24 +70,99 @@ func (p *Access) handleLogin(client libsock.SocketClient, data []byte) error {
 	}
 	user.Update()
 	out.Info("Access.handleLogin: %s on %s", user.Id(), user.Alias)
+	event := libol.AuditEvent{
+		Type:    "login",
+		User:    user.Id(),
+		Source:  client.RemoteAddr(),
+		Network: user.Network,
+		Action:  "access",
+		Result:  "failure",
+	}
+	if err := cache.Lockout.Check(user.Id(), client.RemoteAddr()); err != nil {
+		p.locked++
+		client.SetStatus(libsock.ClUnAuth)
+		event.Detail = err.Error()
+		libol.Audit(event)
+		return err
+	}
+	if cache.Traffic.Blocked(user.Id()) {
+		// not a failure of password, so isn't locked out.
+		p.failed++
+		client.SetStatus(libsock.ClUnAuth)
+		event.Detail = "out of quota"
+		libol.Audit(event)
+		return libol.NewErr("%s out of quota", user.Id())
+	}
 	if now, err := cache.User.Check(user); now != nil {
+		if err := p.checkPosture(client, user); err != nil {
+			p.failed++
+			client.SetStatus(libsock.ClUnAuth)
+			event.Detail = err.Error()
+			libol.Audit(event)
+			return err
+		}
 		if now.Role != "admin" && now.Last != nil {
 			// To offline lastly client if guest.
 			p.master.OffClient(now.Last)
 		}
 		p.success++
+		cache.Lockout.Success(user.Id())
 		now.Last = client
 		client.SetStatus(libsock.ClAuth)
 		out.Info("Access.handleLogin: success")
+		event.Result = "success"
+		if user.Restricted {
+			event.Detail = "restricted by posture"
+		}
+		libol.Audit(event)
 		_ = p.onAuth(client, user)
 		return nil
 	} else {
 		p.failed++
+		cache.Lockout.Fail(user.Id(), client.RemoteAddr())
 		client.SetStatus(libsock.ClUnAuth)
+		event.Detail = err.Error()
+		libol.Audit(event)
 		return err
 	}
 }
 
+// checkPosture rejects a device not compliant with policy of network, or
+// restricts it by action of the policy if acl rules of restricted devices
+// are configured.
+func (p *Access) checkPosture(client libsock.SocketClient, user *models.User) error {
+	out := client.Out()
+	cfg := co.GetNetwork(user.Network)
+	if cfg == nil || cfg.Posture == nil {
+		return nil
+	}
+	violations := CheckPosture(cfg.Posture, user.Posture)
+	if len(violations) == 0 {
+		return nil
+	}
+	out.Warn("Access.checkPosture: %s %s", user.Id(), strings.Join(violations, ", "))
+	switch cfg.Posture.Action {
+	case "restrict":
+		if cfg.Fastpath == "enable" {
+			// rejected as acl rules are bypassed by fastpath.
+			out.Warn("Access.checkPosture: %s restricting is bypassed by fastpath", user.Network)
+			return libol.NewErr("posture: %s", strings.Join(violations, ", "))
+		}
+		if !Restrictable(user.Network) {
+			// rejected if no rules to restrict it.
+			out.Warn("Access.checkPosture: %s no acl rules of posture:restricted", user.Network)
+			return libol.NewErr("posture: %s", strings.Join(violations, ", "))
+		}
+		user.Restricted = true
+		user.Violations = violations
+	case "allow":
+		user.Violations = violations
+	default:
+		return libol.NewErr("posture: %s", strings.Join(violations, ", "))
+	}
+	return nil
+}
+
 func (p *Access) onAuth(client libsock.SocketClient, user *models.User) error {
 	out := client.Out()
 	if !client.Have(libsock.ClAuth) {
@@ -102,6 +180,7 @@ func (p *Access) onAuth(client libsock.SocketClient, user *models.User) error {
 	}
 	m := models.NewAccess(client, dev, proto)
 	m.SetUser(user)
+	m.Filters = p.master.Filters(user.Network)
 	// free point has same uuid.
 	if om := cache.Access.GetByUUID(m.UUID); om != nil {
 		out.Info("Access.onAuth: OffClient %s", om.Client)
@@ -121,6 +200,6 @@ func (p *Access) onAuth(client libsock.SocketClient, user *models.User) error {
 	return nil
 }
 
-func (p *Access) Stats() (success, failed int) {
-	return p.success, p.failed
+func (p *Access) Stats() (success, failed, locked int) {
+	return p.success, p.failed, p.locked
 }
@@ -2,6 +2,7 @@ package app
 
 import (
 	"github.com/luscis/openlan/pkg/libsock"
+	"github.com/luscis/openlan/pkg/models"
 	"github.com/luscis/openlan/pkg/network"
 )
 
@@ -11,4 +12,5 @@ type Master interface {
 	OffClient(client libsock.SocketClient)
 	ReadTap(device network.Taper, readAt func(f *libsock.FrameMessage) error)
 	NewTap(tenant string) (network.Taper, error)
+	Filters(tenant string) []models.Filter
 }
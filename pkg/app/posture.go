@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/models"
+)
+
+func hasString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPosture returns violations of a device by policy of network.
+func CheckPosture(policy *co.Posture, posture *models.Posture) []string {
+	if posture == nil {
+		return []string{"posture not reported"}
+	}
+	violations := make([]string, 0, 4)
+	if len(policy.Systems) > 0 && !hasString(policy.Systems, posture.System) {
+		violations = append(violations, fmt.Sprintf("system %s not allowed", posture.System))
+	}
+	if min, ok := policy.OsVersion[posture.System]; ok {
+		if libol.CompareVersion(posture.OsVersion, min) < 0 {
+			violations = append(violations, fmt.Sprintf("osVersion %q less than %s", posture.OsVersion, min))
+		}
+	}
+	if policy.Version != "" && libol.CompareVersion(posture.Version, policy.Version) < 0 {
+		violations = append(violations, fmt.Sprintf("version %q less than %s", posture.Version, policy.Version))
+	}
+	if policy.Encrypted && !posture.Encrypted {
+		violations = append(violations, "disk not encrypted")
+	}
+	if policy.Firewall && !posture.Firewall {
+		violations = append(violations, "firewall off")
+	}
+	for _, agent := range policy.Agents {
+		if !hasString(posture.Agents, agent) {
+			violations = append(violations, fmt.Sprintf("agent %s not running", agent))
+		}
+	}
+	return violations
+}
+
+// Restrictable checks whether the acl of network has rules for restricted
+// devices, otherwise restricting them is nothing.
+func Restrictable(network string) bool {
+	acl := co.GetAcl(network)
+	if acl == nil {
+		return false
+	}
+	for _, rule := range acl.Rules {
+		if rule.SrcIdentity == "posture:restricted" || rule.DstIdentity == "posture:restricted" {
+			return true
+		}
+	}
+	return false
+}
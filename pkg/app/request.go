@@ -55,6 +55,13 @@ func findLease(ifAddr string, p *models.Access) *schema.Lease {
 	alias := p.Alias
 	network := p.Network
 	lease := cache.Network.GetLease(alias, network) // try by alias firstly
+	if lease != nil && lease.State == "active" && lease.Client != p.Client.String() {
+		// the same alias is still online at another point.
+		if cache.Access.Get(lease.Client) != nil {
+			libol.Warn("findLease: %s@%s already used by %s", alias, network, lease.Client)
+			return nil
+		}
+	}
 	if ifAddr == "" {
 		if lease == nil { // now to alloc it.
 			lease = cache.Network.NewLease(alias, network)
@@ -63,22 +70,25 @@ func findLease(ifAddr string, p *models.Access) *schema.Lease {
 			return nil
 		}
 		// has static address.
-		lease.Client = p.Client.String()
-		return lease
+		return cache.Network.RenewLease(lease, p.Client.String())
 	}
 	ipAddr := strings.SplitN(ifAddr, "/", 2)[0]
+	has := cache.Network.GetLeaseByAddr(ipAddr, network)
 	if lease == nil { //renew it.
-		has := cache.Network.GetLeaseByAddr(ipAddr, network)
 		if has == nil {
 			lease = cache.Network.AddLease(alias, ipAddr, network)
 		} else {
 			lease = cache.Network.NewLease(alias, network)
 		}
-	} else if lease.Address != ipAddr { // update
-		lease = cache.Network.AddLease(alias, ipAddr, network)
+	} else if lease.Address != ipAddr && lease.Type == "dynamic" { // update
+		if has == nil {
+			lease = cache.Network.AddLease(alias, ipAddr, network)
+		}
+	}
+	if lease == nil {
+		return nil
 	}
-	lease.Client = p.Client.String()
-	return lease
+	return cache.Network.RenewLease(lease, p.Client.String())
 }
 
 func (r *Request) onIpAddr(client libsock.SocketClient, data []byte) {
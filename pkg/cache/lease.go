@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+const maxHistory = 1024
+
+type leaseDB struct {
+	Leases  []*schema.Lease     `json:"leases"`
+	History []schema.LeaseEvent `json:"history"`
+}
+
+func (w *network) SetFile(file string) {
+	w.File = file
+}
+
+// lifetime returns seconds of lease time and grace period of a network.
+func (w *network) lifetime(network string) (int64, int64) {
+	lease, grace := int64(86400), int64(300)
+	n := w.Get(network)
+	if n == nil {
+		return lease, grace
+	}
+	if cfg, ok := n.Config.(*config.Network); ok && cfg.Subnet != nil {
+		if cfg.Subnet.LeaseTime > 0 {
+			lease = int64(cfg.Subnet.LeaseTime)
+		}
+		if cfg.Subnet.Grace > 0 {
+			grace = int64(cfg.Subnet.Grace)
+		}
+	}
+	return lease, grace
+}
+
+func (w *network) record(action string, lease *schema.Lease, reason string) {
+	if len(w.History) >= maxHistory {
+		w.History = w.History[1:]
+	}
+	w.History = append(w.History, schema.LeaseEvent{
+		Time:    time.Now().Unix(),
+		Action:  action,
+		Alias:   lease.Alias,
+		Address: lease.Address,
+		Network: lease.Network,
+		Client:  lease.Client,
+		Reason:  reason,
+	})
+	libol.Audit(libol.AuditEvent{
+		Type:    "lease",
+		User:    lease.Alias,
+		Source:  lease.Client,
+		Network: lease.Network,
+		Action:  action,
+		Params:  lease.Address,
+		Detail:  reason,
+	})
+}
+
+// expire unbinds the released leases out of lease time from its alias, and
+// frees the addresses after the grace period.
+func (w *network) expire() {
+	now := time.Now().Unix()
+	leases := make([]*schema.Lease, 0, 32)
+	w.Addr.Iter(func(k string, v interface{}) {
+		leases = append(leases, v.(*schema.Lease))
+	})
+	for _, lease := range leases {
+		if lease.Type != "dynamic" || lease.ExpireAt == 0 || lease.ExpireAt > now {
+			continue
+		}
+		uuid := lease.Alias + "@" + lease.Network
+		if lease.State == "released" {
+			libol.Info("network.expire {%s %s}", uuid, lease.Address)
+			if obj := w.UUID.Get(uuid); obj == lease {
+				w.UUID.Del(uuid)
+			}
+			lease = w.update(lease, func(obj *schema.Lease) {
+				obj.State = "expired"
+			})
+			w.record("expire", lease, "")
+			w.dirty = true
+		}
+		_, grace := w.lifetime(lease.Network)
+		if lease.ExpireAt+grace <= now {
+			w.Addr.Del(lease.Address + "@" + lease.Network)
+			w.freeAddr(lease.Address, lease.Network)
+			w.dirty = true
+		}
+	}
+}
+
+// Expire is called by a timer, and not by allocating.
+func (w *network) Expire() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.expire()
+}
+
+// RenewLease marks the lease is used by the client, and returns the lease
+// renewed.
+func (w *network) RenewLease(lease *schema.Lease, client string) *schema.Lease {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	// the lease may be renewed by others.
+	older := w.GetLeaseByAddr(lease.Address, lease.Network)
+	if older == nil || older.Alias != lease.Alias {
+		return lease
+	}
+	obj := w.update(older, func(obj *schema.Lease) {
+		obj.State = "active"
+		obj.Client = client
+		obj.UpdateAt = time.Now().Unix()
+		obj.ExpireAt = 0
+	})
+	if older.State != "active" || older.Client != client {
+		w.record("renew", obj, "")
+	}
+	w.dirty = true
+	return obj
+}
+
+// ReleaseLease keeps the lease for the alias in lease time, and the alias
+// gets the same address if come back.
+func (w *network) ReleaseLease(alias, network string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	lease := w.GetLease(alias, network)
+	if lease == nil || lease.Type == "static" || lease.State != "active" {
+		return
+	}
+	now := time.Now().Unix()
+	obj := w.update(lease, func(obj *schema.Lease) {
+		obj.State = "released"
+		obj.UpdateAt = now
+		if obj.Type == "dynamic" {
+			expire, _ := w.lifetime(network)
+			obj.ExpireAt = now + expire
+		}
+		obj.Client = ""
+	})
+	event := *obj
+	event.Client = lease.Client
+	w.record("release", &event, "")
+	w.dirty = true
+}
+
+// AddStatic adds a static lease, and evicts the others on the address.
+func (w *network) AddStatic(alias, ipStr, network string) *schema.Lease {
+	if ipStr == "" || alias == "" {
+		return nil
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if has := w.GetLeaseByAddr(ipStr, network); has != nil && has.Alias != alias {
+		libol.Warn("network.AddStatic: %s conflicts with %s", ipStr, has.Alias)
+		w.record("conflict", has, "static for "+alias)
+		w.delLease(has.Alias, network)
+	}
+	obj := newLease(alias, ipStr, network)
+	obj.Type = "static"
+	w.addLease(obj)
+	w.dirty = true
+	return obj
+}
+
+// Reserve binds the address to the alias forever.
+func (w *network) Reserve(alias, ipStr, network string) (*schema.Lease, error) {
+	if ipStr == "" || alias == "" {
+		return nil, libol.NewErr("alias and address are required")
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	pool, ok := w.pools[network]
+	if !ok {
+		return nil, libol.NewErr("%s has no pool", network)
+	}
+	has := w.GetLeaseByAddr(ipStr, network)
+	if has != nil && has.Alias != alias {
+		return nil, libol.NewErr("%s already leased to %s", ipStr, has.Alias)
+	}
+	if has := w.GetLease(alias, network); has != nil && has.Type == "static" {
+		return nil, libol.NewErr("%s has static %s", alias, has.Address)
+	}
+	// the gateway and excluded are never reserved.
+	if has == nil && !pool.Reserve(ipStr) {
+		return nil, libol.NewErr("%s out of pool or excluded", ipStr)
+	}
+	obj := newLease(alias, ipStr, network)
+	obj.Type = "reserved"
+	obj.State = "released"
+	w.addLease(obj)
+	w.record("reserve", obj, "")
+	w.dirty = true
+	return obj, nil
+}
+
+func (w *network) ListHistory(network, alias string) []schema.LeaseEvent {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	items := make([]schema.LeaseEvent, 0, len(w.History))
+	for _, obj := range w.History {
+		if network != "" && obj.Network != network {
+			continue
+		}
+		if alias != "" && obj.Alias != alias {
+			continue
+		}
+		items = append(items, obj)
+	}
+	return items
+}
+
+// Save writes leases to the file if changed. Leases aren't changed after
+// added, so they're written out of the lock.
+func (w *network) Save() {
+	w.saving.Lock()
+	defer w.saving.Unlock()
+
+	w.lock.Lock()
+	if w.File == "" || !w.dirty {
+		w.lock.Unlock()
+		return
+	}
+	file := w.File
+	data := &leaseDB{
+		Leases:  make([]*schema.Lease, 0, 32),
+		History: append([]schema.LeaseEvent{}, w.History...),
+	}
+	w.Addr.Iter(func(k string, v interface{}) {
+		lease := v.(*schema.Lease)
+		if lease.Type != "static" {
+			data.Leases = append(data.Leases, lease)
+		}
+	})
+	w.dirty = false
+	w.lock.Unlock()
+
+	if err := libol.MarshalSave(data, file, false); err != nil {
+		libol.Warn("network.Save: %s", err)
+		w.lock.Lock()
+		w.dirty = true
+		w.lock.Unlock()
+	}
+}
+
+// Load restores leases from the file. A lease is dropped if its address
+// is used by the static hosts already.
+func (w *network) Load() {
+	if w.File == "" {
+		return
+	}
+	data := &leaseDB{}
+	if err := libol.UnmarshalLoad(data, w.File); err != nil {
+		libol.Debug("network.Load: %s", err)
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.History = data.History
+	now := time.Now().Unix()
+	for _, lease := range data.Leases {
+		if lease.Alias == "" || lease.Address == "" {
+			continue
+		}
+		if has := w.GetLeaseByAddr(lease.Address, lease.Network); has != nil && has.Alias != lease.Alias {
+			libol.Warn("network.Load: %s conflicts with %s", lease.Address, has.Alias)
+			w.record("conflict", lease, "used by "+has.Alias)
+			continue
+		}
+		if has := w.GetLease(lease.Alias, lease.Network); has != nil && has.Type == "static" {
+			continue
+		}
+		if lease.State == "active" {
+			// clients are offline after restart.
+			lease.State = "released"
+			lease.Client = ""
+			if lease.Type == "dynamic" {
+				expire, _ := w.lifetime(lease.Network)
+				lease.ExpireAt = now + expire
+			}
+		}
+		if lease.State != "expired" {
+			_ = w.UUID.Mod(lease.Alias+"@"+lease.Network, lease)
+		}
+		_ = w.Addr.Mod(lease.Address+"@"+lease.Network, lease)
+		w.holdAddr(lease.Address, lease.Network)
+	}
+	w.expire()
+	w.dirty = true
+}
@@ -0,0 +1,6 @@
+package cache
+
+import "github.com/luscis/openlan/pkg/libol"
+
+// Lockout tracks failed logins of access, openvpn and http api.
+var Lockout = libol.NewLockout()
@@ -1,10 +1,11 @@
 package cache
 
 import (
-	"encoding/binary"
 	"net"
+	"sync"
 	"time"
 
+	"github.com/luscis/openlan/pkg/config"
 	"github.com/luscis/openlan/pkg/libol"
 	"github.com/luscis/openlan/pkg/models"
 	"github.com/luscis/openlan/pkg/schema"
@@ -14,14 +15,81 @@ type network struct {
 	Networks *libol.SafeStrMap
 	UUID     *libol.SafeStrMap
 	Addr     *libol.SafeStrMap
+	File     string
+	History  []schema.LeaseEvent
+	pools    map[string]*Pool
+	lock     sync.Mutex
+	dirty    bool       // leases changed and not saved.
+	saving   sync.Mutex // file written out of lock.
 }
 
 func (w *network) Add(n *models.Network) {
 	_ = w.Networks.Mod(n.Name, n)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.newPool(n)
 }
 
 func (w *network) Del(name string) {
 	w.Networks.Del(name)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	delete(w.pools, name)
+}
+
+func (w *network) newPool(n *models.Network) {
+	ranges := make([]string, 0, 4)
+	if n.IpStart != "" && n.IpEnd != "" {
+		ranges = append(ranges, n.IpStart+"-"+n.IpEnd)
+	}
+	excludes := make([]string, 0, 4)
+	if addr, _, err := net.ParseCIDR(n.Address); err == nil {
+		excludes = append(excludes, addr.String())
+	}
+	if cfg, ok := n.Config.(*config.Network); ok && cfg.Subnet != nil {
+		ranges = append(ranges, cfg.Subnet.Ranges...)
+		excludes = append(excludes, cfg.Subnet.Excludes...)
+	}
+	if len(ranges) == 0 {
+		delete(w.pools, n.Name)
+		return
+	}
+	pool, err := NewPool(ranges...)
+	if err != nil {
+		libol.Warn("network.newPool: %s %s", n.Name, err)
+		delete(w.pools, n.Name)
+		return
+	}
+	for _, addr := range excludes {
+		pool.Exclude(addr)
+	}
+	w.Addr.Iter(func(k string, v interface{}) {
+		lease := v.(*schema.Lease)
+		if lease.Network == n.Name {
+			pool.Reserve(lease.Address)
+		}
+	})
+	w.pools[n.Name] = pool
+}
+
+func (w *network) holdAddr(addr, network string) {
+	if pool, ok := w.pools[network]; ok {
+		pool.Reserve(addr)
+	}
+}
+
+func (w *network) freeAddr(addr, network string) {
+	if pool, ok := w.pools[network]; ok {
+		pool.Release(addr)
+	}
+}
+
+func (w *network) Pool(network string) *Pool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.pools[network]
 }
 
 func (w *network) Get(name string) *models.Network {
@@ -55,21 +123,9 @@ func (w *network) ListLease() <-chan *schema.Lease {
 	return c
 }
 
-func (w *network) allocLease(sAddr, eAddr, network string) string {
-	sIp := net.ParseIP(sAddr)
-	eIp := net.ParseIP(eAddr)
-	if sIp == nil || eIp == nil {
-		return ""
-	}
-	start := binary.BigEndian.Uint32(sIp.To4()[:4])
-	end := binary.BigEndian.Uint32(eIp.To4()[:4])
-	for i := start; i <= end; i++ {
-		tmp := make([]byte, 4)
-		binary.BigEndian.PutUint32(tmp[:4], i)
-		tmpStr := net.IP(tmp).String()
-		if ok := w.GetLeaseByAddr(tmpStr, network); ok == nil {
-			return tmpStr
-		}
+func (w *network) allocLease(network string) string {
+	if pool, ok := w.pools[network]; ok {
+		return pool.Alloc()
 	}
 	return ""
 }
@@ -79,17 +135,22 @@ func (w *network) NewLease(alias, network string) *schema.Lease {
 	if n == nil || alias == "" {
 		return nil
 	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
 	uuid := alias + "@" + network
 	if obj, ok := w.UUID.GetEx(uuid); ok {
-		l := obj.(*schema.Lease)
-		return l // how to resolve conflict with new point?.
+		// sticky lease of this alias.
+		return obj.(*schema.Lease)
 	}
-	ipStr := w.allocLease(n.IpStart, n.IpEnd, network)
+	ipStr := w.allocLease(network)
 	if ipStr == "" {
 		return nil
 	}
-	w.AddLease(alias, ipStr, network)
-	return w.GetLease(alias, network)
+	obj := w.addLease(newLease(alias, ipStr, network))
+	w.record("alloc", obj, "")
+	w.dirty = true
+	return obj
 }
 
 func (w *network) GetLease(alias string, network string) *schema.Lease {
@@ -108,29 +169,66 @@ func (w *network) GetLeaseByAddr(addr string, network string) *schema.Lease {
 	return nil
 }
 
+func newLease(alias, ipStr, network string) *schema.Lease {
+	now := time.Now().Unix()
+	return &schema.Lease{
+		Alias:    alias,
+		Address:  ipStr,
+		Network:  network,
+		Type:     "dynamic",
+		State:    "active",
+		CreateAt: now,
+		UpdateAt: now,
+	}
+}
+
+// addLease binds a new lease, and it's never changed after added.
+func (w *network) addLease(obj *schema.Lease) *schema.Lease {
+	uuid := obj.Alias + "@" + obj.Network
+	libol.Info("network.AddLease {%s %s}", uuid, obj.Address)
+	if older := w.UUID.Get(uuid); older != nil {
+		lease := older.(*schema.Lease)
+		ruid := lease.Address + "@" + obj.Network
+		w.Addr.Del(ruid)
+		w.freeAddr(lease.Address, obj.Network)
+	}
+	_ = w.UUID.Mod(uuid, obj)
+	ruid := obj.Address + "@" + obj.Network
+	_ = w.Addr.Mod(ruid, obj)
+	w.holdAddr(obj.Address, obj.Network)
+	return obj
+}
+
+// update replaces a lease by its copy changed. Leases are read without
+// the lock, so the older is never changed.
+func (w *network) update(lease *schema.Lease, change func(obj *schema.Lease)) *schema.Lease {
+	obj := *lease
+	change(&obj)
+	uuid := obj.Alias + "@" + obj.Network
+	if w.UUID.Get(uuid) == lease {
+		_ = w.UUID.Mod(uuid, &obj)
+	}
+	ruid := obj.Address + "@" + obj.Network
+	if w.Addr.Get(ruid) == lease {
+		_ = w.Addr.Mod(ruid, &obj)
+	}
+	return &obj
+}
+
 func (w *network) AddLease(alias, ipStr, network string) *schema.Lease {
 	if ipStr == "" || alias == "" {
 		return nil
 	}
-	uuid := alias + "@" + network
-	libol.Info("network.AddLease {%s %s}", uuid, ipStr)
-	obj := &schema.Lease{
-		Alias:   alias,
-		Address: ipStr,
-		Network: network,
-	}
-	if obj := w.UUID.Get(uuid); obj != nil {
-		lease := obj.(*schema.Lease)
-		ruid := lease.Address + "@" + network
-		w.Addr.Del(ruid)
-	}
-	_ = w.UUID.Set(uuid, obj)
-	ruid := ipStr + "@" + network
-	_ = w.Addr.Set(ruid, obj)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	obj := w.addLease(newLease(alias, ipStr, network))
+	w.record("alloc", obj, "")
+	w.dirty = true
 	return obj
 }
 
-func (w *network) DelLease(alias string, network string) {
+func (w *network) delLease(alias string, network string) {
 	uuid := alias + "@" + network
 	libol.Debug("network.DelLease %s", uuid)
 	addr := ""
@@ -140,6 +238,7 @@ func (w *network) DelLease(alias string, network string) {
 		libol.Info("network.DelLease {%s %s} by UUID", uuid, addr)
 		if lease.Type != "static" {
 			w.UUID.Del(uuid)
+			w.record("delete", lease, "")
 		}
 	}
 	ruid := addr + "@" + network
@@ -148,14 +247,24 @@ func (w *network) DelLease(alias string, network string) {
 		libol.Info("network.DelLease {%s %s} by Addr", ruid, alias)
 		if lease.Type != "static" {
 			w.Addr.Del(ruid)
+			w.freeAddr(addr, network)
 		}
 	}
 }
 
+func (w *network) DelLease(alias string, network string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.delLease(alias, network)
+	w.dirty = true
+}
+
 var Network = network{
 	Networks: libol.NewSafeStrMap(128),
-	UUID:     libol.NewSafeStrMap(1024),
-	Addr:     libol.NewSafeStrMap(1024),
+	UUID:     libol.NewSafeStrMap(0),
+	Addr:     libol.NewSafeStrMap(0),
+	pools:    make(map[string]*Pool),
 }
 
 const speedTimeout = 5
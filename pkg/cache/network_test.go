@@ -1,10 +1,15 @@
 package cache
 
 import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luscis/openlan/pkg/config"
 	"github.com/luscis/openlan/pkg/models"
 	"github.com/luscis/openlan/pkg/schema"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func Test_Network_LeaseAdd(t *testing.T) {
@@ -72,3 +77,125 @@ func Test_Network_LeaseAdd(t *testing.T) {
 		assert.Equal(t, "192.168.1.1", le2.Address, "MUST be .1")
 	}
 }
+
+func Test_Network_LeaseSticky(t *testing.T) {
+	n := &models.Network{
+		IpStart: "192.168.2.1",
+		IpEnd:   "192.168.2.3",
+		Name:    "fake-sticky",
+		Config: &config.Network{
+			Subnet: &config.Subnet{LeaseTime: 60, Grace: 30},
+		},
+	}
+	Network.Add(n)
+	Network.AddStatic("fake-host", "192.168.2.2", n.Name)
+
+	le1 := Network.NewLease("fake-aa", n.Name)
+	assert.Equal(t, "192.168.2.1", le1.Address, "MUST be .1")
+	Network.RenewLease(le1, "1.1.1.1:1000")
+	le2 := Network.NewLease("fake-bb", n.Name)
+	assert.Equal(t, "192.168.2.3", le2.Address, "MUST skip static .2")
+
+	// released lease is kept for the alias.
+	Network.ReleaseLease("fake-aa", n.Name)
+	assert.Equal(t, "active", le1.State, "MUST be copied on update")
+	assert.Equal(t, "released", Network.GetLease("fake-aa", n.Name).State)
+	assert.Nil(t, Network.NewLease("fake-cc", n.Name), "MUST be exhausted")
+	le3 := Network.NewLease("fake-aa", n.Name)
+	assert.Equal(t, "192.168.2.1", le3.Address, "MUST be same address")
+	le3 = Network.RenewLease(le3, "1.1.1.1:1001")
+	assert.Equal(t, "1.1.1.1:1001", le3.Client)
+
+	// expired lease is not reused in grace period.
+	Network.ReleaseLease("fake-aa", n.Name)
+	Network.GetLease("fake-aa", n.Name).ExpireAt = time.Now().Unix() - 1
+	Network.Expire()
+	assert.Nil(t, Network.NewLease("fake-cc", n.Name), "MUST be in grace")
+	assert.Equal(t, "expired", Network.GetLeaseByAddr("192.168.2.1", n.Name).State)
+	assert.Nil(t, Network.GetLease("fake-aa", n.Name), "MUST be unbound")
+	Network.GetLeaseByAddr("192.168.2.1", n.Name).ExpireAt = time.Now().Unix() - 31
+	Network.Expire()
+	le4 := Network.NewLease("fake-cc", n.Name)
+	assert.Equal(t, "192.168.2.1", le4.Address, "MUST be reused")
+
+	// reservation conflicts with leased address.
+	_, err := Network.Reserve("fake-dd", "192.168.2.2", n.Name)
+	assert.NotNil(t, err, "MUST conflict with static")
+	_, err = Network.Reserve("fake-dd", "192.168.2.10", n.Name)
+	assert.NotNil(t, err, "MUST be out of pool")
+	Network.DelLease("fake-bb", n.Name)
+	_, err = Network.Reserve("fake-dd", "192.168.2.3", n.Name)
+	assert.Nil(t, err)
+
+	history := Network.ListHistory(n.Name, "fake-aa")
+	actions := make([]string, 0, 8)
+	for _, obj := range history {
+		actions = append(actions, obj.Action)
+	}
+	assert.Equal(t, []string{"alloc", "renew", "release", "renew", "release", "expire"}, actions)
+}
+
+func Test_Network_LeaseLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lease.json")
+	n := &models.Network{
+		IpStart: "192.168.3.1",
+		IpEnd:   "192.168.3.10",
+		Name:    "fake-load",
+	}
+	Network.Add(n)
+	Network.SetFile(file)
+	defer Network.SetFile("")
+
+	le := Network.NewLease("fake-aa", n.Name)
+	Network.RenewLease(le, "1.1.1.1:1000")
+	Network.NewLease("fake-bb", n.Name)
+	Network.DelLease("fake-aa", n.Name)
+	Network.DelLease("fake-bb", n.Name)
+	assert.Nil(t, Network.GetLease("fake-aa", n.Name))
+
+	// restore from file, and fake-bb conflicts with a static host.
+	data := `{"leases": [
+		{"alias": "fake-aa", "address": "192.168.3.1", "network": "fake-load", "type": "dynamic", "state": "active"},
+		{"alias": "fake-bb", "address": "192.168.3.2", "network": "fake-load", "type": "dynamic", "state": "active"}
+	]}`
+	Network.AddStatic("fake-host", "192.168.3.2", n.Name)
+	assert.Nil(t, os.WriteFile(file, []byte(data), 0600))
+	Network.Load()
+	Network.Save()
+	le1 := Network.GetLease("fake-aa", n.Name)
+	assert.Equal(t, "192.168.3.1", le1.Address)
+	assert.Equal(t, "released", le1.State)
+	assert.Nil(t, Network.GetLease("fake-bb", n.Name), "MUST be dropped")
+	history := Network.ListHistory(n.Name, "fake-bb")
+	assert.Equal(t, "conflict", history[len(history)-1].Action)
+
+	// saved out of lock.
+	saved, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Contains(t, string(saved), `"fake-aa"`)
+	assert.NotContains(t, string(saved), `"fake-host"`)
+}
+
+func Test_Network_Reserve(t *testing.T) {
+	n := &models.Network{
+		Address: "192.168.5.1/24",
+		IpStart: "192.168.5.1",
+		IpEnd:   "192.168.5.10",
+		Name:    "fake-reserve",
+	}
+	Network.Add(n)
+
+	_, err := Network.Reserve("fake-aa", "192.168.5.1", n.Name)
+	assert.NotNil(t, err, "MUST not be gateway")
+	_, err = Network.Reserve("fake-aa", "192.168.5.20", n.Name)
+	assert.NotNil(t, err, "MUST be in pool")
+	le, err := Network.Reserve("fake-aa", "192.168.5.5", n.Name)
+	assert.Nil(t, err)
+	assert.Equal(t, "reserved", le.Type)
+	_, err = Network.Reserve("fake-aa", "192.168.5.5", n.Name)
+	assert.Nil(t, err, "MUST be reserved again")
+	_, err = Network.Reserve("fake-bb", "192.168.5.5", n.Name)
+	assert.NotNil(t, err, "MUST be leased")
+	_, err = Network.Reserve("fake-aa", "192.168.5.5", "fake-none")
+	assert.NotNil(t, err, "MUST have pool")
+}
@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/luscis/openlan/pkg/libol"
+)
+
+type poolRange struct {
+	start  uint32
+	end    uint32
+	offset int
+}
+
+// Pool is a bitmap allocator of IPv4 addresses in disjoint ranges. The
+// excluded addresses are never allocated, and the reserved are allocated
+// for a specified lease.
+type Pool struct {
+	lock     sync.Mutex
+	ranges   []poolRange
+	bits     []uint64
+	size     int
+	used     int
+	hint     int // lowest word may have a free bit.
+	excludes map[uint32]bool
+}
+
+func ip2Uint(addr string) (uint32, bool) {
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() == nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(ip.To4()), true
+}
+
+func uint2Ip(value uint32) string {
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, value)
+	return net.IP(tmp).String()
+}
+
+// NewPool returns a pool by ranges like '192.168.1.10-192.168.1.100', or a
+// single address.
+func NewPool(ranges ...string) (*Pool, error) {
+	p := &Pool{
+		excludes: make(map[uint32]bool),
+	}
+	for _, value := range ranges {
+		values := strings.SplitN(value, "-", 2)
+		if len(values) == 1 {
+			values = append(values, values[0])
+		}
+		start, ok0 := ip2Uint(strings.TrimSpace(values[0]))
+		end, ok1 := ip2Uint(strings.TrimSpace(values[1]))
+		if !ok0 || !ok1 || start > end {
+			return nil, libol.NewErr("invalid range %s", value)
+		}
+		for _, r := range p.ranges {
+			if start <= r.end && end >= r.start {
+				return nil, libol.NewErr("range %s overlaps", value)
+			}
+		}
+		p.ranges = append(p.ranges, poolRange{
+			start:  start,
+			end:    end,
+			offset: p.size,
+		})
+		p.size += int(end-start) + 1
+	}
+	p.bits = make([]uint64, (p.size+63)/64)
+	// mark the tail out of size as used.
+	if tail := p.size % 64; tail != 0 {
+		p.bits[len(p.bits)-1] = ^uint64(0) << uint(tail)
+	}
+	return p, nil
+}
+
+func (p *Pool) index(value uint32) int {
+	for _, r := range p.ranges {
+		if value >= r.start && value <= r.end {
+			return r.offset + int(value-r.start)
+		}
+	}
+	return -1
+}
+
+func (p *Pool) addr(index int) string {
+	for _, r := range p.ranges {
+		if index >= r.offset && index <= r.offset+int(r.end-r.start) {
+			return uint2Ip(r.start + uint32(index-r.offset))
+		}
+	}
+	return ""
+}
+
+func (p *Pool) isSet(index int) bool {
+	return p.bits[index/64]&(1<<uint(index%64)) != 0
+}
+
+func (p *Pool) set(index int) {
+	p.bits[index/64] |= 1 << uint(index%64)
+	p.used++
+}
+
+func (p *Pool) clear(index int) {
+	word := index / 64
+	p.bits[word] &^= 1 << uint(index%64)
+	p.used--
+	if word < p.hint {
+		p.hint = word
+	}
+}
+
+// Alloc returns the lowest free address, or empty if exhausted.
+func (p *Pool) Alloc() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for word := p.hint; word < len(p.bits); word++ {
+		value := p.bits[word]
+		if value == ^uint64(0) {
+			continue
+		}
+		index := word*64 + bits.TrailingZeros64(^value)
+		p.hint = word
+		p.set(index)
+		return p.addr(index)
+	}
+	p.hint = len(p.bits)
+	return ""
+}
+
+// Reserve allocates the address if it's free in the pool.
+func (p *Pool) Reserve(addr string) bool {
+	value, ok := ip2Uint(addr)
+	if !ok {
+		return false
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	index := p.index(value)
+	if index == -1 || p.isSet(index) {
+		return false
+	}
+	p.set(index)
+	return true
+}
+
+// Release frees the address, and the excluded is kept.
+func (p *Pool) Release(addr string) {
+	value, ok := ip2Uint(addr)
+	if !ok {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	index := p.index(value)
+	if index == -1 || !p.isSet(index) || p.excludes[value] {
+		return
+	}
+	p.clear(index)
+}
+
+// Exclude marks the address never be allocated.
+func (p *Pool) Exclude(addr string) {
+	value, ok := ip2Uint(addr)
+	if !ok {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	index := p.index(value)
+	if index == -1 || p.excludes[value] {
+		return
+	}
+	p.excludes[value] = true
+	if !p.isSet(index) {
+		p.set(index)
+	}
+}
+
+func (p *Pool) Has(addr string) bool {
+	value, ok := ip2Uint(addr)
+	if !ok {
+		return false
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	index := p.index(value)
+	return index != -1 && p.isSet(index)
+}
+
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Free returns number of the free addresses.
+func (p *Pool) Free() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.size - p.used
+}
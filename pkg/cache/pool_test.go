@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Pool_Ranges(t *testing.T) {
+	_, err := NewPool("192.168.1.10-192.168.1.1")
+	assert.NotNil(t, err, "MUST be invalid")
+	_, err = NewPool("192.168.1.1-192.168.1.10", "192.168.1.5-192.168.1.20")
+	assert.NotNil(t, err, "MUST be overlapped")
+
+	p, err := NewPool("192.168.1.1-192.168.1.2", "10.0.0.1", "172.16.0.1-172.16.0.2")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, p.Size())
+	p.Exclude("192.168.1.2")
+	assert.True(t, p.Reserve("172.16.0.1"))
+	assert.False(t, p.Reserve("172.16.0.1"), "MUST be used")
+	assert.False(t, p.Reserve("172.16.0.3"), "MUST be out of pool")
+
+	assert.Equal(t, "192.168.1.1", p.Alloc())
+	assert.Equal(t, "10.0.0.1", p.Alloc())
+	assert.Equal(t, "172.16.0.2", p.Alloc())
+	assert.Equal(t, "", p.Alloc(), "MUST be exhausted")
+	assert.Equal(t, 0, p.Free())
+
+	p.Release("192.168.1.2")
+	assert.Equal(t, "", p.Alloc(), "MUST keep excluded")
+	p.Release("10.0.0.1")
+	assert.Equal(t, 1, p.Free())
+	assert.Equal(t, "10.0.0.1", p.Alloc())
+}
+
+func Test_Pool_Exhaustion(t *testing.T) {
+	p, err := NewPool("10.1.0.0-10.1.255.255")
+	assert.Nil(t, err)
+	assert.Equal(t, 65536, p.Size())
+	for i := 0; i < p.Size(); i++ {
+		if p.Alloc() == "" {
+			t.Fatalf("exhausted at %d", i)
+		}
+	}
+	assert.Equal(t, "", p.Alloc(), "MUST be exhausted")
+	p.Release("10.1.128.7")
+	assert.Equal(t, "10.1.128.7", p.Alloc())
+	assert.Equal(t, "", p.Alloc(), "MUST be exhausted")
+}
+
+func Test_Pool_Concurrent(t *testing.T) {
+	p, err := NewPool("10.2.0.1-10.2.3.254", "10.3.0.1-10.3.0.100")
+	assert.Nil(t, err)
+
+	var lock sync.Mutex
+	seen := make(map[string]bool)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				addr := p.Alloc()
+				if addr == "" {
+					return
+				}
+				lock.Lock()
+				if seen[addr] {
+					t.Errorf("%s allocated twice", addr)
+				}
+				seen[addr] = true
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, p.Size(), len(seen))
+	assert.Equal(t, 0, p.Free())
+
+	for addr := range seen {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			p.Release(addr)
+		}(addr)
+	}
+	wg.Wait()
+	assert.Equal(t, p.Size(), p.Free())
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/models"
+)
+
+type schedule struct {
+	Schedules *libol.SafeStrMap
+}
+
+func (s *schedule) Add(obj *models.Schedule) {
+	_ = s.Schedules.Mod(obj.Name, obj)
+}
+
+func (s *schedule) Del(name string) {
+	s.Schedules.Del(name)
+}
+
+func (s *schedule) Get(name string) *models.Schedule {
+	if v := s.Schedules.Get(name); v != nil {
+		return v.(*models.Schedule)
+	}
+	return nil
+}
+
+func (s *schedule) List() <-chan *models.Schedule {
+	c := make(chan *models.Schedule, 128)
+
+	go func() {
+		s.Schedules.Iter(func(k string, v interface{}) {
+			c <- v.(*models.Schedule)
+		})
+		c <- nil //Finish channel by nil.
+	}()
+
+	return c
+}
+
+// Active returns whether in windows of a schedule now, and it's always
+// active without schedule, but never if not found.
+func (s *schedule) Active(name string) bool {
+	if name == "" {
+		return true
+	}
+	if obj := s.Get(name); obj != nil {
+		return obj.Active(time.Now())
+	}
+	return false
+}
+
+var Schedule = schedule{
+	Schedules: libol.NewSafeStrMap(1024),
+}
@@ -0,0 +1,234 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type trafficSession struct {
+	User   string `json:"user"`
+	RxLast uint64 `json:"rxLast"`
+	TxLast uint64 `json:"txLast"`
+}
+
+// trafficDB keeps the sessions, so counters of the sessions alive over
+// restart, e.g. openvpn, are not accounted twice.
+type trafficDB struct {
+	Users    []*schema.Traffic          `json:"users"`
+	Sessions map[string]*trafficSession `json:"sessions"`
+}
+
+// traffic accounts bytes of users by sampling the counters of sessions,
+// which are restarted from zero on every connection.
+type traffic struct {
+	File     string
+	users    map[string]*schema.Traffic
+	sessions map[string]*trafficSession
+	dirty    bool
+	lock     sync.Mutex
+}
+
+func (w *traffic) SetFile(file string) {
+	w.File = file
+}
+
+func (w *traffic) roll(obj *schema.Traffic, now time.Time) {
+	day := now.Format("2006-01-02")
+	month := now.Format("2006-01")
+	if obj.Day != day {
+		obj.Day = day
+		obj.Daily = 0
+		w.dirty = true
+	}
+	if obj.Month != month {
+		obj.Month = month
+		obj.Monthly = 0
+		w.dirty = true
+	}
+}
+
+func (w *traffic) account(session, user, network string, rx, tx uint64, now time.Time) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	obj, ok := w.users[user]
+	if !ok {
+		obj = &schema.Traffic{
+			User:    user,
+			Network: network,
+		}
+		w.users[user] = obj
+	}
+	w.roll(obj, now)
+
+	last, ok := w.sessions[session]
+	if !ok || last.User != user || rx < last.RxLast || tx < last.TxLast {
+		// new connection with counters from zero.
+		last = &trafficSession{User: user}
+		w.sessions[session] = last
+	}
+	delta := (rx - last.RxLast) + (tx - last.TxLast)
+	if delta == 0 {
+		return
+	}
+	obj.RxBytes += rx - last.RxLast
+	obj.TxBytes += tx - last.TxLast
+	obj.Daily += delta
+	obj.Monthly += delta
+	obj.UpdateAt = now.Unix()
+	last.RxLast = rx
+	last.TxLast = tx
+	w.dirty = true
+}
+
+// Account adds the counters of a session to its user.
+func (w *traffic) Account(session, user, network string, rx, tx uint64) {
+	w.account(session, user, network, rx, tx, time.Now())
+}
+
+// Sweep forgets the sessions not alive.
+func (w *traffic) Sweep(alive map[string]bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for session := range w.sessions {
+		if !alive[session] {
+			delete(w.sessions, session)
+			w.dirty = true
+		}
+	}
+}
+
+// Rollover resets daily and monthly bytes of users in new period.
+func (w *traffic) Rollover() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := time.Now()
+	for _, obj := range w.users {
+		w.roll(obj, now)
+	}
+}
+
+func (w *traffic) Get(user string) *schema.Traffic {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if obj, ok := w.users[user]; ok {
+		value := *obj
+		return &value
+	}
+	return nil
+}
+
+func (w *traffic) List(network, user string) []schema.Traffic {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	items := make([]schema.Traffic, 0, len(w.users))
+	for _, obj := range w.users {
+		if network != "" && obj.Network != network {
+			continue
+		}
+		if user != "" && obj.User != user {
+			continue
+		}
+		items = append(items, *obj)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].User < items[j].User
+	})
+	return items
+}
+
+// Reset clears bytes of the user in current period.
+func (w *traffic) Reset(user string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	obj, ok := w.users[user]
+	if !ok {
+		return false
+	}
+	obj.Daily = 0
+	obj.Monthly = 0
+	w.dirty = true
+	return true
+}
+
+func (w *traffic) SetState(user, state string, restore *schema.Qos) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if obj, ok := w.users[user]; ok {
+		obj.State = state
+		obj.Restore = restore
+		w.dirty = true
+	}
+}
+
+// Blocked returns true if the user is disconnected by quota.
+func (w *traffic) Blocked(user string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if obj, ok := w.users[user]; ok {
+		return obj.State == "blocked"
+	}
+	return false
+}
+
+func (w *traffic) Save() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.File == "" || !w.dirty {
+		return
+	}
+	data := &trafficDB{
+		Users:    make([]*schema.Traffic, 0, len(w.users)),
+		Sessions: w.sessions,
+	}
+	for _, obj := range w.users {
+		data.Users = append(data.Users, obj)
+	}
+	if err := libol.MarshalSave(data, w.File, false); err != nil {
+		libol.Warn("traffic.Save: %s", err)
+		return
+	}
+	w.dirty = false
+}
+
+func (w *traffic) Load() {
+	if w.File == "" {
+		return
+	}
+	data := &trafficDB{}
+	if err := libol.UnmarshalLoad(data, w.File); err != nil {
+		libol.Debug("traffic.Load: %s", err)
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := time.Now()
+	for _, obj := range data.Users {
+		if obj.User == "" {
+			continue
+		}
+		w.users[obj.User] = obj
+		w.roll(obj, now)
+	}
+	for session, obj := range data.Sessions {
+		w.sessions[session] = obj
+	}
+}
+
+var Traffic = traffic{
+	users:    make(map[string]*schema.Traffic, 1024),
+	sessions: make(map[string]*trafficSession, 1024),
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luscis/openlan/pkg/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTraffic() *traffic {
+	return &traffic{
+		users:    make(map[string]*schema.Traffic),
+		sessions: make(map[string]*trafficSession),
+	}
+}
+
+func Test_Traffic_Account(t *testing.T) {
+	w := newTraffic()
+	day0 := time.Date(2026, 1, 31, 23, 0, 0, 0, time.Local)
+
+	w.account("access:1.1.1.1:1000", "hi@fake", "fake", 100, 200, day0)
+	w.account("access:1.1.1.1:1000", "hi@fake", "fake", 150, 300, day0)
+	obj := w.Get("hi@fake")
+	assert.Equal(t, uint64(150), obj.RxBytes)
+	assert.Equal(t, uint64(300), obj.TxBytes)
+	assert.Equal(t, uint64(450), obj.Daily)
+
+	// reconnected with counters from zero.
+	w.account("access:1.1.1.1:1000", "hi@fake", "fake", 10, 20, day0)
+	// another session of the same user.
+	w.account("openvpn:fake:2.2.2.2:1000:1", "hi@fake", "fake", 5, 5, day0)
+	obj = w.Get("hi@fake")
+	assert.Equal(t, uint64(490), obj.Daily)
+	assert.Equal(t, uint64(490), obj.Monthly)
+
+	// new day and month.
+	day1 := day0.Add(2 * time.Hour)
+	w.account("openvpn:fake:2.2.2.2:1000:1", "hi@fake", "fake", 10, 10, day1)
+	obj = w.Get("hi@fake")
+	assert.Equal(t, uint64(10), obj.Daily, "MUST be reset by day")
+	assert.Equal(t, uint64(10), obj.Monthly, "MUST be reset by month")
+	assert.Equal(t, uint64(170), obj.RxBytes)
+
+	w.Sweep(map[string]bool{"openvpn:fake:2.2.2.2:1000:1": true})
+	assert.Equal(t, 1, len(w.sessions))
+
+	assert.True(t, w.Reset("hi@fake"))
+	assert.Equal(t, uint64(0), w.Get("hi@fake").Daily)
+	assert.False(t, w.Reset("fake@fake"))
+}
+
+func Test_Traffic_Load(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traffic.json")
+	w := newTraffic()
+	w.SetFile(file)
+	w.Account("openvpn:fake:2.2.2.2:1000:1", "hi@fake", "fake", 100, 100)
+	w.SetState("hi@fake", "blocked", nil)
+	w.Save()
+
+	n := newTraffic()
+	n.SetFile(file)
+	n.Load()
+	assert.True(t, n.Blocked("hi@fake"))
+	assert.False(t, n.Blocked("fake@fake"))
+	// the session over restart isn't accounted twice.
+	n.Account("openvpn:fake:2.2.2.2:1000:1", "hi@fake", "fake", 150, 100)
+	assert.Equal(t, uint64(250), n.Get("hi@fake").Daily)
+	assert.Equal(t, 1, len(n.List("fake", "")))
+	assert.Equal(t, 0, len(n.List("fake", "fake@fake")))
+}
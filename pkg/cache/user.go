@@ -38,7 +38,7 @@ func (w *user) Load() {
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
 		line := scanner.Text()
-		columns := strings.SplitN(line, ":", 4)
+		columns := strings.SplitN(line, ":", 5)
 		if len(columns) < 2 {
 			continue
 		}
@@ -52,12 +52,17 @@ func (w *user) Load() {
 		if len(columns) > 3 {
 			leStr = columns[3]
 		}
+		var groups []string
+		if len(columns) > 4 && columns[4] != "" {
+			groups = strings.Split(columns[4], ",")
+		}
 		lease, _ := libol.GetLeaseTime(leStr)
 		obj := &models.User{
 			Name:     user,
 			Password: pass,
 			Role:     role,
 			Lease:    lease,
+			Groups:   groups,
 		}
 		obj.Update()
 		w.Add(obj)
@@ -86,6 +91,9 @@ func (w *user) Save() error {
 		line += ":" + obj.Password
 		line += ":" + obj.Role
 		line += ":" + obj.Lease.Format(libol.LeaseTime)
+		if len(obj.Groups) > 0 {
+			line += ":" + strings.Join(obj.Groups, ",")
+		}
 		_, _ = fp.WriteString(line + "\n")
 	}
 	return nil
@@ -118,6 +126,9 @@ func (w *user) Add(user *models.User) {
 		if !user.Lease.IsZero() {
 			older.Lease = user.Lease
 		}
+		if user.Groups != nil {
+			older.Groups = user.Groups
+		}
 	}
 }
 
@@ -155,7 +166,8 @@ func (w *user) CheckLDAP(obj *models.User) *models.User {
 	u := w.Get(obj.Id())
 	libol.Debug("CheckLDAP %s", u)
 	if u == nil || u.Role == "ldap" {
-		if ok, err := ldap.Login(obj.Id(), obj.Password); !ok {
+		groups, err := ldap.Authenticate(obj.Id(), obj.Password)
+		if err != nil {
 			libol.Warn("CheckLDAP %s", err)
 			return nil
 		}
@@ -164,6 +176,7 @@ func (w *user) CheckLDAP(obj *models.User) *models.User {
 			Password: obj.Password,
 			Role:     "ldap",
 			Alias:    obj.Alias,
+			Groups:   groups,
 		}
 		user.Update()
 		w.Add(user)
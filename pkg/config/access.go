@@ -42,6 +42,7 @@ type Access struct {
 	Fallback    string    `json:"fallback,omitempty" yaml:"fallback,omitempty"`
 	Run1        string    `json:"run1,omitempty" yaml:"run1,omitempty"`
 	Run0        string    `json:"run0,omitempty" yaml:"run0,omitempty"`
+	Agents      []string  `json:"agents,omitempty" yaml:"agents,omitempty"` // reported in posture if running.
 }
 
 type ForwardRule struct {
@@ -88,7 +89,7 @@ func (ap *Access) Initialize() error {
 		return err
 	}
 	ap.Correct()
-	libol.SetLogger(ap.Log.File, ap.Log.Verbose)
+	ap.Log.Setup()
 	return nil
 }
 
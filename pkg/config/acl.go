@@ -3,9 +3,10 @@ package config
 import "github.com/luscis/openlan/pkg/libol"
 
 type ACL struct {
-	File  string     `json:"-" yaml:"-"`
-	Name  string     `json:"name" yaml:"name"`
-	Rules []*ACLRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+	File   string      `json:"-" yaml:"-"`
+	Name   string      `json:"name" yaml:"name"`
+	Groups []*ACLGroup `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Rules  []*ACLRule  `json:"rules,omitempty" yaml:"rules,omitempty"`
 }
 
 func (ru *ACL) Save() {
@@ -15,6 +16,9 @@ func (ru *ACL) Save() {
 }
 
 func (ru *ACL) Correct(sw *Switch) {
+	for _, group := range ru.Groups {
+		group.Correct()
+	}
 	for _, rule := range ru.Rules {
 		rule.Correct()
 	}
@@ -23,14 +27,35 @@ func (ru *ACL) Correct(sw *Switch) {
 	}
 }
 
+// ACLGroup is addresses, ports or services referenced by rules in @NAME.
+type ACLGroup struct {
+	Name    string   `json:"name" yaml:"name"`
+	Type    string   `json:"type,omitempty" yaml:"type,omitempty"` // address, port or service
+	Members []string `json:"members,omitempty" yaml:"members,omitempty"`
+}
+
+func (ru *ACLGroup) Correct() {
+	if ru.Type == "" {
+		ru.Type = "address"
+	}
+}
+
 type ACLRule struct {
-	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
-	SrcIp   string `json:"source,omitempty" yaml:"source,omitempty"`
-	DstIp   string `json:"destination,omitempty" yaml:"destination,omitempty"`
-	Proto   string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
-	SrcPort int    `json:"sport,omitempty" yaml:"sport,omitempty"`
-	DstPort int    `json:"dport,omitempty" yaml:"dport,omitempty"`
-	Action  string `json:"action,omitempty" yaml:"action,omitempty"`
+	Name        string `json:"name,omitempty" yaml:"name,omitempty"`
+	Priority    int    `json:"priority,omitempty" yaml:"priority,omitempty"`
+	SrcIp       string `json:"source,omitempty" yaml:"source,omitempty"`
+	DstIp       string `json:"destination,omitempty" yaml:"destination,omitempty"`
+	SrcIdentity string `json:"sourceIdentity,omitempty" yaml:"sourceIdentity,omitempty"`
+	DstIdentity string `json:"destinationIdentity,omitempty" yaml:"destinationIdentity,omitempty"`
+	Proto       string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	SrcPort     int    `json:"sport,omitempty" yaml:"sport,omitempty"`
+	DstPort     int    `json:"dport,omitempty" yaml:"dport,omitempty"`
+	SrcPorts    string `json:"sourcePorts,omitempty" yaml:"sourcePorts,omitempty"`
+	DstPorts    string `json:"destinationPorts,omitempty" yaml:"destinationPorts,omitempty"`
+	Service     string `json:"service,omitempty" yaml:"service,omitempty"`
+	IcmpType    string `json:"icmpType,omitempty" yaml:"icmpType,omitempty"`
+	Schedule    string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	Action      string `json:"action,omitempty" yaml:"action,omitempty"`
 }
 
 func (ru *ACLRule) Correct() {
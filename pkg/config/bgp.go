@@ -10,6 +10,13 @@ type BgpNeighbor struct {
 	State    string   `json:"state,omitempty" yaml:"state,omitempty"`
 	Advertis []string `json:"advertis,omitempty" yaml:"advertis,omitempty"`
 	Receives []string `json:"receives,omitempty" yaml:"receives,omitempty"`
+	// policy of routes by route-map.
+	LocalPref   int      `json:"localpref,omitempty" yaml:"localpref,omitempty"`
+	Med         int      `json:"med,omitempty" yaml:"med,omitempty"`
+	Prepend     int      `json:"prepend,omitempty" yaml:"prepend,omitempty"` // times of local as prepended.
+	Communities []string `json:"communities,omitempty" yaml:"communities,omitempty"`
+	Bfd         bool     `json:"bfd,omitempty" yaml:"bfd,omitempty"`
+	Graceful    bool     `json:"graceful,omitempty" yaml:"graceful,omitempty"` // graceful restart.
 }
 
 func (s *BgpNeighbor) Correct() {
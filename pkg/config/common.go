@@ -5,6 +5,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/luscis/openlan/pkg/libol"
 )
@@ -30,14 +31,60 @@ func VarDir(name ...string) string {
 }
 
 type Log struct {
-	File    string `json:"file,omitempty" yaml:"file,omitempty"`
-	Verbose int    `json:"level,omitempty" yaml:"level,omitempty"`
+	File     string         `json:"file,omitempty" yaml:"file,omitempty"`
+	Verbose  int            `json:"level,omitempty" yaml:"level,omitempty"`
+	Format   string         `json:"format,omitempty" yaml:"format,omitempty"`     // text or json.
+	MaxSize  int            `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`   // in MiB.
+	Interval string         `json:"interval,omitempty" yaml:"interval,omitempty"` // like 24h.
+	Backups  int            `json:"backups,omitempty" yaml:"backups,omitempty"`
+	Syslog   string         `json:"syslog,omitempty" yaml:"syslog,omitempty"` // local or address like udp://host:514.
+	Modules  map[string]int `json:"modules,omitempty" yaml:"modules,omitempty"`
 }
 
 func (l *Log) Correct() {
 	if l.Verbose == 0 {
 		l.Verbose = libol.INFO
 	}
+	if l.Format == "" {
+		l.Format = "text"
+	}
+}
+
+// Setup applies file, level, format, rotation, forwarding and levels of
+// modules to logger.
+func (l *Log) Setup() {
+	libol.SetLogger(l.File, l.Verbose)
+	libol.SetLogFormat(l.Format)
+	interval, err := time.ParseDuration(l.Interval)
+	if l.Interval != "" && err != nil {
+		libol.Warn("Log.Setup: interval %s", err)
+	}
+	libol.SetLogRotate(int64(l.MaxSize)<<20, interval, l.Backups)
+	if err := libol.SetLogForward(l.Syslog); err != nil {
+		libol.Warn("Log.Setup: %s", err)
+	}
+	for name, level := range l.Modules {
+		libol.SetModule(name, level)
+	}
+}
+
+type Audit struct {
+	File    string `json:"file,omitempty" yaml:"file,omitempty"`
+	MaxSize int    `json:"maxSize,omitempty" yaml:"maxSize,omitempty"` // in MiB.
+	Backups int    `json:"backups,omitempty" yaml:"backups,omitempty"`
+	Syslog  string `json:"syslog,omitempty" yaml:"syslog,omitempty"` // local or address like udp://host:514.
+}
+
+func (a *Audit) Correct() {
+	if a.File == "" {
+		a.File = LogFile("openlan-audit.log")
+	}
+	if a.MaxSize == 0 {
+		a.MaxSize = 10
+	}
+	if a.Backups == 0 {
+		a.Backups = 5
+	}
 }
 
 func LogFile(file string) string {
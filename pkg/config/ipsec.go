@@ -12,6 +12,8 @@ type IPSecTunnel struct {
 	RightPort int    `json:"remoteport,omitempty" yaml:"remoteport,omitempty"`
 	Transport string `json:"protocol" yaml:"protocol"`
 	Secret    string `json:"secret" yaml:"secret"`
+	Cert      string `json:"cert,omitempty" yaml:"cert,omitempty"` // nickname of local certificate.
+	Ike       string `json:"ike,omitempty" yaml:"ike,omitempty"`   // ikev1 or ikev2.
 	State     string `json:"state" yaml:"state"`
 }
 
@@ -23,6 +25,9 @@ func (s *IPSecTunnel) Correct() {
 	if s.RightId == "" {
 		s.RightId = s.Right
 	}
+	if s.Ike == "" {
+		s.Ike = "ikev1"
+	}
 }
 
 func (s *IPSecTunnel) Id() string {
@@ -0,0 +1,27 @@
+package config
+
+type Lockout struct {
+	MaxFailed  int `json:"maxFailed,omitempty" yaml:"maxFailed,omitempty"`
+	Backoff    int `json:"backoff,omitempty" yaml:"backoff,omitempty"`       // in seconds.
+	MaxBackoff int `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"` // in seconds.
+	Window     int `json:"window,omitempty" yaml:"window,omitempty"`         // in seconds.
+	Block      int `json:"block,omitempty" yaml:"block,omitempty"`           // failures of a source before blocked.
+}
+
+func (l *Lockout) Correct() {
+	if l.MaxFailed == 0 {
+		l.MaxFailed = 5
+	}
+	if l.Backoff == 0 {
+		l.Backoff = 30
+	}
+	if l.MaxBackoff == 0 {
+		l.MaxBackoff = 3600
+	}
+	if l.Window == 0 {
+		l.Window = 900
+	}
+	if l.Block == 0 {
+		l.Block = 20
+	}
+}
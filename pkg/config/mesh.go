@@ -0,0 +1,31 @@
+package config
+
+import "strings"
+
+type MeshSpecifies struct {
+	Name     string   `json:"-" yaml:"-"`
+	Seeds    []string `json:"seeds" yaml:"seeds"` // switches to discover peers from.
+	Protocol string   `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	Secret   string   `json:"secret,omitempty" yaml:"secret,omitempty"` // username@network:password
+	Crypt    string   `json:"crypt,omitempty" yaml:"crypt,omitempty"`
+	Interval int      `json:"interval,omitempty" yaml:"interval,omitempty"`
+	ApiPort  int      `json:"apiPort,omitempty" yaml:"apiPort,omitempty"`
+	Insecure bool     `json:"insecure,omitempty" yaml:"insecure,omitempty"` // skip to verify peers by the CA.
+}
+
+func (m *MeshSpecifies) Correct() {
+	if m.Protocol == "" {
+		m.Protocol = "tls"
+	}
+	if m.Interval == 0 {
+		m.Interval = 30
+	}
+	if m.ApiPort == 0 {
+		m.ApiPort = 10000
+	}
+}
+
+// Host returns the address of the seed without port.
+func (m *MeshSpecifies) Host(seed string) string {
+	return strings.SplitN(seed, ":", 2)[0]
+}
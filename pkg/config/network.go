@@ -46,11 +46,20 @@ type Network struct {
 	DhcpConfig *Dhcp               `json:"dhcpConfig,omitempty" yaml:"dhcpConfig,omitempty"`
 	Outputs    []*Output           `json:"outputs,omitempty" yaml:"outputs,omitempty"`
 	ZTrust     string              `json:"ztrust,omitempty" yaml:"ztrust,omitempty"`
+	ZPolicy    []*ZPolicy          `json:"zpolicy,omitempty" yaml:"zpolicy,omitempty"`
 	Qos        string              `json:"qos,omitempty" yaml:"qos,omitempty"`
 	Snat       string              `json:"snat,omitempty" yaml:"snat,omitempty"`
+	Guard      string              `json:"guard,omitempty" yaml:"guard,omitempty"` // source guard of access clients.
+	Quota      *Quota              `json:"quota,omitempty" yaml:"quota,omitempty"`
+	Suppress   *Suppress           `json:"suppress,omitempty" yaml:"suppress,omitempty"`
+	Posture    *Posture            `json:"posture,omitempty" yaml:"posture,omitempty"`
+	Fastpath   string              `json:"fastpath,omitempty" yaml:"fastpath,omitempty"` // userspace switching of access clients, not with acl, ztrust, qos or posture restrict.
 	Namespace  string              `json:"namespace,omitempty" yaml:"namespace,omitempty"`
 	FindHop    map[string]*FindHop `json:"findhop,omitempty" yaml:"findhop,omitempty"`
 	Dnat       []*DNAT             `json:"dnat,omitempty" yaml:"dnat,omitempty"`
+	VxLAN      *VxLANSpecifies     `json:"vxlan,omitempty" yaml:"vxlan,omitempty"`
+	Mesh       *MeshSpecifies      `json:"mesh,omitempty" yaml:"mesh,omitempty"`
+	WireGuard  *WireGuard          `json:"wireguard,omitempty" yaml:"wireguard,omitempty"`
 	AddrPool   string              `json:"-" yaml:"-"`
 }
 
@@ -135,6 +144,18 @@ func (n *Network) Correct() {
 		if n.Subnet.Netmask == "" {
 			n.Subnet.Netmask = ipMask
 		}
+		n.Subnet.Correct()
+		if n.VxLAN != nil {
+			n.VxLAN.Correct()
+			n.VxLAN.Name = n.Name
+		}
+		if n.Mesh != nil {
+			n.Mesh.Correct()
+			n.Mesh.Name = n.Name
+		}
+		if n.WireGuard != nil {
+			n.WireGuard.Correct(n.Name)
+		}
 	}
 
 	CorrectRoutes(n.Routes, ipAddr)
@@ -142,6 +163,19 @@ func (n *Network) Correct() {
 		n.OpenVPN.Correct(n.AddrPool, n.Name)
 	}
 
+	if n.Quota != nil {
+		n.Quota.Correct()
+	}
+	if n.Suppress != nil {
+		n.Suppress.Correct()
+	}
+	if n.Posture != nil {
+		n.Posture.Correct()
+	}
+	for _, policy := range n.ZPolicy {
+		policy.Correct()
+	}
+
 	for key, value := range n.FindHop {
 		value.Correct()
 		n.FindHop[key] = value
@@ -151,6 +185,25 @@ func (n *Network) Correct() {
 	}
 }
 
+// FastpathBypass returns the policies of network which fastpath would
+// bypass, as frames between clients never pass the bridge and firewall.
+func (n *Network) FastpathBypass(acl *ACL, qos *Qos) []string {
+	var policies []string
+	if acl != nil && len(acl.Rules) > 0 {
+		policies = append(policies, "acl")
+	}
+	if n.ZTrust == "enable" {
+		policies = append(policies, "ztrust")
+	}
+	if qos != nil && len(qos.Config) > 0 {
+		policies = append(policies, "qos")
+	}
+	if n.Posture != nil && n.Posture.Action == "restrict" {
+		policies = append(policies, "posture")
+	}
+	return policies
+}
+
 func (n *Network) Dir(module string) string {
 	var file string
 
@@ -11,15 +11,16 @@ type Linker interface {
 }
 
 type Output struct {
-	Segment  int    `json:"segment" yaml:"segment"`
-	Protocol string `json:"protocol,omitempty" yaml:"protocol,omitempty"` // gre, vxlan, tcp/tls/wss etc.
-	Remote   string `json:"remote" yaml:"remote"`
-	Fallback string `json:"fallback,omitempty" yaml:"fallback,omitempty"`
-	DstPort  int    `json:"dstport,omitempty" yaml:"dstport,omitempty"`
-	Link     string `json:"link,omitempty" yaml:"link,omitempty"` // link name
-	Secret   string `json:"secret,omitempty" yaml:"secret,omitempty"`
-	Crypt    string `json:"crypt,omitempty" yaml:"crypt,omitempty"`
-	Linker   Linker `json:"-" yaml:"-"`
+	Segment    int      `json:"segment" yaml:"segment"`
+	Protocol   string   `json:"protocol,omitempty" yaml:"protocol,omitempty"` // gre, vxlan, wireguard, tcp/tls/wss etc.
+	Remote     string   `json:"remote" yaml:"remote"`
+	Fallback   string   `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	DstPort    int      `json:"dstport,omitempty" yaml:"dstport,omitempty"`
+	Link       string   `json:"link,omitempty" yaml:"link,omitempty"` // link name
+	Secret     string   `json:"secret,omitempty" yaml:"secret,omitempty"`
+	Crypt      string   `json:"crypt,omitempty" yaml:"crypt,omitempty"`
+	AllowedIPs []string `json:"allowedIps,omitempty" yaml:"allowedIps,omitempty"` // routed by wireguard, and bridged not supported.
+	Linker     Linker   `json:"-" yaml:"-"`
 }
 
 func (o *Output) Id() string {
@@ -32,6 +33,8 @@ func (o *Output) Correct() {
 		o.Link = fmt.Sprintf("%s%d", "xgi", o.Segment)
 	case "vxlan":
 		o.Link = fmt.Sprintf("%s%d", "xei", o.Segment)
+	case "wireguard":
+		o.Link = fmt.Sprintf("%s:%s", "wg", o.Remote)
 	case "tcp", "udp", "tls", "wss":
 		user := strings.SplitN(o.Secret, ":", 2)[0]
 		o.Link = fmt.Sprintf("%s:%s:%s", o.Protocol, o.Remote, user)
@@ -0,0 +1,19 @@
+package config
+
+// Posture checks devices of access clients at login, and rejects or
+// restricts them if not compliant.
+type Posture struct {
+	Action    string            `json:"action,omitempty" yaml:"action,omitempty"`       // reject, restrict by acl rules of posture:restricted, or allow
+	Systems   []string          `json:"systems,omitempty" yaml:"systems,omitempty"`     // linux, darwin or windows, and any if empty.
+	OsVersion map[string]string `json:"osVersion,omitempty" yaml:"osVersion,omitempty"` // minimum by system
+	Version   string            `json:"version,omitempty" yaml:"version,omitempty"`     // minimum of access client
+	Encrypted bool              `json:"encrypted,omitempty" yaml:"encrypted,omitempty"`
+	Firewall  bool              `json:"firewall,omitempty" yaml:"firewall,omitempty"`
+	Agents    []string          `json:"agents,omitempty" yaml:"agents,omitempty"`
+}
+
+func (p *Posture) Correct() {
+	if p.Action == "" {
+		p.Action = "reject"
+	}
+}
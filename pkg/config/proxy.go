@@ -57,6 +57,7 @@ type HttpProxy struct {
 	Backends   ToForwards  `json:"backends,omitempty" yaml:"backends,omitempty"`
 	Socks      *HttpSocks  `json:"socks,omitempty" yaml:"socks,omitempty"`
 	SocksProxy *SocksProxy `json:"-" yaml:"-"`
+	Lockout    *Lockout    `json:"lockout,omitempty" yaml:"lockout,omitempty"`
 }
 
 func (h *HttpProxy) Initialize() error {
@@ -92,6 +93,10 @@ func (h *HttpProxy) Correct() {
 	if !filepath.IsAbs(h.CaCert) {
 		h.CaCert = filepath.Join(h.ConfDir, h.CaCert)
 	}
+	if h.Lockout == nil {
+		h.Lockout = &Lockout{}
+	}
+	h.Lockout.Correct()
 	if h.Socks != nil {
 		h.SocksProxy = &SocksProxy{
 			Listen: h.Socks.Listen,
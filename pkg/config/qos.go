@@ -1,17 +1,25 @@
 package config
 
-import "github.com/luscis/openlan/pkg/libol"
+import (
+	"strings"
+
+	"github.com/luscis/openlan/pkg/libol"
+)
 
 type Qos struct {
-	File   string               `json:"-" yaml:"-"`
-	Name   string               `json:"name" yaml:"name"`
-	Config map[string]*QosLimit `json:"qos,omitempty" yaml:"qos,omitempty"`
+	File     string               `json:"-" yaml:"-"`
+	Name     string               `json:"name" yaml:"name"`
+	Config   map[string]*QosLimit `json:"qos,omitempty" yaml:"qos,omitempty"`
+	Priority []*QosPriority       `json:"priority,omitempty" yaml:"priority,omitempty"`
 }
 
 func (q *Qos) Correct(sw *Switch) {
 	for _, rule := range q.Config {
 		rule.Correct()
 	}
+	for _, rule := range q.Priority {
+		rule.Correct()
+	}
 	if q.File == "" {
 		q.File = sw.Dir("qos", q.Name)
 	}
@@ -23,9 +31,35 @@ func (q *Qos) Save() {
 	}
 }
 
+// QosLimit is speed of an user, in is upload from the user and out is
+// download to it.
 type QosLimit struct {
-	InSpeed float64 `json:"inSpeed,omitempty" yaml:"inSpeed,omitempty"`
+	InSpeed  float64 `json:"inSpeed,omitempty" yaml:"inSpeed,omitempty"`   // Mbit
+	InCeil   float64 `json:"inCeil,omitempty" yaml:"inCeil,omitempty"`     // Mbit
+	OutSpeed float64 `json:"outSpeed,omitempty" yaml:"outSpeed,omitempty"` // Mbit
+	OutCeil  float64 `json:"outCeil,omitempty" yaml:"outCeil,omitempty"`   // Mbit
+	Burst    int     `json:"burst,omitempty" yaml:"burst,omitempty"`       // KiB
+	Schedule string  `json:"schedule,omitempty" yaml:"schedule,omitempty"` // limited only in windows
 }
 
 func (ql *QosLimit) Correct() {
+	if ql.InCeil < ql.InSpeed {
+		ql.InCeil = ql.InSpeed
+	}
+	if ql.OutCeil < ql.OutSpeed {
+		ql.OutCeil = ql.OutSpeed
+	}
+}
+
+// QosPriority matches the traffic preferred, e.g. voice by DSCP 46 or
+// ports of services.
+type QosPriority struct {
+	Name     string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Dscp     []int    `json:"dscp,omitempty" yaml:"dscp,omitempty"`
+	Protocol string   `json:"protocol,omitempty" yaml:"protocol,omitempty"` // udp, tcp or both if empty.
+	Ports    []string `json:"ports,omitempty" yaml:"ports,omitempty"`       // 5060 or 10000-20000
+}
+
+func (qp *QosPriority) Correct() {
+	qp.Protocol = strings.ToLower(qp.Protocol)
 }
@@ -0,0 +1,35 @@
+package config
+
+type QuotaLimit struct {
+	Daily   uint64 `json:"daily,omitempty" yaml:"daily,omitempty"`     // MiB
+	Monthly uint64 `json:"monthly,omitempty" yaml:"monthly,omitempty"` // MiB
+}
+
+type Quota struct {
+	QuotaLimit `yaml:",inline"`
+	Action     string                 `json:"action,omitempty" yaml:"action,omitempty"` // throttle or disconnect
+	Speed      float64                `json:"speed,omitempty" yaml:"speed,omitempty"`   // Mbit when throttled
+	Users      map[string]*QuotaLimit `json:"users,omitempty" yaml:"users,omitempty"`
+}
+
+func (q *Quota) Correct() {
+	if q.Action == "" {
+		q.Action = "disconnect"
+	}
+	if q.Action == "throttle" && q.Speed <= 0 {
+		q.Speed = 1
+	}
+	if q.Users == nil {
+		q.Users = make(map[string]*QuotaLimit, 32)
+	}
+}
+
+// Limit returns bytes of daily and monthly quota for the user, and zero
+// is unlimited.
+func (q *Quota) Limit(user string) (uint64, uint64) {
+	limit := q.QuotaLimit
+	if obj, ok := q.Users[user]; ok {
+		limit = *obj
+	}
+	return limit.Daily << 20, limit.Monthly << 20
+}
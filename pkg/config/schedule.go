@@ -0,0 +1,18 @@
+package config
+
+import "strings"
+
+// Schedule is windows of time in days of week, and referenced in name by
+// rules of acl, guests of zero trust and limits of qos.
+type Schedule struct {
+	Name     string   `json:"name" yaml:"name"`
+	Days     []string `json:"days,omitempty" yaml:"days,omitempty"`       // mon, tue ... sun, weekday or weekend
+	Windows  []string `json:"windows,omitempty" yaml:"windows,omitempty"` // 09:00-18:00 or 22:00-06:00
+	Timezone string   `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+}
+
+func (s *Schedule) Correct() {
+	for i, day := range s.Days {
+		s.Days[i] = strings.ToLower(day)
+	}
+}
@@ -11,6 +11,22 @@ type Subnet struct {
 	End     string `json:"endAt,omitempty" yaml:"endAt,omitempty"`
 	Netmask string `json:"netmask,omitempty" yaml:"netmask,omitempty"`
 	CIDR    string `json:"cidr,omitempty" yaml:"cidr,omitempty"`
+	// more ranges like 'start-end', and addresses never allocated.
+	Ranges   []string `json:"ranges,omitempty" yaml:"ranges,omitempty"`
+	Excludes []string `json:"excludes,omitempty" yaml:"excludes,omitempty"`
+	// seconds of a released lease kept for its alias.
+	LeaseTime int `json:"leaseTime,omitempty" yaml:"leaseTime,omitempty"`
+	// seconds of an expired address not reused by others.
+	Grace int `json:"grace,omitempty" yaml:"grace,omitempty"`
+}
+
+func (s *Subnet) Correct() {
+	if s.LeaseTime == 0 {
+		s.LeaseTime = 86400
+	}
+	if s.Grace == 0 {
+		s.Grace = 300
+	}
 }
 
 type MultiPath struct {
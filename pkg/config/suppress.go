@@ -0,0 +1,24 @@
+package config
+
+// Suppress reduces broadcast and multicast of access clients on overlay.
+type Suppress struct {
+	Proxy    string `json:"proxy,omitempty" yaml:"proxy,omitempty"`       // ARP/ND proxy, enable or disable
+	Rate     int    `json:"rate,omitempty" yaml:"rate,omitempty"`         // broadcast and multicast per client, pps
+	Burst    int    `json:"burst,omitempty" yaml:"burst,omitempty"`       // frames
+	Snooping string `json:"snooping,omitempty" yaml:"snooping,omitempty"` // IGMP/MLD snooping, enable or disable
+}
+
+func (s *Suppress) Correct() {
+	if s.Proxy == "" {
+		s.Proxy = "enable"
+	}
+	if s.Rate == 0 {
+		s.Rate = 100
+	}
+	if s.Burst < s.Rate {
+		s.Burst = s.Rate * 2
+	}
+	if s.Snooping == "" {
+		s.Snooping = "enable"
+	}
+}
@@ -9,10 +9,11 @@ import (
 )
 
 type Queue struct {
-	SockWr int `json:"sockWr" yaml:"sockWr"` // per frames about 1572(1514+4+20+20+14)bytes
-	SockRd int `json:"sockRd" yaml:"sockRd"` // per frames
-	TapWr  int `json:"tapWr" yaml:"tapWr"`   // per frames about 1572((1514+4+20+20+14))bytes
-	TapRd  int `json:"tapRd" yaml:"tapRd"`   // per frames
+	SockWr int `json:"sockWr" yaml:"sockWr"`                     // per frames about 1572(1514+4+20+20+14)bytes
+	SockRd int `json:"sockRd" yaml:"sockRd"`                     // per frames
+	TapWr  int `json:"tapWr" yaml:"tapWr"`                       // per frames about 1572((1514+4+20+20+14))bytes
+	TapRd  int `json:"tapRd" yaml:"tapRd"`                       // per frames
+	TapQus int `json:"tapQus,omitempty" yaml:"tapQus,omitempty"` // queues of tap by IFF_MULTI_QUEUE
 }
 
 var (
@@ -20,12 +21,13 @@ var (
 	QdSrd = 32 * 4
 	QdTwr = 32 * 2
 	QdTrd = 2
+	QdTqu = 1
 	QdVsd = 32 * 8
 	QdVWr = 32 * 4
 )
 
 func (q *Queue) String() string {
-	return fmt.Sprintf("socket write:%d,read:%d tap write:%d,read:%d", q.SockWr, q.SockRd, q.TapWr, q.TapRd)
+	return fmt.Sprintf("socket write:%d,read:%d tap write:%d,read:%d,queues:%d", q.SockWr, q.SockRd, q.TapWr, q.TapRd, q.TapQus)
 }
 
 func (q *Queue) Correct() {
@@ -41,6 +43,9 @@ func (q *Queue) Correct() {
 	if q.TapRd == 0 {
 		q.TapRd = QdTrd
 	}
+	if q.TapQus == 0 {
+		q.TapQus = QdTqu
+	}
 	libol.Info("Queue %s", q)
 }
 
@@ -92,27 +97,32 @@ func (p *Limit) String() string {
 }
 
 type Switch struct {
-	File      string              `json:"-" yaml:"-"`
-	Alias     string              `json:"alias" yaml:"alias"`
-	Queue     Queue               `json:"-" yaml:"-"`
-	Limit     Limit               `json:"limit" yaml:"limit"`
-	Protocol  string              `json:"protocol" yaml:"protocol"` // stream: tcp|tls|ws|wss, packet: udp|kcp (comma separated)
-	Listen    string              `json:"listen" yaml:"listen"`
-	Timeout   int                 `json:"timeout" yaml:"timeout"`
-	Http      *Http               `json:"http,omitempty" yaml:"http,omitempty"`
-	Log       Log                 `json:"log" yaml:"log"`
-	Cert      *Cert               `json:"cert,omitempty" yaml:"cert,omitempty"`
-	Crypt     *Crypt              `json:"crypt,omitempty" yaml:"crypt,omitempty"`
-	Network   map[string]*Network `json:"network,omitempty" yaml:"network,omitempty"`
-	Acl       map[string]*ACL     `json:"acl,omitempty" yaml:"acl,omitempty"`
-	Qos       map[string]*Qos     `json:"qos,omitempty" yaml:"qos,omitempty"`
-	FireWall  []FlowRule          `json:"firewall,omitempty" yaml:"firewall,omitempty"`
-	PassFile  string              `json:"-" yaml:"-"`
-	Ldap      *LDAP               `json:"ldap,omitempty" yaml:"ldap,omitempty"`
-	AddrPool  string              `json:"pool,omitempty" yaml:"pool,omitempty"`
-	ConfDir   string              `json:"-" yaml:"-"`
-	TokenFile string              `json:"-" yaml:"-"`
-	PidFile   string              `json:"-" yaml:"-"`
+	File        string               `json:"-" yaml:"-"`
+	Alias       string               `json:"alias" yaml:"alias"`
+	Queue       Queue                `json:"-" yaml:"-"`
+	Limit       Limit                `json:"limit" yaml:"limit"`
+	Protocol    string               `json:"protocol" yaml:"protocol"` // stream: tcp|tls|ws|wss, packet: udp|kcp (comma separated)
+	Listen      string               `json:"listen" yaml:"listen"`
+	Timeout     int                  `json:"timeout" yaml:"timeout"`
+	Http        *Http                `json:"http,omitempty" yaml:"http,omitempty"`
+	Log         Log                  `json:"log" yaml:"log"`
+	Audit       *Audit               `json:"audit,omitempty" yaml:"audit,omitempty"`
+	Lockout     *Lockout             `json:"lockout,omitempty" yaml:"lockout,omitempty"`
+	Cert        *Cert                `json:"cert,omitempty" yaml:"cert,omitempty"`
+	Crypt       *Crypt               `json:"crypt,omitempty" yaml:"crypt,omitempty"`
+	Network     map[string]*Network  `json:"network,omitempty" yaml:"network,omitempty"`
+	Acl         map[string]*ACL      `json:"acl,omitempty" yaml:"acl,omitempty"`
+	Qos         map[string]*Qos      `json:"qos,omitempty" yaml:"qos,omitempty"`
+	FireWall    []FlowRule           `json:"firewall,omitempty" yaml:"firewall,omitempty"`
+	Schedule    map[string]*Schedule `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	PassFile    string               `json:"-" yaml:"-"`
+	LeaseFile   string               `json:"-" yaml:"-"`
+	TrafficFile string               `json:"-" yaml:"-"`
+	Ldap        *LDAP                `json:"ldap,omitempty" yaml:"ldap,omitempty"`
+	AddrPool    string               `json:"pool,omitempty" yaml:"pool,omitempty"`
+	ConfDir     string               `json:"-" yaml:"-"`
+	TokenFile   string               `json:"-" yaml:"-"`
+	PidFile     string               `json:"-" yaml:"-"`
 }
 
 func NewSwitch() *Switch {
@@ -158,6 +168,14 @@ func (s *Switch) LoadExtend() {
 
 func (s *Switch) Correct() {
 	s.Log.Correct()
+	if s.Audit == nil {
+		s.Audit = &Audit{}
+	}
+	s.Audit.Correct()
+	if s.Lockout == nil {
+		s.Lockout = &Lockout{}
+	}
+	s.Lockout.Correct()
 	s.Queue.Correct()
 
 	if s.Alias == "" {
@@ -186,8 +204,17 @@ func (s *Switch) Correct() {
 	}
 	s.Crypt.Correct()
 	s.Limit.Correct()
+	if s.Schedule == nil {
+		s.Schedule = make(map[string]*Schedule, 32)
+	}
+	for name, obj := range s.Schedule {
+		obj.Name = name
+		obj.Correct()
+	}
 
 	s.PassFile = s.Dir("password", "")
+	s.LeaseFile = s.Dir("lease.json", "")
+	s.TrafficFile = s.Dir("traffic.json", "")
 	if s.AddrPool == "" {
 		s.AddrPool = "100.255"
 	}
@@ -297,6 +324,12 @@ func (s *Switch) CorrectNetwork(obj *Network, format string) {
 		obj.Correct(s)
 		s.Qos[obj.Name] = obj
 	}
+	if obj.Fastpath == "enable" {
+		if policies := obj.FastpathBypass(s.Acl[obj.Name], s.Qos[obj.Name]); len(policies) > 0 {
+			libol.Warn("Switch.CorrectNetwork: %s fastpath disabled by %v", obj.Name, policies)
+			obj.Fastpath = "disable"
+		}
+	}
 }
 
 func (s *Switch) CorrectNetworks() {
@@ -425,3 +458,75 @@ func (s *Switch) GetACL(name string) *ACL {
 func (s *Switch) GetQos(name string) *Qos {
 	return s.Qos[name]
 }
+
+// Snapshot is a deep copy of one network with its acl and qos, kept
+// to roll back a change that was never confirmed.
+type Snapshot struct {
+	Network *Network
+	Acl     *ACL
+	Qos     *Qos
+}
+
+// Save writes the network, acl and qos of snapshot to their files.
+func (s *Snapshot) Save() {
+	s.Network.Save()
+	if s.Acl != nil {
+		s.Acl.Save()
+	}
+	if s.Qos != nil {
+		s.Qos.Save()
+	}
+}
+
+func (s *Switch) clone(src, dst any) error {
+	data, err := libol.Marshal(src, false)
+	if err != nil {
+		return err
+	}
+	return libol.Unmarshal(dst, data)
+}
+
+func (s *Switch) Snapshot(name string) (*Snapshot, error) {
+	obj := s.GetNetwork(name)
+	if obj == nil {
+		return nil, libol.NewErr("network %s notFound", name)
+	}
+	snap := &Snapshot{
+		Network: &Network{
+			Provider: obj.Provider,
+		},
+	}
+	// keep the same type of specifies when decoding.
+	snap.Network.NewSpecifies()
+	if err := s.clone(obj, snap.Network); err != nil {
+		return nil, err
+	}
+	snap.Network.ConfDir = obj.ConfDir
+	snap.Network.File = obj.File
+	snap.Network.Alias = obj.Alias
+	snap.Network.AddrPool = obj.AddrPool
+	if acl := s.GetACL(name); acl != nil {
+		snap.Acl = &ACL{File: acl.File}
+		if err := s.clone(acl, snap.Acl); err != nil {
+			return nil, err
+		}
+	}
+	if qos := s.GetQos(name); qos != nil {
+		snap.Qos = &Qos{File: qos.File}
+		if err := s.clone(qos, snap.Qos); err != nil {
+			return nil, err
+		}
+	}
+	return snap, nil
+}
+
+func (s *Switch) Restore(snap *Snapshot) {
+	name := snap.Network.Name
+	s.Network[name] = snap.Network
+	if snap.Acl != nil {
+		s.Acl[name] = snap.Acl
+	}
+	if snap.Qos != nil {
+		s.Qos[name] = snap.Qos
+	}
+}
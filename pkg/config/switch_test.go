@@ -16,3 +16,65 @@ func TestSwitch(t *testing.T) {
 	sw.Correct()
 	assert.Equal(t, "192.168.1.0:10002", sw.Listen, "be the same.")
 }
+
+func TestSwitchSnapshot(t *testing.T) {
+	sw := Switch{
+		Network: map[string]*Network{},
+		Acl:     map[string]*ACL{},
+		Qos:     map[string]*Qos{},
+	}
+	sw.Network["a"] = &Network{
+		Name:   "a",
+		File:   "/tmp/a.json",
+		Routes: []PrefixRoute{{Prefix: "192.168.1.0/24"}},
+	}
+	sw.Acl["a"] = &ACL{Name: "a", Rules: []*ACLRule{{SrcIp: "1.1.1.1", Action: "drop"}}}
+
+	snap, err := sw.Snapshot("a")
+	assert.Nil(t, err, "snapshot a")
+	sw.Network["a"].Routes = nil
+	sw.Acl["a"].Rules = nil
+
+	sw.Restore(snap)
+	assert.Equal(t, "/tmp/a.json", sw.Network["a"].File, "be the same.")
+	assert.Equal(t, 1, len(sw.Network["a"].Routes), "be the same.")
+	assert.Equal(t, "1.1.1.1", sw.Acl["a"].Rules[0].SrcIp, "be the same.")
+
+	_, err = sw.Snapshot("b")
+	assert.NotNil(t, err, "b notFound")
+}
+
+func TestSwitchSnapshotSpecifies(t *testing.T) {
+	sw := Switch{
+		Network: map[string]*Network{},
+	}
+	sw.Network["bgp"] = &Network{
+		Name:      "bgp",
+		Provider:  "bgp",
+		Specifies: &BgpSpecifies{LocalAs: 65001},
+	}
+	snap, err := sw.Snapshot("bgp")
+	assert.Nil(t, err, "snapshot bgp")
+	spec, ok := snap.Network.Specifies.(*BgpSpecifies)
+	assert.True(t, ok, "keep type of specifies")
+	assert.Equal(t, 65001, spec.LocalAs, "be the same.")
+}
+
+func TestSwitchFastpathBypass(t *testing.T) {
+	sw := Switch{
+		Network: map[string]*Network{},
+		Acl:     map[string]*ACL{},
+		Qos:     map[string]*Qos{},
+	}
+	sw.Acl["a"] = &ACL{Name: "a", Rules: []*ACLRule{{SrcIp: "1.1.1.1", Action: "drop"}}}
+	a := &Network{Name: "a", Fastpath: "enable"}
+	sw.CorrectNetwork(a, "")
+	assert.Equal(t, "disable", a.Fastpath, "bypass acl")
+
+	b := &Network{Name: "b", Fastpath: "enable"}
+	sw.CorrectNetwork(b, "")
+	assert.Equal(t, "enable", b.Fastpath, "be the same.")
+	b.Posture = &Posture{Action: "restrict"}
+	b.ZTrust = "enable"
+	assert.Equal(t, []string{"ztrust", "posture"}, b.FastpathBypass(nil, nil), "be the same.")
+}
@@ -1,10 +1,48 @@
 package config
 
+import "fmt"
+
 type VxLANSpecifies struct {
-	Name   string `json:"-" yaml:"-"`
-	Vni    uint32 `json:"vni" yaml:"vni"`
-	Fabric string `json:"fabric" yaml:"fabric"`
+	Name    string   `json:"-" yaml:"-"`
+	Vni     uint32   `json:"vni" yaml:"vni"`
+	Fabric  string   `json:"fabric" yaml:"fabric"` // bgp network to exchange evpn routes.
+	Local   string   `json:"local,omitempty" yaml:"local,omitempty"`
+	DstPort int      `json:"dstport,omitempty" yaml:"dstport,omitempty"`
+	Mtu     int      `json:"mtu,omitempty" yaml:"mtu,omitempty"`     // derived from the underlay if zero.
+	Peers   []string `json:"peers,omitempty" yaml:"peers,omitempty"` // static vteps without evpn.
 }
 
 func (c *VxLANSpecifies) Correct() {
+	if c.DstPort == 0 {
+		c.DstPort = 4789
+	}
+}
+
+func (c *VxLANSpecifies) Device() string {
+	return fmt.Sprintf("%s%d", "evi", c.Vni)
+}
+
+func (c *VxLANSpecifies) FindPeer(value string) int {
+	for index, obj := range c.Peers {
+		if obj == value {
+			return index
+		}
+	}
+	return -1
+}
+
+func (c *VxLANSpecifies) AddPeer(value string) bool {
+	find := c.FindPeer(value)
+	if find == -1 {
+		c.Peers = append(c.Peers, value)
+	}
+	return find == -1
+}
+
+func (c *VxLANSpecifies) DelPeer(value string) bool {
+	find := c.FindPeer(value)
+	if find != -1 {
+		c.Peers = append(c.Peers[:find], c.Peers[find+1:]...)
+	}
+	return find != -1
 }
@@ -0,0 +1,56 @@
+package config
+
+type WireGuardPeer struct {
+	Name       string   `json:"name" yaml:"name"`
+	PublicKey  string   `json:"publicKey" yaml:"publicKey"`
+	PrivateKey string   `json:"privateKey,omitempty" yaml:"privateKey,omitempty"` // generated by switch.
+	Address    string   `json:"address,omitempty" yaml:"address,omitempty"`
+	AllowedIPs []string `json:"allowedIps,omitempty" yaml:"allowedIps,omitempty"`
+}
+
+type WireGuard struct {
+	Network    string           `json:"-" yaml:"-"`
+	Listen     int              `json:"listen,omitempty" yaml:"listen,omitempty"`
+	PrivateKey string           `json:"privateKey,omitempty" yaml:"privateKey,omitempty"`
+	Endpoint   string           `json:"endpoint,omitempty" yaml:"endpoint,omitempty"` // address exported to clients.
+	Peers      []*WireGuardPeer `json:"peers,omitempty" yaml:"peers,omitempty"`
+}
+
+func (w *WireGuard) Correct(network string) {
+	w.Network = network
+	if w.Listen == 0 {
+		w.Listen = 51820
+	}
+}
+
+func (w *WireGuard) Device() string {
+	if len(w.Network) > 12 {
+		return "wg-" + w.Network[:12]
+	}
+	return "wg-" + w.Network
+}
+
+func (w *WireGuard) FindPeer(name string) (*WireGuardPeer, int) {
+	for index, obj := range w.Peers {
+		if obj.Name == name {
+			return obj, index
+		}
+	}
+	return nil, -1
+}
+
+func (w *WireGuard) AddPeer(value *WireGuardPeer) bool {
+	_, find := w.FindPeer(value.Name)
+	if find == -1 {
+		w.Peers = append(w.Peers, value)
+	}
+	return find == -1
+}
+
+func (w *WireGuard) DelPeer(name string) (*WireGuardPeer, bool) {
+	obj, find := w.FindPeer(name)
+	if find != -1 {
+		w.Peers = append(w.Peers[:find], w.Peers[find+1:]...)
+	}
+	return obj, find != -1
+}
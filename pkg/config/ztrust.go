@@ -0,0 +1,23 @@
+package config
+
+import "strings"
+
+// ZPolicy allows users or groups to knock services by themselves, and
+// approved by admin if required.
+type ZPolicy struct {
+	Name     string   `json:"name" yaml:"name"`
+	Users    []string `json:"users,omitempty" yaml:"users,omitempty"` // name@network
+	Groups   []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Services []string `json:"services" yaml:"services"`                     // protocol:destination[:port], e.g. tcp:10.0.0.0/24:22
+	MaxAge   int      `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`     // seconds
+	Approval bool     `json:"approval,omitempty" yaml:"approval,omitempty"` // pending until approved by admin.
+}
+
+func (p *ZPolicy) Correct() {
+	for i, service := range p.Services {
+		p.Services[i] = strings.ToLower(strings.TrimSpace(service))
+	}
+	if p.MaxAge <= 0 {
+		p.MaxAge = 3600
+	}
+}
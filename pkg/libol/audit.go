@@ -0,0 +1,224 @@
+package libol
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEvent is an action of admin or user, and written as a JSON line.
+type AuditEvent struct {
+	Time    int64  `json:"time"`
+	Type    string `json:"type"` // api, login, openvpn, lease or ztrust.
+	User    string `json:"user,omitempty"`
+	Token   string `json:"token,omitempty"` // masked.
+	Source  string `json:"source,omitempty"`
+	Network string `json:"network,omitempty"`
+	Action  string `json:"action"`
+	Params  string `json:"params,omitempty"`
+	Result  string `json:"result,omitempty"` // success or failure.
+	Detail  string `json:"detail,omitempty"`
+}
+
+type AuditFilter struct {
+	Type    string
+	User    string
+	Network string
+	Action  string
+	Result  string
+	Since   int64
+	Until   int64
+	Limit   int
+}
+
+func (f *AuditFilter) Match(e *AuditEvent) bool {
+	if f.Type != "" && f.Type != e.Type {
+		return false
+	}
+	if f.User != "" && f.User != e.User && !strings.HasPrefix(e.User, f.User+"@") {
+		return false
+	}
+	if f.Network != "" && f.Network != e.Network {
+		return false
+	}
+	if f.Action != "" && !strings.Contains(e.Action, f.Action) {
+		return false
+	}
+	if f.Result != "" && f.Result != e.Result {
+		return false
+	}
+	if f.Since > 0 && e.Time < f.Since {
+		return false
+	}
+	if f.Until > 0 && e.Time > f.Until {
+		return false
+	}
+	return true
+}
+
+const (
+	maxAuditParams = 1024
+	auditTag       = "openlan-audit"
+)
+
+type auditor struct {
+	Lock   sync.Mutex
+	file   *rotateFile
+	syslog io.WriteCloser
+}
+
+var Auditor = &auditor{}
+
+// SetAudit opens the audit file, which rotated beyond size with backups,
+// and forwards events to syslog if given local or an address.
+func SetAudit(file string, size int64, backups int, syslog string) {
+	a := Auditor
+	a.Lock.Lock()
+	defer a.Lock.Unlock()
+	a.close()
+	if file != "" {
+		if fp, err := openRotate(file); err != nil {
+			Error("Auditor.Set: %s", err)
+		} else {
+			fp.Set(size, 0, backups)
+			a.file = fp
+		}
+	}
+	if syslog != "" {
+		if w, err := dialSyslog(syslog, auditTag); err != nil {
+			Error("Auditor.Set: %s", err)
+		} else {
+			a.syslog = w
+		}
+	}
+}
+
+func Audit(event AuditEvent) {
+	Auditor.Write(&event)
+}
+
+func (a *auditor) close() {
+	if a.file != nil {
+		_ = a.file.Close()
+		a.file = nil
+	}
+	if a.syslog != nil {
+		_ = a.syslog.Close()
+		a.syslog = nil
+	}
+}
+
+func (a *auditor) Write(e *AuditEvent) {
+	if e.Time == 0 {
+		e.Time = time.Now().Unix()
+	}
+	if len(e.Params) > maxAuditParams {
+		e.Params = e.Params[:maxAuditParams] + "..."
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		Warn("Auditor.Write: %s", err)
+		return
+	}
+	a.Lock.Lock()
+	defer a.Lock.Unlock()
+	if a.file != nil {
+		if _, err := a.file.Write(append(data, '\n')); err != nil {
+			Warn("Auditor.Write: %s", err)
+		}
+	}
+	if a.syslog != nil {
+		if _, err := a.syslog.Write(data); err != nil {
+			Warn("Auditor.Write: %s", err)
+		}
+	}
+}
+
+// List returns the last events matched by filter from the oldest backup to
+// the current file.
+func (a *auditor) List(filter AuditFilter) []AuditEvent {
+	a.Lock.Lock()
+	defer a.Lock.Unlock()
+	items := make([]AuditEvent, 0, 128)
+	if a.file == nil {
+		return items
+	}
+	for i := a.file.Backups; i >= 0; i-- {
+		fp, err := os.Open(a.file.Backup(i))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(fp)
+		scanner.Buffer(make([]byte, 4096), 1<<20)
+		for scanner.Scan() {
+			e := AuditEvent{}
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				continue
+			}
+			if !filter.Match(&e) {
+				continue
+			}
+			items = append(items, e)
+			if filter.Limit > 0 && len(items) > filter.Limit {
+				items = items[1:]
+			}
+		}
+		_ = fp.Close()
+	}
+	return items
+}
+
+// MaskToken keeps few characters of a secret to tell it from others.
+func MaskToken(value string) string {
+	if len(value) <= 8 {
+		return "***"
+	}
+	return value[:4] + "***"
+}
+
+var auditSecrets = []string{"pass", "secret", "token", "psk", "key"}
+
+func maskParams(value interface{}) interface{} {
+	switch obj := value.(type) {
+	case map[string]interface{}:
+		for k, v := range obj {
+			key := strings.ToLower(k)
+			masked := false
+			for _, s := range auditSecrets {
+				if strings.Contains(key, s) {
+					masked = true
+					break
+				}
+			}
+			if masked {
+				obj[k] = "***"
+			} else {
+				obj[k] = maskParams(v)
+			}
+		}
+	case []interface{}:
+		for i, v := range obj {
+			obj[i] = maskParams(v)
+		}
+	}
+	return value
+}
+
+// AuditParams masks passwords, secrets and keys of a JSON body.
+func AuditParams(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "<not json>"
+	}
+	if out, err := json.Marshal(maskParams(value)); err == nil {
+		return string(out)
+	}
+	return ""
+}
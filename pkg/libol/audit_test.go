@@ -0,0 +1,42 @@
+package libol
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditRotate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	fp, err := openRotate(file)
+	assert.Nil(t, err)
+	fp.Set(256, 0, 2)
+	a := &auditor{file: fp}
+	for i := 0; i < 16; i++ {
+		a.Write(&AuditEvent{Type: "login", User: "hi@default", Action: "access", Result: "success"})
+	}
+	a.Write(&AuditEvent{Type: "api", User: "admin", Action: "POST /api/user", Result: "failure"})
+
+	_, err = os.Stat(file + ".2")
+	assert.Nil(t, err)
+	_, err = os.Stat(file + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	items := a.List(AuditFilter{Type: "api"})
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, "admin", items[0].User)
+	items = a.List(AuditFilter{User: "hi", Limit: 2})
+	assert.Equal(t, 2, len(items))
+	items = a.List(AuditFilter{Result: "failure", Action: "/api/user"})
+	assert.Equal(t, 1, len(items))
+}
+
+func TestAuditParams(t *testing.T) {
+	value := AuditParams([]byte(`{"name":"hi","password":"123","crypt":{"secret":"abc"}}`))
+	assert.Equal(t, `{"crypt":{"secret":"***"},"name":"hi","password":"***"}`, value)
+	assert.Equal(t, "", AuditParams(nil))
+	assert.Equal(t, "***", MaskToken("abc"))
+	assert.Equal(t, "abcd***", MaskToken("abcdefghijk"))
+}
@@ -0,0 +1,350 @@
+package libol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type filterPacket struct {
+	src     net.HardwareAddr
+	dst     net.HardwareAddr
+	proto   uint16 // ether type after vlan.
+	vlan    bool
+	srcIp   net.IP
+	dstIp   net.IP
+	ipProto int
+	sport   int
+	dport   int
+}
+
+func newFilterPacket(frame []byte) *filterPacket {
+	p := &filterPacket{ipProto: -1, sport: -1, dport: -1}
+	if len(frame) < EtherLen {
+		return p
+	}
+	p.dst = frame[0:6]
+	p.src = frame[6:12]
+	p.proto = binary.BigEndian.Uint16(frame[12:14])
+	data := frame[EtherLen:]
+	if p.proto == EthVlan && len(data) >= VlanLen {
+		p.vlan = true
+		p.proto = binary.BigEndian.Uint16(data[2:4])
+		data = data[VlanLen:]
+	}
+	hlen := 0
+	switch p.proto {
+	case EthArp:
+		if len(data) >= 28 {
+			p.srcIp = data[14:18]
+			p.dstIp = data[24:28]
+		}
+		return p
+	case EthIp4:
+		if len(data) < Ipv4Len {
+			return p
+		}
+		hlen = int(data[0]&0x0f) * 4
+		p.ipProto = int(data[9])
+		p.srcIp = data[12:16]
+		p.dstIp = data[16:20]
+		if binary.BigEndian.Uint16(data[6:8])&0x1fff != 0 {
+			return p // ports only in the first fragment.
+		}
+	case EthIp6:
+		if len(data) < 40 {
+			return p
+		}
+		hlen = 40
+		p.ipProto = int(data[6])
+		p.srcIp = data[8:24]
+		p.dstIp = data[24:40]
+	default:
+		return p
+	}
+	if (p.ipProto == IpTcp || p.ipProto == IpUdp) && len(data) >= hlen+4 {
+		p.sport = int(binary.BigEndian.Uint16(data[hlen : hlen+2]))
+		p.dport = int(binary.BigEndian.Uint16(data[hlen+2 : hlen+4]))
+	}
+	return p
+}
+
+type filterFunc func(p *filterPacket) bool
+
+// PacketFilter matches frames by an expression like tcpdump, and supports
+// a subset of BPF syntax:
+//
+//	[src|dst] host ADDR, [src|dst] net CIDR, [src|dst] port PORT,
+//	[src|dst] portrange MIN-MAX, ether [src|dst] host MAC,
+//	arp, ip, ip6, tcp, udp, icmp, icmp6, vlan,
+//	and combined by not, and, or, and parentheses.
+type PacketFilter struct {
+	text  string
+	match filterFunc
+}
+
+func NewPacketFilter(text string) (*PacketFilter, error) {
+	f := &PacketFilter{text: strings.TrimSpace(text)}
+	if f.text == "" {
+		return f, nil
+	}
+	p := &filterParser{tokens: filterTokens(f.text)}
+	match, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, NewErr("unexpected %q", p.tokens[p.pos])
+	}
+	f.match = match
+	return f, nil
+}
+
+func (f *PacketFilter) String() string {
+	return f.text
+}
+
+func (f *PacketFilter) Match(frame []byte) bool {
+	if f.match == nil {
+		return true
+	}
+	return f.match(newFilterPacket(frame))
+}
+
+func filterTokens(text string) []string {
+	text = strings.NewReplacer("(", " ( ", ")", " ) ", "!", " ! ").Replace(text)
+	tokens := strings.Fields(text)
+	for i, token := range tokens {
+		switch token {
+		case "&&":
+			tokens[i] = "and"
+		case "||":
+			tokens[i] = "or"
+		case "!":
+			tokens[i] = "not"
+		}
+	}
+	return tokens
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", NewErr("unexpected end")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	return token, nil
+}
+
+func (p *filterParser) expr() (filterFunc, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "or" {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		a, b := left, right
+		left = func(pkt *filterPacket) bool { return a(pkt) || b(pkt) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) term() (filterFunc, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "and" {
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		a, b := left, right
+		left = func(pkt *filterPacket) bool { return a(pkt) && b(pkt) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) factor() (filterFunc, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case "not":
+		inner, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return func(pkt *filterPacket) bool { return !inner(pkt) }, nil
+	case "(":
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if token, err := p.next(); err != nil || token != ")" {
+			return nil, NewErr("missing )")
+		}
+		return inner, nil
+	}
+	p.pos--
+	return p.primitive()
+}
+
+func filterProto(token string) filterFunc {
+	switch token {
+	case "arp":
+		return func(pkt *filterPacket) bool { return pkt.proto == EthArp }
+	case "ip":
+		return func(pkt *filterPacket) bool { return pkt.proto == EthIp4 }
+	case "ip6":
+		return func(pkt *filterPacket) bool { return pkt.proto == EthIp6 }
+	case "tcp":
+		return func(pkt *filterPacket) bool { return pkt.ipProto == IpTcp }
+	case "udp":
+		return func(pkt *filterPacket) bool { return pkt.ipProto == IpUdp }
+	case "icmp":
+		return func(pkt *filterPacket) bool { return pkt.proto == EthIp4 && pkt.ipProto == IpIcmp }
+	case "icmp6":
+		return func(pkt *filterPacket) bool { return pkt.proto == EthIp6 && pkt.ipProto == 58 }
+	case "vlan":
+		return func(pkt *filterPacket) bool { return pkt.vlan }
+	}
+	return nil
+}
+
+func (p *filterParser) primitive() (filterFunc, error) {
+	token, _ := p.next()
+	if token == "ether" {
+		return p.ether()
+	}
+	if proto := filterProto(token); proto != nil {
+		switch p.peek() {
+		case "src", "dst", "host", "net", "port", "portrange":
+			// e.g. tcp port 80.
+			match, err := p.primitive()
+			if err != nil {
+				return nil, err
+			}
+			return func(pkt *filterPacket) bool { return proto(pkt) && match(pkt) }, nil
+		}
+		return proto, nil
+	}
+	dir := ""
+	if token == "src" || token == "dst" {
+		dir = token
+		if token, _ = p.next(); token == "" {
+			return nil, NewErr("unexpected end")
+		}
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case "host":
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, NewErr("invalid host %q", value)
+		}
+		return filterAddr(dir, func(addr net.IP) bool { return ip.Equal(addr) }), nil
+	case "net":
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, NewErr("invalid net %q", value)
+		}
+		return filterAddr(dir, func(addr net.IP) bool { return addr != nil && ipNet.Contains(addr) }), nil
+	case "port", "portrange":
+		min, max, err := filterPorts(token, value)
+		if err != nil {
+			return nil, err
+		}
+		match := func(port int) bool { return port >= min && port <= max }
+		switch dir {
+		case "src":
+			return func(pkt *filterPacket) bool { return match(pkt.sport) }, nil
+		case "dst":
+			return func(pkt *filterPacket) bool { return match(pkt.dport) }, nil
+		}
+		return func(pkt *filterPacket) bool { return match(pkt.sport) || match(pkt.dport) }, nil
+	}
+	return nil, NewErr("unknown %q", token)
+}
+
+func (p *filterParser) ether() (filterFunc, error) {
+	dir := ""
+	if token := p.peek(); token == "src" || token == "dst" {
+		dir = token
+		p.pos++
+	}
+	if p.peek() == "host" {
+		p.pos++
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	mac, err := net.ParseMAC(value)
+	if err != nil {
+		return nil, NewErr("invalid ether %q", value)
+	}
+	return func(pkt *filterPacket) bool {
+		switch dir {
+		case "src":
+			return bytes.Equal(pkt.src, mac)
+		case "dst":
+			return bytes.Equal(pkt.dst, mac)
+		}
+		return bytes.Equal(pkt.src, mac) || bytes.Equal(pkt.dst, mac)
+	}, nil
+}
+
+func filterAddr(dir string, match func(addr net.IP) bool) filterFunc {
+	switch dir {
+	case "src":
+		return func(pkt *filterPacket) bool { return pkt.srcIp != nil && match(pkt.srcIp) }
+	case "dst":
+		return func(pkt *filterPacket) bool { return pkt.dstIp != nil && match(pkt.dstIp) }
+	}
+	return func(pkt *filterPacket) bool {
+		return (pkt.srcIp != nil && match(pkt.srcIp)) || (pkt.dstIp != nil && match(pkt.dstIp))
+	}
+}
+
+func filterPorts(kind, value string) (int, int, error) {
+	values := []string{value, value}
+	if kind == "portrange" {
+		values = strings.SplitN(value, "-", 2)
+		if len(values) != 2 {
+			return 0, 0, NewErr("invalid portrange %q", value)
+		}
+	}
+	min, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0, 0, NewErr("invalid port %q", value)
+	}
+	max, err := strconv.Atoi(values[1])
+	if err != nil || min > max {
+		return 0, 0, NewErr("invalid port %q", value)
+	}
+	return min, max, nil
+}
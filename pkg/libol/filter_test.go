@@ -0,0 +1,75 @@
+package libol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func filterUdp(src, dst string, sport, dport uint16) []byte {
+	eth := NewEtherIP4()
+	copy(eth.Dst, EthAll)
+	copy(eth.Src, []byte{0x02, 0, 0, 0, 0, 0x01})
+	ip4 := NewIpv4()
+	ip4.Protocol = IpUdp
+	copy(ip4.Source, net.ParseIP(src).To4())
+	copy(ip4.Destination, net.ParseIP(dst).To4())
+	udp := NewUdp()
+	udp.Source = sport
+	udp.Destination = dport
+	frame := append(eth.Encode(), ip4.Encode()...)
+	return append(frame, udp.Encode()...)
+}
+
+func TestPacketFilter(t *testing.T) {
+	frame := filterUdp("192.168.1.2", "10.0.0.1", 5000, 53)
+	cases := []struct {
+		filter string
+		match  bool
+	}{
+		{"", true},
+		{"udp", true},
+		{"tcp", false},
+		{"ip and not ip6", true},
+		{"host 10.0.0.1", true},
+		{"src host 10.0.0.1", false},
+		{"dst host 10.0.0.1", true},
+		{"net 192.168.0.0/16 and udp port 53", true},
+		{"tcp port 53", false},
+		{"src port 53", false},
+		{"portrange 50-60", true},
+		{"ether src 02:00:00:00:00:01", true},
+		{"ether dst host 02:00:00:00:00:01", false},
+		{"arp or (udp && !port 80)", true},
+		{"arp || icmp", false},
+	}
+	for _, c := range cases {
+		f, err := NewPacketFilter(c.filter)
+		if assert.Nil(t, err, c.filter) {
+			assert.Equal(t, c.match, f.Match(frame), c.filter)
+		}
+	}
+	for _, bad := range []string{"host", "host 1.1.1", "port x", "(udp", "udp foo", "portrange 9-1"} {
+		_, err := NewPacketFilter(bad)
+		assert.NotNil(t, err, bad)
+	}
+}
+
+func TestPcapWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewPcapWriter(&buf, 16)
+	assert.Nil(t, err)
+	frame := filterUdp("192.168.1.2", "10.0.0.1", 5000, 53)
+	assert.Nil(t, w.Write(time.Unix(100, 2000), frame))
+	data := buf.Bytes()
+	assert.Equal(t, 24+16+16, len(data))
+	assert.Equal(t, uint32(PcapMagic), binary.LittleEndian.Uint32(data[0:4]))
+	assert.Equal(t, uint32(100), binary.LittleEndian.Uint32(data[24:28]))
+	assert.Equal(t, uint32(2), binary.LittleEndian.Uint32(data[28:32]))
+	assert.Equal(t, uint32(16), binary.LittleEndian.Uint32(data[32:36]))
+	assert.Equal(t, uint32(len(frame)), binary.LittleEndian.Uint32(data[36:40]))
+}
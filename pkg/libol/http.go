@@ -1,6 +1,7 @@
 package libol
 
 import (
+	"context"
 	"crypto/tls"
 	"io"
 	"net/http"
@@ -15,6 +16,7 @@ type HttpClient struct {
 	TlsConfig *tls.Config
 	Client    *http.Client
 	Timeout   time.Duration
+	Context   context.Context
 }
 
 func (cl *HttpClient) Do() (*http.Response, error) {
@@ -24,7 +26,10 @@ func (cl *HttpClient) Do() (*http.Response, error) {
 	if cl.TlsConfig == nil {
 		cl.TlsConfig = &tls.Config{InsecureSkipVerify: true}
 	}
-	req, err := http.NewRequest(cl.Method, cl.Url, cl.Payload)
+	if cl.Context == nil {
+		cl.Context = context.Background()
+	}
+	req, err := http.NewRequestWithContext(cl.Context, cl.Method, cl.Url, cl.Payload)
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package libol
 import (
 	"crypto/tls"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-ldap/ldap"
@@ -47,9 +48,18 @@ func NewLDAPService(cfg LDAPConfig) (*LDAPService, error) {
 }
 
 func (l *LDAPService) Login(userName, password string) (bool, error) {
+	if _, err := l.Authenticate(userName, password); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Authenticate binds as the user, and returns common names of groups
+// in memberOf of this user.
+func (l *LDAPService) Authenticate(userName, password string) ([]string, error) {
 	cfg := l.Cfg
 	if err := l.Conn.Bind(cfg.BindUser, cfg.BindPass); err != nil {
-		return false, err
+		return nil, err
 	}
 
 	request := ldap.NewSearchRequest(
@@ -57,21 +67,33 @@ func (l *LDAPService) Login(userName, password string) (bool, error) {
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
 		0, 0, false,
 		"("+fmt.Sprintf(cfg.Filter, userName)+")",
-		[]string{cfg.Attr}, nil,
+		[]string{cfg.Attr, "memberOf"}, nil,
 	)
 	result, err := l.Conn.Search(request)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	if len(result.Entries) <= 0 {
-		return false, fmt.Errorf("User not found")
+		return nil, fmt.Errorf("User not found")
 	}
 
 	obj := result.Entries[0]
 	if err = l.Conn.Bind(obj.DN, password); err != nil {
-		return false, err
+		return nil, err
 	}
-	return true, nil
+	groups := make([]string, 0, 4)
+	for _, value := range obj.GetAttributeValues("memberOf") {
+		dn, err := ldap.ParseDN(value)
+		if err != nil || len(dn.RDNs) == 0 {
+			continue
+		}
+		for _, attr := range dn.RDNs[0].Attributes {
+			if strings.EqualFold(attr.Type, "cn") {
+				groups = append(groups, attr.Value)
+			}
+		}
+	}
+	return groups, nil
 }
 
 func (l *LDAPService) State() string {
@@ -0,0 +1,225 @@
+package libol
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxLockout = 1024
+
+// LockoutEntry is failed logins of an user or a source address.
+type LockoutEntry struct {
+	Key     string `json:"key"` // user:NAME or source:ADDR.
+	Failed  int    `json:"failed"`
+	Last    int64  `json:"last"`
+	Until   int64  `json:"until"` // locked until.
+	Blocked bool   `json:"blocked"`
+}
+
+// Lockout tracks failed logins by users and sources, and locks them out
+// with exponential backoff. A source is blocked by callbacks if it failed
+// too many times.
+type Lockout struct {
+	lock       sync.Mutex
+	MaxFailed  int   // failures before locked out.
+	Backoff    int64 // seconds of first lockout, and doubled by next failures.
+	MaxBackoff int64
+	Window     int64 // seconds to forget failures since the last one.
+	Block      int   // failures of a source before blocked.
+	OnBlock    func(addr string)
+	OnUnblock  func(addr string)
+	entries    map[string]*LockoutEntry
+}
+
+func NewLockout() *Lockout {
+	return &Lockout{
+		MaxFailed:  5,
+		Backoff:    30,
+		MaxBackoff: 3600,
+		Window:     900,
+		Block:      20,
+		entries:    make(map[string]*LockoutEntry, 32),
+	}
+}
+
+// Set changes the policy, and zero keeps the current one.
+func (l *Lockout) Set(maxFailed int, backoff, maxBackoff, window int64, block int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if maxFailed > 0 {
+		l.MaxFailed = maxFailed
+	}
+	if backoff > 0 {
+		l.Backoff = backoff
+	}
+	if maxBackoff > 0 {
+		l.MaxBackoff = maxBackoff
+	}
+	if window > 0 {
+		l.Window = window
+	}
+	if block > 0 {
+		l.Block = block
+	}
+}
+
+func lockoutSource(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func (l *Lockout) keys(user, source string) []string {
+	keys := make([]string, 0, 2)
+	if user != "" {
+		keys = append(keys, "user:"+user)
+	}
+	if source != "" {
+		keys = append(keys, "source:"+lockoutSource(source))
+	}
+	return keys
+}
+
+// Check returns an error if the user or source is locked out.
+func (l *Lockout) Check(user, source string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := time.Now().Unix()
+	for _, key := range l.keys(user, source) {
+		if e, ok := l.entries[key]; ok && e.Until > now {
+			return NewErr("%s locked out for %ds", key, e.Until-now)
+		}
+	}
+	return nil
+}
+
+func (l *Lockout) backoff(failed int) int64 {
+	shift := failed - l.MaxFailed
+	if shift > 16 {
+		shift = 16
+	}
+	value := l.Backoff << uint(shift)
+	if value > l.MaxBackoff {
+		value = l.MaxBackoff
+	}
+	return value
+}
+
+// Fail records a failed login, and locks out the user or source if it
+// failed too many times.
+func (l *Lockout) Fail(user, source string) {
+	blocked := ""
+	l.lock.Lock()
+	now := time.Now().Unix()
+	for _, key := range l.keys(user, source) {
+		e, ok := l.entries[key]
+		if !ok {
+			e = &LockoutEntry{Key: key}
+			l.entries[key] = e
+		}
+		if e.Until <= now && now-e.Last > l.Window {
+			e.Failed = 0
+		}
+		e.Failed++
+		e.Last = now
+		if e.Failed >= l.MaxFailed {
+			e.Until = now + l.backoff(e.Failed)
+		}
+		if strings.HasPrefix(key, "source:") && l.Block > 0 && e.Failed >= l.Block && !e.Blocked {
+			e.Blocked = true
+			e.Until = now + l.MaxBackoff
+			blocked = strings.TrimPrefix(key, "source:")
+		}
+	}
+	size := len(l.entries)
+	l.lock.Unlock()
+	if size > maxLockout {
+		l.Expire()
+	}
+	if blocked != "" {
+		Warn("Lockout.Fail: block %s", blocked)
+		if l.OnBlock != nil {
+			l.OnBlock(blocked)
+		}
+	}
+}
+
+// Success forgets failures of the user.
+func (l *Lockout) Success(user string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.entries, "user:"+user)
+}
+
+func (l *Lockout) unblock(addrs []string) {
+	for _, addr := range addrs {
+		Info("Lockout.unblock: %s", addr)
+		if l.OnUnblock != nil {
+			l.OnUnblock(addr)
+		}
+	}
+}
+
+// Expire unblocks sources out of lockout, and forgets failures out of
+// window.
+func (l *Lockout) Expire() {
+	var addrs []string
+	l.lock.Lock()
+	now := time.Now().Unix()
+	for key, e := range l.entries {
+		if e.Until > now {
+			continue
+		}
+		if e.Blocked {
+			e.Blocked = false
+			addrs = append(addrs, strings.TrimPrefix(key, "source:"))
+		}
+		if now-e.Last > l.Window {
+			delete(l.entries, key)
+		}
+	}
+	l.lock.Unlock()
+	l.unblock(addrs)
+}
+
+// Clear removes an entry by key, name of user or address of source, and
+// all entries if key is empty.
+func (l *Lockout) Clear(key string) error {
+	var addrs []string
+	l.lock.Lock()
+	found := false
+	for k, e := range l.entries {
+		if key != "" && k != key && k != "user:"+key && k != "source:"+key {
+			continue
+		}
+		found = true
+		if e.Blocked {
+			addrs = append(addrs, strings.TrimPrefix(k, "source:"))
+		}
+		delete(l.entries, k)
+	}
+	l.lock.Unlock()
+	l.unblock(addrs)
+	if key != "" && !found {
+		return NewErr("lockout %s not found", key)
+	}
+	return nil
+}
+
+func (l *Lockout) List() []LockoutEntry {
+	l.Expire()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	items := make([]LockoutEntry, 0, len(l.entries))
+	for _, e := range l.entries {
+		items = append(items, *e)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
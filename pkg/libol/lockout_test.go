@@ -0,0 +1,61 @@
+package libol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockout(t *testing.T) {
+	l := NewLockout()
+	l.Set(3, 10, 60, 0, 5)
+	blocked := ""
+	l.OnBlock = func(addr string) {
+		blocked = addr
+	}
+	l.OnUnblock = func(addr string) {
+		blocked = ""
+	}
+
+	for i := 0; i < 2; i++ {
+		l.Fail("hi@default", "192.168.1.2:4000")
+	}
+	assert.Nil(t, l.Check("hi@default", "192.168.1.2:4001"))
+	l.Fail("hi@default", "192.168.1.2:4000")
+	assert.NotNil(t, l.Check("hi@default", ""))
+	assert.NotNil(t, l.Check("", "192.168.1.2:4002"))
+	assert.Nil(t, l.Check("hei@default", "192.168.1.3:4000"))
+	assert.Equal(t, int64(20), l.backoff(4))
+	assert.Equal(t, int64(60), l.backoff(10))
+
+	l.Fail("", "192.168.1.2:4000")
+	l.Fail("", "192.168.1.2:4000")
+	assert.Equal(t, "192.168.1.2", blocked)
+
+	l.Success("hi@default")
+	assert.NotNil(t, l.Check("hi@default", "192.168.1.2"))
+	assert.Nil(t, l.Clear("192.168.1.2"))
+	assert.Equal(t, "", blocked)
+	assert.Nil(t, l.Check("hi@default", "192.168.1.2"))
+	assert.NotNil(t, l.Clear("192.168.1.2"))
+}
+
+func TestLockoutExpire(t *testing.T) {
+	l := NewLockout()
+	l.Set(1, 10, 60, 60, 1)
+	unblocked := ""
+	l.OnUnblock = func(addr string) {
+		unblocked = addr
+	}
+	l.Fail("", "10.0.0.1")
+	assert.Equal(t, 1, len(l.List()))
+
+	e := l.entries["source:10.0.0.1"]
+	e.Until = time.Now().Unix() - 1
+	l.Expire()
+	assert.Equal(t, "10.0.0.1", unblocked)
+	assert.Equal(t, 1, len(l.List()))
+	e.Last = time.Now().Unix() - 120
+	assert.Equal(t, 0, len(l.List()))
+}
@@ -2,11 +2,16 @@ package libol
 
 import (
 	"container/list"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"runtime/debug"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
 )
 
 const (
@@ -50,26 +55,150 @@ type logger struct {
 	FileLog  *log.Logger
 	Lock     sync.Mutex
 	Errors   *list.List
+	Format   string         // text or json.
+	Modules  map[string]int // levels of modules.
+	modLock  sync.RWMutex
+	minimum  atomic.Int32 // lowest level of global and modules.
+	file     *rotateFile
+	syslog   io.WriteCloser
+}
+
+// logType returns type of a message like "WorkerImpl.Initialize: %s".
+func logType(format string) string {
+	for i, c := range format {
+		if c == '.' {
+			return format[:i]
+		}
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			break
+		}
+	}
+	return ""
+}
+
+// level returns the level of a module by "prefix/Type", "Type" or "prefix",
+// otherwise the global level.
+func (l *logger) level(prefix, kind string) int {
+	l.modLock.RLock()
+	defer l.modLock.RUnlock()
+	if len(l.Modules) == 0 {
+		return l.Level
+	}
+	keys := []string{prefix + "/" + kind, kind, prefix}
+	for _, key := range keys {
+		if key == "" || key == "/" || strings.HasSuffix(key, "/") {
+			continue
+		}
+		if value, ok := l.Modules[key]; ok {
+			return value
+		}
+	}
+	return l.Level
+}
+
+// minLevel returns the lowest level of global and modules.
+func (l *logger) minLevel() int {
+	return int(l.minimum.Load())
+}
+
+// refresh caches the lowest level after levels changed.
+func (l *logger) refresh() {
+	l.modLock.RLock()
+	defer l.modLock.RUnlock()
+	value := l.Level
+	for _, level := range l.Modules {
+		if level < value {
+			value = level
+		}
+	}
+	l.minimum.Store(int32(value))
 }
 
 func (l *logger) Write(level int, format string, v ...any) {
+	l.Record(level, "", nil, format, v...)
+}
+
+// Record writes a message of a module with key/value fields.
+func (l *logger) Record(level int, module string, fields []any, format string, v ...any) {
+	if level < INFO && level < l.minLevel() {
+		return
+	}
 	str, ok := levels[level]
 	if !ok {
 		str = "NULL"
 	}
-	if level >= l.Level {
-		log.Printf(fmt.Sprintf("%s|%s", str, format), v...)
+	kind := logType(format)
+	show := level >= l.level(module, kind)
+	if !show && level < INFO {
+		return
+	}
+	m := fmt.Sprintf(format, v...)
+	var line string
+	if l.Format == "json" {
+		line = l.json(str, module, kind, m, fields)
+	} else {
+		line = l.text(str, module, m, fields)
+	}
+	if show {
+		if l.Format == "json" {
+			fmt.Fprintln(log.Writer(), line)
+		} else {
+			log.Print(line)
+		}
 	}
 	if level >= INFO {
-		l.Save(str, format, v...)
+		l.Save(str, module, m, line)
 	}
 }
 
-func (l *logger) Save(level string, format string, v ...any) {
-	m := fmt.Sprintf(format, v...)
+func (l *logger) text(level, module, message string, fields []any) string {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteString("|")
+	if module != "" {
+		b.WriteString(module)
+		b.WriteString("|")
+	}
+	b.WriteString(message)
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", fields[i], fields[i+1])
+	}
+	return b.String()
+}
+
+func (l *logger) json(level, module, kind, message string, fields []any) string {
+	record := map[string]any{
+		"time":    time.Now().Format(time.RFC3339),
+		"level":   level,
+		"message": message,
+	}
+	if module != "" {
+		record["module"] = module
+	}
+	if kind != "" {
+		record["type"] = kind
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		record[fmt.Sprint(fields[i])] = fields[i+1]
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return l.text(level, module, message, fields)
+	}
+	return string(data)
+}
+
+func (l *logger) Save(level, module, message, line string) {
 	now := time.Now()
-	if l.FileLog != nil {
-		l.FileLog.Printf("%s|%s\n", level, m)
+	if l.file != nil {
+		if l.Format == "json" {
+			_, _ = l.file.Write([]byte(line + "\n"))
+		} else if l.FileLog != nil {
+			l.FileLog.Println(line)
+		}
+	}
+	if l.syslog != nil {
+		_, _ = l.syslog.Write([]byte(line))
 	}
 	l.Lock.Lock()
 	defer l.Lock.Unlock()
@@ -81,7 +210,8 @@ func (l *logger) Save(level string, format string, v ...any) {
 	ele := &Message{
 		Level:   level,
 		Date:    now.Format(time.RFC3339),
-		Message: m,
+		Message: message,
+		Module:  module,
 	}
 	l.Errors.PushBack(ele)
 }
@@ -103,29 +233,95 @@ var Logger = &logger{
 	Level:    INFO,
 	FileName: ".log.error",
 	Errors:   list.New(),
+	Format:   "text",
+	Modules:  make(map[string]int, 32),
+}
+
+func init() {
+	Logger.refresh()
 }
 
 func SetLogger(file string, level int) {
-	Logger.Level = level
+	SetLevel(level)
 	if file == "" || Logger.FileName == file {
 		return
 	}
 	Logger.FileName = file
-	fp, err := OpenWrite(file)
+	fp, err := openRotate(file)
 	if err == nil {
+		Logger.file = fp
 		Logger.FileLog = log.New(fp, "", log.LstdFlags)
 	} else {
 		Warn("Logger.Init: %s", err)
 	}
 }
 
+// SetLogRotate rotates the log file beyond size or interval, and keeps
+// backups.
+func SetLogRotate(size int64, interval time.Duration, backups int) {
+	if Logger.file != nil {
+		Logger.file.Set(size, interval, backups)
+	}
+}
+
+// SetLogFormat writes records as text or json.
+func SetLogFormat(format string) {
+	if format == "json" {
+		Logger.Format = format
+	} else {
+		Logger.Format = "text"
+	}
+}
+
+// SetLogForward forwards records to local syslog or an address like
+// udp://host:514, and stops it if addr is empty.
+func SetLogForward(addr string) error {
+	if Logger.syslog != nil {
+		_ = Logger.syslog.Close()
+		Logger.syslog = nil
+	}
+	if addr == "" {
+		return nil
+	}
+	w, err := dialSyslog(addr, "openlan")
+	if err != nil {
+		return err
+	}
+	Logger.syslog = w
+	return nil
+}
+
+// SetModule changes level of a module, and removes it if level is zero.
+func SetModule(name string, level int) {
+	Logger.modLock.Lock()
+	if level == 0 {
+		delete(Logger.Modules, name)
+	} else {
+		Logger.Modules[name] = level
+	}
+	Logger.modLock.Unlock()
+	Logger.refresh()
+}
+
+func ListModule() map[string]int {
+	Logger.modLock.RLock()
+	defer Logger.modLock.RUnlock()
+	items := make(map[string]int, len(Logger.Modules))
+	for name, level := range Logger.Modules {
+		items[name] = level
+	}
+	return items
+}
+
 func SetLevel(level int) {
 	Logger.Level = level
+	Logger.refresh()
 }
 
 type SubLogger struct {
 	*logger
 	Prefix string
+	Fields []any
 }
 
 func NewSubLogger(prefix string) *SubLogger {
@@ -137,10 +333,6 @@ func NewSubLogger(prefix string) *SubLogger {
 
 var rLogger = NewSubLogger("root")
 
-func HasLog(level int) bool {
-	return rLogger.Has(level)
-}
-
 func Catch(name string) {
 	if err := recover(); err != nil {
 		Fatal("%s|PANIC >>> %s <<<", name, err)
@@ -184,8 +376,20 @@ func Fatal(format string, v ...any) {
 	rLogger.Fatal(format, v...)
 }
 
+// With returns a logger with key/value fields appended to records.
+func (s *SubLogger) With(kv ...any) *SubLogger {
+	fields := make([]any, 0, len(s.Fields)+len(kv))
+	fields = append(fields, s.Fields...)
+	fields = append(fields, kv...)
+	return &SubLogger{
+		logger: s.logger,
+		Prefix: s.Prefix,
+		Fields: fields,
+	}
+}
+
 func (s *SubLogger) Has(level int) bool {
-	if level >= s.Level {
+	if level >= s.level(s.Prefix, "") {
 		return true
 	}
 	return false
@@ -195,52 +399,56 @@ func (s *SubLogger) Fmt(format string) string {
 	return s.Prefix + "|" + format
 }
 
+func (s *SubLogger) write(level int, format string, v ...any) {
+	s.logger.Record(level, s.Prefix, s.Fields, format, v...)
+}
+
 func (s *SubLogger) Print(format string, v ...any) {
-	s.logger.Write(PRINT, s.Fmt(format), v...)
+	s.write(PRINT, format, v...)
 }
 
 func (s *SubLogger) Printf(format string, v ...any) {
-	s.logger.Write(PRINT, s.Fmt(format), v...)
+	s.write(PRINT, format, v...)
 }
 
 func (s *SubLogger) Log(format string, v ...any) {
-	s.logger.Write(LOG, s.Fmt(format), v...)
+	s.write(LOG, format, v...)
 }
 
 func (s *SubLogger) Stack(format string, v ...any) {
-	s.logger.Write(STACK, s.Fmt(format), v...)
+	s.write(STACK, format, v...)
 }
 
 func (s *SubLogger) Debug(format string, v ...any) {
-	s.logger.Write(DEBUG, s.Fmt(format), v...)
+	s.write(DEBUG, format, v...)
 }
 
 func (s *SubLogger) Flow(format string, v ...any) {
-	s.logger.Write(FLOW, s.Fmt(format), v...)
+	s.write(FLOW, format, v...)
 }
 
 func (s *SubLogger) Cmd(format string, v ...any) {
-	s.logger.Write(CMD, s.Fmt(format), v...)
+	s.write(CMD, format, v...)
 }
 
 func (s *SubLogger) Event(format string, v ...any) {
-	s.logger.Write(EVENT, s.Fmt(format), v...)
+	s.write(EVENT, format, v...)
 }
 
 func (s *SubLogger) Info(format string, v ...any) {
-	s.logger.Write(INFO, s.Fmt(format), v...)
+	s.write(INFO, format, v...)
 }
 
 func (s *SubLogger) Warn(format string, v ...any) {
-	s.logger.Write(WARN, s.Fmt(format), v...)
+	s.write(WARN, format, v...)
 }
 
 func (s *SubLogger) Error(format string, v ...any) {
-	s.logger.Write(ERROR, s.Fmt(format), v...)
+	s.write(ERROR, format, v...)
 }
 
 func (s *SubLogger) Fatal(format string, v ...any) {
-	s.logger.Write(FATAL, s.Fmt(format), v...)
+	s.write(FATAL, format, v...)
 }
 
 func LogDate() {
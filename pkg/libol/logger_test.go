@@ -0,0 +1,42 @@
+package libol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerModule(t *testing.T) {
+	defer SetModule("UdpClient", 0)
+	defer SetModule("default/WorkerImpl", 0)
+	defer SetModule("fake", 0)
+
+	assert.Equal(t, "WorkerImpl", logType("WorkerImpl.Initialize: %s"))
+	assert.Equal(t, "", logType("DelUser %s"))
+
+	SetModule("UdpClient", DEBUG)
+	SetModule("default/WorkerImpl", FLOW)
+	assert.Equal(t, DEBUG, Logger.level("10.0.0.1:10002", "UdpClient"))
+	assert.Equal(t, FLOW, Logger.level("default", "WorkerImpl"))
+	assert.Equal(t, Logger.Level, Logger.level("other", "WorkerImpl"))
+	// levels of other modules aren't taken by a sub logger.
+	assert.False(t, NewSubLogger("default").Has(DEBUG))
+	SetModule("fake", DEBUG)
+	assert.True(t, NewSubLogger("fake").Has(DEBUG))
+	assert.Equal(t, DEBUG, Logger.minLevel())
+
+	SetModule("UdpClient", 0)
+	_, ok := ListModule()["UdpClient"]
+	assert.False(t, ok)
+	SetModule("fake", 0)
+	assert.Equal(t, FLOW, Logger.minLevel())
+}
+
+func TestLoggerRecord(t *testing.T) {
+	out := NewSubLogger("default").With("network", "default")
+	assert.Equal(t, "INFO|default|hi network=default", Logger.text("INFO", out.Prefix, "hi", out.Fields))
+	value := Logger.json("INFO", out.Prefix, "WorkerImpl", "hi", out.Fields)
+	assert.Contains(t, value, `"module":"default"`)
+	assert.Contains(t, value, `"type":"WorkerImpl"`)
+	assert.Contains(t, value, `"network":"default"`)
+}
@@ -0,0 +1,57 @@
+package libol
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	PcapMagic    = 0xa1b2c3d4
+	PcapEthernet = 1
+	PcapSnapLen  = 65535
+)
+
+// PcapWriter writes frames in the format of libpcap, and could be read by
+// tcpdump or wireshark.
+type PcapWriter struct {
+	w       io.Writer
+	snapLen int
+	buf     []byte
+}
+
+func NewPcapWriter(w io.Writer, snapLen int) (*PcapWriter, error) {
+	if snapLen <= 0 {
+		snapLen = PcapSnapLen
+	}
+	p := &PcapWriter{
+		w:       w,
+		snapLen: snapLen,
+		buf:     make([]byte, 16+snapLen),
+	}
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:4], PcapMagic)
+	binary.LittleEndian.PutUint16(header[4:6], 2)
+	binary.LittleEndian.PutUint16(header[6:8], 4)
+	binary.LittleEndian.PutUint32(header[16:20], uint32(snapLen))
+	binary.LittleEndian.PutUint32(header[20:24], PcapEthernet)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *PcapWriter) Write(at time.Time, frame []byte) error {
+	size := len(frame)
+	if size > p.snapLen {
+		size = p.snapLen
+	}
+	buf := p.buf[:16+size]
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(at.Unix()))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(at.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(size))
+	binary.LittleEndian.PutUint32(buf[12:16], uint32(len(frame)))
+	copy(buf[16:], frame[:size])
+	_, err := p.w.Write(buf)
+	return err
+}
@@ -0,0 +1,119 @@
+package libol
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// rotateFile is a file rotated beyond size or interval, and the backups
+// kept as file.1, file.2 and so on.
+type rotateFile struct {
+	lock     sync.Mutex
+	File     string
+	MaxSize  int64
+	Interval time.Duration
+	Backups  int
+	fp       *os.File
+	size     int64
+	openAt   time.Time
+}
+
+func openRotate(file string) (*rotateFile, error) {
+	r := &rotateFile{File: file}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Set changes size, interval and backups, and zero is not rotated by it.
+func (r *rotateFile) Set(size int64, interval time.Duration, backups int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.MaxSize = size
+	r.Interval = interval
+	r.Backups = backups
+}
+
+func (r *rotateFile) open() error {
+	fp, err := OpenWrite(r.File)
+	if err != nil {
+		return err
+	}
+	r.size = 0
+	r.openAt = time.Now()
+	if info, err := fp.Stat(); err == nil {
+		r.size = info.Size()
+		if r.size > 0 {
+			r.openAt = info.ModTime()
+		}
+	}
+	r.fp = fp
+	return nil
+}
+
+func (r *rotateFile) Backup(i int) string {
+	if i == 0 {
+		return r.File
+	}
+	return fmt.Sprintf("%s.%d", r.File, i)
+}
+
+func (r *rotateFile) expired(size int) bool {
+	if r.MaxSize > 0 && r.size+int64(size) > r.MaxSize {
+		return true
+	}
+	if r.Interval > 0 && r.size > 0 && time.Since(r.openAt) > r.Interval {
+		return true
+	}
+	return false
+}
+
+// rotate shifts the file to .1, .1 to .2 and so on, and the oldest is
+// dropped out of backups. It is written by the logger, so errors are
+// printed directly.
+func (r *rotateFile) rotate() {
+	_ = r.fp.Close()
+	r.fp = nil
+	if r.Backups < 1 {
+		if err := os.Remove(r.File); err != nil {
+			log.Printf("WARN|rotateFile.rotate: %s", err)
+		}
+	}
+	for i := r.Backups - 1; i >= 0; i-- {
+		if err := os.Rename(r.Backup(i), r.Backup(i+1)); err != nil && !os.IsNotExist(err) {
+			log.Printf("WARN|rotateFile.rotate: %s", err)
+		}
+	}
+	if err := r.open(); err != nil {
+		log.Printf("ERROR|rotateFile.rotate: %s", err)
+	}
+}
+
+func (r *rotateFile) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.fp != nil && r.expired(len(p)) {
+		r.rotate()
+	}
+	if r.fp == nil {
+		return 0, NewErr("%s not opened", r.File)
+	}
+	n, err := r.fp.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotateFile) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.fp == nil {
+		return nil
+	}
+	err := r.fp.Close()
+	r.fp = nil
+	return err
+}
@@ -0,0 +1,25 @@
+package libol
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateInterval(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "openlan.log")
+	fp, err := openRotate(file)
+	assert.Nil(t, err)
+	fp.Set(0, time.Hour, 1)
+	_, _ = fp.Write([]byte("hi\n"))
+	fp.openAt = time.Now().Add(-2 * time.Hour)
+	_, _ = fp.Write([]byte("hei\n"))
+	data, err := os.ReadFile(file + ".1")
+	assert.Nil(t, err)
+	assert.Equal(t, "hi\n", string(data))
+	data, _ = os.ReadFile(file)
+	assert.Equal(t, "hei\n", string(data))
+}
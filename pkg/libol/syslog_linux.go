@@ -0,0 +1,20 @@
+package libol
+
+import (
+	"io"
+	"log/syslog"
+	"strings"
+)
+
+// dialSyslog connects to local syslog or an address like udp://host:514.
+func dialSyslog(addr, tag string) (io.WriteCloser, error) {
+	priority := syslog.LOG_INFO | syslog.LOG_DAEMON
+	if addr == "local" {
+		return syslog.New(priority, tag)
+	}
+	proto := "udp"
+	if values := strings.SplitN(addr, "://", 2); len(values) == 2 {
+		proto, addr = values[0], values[1]
+	}
+	return syslog.Dial(proto, addr, priority, tag)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package libol
+
+import "io"
+
+func dialSyslog(addr, tag string) (io.WriteCloser, error) {
+	return nil, NewErr("dialSyslog notSupport")
+}
@@ -0,0 +1,58 @@
+package libol
+
+import (
+	"sync"
+	"time"
+)
+
+// Ticker calls a function periodically in a goroutine. It is safe to stop
+// more than once, or before started.
+type Ticker struct {
+	lock sync.Mutex
+	done chan bool
+}
+
+// Start calls the function every interval, and stops the previous one if
+// started already.
+func (t *Ticker) Start(interval time.Duration, call func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.stop()
+	ticker := time.NewTicker(interval)
+	done := make(chan bool)
+	t.done = done
+	Go(func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				select {
+				case <-done:
+					// stopped while ticking.
+					return
+				default:
+					call()
+				}
+			}
+		}
+	})
+}
+
+func (t *Ticker) stop() bool {
+	if t.done == nil {
+		return false
+	}
+	close(t.done)
+	t.done = nil
+	return true
+}
+
+// Stop returns false if not started.
+func (t *Ticker) Stop() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.stop()
+}
@@ -0,0 +1,28 @@
+package libol
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicker(t *testing.T) {
+	ticker := &Ticker{}
+	assert.False(t, ticker.Stop(), "not started")
+
+	var count int32
+	ticker.Start(10*time.Millisecond, func() {
+		atomic.AddInt32(&count, 1)
+	})
+	time.Sleep(55 * time.Millisecond)
+	assert.True(t, ticker.Stop())
+	assert.False(t, ticker.Stop(), "stopped twice")
+
+	time.Sleep(10 * time.Millisecond)
+	value := atomic.LoadInt32(&count)
+	assert.True(t, value > 0, "called")
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, value, atomic.LoadInt32(&count), "not called after stopped")
+}
@@ -17,6 +17,7 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	"unicode"
 
 	"gopkg.in/yaml.v2"
 )
@@ -389,6 +390,32 @@ func GetSuffix(value string, index int) string {
 	return ""
 }
 
+// CompareVersion compares numbers in versions like v24.01.2 or 10.0.19045,
+// and returns -1, 0 or 1.
+func CompareVersion(a, b string) int {
+	isSep := func(r rune) bool {
+		return !unicode.IsDigit(r)
+	}
+	av := strings.FieldsFunc(a, isSep)
+	bv := strings.FieldsFunc(b, isSep)
+	for i := 0; i < len(av) || i < len(bv); i++ {
+		var x, y int
+		if i < len(av) {
+			x, _ = strconv.Atoi(av[i])
+		}
+		if i < len(bv) {
+			y, _ = strconv.Atoi(bv[i])
+		}
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+	}
+	return 0
+}
+
 func Sudo(bin string, args ...string) (string, error) {
 	binArgs := append([]string{bin}, args...)
 	out, err := exec.Command("sudo", binArgs...).CombinedOutput()
@@ -48,6 +48,13 @@ func TestPrettyTime(t *testing.T) {
 	assert.Equal(t, "1d23h", s, "be the same.")
 }
 
+func TestCompareVersion(t *testing.T) {
+	assert.Equal(t, 0, CompareVersion("v24.01.2", "24.1.2"), "be the same.")
+	assert.Equal(t, -1, CompareVersion("v24.01", "24.1.2"), "be less.")
+	assert.Equal(t, 1, CompareVersion("10.0.19045", "10.0.9200"), "be greater.")
+	assert.Equal(t, -1, CompareVersion("", "1"), "be less.")
+}
+
 func TestPrettyBytes(t *testing.T) {
 	var s string
 
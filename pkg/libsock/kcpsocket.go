@@ -183,7 +183,7 @@ func (c *KcpClient) Connect() error {
 		return err
 	}
 	if err := conn.SetDSCP(46); err != nil {
-		c.out.Warn("KcpClient.SetDSCP: ", err)
+		c.out.Warn("KcpClient.SetDSCP: %s", err)
 	}
 	setConn(conn, c.kcpCfg)
 	c.Try(conn)
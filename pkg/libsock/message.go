@@ -11,6 +11,9 @@ import (
 	"github.com/xtaci/kcp-go/v5"
 )
 
+// msgOut logs frames of messagers, and they're shown by module "libsock".
+var msgOut = libol.NewSubLogger("libsock")
+
 const (
 	MaxFrame = 1600
 	MaxBuf   = 4096
@@ -118,8 +121,8 @@ func NewFrameMessage(maxSize int) *FrameMessage {
 		maxSize = MaxBuf
 	}
 	maxSize += HlSize + EthDI
-	if libol.HasLog(libol.DEBUG) {
-		libol.Debug("NewFrameMessage: size %d", maxSize)
+	if msgOut.Has(libol.DEBUG) {
+		msgOut.Debug("NewFrameMessage: size %d", maxSize)
 	}
 	m := FrameMessage{
 		params: make([]byte, 0, 2),
@@ -393,8 +396,8 @@ func (s *StreamMessagerImpl) writeX(conn net.Conn, buf []byte) error {
 		return libol.NewErr("connection is nil")
 	}
 	size := len(buf)
-	if libol.HasLog(libol.LOG) {
-		libol.Log("StreamMessagerImpl.writeX: %d %s Data %x", size, conn.RemoteAddr(), buf)
+	if msgOut.Has(libol.LOG) {
+		msgOut.Log("StreamMessagerImpl.writeX: %d %s Data %x", size, conn.RemoteAddr(), buf)
 	}
 	n, err := s.write(conn, buf)
 	if err != nil {
@@ -407,15 +410,15 @@ func (s *StreamMessagerImpl) writeX(conn net.Conn, buf []byte) error {
 	left := size - offset
 	for left > 0 {
 		tmp := buf[offset:]
-		if libol.HasLog(libol.LOG) {
-			libol.Log("StreamMessagerImpl.writeX: tmp %s %d", conn.RemoteAddr(), len(tmp))
+		if msgOut.Has(libol.LOG) {
+			msgOut.Log("StreamMessagerImpl.writeX: tmp %s %d", conn.RemoteAddr(), len(tmp))
 		}
 		n, err := s.write(conn, tmp)
 		if err != nil {
 			return err
 		}
-		if libol.HasLog(libol.LOG) {
-			libol.Log("StreamMessagerImpl.writeX: %s snd %d, size %d", conn.RemoteAddr(), n, size)
+		if msgOut.Has(libol.LOG) {
+			msgOut.Log("StreamMessagerImpl.writeX: %s snd %d, size %d", conn.RemoteAddr(), n, size)
 		}
 		offset += n
 		left = size - offset
@@ -473,8 +476,8 @@ func (s *StreamMessagerImpl) readX(conn net.Conn, buf []byte) error {
 	}
 	offset := 0
 	left := len(buf)
-	if libol.HasLog(libol.LOG) {
-		libol.Log("StreamMessagerImpl.readX: %s %d", conn.RemoteAddr(), len(buf))
+	if msgOut.Has(libol.LOG) {
+		msgOut.Log("StreamMessagerImpl.readX: %s %d", conn.RemoteAddr(), len(buf))
 	}
 	for left > 0 {
 		tmp := make([]byte, left)
@@ -486,8 +489,8 @@ func (s *StreamMessagerImpl) readX(conn net.Conn, buf []byte) error {
 		offset += n
 		left -= n
 	}
-	if libol.HasLog(libol.LOG) {
-		libol.Log("StreamMessagerImpl.readX: Data %s %x", conn.RemoteAddr(), buf)
+	if msgOut.Has(libol.LOG) {
+		msgOut.Log("StreamMessagerImpl.readX: Data %s %x", conn.RemoteAddr(), buf)
 	}
 	return nil
 }
@@ -515,8 +518,8 @@ func (s *StreamMessagerImpl) decode(tmp []byte, min int) (*FrameMessage, error)
 		if s.block != nil {
 			s.block.Decrypt(frameData, frameData)
 		}
-		if libol.HasLog(libol.DEBUG) {
-			libol.Debug("StreamMessagerImpl.decode: %d %x", fs, tmp[:fs])
+		if msgOut.Has(libol.DEBUG) {
+			msgOut.Debug("StreamMessagerImpl.decode: %d %x", fs, tmp[:fs])
 		}
 		buf := make([]byte, HlSize+h.frameLen)
 		copy(buf, h.magic[:])
@@ -598,8 +601,8 @@ func (s *PacketMessagerImpl) Send(conn net.Conn, frame *FrameMessage) (int, erro
 	if s.block != nil {
 		s.block.Encrypt(frame.frame, frame.frame)
 	}
-	if libol.HasLog(libol.DEBUG) {
-		libol.Debug("PacketMessagerImpl.Send: %s %d %x", conn.RemoteAddr(), frame.size, frame.buffer)
+	if msgOut.Has(libol.DEBUG) {
+		msgOut.Debug("PacketMessagerImpl.Send: %s %d %x", conn.RemoteAddr(), frame.size, frame.buffer)
 	}
 	now := time.Now()
 	if shouldRefreshDeadline(s.writeAt, s.timeout, now) {
@@ -632,9 +635,12 @@ func (s *PacketMessagerImpl) Receive(conn net.Conn, min int) (*FrameMessage, err
 	if s.bufSize == 0 {
 		s.bufSize = MaxMsg
 	}
-	frame := NewFrameMessage(s.bufSize)
-	if libol.HasLog(libol.DEBUG) {
-		libol.Debug("PacketMessagerImpl.Receive %s %d", conn.RemoteAddr(), s.timeout)
+	// the buffer is only for reading, and the frame is copied from it.
+	pool := GetBufferPool(s.bufSize + HlSize + EthDI)
+	buffer := pool.Get()
+	defer pool.Put(buffer)
+	if msgOut.Has(libol.DEBUG) {
+		msgOut.Debug("PacketMessagerImpl.Receive %s %d", conn.RemoteAddr(), s.timeout)
 	}
 	now := time.Now()
 	if shouldRefreshDeadline(s.readAt, s.timeout, now) {
@@ -644,17 +650,17 @@ func (s *PacketMessagerImpl) Receive(conn net.Conn, min int) (*FrameMessage, err
 			return nil, err
 		}
 	}
-	n, err := conn.Read(frame.buffer)
+	n, err := conn.Read(buffer)
 	if err != nil {
 		return nil, err
 	}
-	if libol.HasLog(libol.DEBUG) {
-		libol.Debug("PacketMessagerImpl.Receive: %s %x", conn.RemoteAddr(), frame.buffer[:n])
+	if msgOut.Has(libol.DEBUG) {
+		msgOut.Debug("PacketMessagerImpl.Receive: %s %x", conn.RemoteAddr(), buffer[:n])
 	}
 	if n <= 4 {
 		return nil, libol.NewErr("%s: small frame", conn.RemoteAddr())
 	}
-	h, err := decodeFrameHeader(frame.buffer[:n], min)
+	h, err := decodeFrameHeader(buffer[:n], min)
 	if err != nil || h == nil {
 		if err != nil {
 			return nil, libol.NewErr("%s: %s", conn.RemoteAddr(), err)
@@ -671,7 +677,7 @@ func (s *PacketMessagerImpl) Receive(conn net.Conn, min int) (*FrameMessage, err
 	}
 
 	// Build the frame message.
-	frameData := frame.buffer[h.frameAt : h.frameAt+h.frameLen]
+	frameData := buffer[h.frameAt : h.frameAt+h.frameLen]
 	if s.block != nil {
 		s.block.Decrypt(frameData, frameData)
 	}
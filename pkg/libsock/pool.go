@@ -0,0 +1,49 @@
+package libsock
+
+import (
+	"sync"
+)
+
+// BufferPool reuses buffers of same size to reduce allocations on the data
+// path, e.g. receiving datagrams.
+type BufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+func NewBufferPool(size int) *BufferPool {
+	p := &BufferPool{size: size}
+	p.pool.New = func() any {
+		buf := make([]byte, size)
+		return &buf
+	}
+	return p
+}
+
+func (p *BufferPool) Size() int {
+	return p.size
+}
+
+func (p *BufferPool) Get() []byte {
+	buf := p.pool.Get().(*[]byte)
+	return (*buf)[:p.size]
+}
+
+func (p *BufferPool) Put(buf []byte) {
+	if cap(buf) < p.size {
+		return
+	}
+	buf = buf[:p.size]
+	p.pool.Put(&buf)
+}
+
+var bufferPools sync.Map
+
+// GetBufferPool returns the shared pool by size.
+func GetBufferPool(size int) *BufferPool {
+	if p, ok := bufferPools.Load(size); ok {
+		return p.(*BufferPool)
+	}
+	p, _ := bufferPools.LoadOrStore(size, NewBufferPool(size))
+	return p.(*BufferPool)
+}
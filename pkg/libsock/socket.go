@@ -134,6 +134,13 @@ func (t *StreamSocket) String() string {
 	return t.protocol + ":" + t.remoteAddr
 }
 
+func (t *StreamSocket) Out() *libol.SubLogger {
+	if t.out == nil {
+		t.out = libol.NewSubLogger(t.address)
+	}
+	return t.out
+}
+
 func (t *StreamSocket) IsOk() bool {
 	return t.connection != nil
 }
@@ -143,9 +150,9 @@ func (t *StreamSocket) WriteMsg(frame *FrameMessage) error {
 		t.dropped.Add(1)
 		return libol.NewErr("%s not okay", t)
 	}
-	if libol.HasLog(libol.CMD) && frame.IsControl() {
+	if t.Out().Has(libol.CMD) && frame.IsControl() {
 		action, params := frame.CmdAndParams()
-		libol.Cmd("StreamSocket.WriteMsg: %s%s", action, params)
+		t.Out().Cmd("StreamSocket.WriteMsg: %s%s", action, params)
 	}
 	if t.message == nil { // default is stream message
 		t.message = &StreamMessagerImpl{}
@@ -160,8 +167,8 @@ func (t *StreamSocket) WriteMsg(frame *FrameMessage) error {
 }
 
 func (t *StreamSocket) ReadMsg() (*FrameMessage, error) {
-	if libol.HasLog(libol.LOG) {
-		libol.Log("StreamSocket.ReadMsg: %s", t)
+	if t.Out().Has(libol.LOG) {
+		t.Out().Log("StreamSocket.ReadMsg: %s", t)
 	}
 	if !t.IsOk() {
 		return nil, libol.NewErr("%s not okay", t)
@@ -285,13 +292,6 @@ func (s *SocketClientImpl) Close() {
 func (s *SocketClientImpl) Terminal() {
 }
 
-func (s *SocketClientImpl) Out() *libol.SubLogger {
-	if s.out == nil {
-		s.out = libol.NewSubLogger(s.address)
-	}
-	return s.out
-}
-
 func (s *SocketClientImpl) Retry() bool {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -610,9 +610,9 @@ func (t *SocketServerImpl) Read(client SocketClient, ReadAt ReadClient) {
 			break
 		}
 		t.statistics.Add(SsRecv, 1)
-		if libol.HasLog(libol.LOG) {
-			libol.Log("SocketServerImpl.Read: length: %d ", frame.size)
-			libol.Log("SocketServerImpl.Read: frame : %x", frame)
+		if client.Out().Has(libol.LOG) {
+			client.Out().Log("SocketServerImpl.Read: length: %d ", frame.size)
+			client.Out().Log("SocketServerImpl.Read: frame : %x", frame)
 		}
 		queue <- frame
 	}
@@ -0,0 +1,266 @@
+package libsock
+
+import (
+	"net"
+	"sync"
+
+	"github.com/luscis/openlan/pkg/libol"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	udpBatchSize   = 64
+	udpGROBufSize  = 65535
+	udpGSOMaxSize  = 65000
+	udpGSOMaxCount = 64
+	udpSockBufSize = 4 << 20
+)
+
+type batchConn interface {
+	ReadBatch(ms []ipv4.Message, flags int) (int, error)
+	WriteBatch(ms []ipv4.Message, flags int) (int, error)
+}
+
+type udpPacket struct {
+	addr *net.UDPAddr // nil for connected socket.
+	data []byte
+}
+
+// UDPBatch reads and writes datagrams of an UDP socket in batches by
+// recvmmsg and sendmmsg, and coalesces them by GRO and GSO if the kernel
+// supports.
+type UDPBatch struct {
+	lock      sync.Mutex
+	conn      *net.UDPConn
+	xconn     batchConn
+	connected bool
+	pool      *BufferPool
+	gro       bool
+	gso       bool
+	queue     chan *udpPacket
+	done      chan struct{}
+	closed    bool
+	err       error // last error of sending.
+}
+
+func NewUDPBatch(conn *net.UDPConn, bufSize int, connected bool) *UDPBatch {
+	b := &UDPBatch{
+		conn:      conn,
+		connected: connected,
+		pool:      GetBufferPool(bufSize),
+		queue:     make(chan *udpPacket, udpBatchSize*16),
+		done:      make(chan struct{}),
+	}
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() != nil {
+		b.xconn = ipv4.NewPacketConn(conn)
+	} else {
+		b.xconn = ipv6.NewPacketConn(conn)
+	}
+	// coalesced datagrams are large, and need more buffer of socket.
+	_ = conn.SetReadBuffer(udpSockBufSize)
+	_ = conn.SetWriteBuffer(udpSockBufSize)
+	b.gro = udpEnableGRO(conn)
+	b.gso = udpSupportGSO(conn)
+	libol.Debug("NewUDPBatch: %s gro %t gso %t", conn.LocalAddr(), b.gro, b.gso)
+	libol.Go(b.writeLoop)
+	return b
+}
+
+func (b *UDPBatch) Pool() *BufferPool {
+	return b.pool
+}
+
+// ReadLoop receives datagrams until error, and data of call should be put
+// back to pool after used.
+func (b *UDPBatch) ReadLoop(call func(addr *net.UDPAddr, data []byte)) error {
+	size := b.pool.Size()
+	if b.gro {
+		size = udpGROBufSize
+	}
+	msgs := make([]ipv4.Message, udpBatchSize)
+	for i := range msgs {
+		msgs[i].Buffers = [][]byte{make([]byte, size)}
+		if b.gro {
+			msgs[i].OOB = make([]byte, udpOOBSize)
+		}
+	}
+	for {
+		n, err := b.xconn.ReadBatch(msgs, 0)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			msg := &msgs[i]
+			addr, _ := msg.Addr.(*net.UDPAddr)
+			data := msg.Buffers[0][:msg.N]
+			segment := 0
+			if b.gro {
+				segment = udpGROSize(msg.OOB[:msg.NN])
+			}
+			if segment <= 0 {
+				segment = len(data)
+			}
+			for len(data) > 0 {
+				sz := segment
+				if sz > len(data) {
+					sz = len(data)
+				}
+				buf := b.pool.Get()
+				call(addr, buf[:copy(buf, data[:sz])])
+				data = data[sz:]
+			}
+		}
+	}
+}
+
+// Write queues a copy of data to addr, and returns the last error of
+// sending if has.
+func (b *UDPBatch) Write(addr *net.UDPAddr, data []byte) (int, error) {
+	b.lock.Lock()
+	err := b.err
+	b.err = nil
+	b.lock.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	var buf []byte
+	if len(data) <= b.pool.Size() {
+		buf = b.pool.Get()
+	} else {
+		buf = make([]byte, len(data))
+	}
+	buf = buf[:copy(buf, data)]
+	if b.connected {
+		addr = nil
+	}
+	select {
+	case <-b.done:
+		b.pool.Put(buf)
+		return 0, libol.NewErr("write to closed")
+	case b.queue <- &udpPacket{addr: addr, data: buf}:
+	}
+	return len(data), nil
+}
+
+func sameUDPAddr(a, b *net.UDPAddr) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Port == b.Port && a.IP.Equal(b.IP)
+}
+
+// coalesce returns messages of packets with count of packets in each, and
+// the continuous packets of same size to same address are merged as a GSO
+// message.
+func (b *UDPBatch) coalesce(packets []*udpPacket, msgs []ipv4.Message, counts []int, merged [][]byte) ([]ipv4.Message, []int) {
+	msgs = msgs[:0]
+	counts = counts[:0]
+	for i := 0; i < len(packets); {
+		first := packets[i]
+		j := i + 1
+		total := len(first.data)
+		if b.gso {
+			for j < len(packets) && j-i < udpGSOMaxCount {
+				next := packets[j]
+				if !sameUDPAddr(first.addr, next.addr) || len(next.data) > len(first.data) {
+					break
+				}
+				if total+len(next.data) > udpGSOMaxSize {
+					break
+				}
+				total += len(next.data)
+				j++
+				if len(next.data) < len(first.data) {
+					break // only the last could be smaller.
+				}
+			}
+		}
+		msg := ipv4.Message{}
+		if first.addr != nil {
+			msg.Addr = first.addr
+		}
+		if j-i == 1 {
+			msg.Buffers = [][]byte{first.data}
+		} else {
+			buf := merged[len(msgs)][:0]
+			for _, p := range packets[i:j] {
+				buf = append(buf, p.data...)
+			}
+			merged[len(msgs)] = buf
+			msg.Buffers = [][]byte{buf}
+			msg.OOB = udpGSOControl(len(first.data))
+		}
+		msgs = append(msgs, msg)
+		counts = append(counts, j-i)
+		i = j
+	}
+	return msgs, counts
+}
+
+func (b *UDPBatch) send(packets []*udpPacket, msgs []ipv4.Message, counts []int, merged [][]byte) {
+	for len(packets) > 0 {
+		msgs, counts = b.coalesce(packets, msgs, counts, merged)
+		n, err := b.xconn.WriteBatch(msgs, 0)
+		if err != nil {
+			if b.gso && udpGSOError(err) {
+				libol.Warn("UDPBatch.send: disable gso by %s", err)
+				b.gso = false
+				continue
+			}
+			libol.Debug("UDPBatch.send: %s", err)
+			b.lock.Lock()
+			b.err = err
+			b.lock.Unlock()
+			// skip the failed message, e.g. unreachable.
+			n = 1
+		}
+		sent := 0
+		for _, count := range counts[:n] {
+			sent += count
+		}
+		packets = packets[sent:]
+	}
+}
+
+func (b *UDPBatch) writeLoop() {
+	packets := make([]*udpPacket, 0, udpBatchSize)
+	msgs := make([]ipv4.Message, 0, udpBatchSize)
+	counts := make([]int, 0, udpBatchSize)
+	// buffers of GSO messages, and allocated on demand.
+	merged := make([][]byte, udpBatchSize)
+	for {
+		select {
+		case <-b.done:
+			return
+		case p := <-b.queue:
+			packets = append(packets, p)
+		}
+	more:
+		for len(packets) < udpBatchSize {
+			select {
+			case p := <-b.queue:
+				packets = append(packets, p)
+			default:
+				break more
+			}
+		}
+		b.send(packets, msgs, counts, merged)
+		for i, p := range packets {
+			b.pool.Put(p.data)
+			packets[i] = nil
+		}
+		packets = packets[:0]
+	}
+}
+
+func (b *UDPBatch) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	return b.conn.Close()
+}
@@ -0,0 +1,71 @@
+package libsock
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+var udpOOBSize = unix.CmsgSpace(4)
+
+func udpSetsockopt(conn *net.UDPConn, opt, value int) bool {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = unix.SetsockoptInt(int(fd), unix.SOL_UDP, opt, value)
+	}); err != nil {
+		return false
+	}
+	return serr == nil
+}
+
+// udpEnableGRO enables receiving coalesced datagrams.
+func udpEnableGRO(conn *net.UDPConn) bool {
+	return udpSetsockopt(conn, unix.UDP_GRO, 1)
+}
+
+// udpSupportGSO checks the kernel supports segmentation offload by option
+// of socket, and zero is to segment by control message of every sending.
+func udpSupportGSO(conn *net.UDPConn) bool {
+	return udpSetsockopt(conn, unix.UDP_SEGMENT, 0)
+}
+
+// udpGROSize returns the size of segments in coalesced datagram.
+func udpGROSize(oob []byte) int {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0
+	}
+	for _, m := range msgs {
+		if m.Header.Level == unix.SOL_UDP && m.Header.Type == unix.UDP_GRO && len(m.Data) >= 2 {
+			if len(m.Data) >= 4 {
+				return int(binary.NativeEndian.Uint32(m.Data))
+			}
+			return int(binary.NativeEndian.Uint16(m.Data))
+		}
+	}
+	return 0
+}
+
+// udpGSOControl returns the control message to segment by size.
+func udpGSOControl(size int) []byte {
+	buf := make([]byte, unix.CmsgSpace(2))
+	h := (*unix.Cmsghdr)(unsafe.Pointer(&buf[0]))
+	h.Level = unix.SOL_UDP
+	h.Type = unix.UDP_SEGMENT
+	h.SetLen(unix.CmsgLen(2))
+	binary.NativeEndian.PutUint16(buf[unix.CmsgLen(0):], uint16(size))
+	return buf
+}
+
+// udpGSOError checks the sending is failed by segmentation offload, e.g.
+// the checksum offload is disabled on the device.
+func udpGSOError(err error) bool {
+	return errors.Is(err, unix.EIO) || errors.Is(err, unix.EINVAL)
+}
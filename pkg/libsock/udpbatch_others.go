@@ -0,0 +1,29 @@
+//go:build !linux
+
+package libsock
+
+import (
+	"net"
+)
+
+var udpOOBSize = 0
+
+func udpEnableGRO(conn *net.UDPConn) bool {
+	return false
+}
+
+func udpSupportGSO(conn *net.UDPConn) bool {
+	return false
+}
+
+func udpGROSize(oob []byte) int {
+	return 0
+}
+
+func udpGSOControl(size int) []byte {
+	return nil
+}
+
+func udpGSOError(err error) bool {
+	return false
+}
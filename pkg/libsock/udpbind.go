@@ -9,12 +9,12 @@ import (
 )
 
 type UDPBind struct {
-	lock       sync.RWMutex
-	bufSize    int
-	connection *net.UDPConn
-	address    *net.UDPAddr
-	sessions   *libol.SafeStrMap
-	accept     chan *UDPBindConn
+	lock     sync.RWMutex
+	bufSize  int
+	batches  []*UDPBatch
+	address  *net.UDPAddr
+	sessions *libol.SafeStrMap
+	accept   chan *UDPBindConn
 }
 
 func UDPBindListen(addr string, clients, bufSize int) (net.Listener, error) {
@@ -32,55 +32,76 @@ func UDPBindListen(addr string, clients, bufSize int) (net.Listener, error) {
 		accept:   make(chan *UDPBindConn, 2),
 		bufSize:  bufSize,
 	}
-	conn, err := net.ListenUDP("udp", udpAddr)
-	if err != nil {
-		return nil, err
+	// sendmmsg can't send to IPv4 on dual stack, so listen IPv4 and IPv6
+	// by different sockets.
+	if udpAddr.IP == nil || udpAddr.IP.IsUnspecified() {
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: udpAddr.Port})
+		if err != nil {
+			return nil, err
+		}
+		x.address = conn.LocalAddr().(*net.UDPAddr)
+		x.batches = append(x.batches, NewUDPBatch(conn, bufSize, false))
+		addr6 := &net.UDPAddr{IP: net.IPv6unspecified, Port: x.address.Port}
+		if conn6, err := net.ListenUDP("udp6", addr6); err == nil {
+			x.batches = append(x.batches, NewUDPBatch(conn6, bufSize, false))
+		} else {
+			libol.Warn("UDPBindListen: %s", err)
+		}
+	} else {
+		network := "udp6"
+		if udpAddr.IP.To4() != nil {
+			network = "udp4"
+		}
+		conn, err := net.ListenUDP(network, udpAddr)
+		if err != nil {
+			return nil, err
+		}
+		x.address = conn.LocalAddr().(*net.UDPAddr)
+		x.batches = append(x.batches, NewUDPBatch(conn, bufSize, false))
+	}
+	for _, batch := range x.batches {
+		libol.Go(func() {
+			x.Loop(batch)
+		})
 	}
-	x.connection = conn
-	libol.Go(x.Loop)
 	return x, nil
 }
 
-func (x *UDPBind) Recv(udpAddr *net.UDPAddr, data []byte) error {
+func (x *UDPBind) Recv(batch *UDPBatch, udpAddr *net.UDPAddr, data []byte) error {
 	// dispatch to UDPBindConn and new accept
 	addr := udpAddr.String()
 	if obj, ok := x.sessions.GetEx(addr); ok {
 		conn := obj.(*UDPBindConn)
-		conn.toQueue(data)
+		conn.toQueue(udpAddr, data)
 		return nil
 	}
-	conn := &UDPBindConn{
-		connection: x.connection,
-		remoteAddr: udpAddr,
-		localAddr:  x.address,
-		readQueue:  make(chan []byte, 1024),
-		closed:     false,
-		onClose: func(conn *UDPBindConn) {
-			libol.Info("UDPBind.Recv: onClose %s", conn)
-			x.sessions.Del(addr)
-		},
+	conn := newUDPBindConn(batch, x.address, udpAddr)
+	conn.onClose = func(conn *UDPBindConn) {
+		libol.Info("UDPBind.Recv: onClose %s", conn)
+		x.sessions.Del(addr)
 	}
 	if err := x.sessions.Set(addr, conn); err != nil {
 		return libol.NewErr("session.Set: %s", err)
 	}
 	x.accept <- conn
-	conn.toQueue(data)
+	conn.toQueue(udpAddr, data)
 	return nil
 }
 
 // Loop forever
-func (x *UDPBind) Loop() {
-	for {
-		data := make([]byte, x.bufSize)
-		n, udpAddr, err := x.connection.ReadFromUDP(data)
-		if err != nil {
-			libol.Error("UDPBind.Loop %s", err)
-			break
+func (x *UDPBind) Loop(batch *UDPBatch) {
+	pool := batch.Pool()
+	err := batch.ReadLoop(func(udpAddr *net.UDPAddr, data []byte) {
+		if udpAddr == nil {
+			pool.Put(data)
+			return
 		}
-		if err := x.Recv(udpAddr, data[:n]); err != nil {
+		if err := x.Recv(batch, udpAddr, data); err != nil {
+			pool.Put(data)
 			libol.Warn("UDPBind.Loop: %s", err)
 		}
-	}
+	})
+	libol.Error("UDPBind.Loop %s", err)
 }
 
 // Accept waits for and returns the next connection to the listener.
@@ -94,7 +115,9 @@ func (x *UDPBind) Close() error {
 	x.lock.Lock()
 	defer x.lock.Unlock()
 
-	_ = x.connection.Close()
+	for _, batch := range x.batches {
+		_ = batch.Close()
+	}
 	return nil
 }
 
@@ -105,25 +128,68 @@ func (x *UDPBind) Addr() net.Addr {
 
 type UDPBindConn struct {
 	lock       sync.RWMutex
-	connection *net.UDPConn
+	batch      *UDPBatch
 	remoteAddr *net.UDPAddr
 	localAddr  *net.UDPAddr
 	readQueue  chan []byte
 	closed     bool
+	done       chan struct{}
 	readDead   time.Time
 	writeDead  time.Time
 	onClose    func(conn *UDPBindConn)
 }
 
-func (c *UDPBindConn) toQueue(b []byte) {
+func newUDPBindConn(batch *UDPBatch, local, remote *net.UDPAddr) *UDPBindConn {
+	return &UDPBindConn{
+		batch:      batch,
+		remoteAddr: remote,
+		localAddr:  local,
+		readQueue:  make(chan []byte, 1024),
+		done:       make(chan struct{}),
+	}
+}
+
+// DialUDPBatch connects to addr, and the connection reads and writes in
+// batches.
+func DialUDPBatch(addr string, bufSize int) (net.Conn, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, udpAddr)
+	if err != nil {
+		return nil, err
+	}
+	if bufSize == 0 {
+		bufSize = 4096
+	}
+	batch := NewUDPBatch(conn, bufSize, true)
+	c := newUDPBindConn(batch, conn.LocalAddr().(*net.UDPAddr), udpAddr)
+	c.onClose = func(conn *UDPBindConn) {
+		_ = batch.Close()
+	}
+	libol.Go(func() {
+		err := batch.ReadLoop(c.toQueue)
+		libol.Debug("DialUDPBatch: %s %s", c, err)
+		_ = c.Close()
+	})
+	return c, nil
+}
+
+func (c *UDPBindConn) toQueue(addr *net.UDPAddr, b []byte) {
 	c.lock.RLock()
 	if c.closed {
 		c.lock.RUnlock()
+		c.batch.Pool().Put(b)
 		return
 	} else {
 		c.lock.RUnlock()
 	}
-	c.readQueue <- b
+	select {
+	case c.readQueue <- b:
+	case <-c.done:
+		c.batch.Pool().Put(b)
+	}
 }
 
 func (c *UDPBindConn) Read(b []byte) (n int, err error) {
@@ -149,11 +215,15 @@ func (c *UDPBindConn) Read(b []byte) (n int, err error) {
 	select {
 	case <-outChan:
 		return 0, libol.NewErr("read timeout")
+	case <-c.done:
+		return 0, libol.NewErr("read on closed")
 	case d := <-c.readQueue:
 		if timeout != nil {
 			timeout.Stop()
 		}
-		return copy(b, d), nil
+		n := copy(b, d)
+		c.batch.Pool().Put(d)
+		return n, nil
 	}
 }
 
@@ -165,7 +235,7 @@ func (c *UDPBindConn) Write(b []byte) (n int, err error) {
 	} else {
 		c.lock.RUnlock()
 	}
-	return c.connection.WriteToUDP(b, c.remoteAddr)
+	return c.batch.Write(c.remoteAddr, b)
 }
 
 func (c *UDPBindConn) Close() error {
@@ -177,8 +247,8 @@ func (c *UDPBindConn) Close() error {
 	if c.onClose != nil {
 		c.onClose(c)
 	}
-	c.connection = nil
 	c.closed = true
+	close(c.done)
 
 	return nil
 }
@@ -0,0 +1,201 @@
+package libsock
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUDPBindBatch(t *testing.T) {
+	listener, err := UDPBindListen("127.0.0.1:0", 16, 2048)
+	if err != nil {
+		t.Fatalf("listen %s", err)
+	}
+	defer listener.Close()
+
+	client, err := DialUDPBatch(listener.Addr().String(), 2048)
+	if err != nil {
+		t.Fatalf("dial %s", err)
+	}
+	defer client.Close()
+
+	count := 100
+	for i := 0; i < count; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, 1400)
+		if i == count-1 {
+			data = data[:100]
+		}
+		if _, err := client.Write(data); err != nil {
+			t.Fatalf("write %s", err)
+		}
+	}
+
+	server, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept %s", err)
+	}
+	buf := make([]byte, 2048)
+	for i := 0; i < count; i++ {
+		_ = server.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := server.Read(buf)
+		if err != nil {
+			t.Fatalf("read %d %s", i, err)
+		}
+		if (i < count-1 && n != 1400) || (i == count-1 && n != 100) || buf[0] != byte(i) {
+			t.Fatalf("read %d size %d data %d", i, n, buf[0])
+		}
+		// echo back by batch writing.
+		if _, err := server.Write(buf[:n]); err != nil {
+			t.Fatalf("echo %s", err)
+		}
+	}
+	for i := 0; i < count; i++ {
+		_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := client.Read(buf)
+		if err != nil {
+			t.Fatalf("read echo %d %s", i, err)
+		}
+		if buf[0] != byte(i) || n == 0 {
+			t.Fatalf("read echo %d data %d", i, buf[0])
+		}
+	}
+
+	_ = server.Close()
+	if _, err := server.Read(buf); err == nil {
+		t.Errorf("read on closed")
+	}
+}
+
+func TestUDPBindAny(t *testing.T) {
+	listener, err := UDPBindListen(":0", 16, 2048)
+	if err != nil {
+		t.Fatalf("listen %s", err)
+	}
+	defer listener.Close()
+
+	port := listener.Addr().(*net.UDPAddr).Port
+	client, err := DialUDPBatch(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), 2048)
+	if err != nil {
+		t.Fatalf("dial %s", err)
+	}
+	defer client.Close()
+	if _, err := client.Write([]byte("hello")); err != nil {
+		t.Fatalf("write %s", err)
+	}
+	server, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept %s", err)
+	}
+	buf := make([]byte, 2048)
+	_ = server.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := server.Read(buf)
+	if err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("read %q %v", buf[:n], err)
+	}
+	// the IPv4 client is answered by sendmmsg of IPv4 socket.
+	if _, err := server.Write(buf[:n]); err != nil {
+		t.Fatalf("echo %s", err)
+	}
+	_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if n, err = client.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("read echo %q %v", buf[:n], err)
+	}
+}
+
+func TestUDPBatchWriteError(t *testing.T) {
+	// no listener on the port, and sending is refused.
+	peer, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen %s", err)
+	}
+	addr := peer.LocalAddr().(*net.UDPAddr)
+	_ = peer.Close()
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		t.Fatalf("dial %s", err)
+	}
+	batch := NewUDPBatch(conn, 2048, true)
+	defer batch.Close()
+	for i := 0; i < 100; i++ {
+		if _, err := batch.Write(addr, []byte("hello")); err != nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("expected error of sending")
+}
+
+func TestBufferPool(t *testing.T) {
+	pool := GetBufferPool(128)
+	if pool != GetBufferPool(128) {
+		t.Errorf("pool isn't shared")
+	}
+	buf := pool.Get()
+	if len(buf) != 128 {
+		t.Errorf("size %d", len(buf))
+	}
+	pool.Put(buf[:10])
+	if buf = pool.Get(); len(buf) != 128 {
+		t.Errorf("size %d after put", len(buf))
+	}
+	pool.Put(make([]byte, 64)) // dropped by small.
+}
+
+// BenchmarkUDPBind measures packets per second over loopback.
+func BenchmarkUDPBind(b *testing.B) {
+	listener, err := UDPBindListen("127.0.0.1:0", 16, 2048)
+	if err != nil {
+		b.Fatalf("listen %s", err)
+	}
+	defer listener.Close()
+
+	client, err := DialUDPBatch(listener.Addr().String(), 2048)
+	if err != nil {
+		b.Fatalf("dial %s", err)
+	}
+	defer client.Close()
+
+	data := make([]byte, 1400)
+	if _, err := client.Write(data); err != nil {
+		b.Fatalf("write %s", err)
+	}
+	server, err := listener.Accept()
+	if err != nil {
+		b.Fatalf("accept %s", err)
+	}
+	type result struct {
+		count int
+		last  time.Time
+	}
+	received := make(chan result)
+	go func() {
+		buf := make([]byte, 2048)
+		r := result{}
+		for {
+			_ = server.SetReadDeadline(time.Now().Add(time.Second))
+			if _, err := server.Read(buf); err != nil {
+				break
+			}
+			r.count++
+			r.last = time.Now()
+		}
+		received <- r
+	}()
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Write(data); err != nil {
+			b.Fatalf("write %s", err)
+		}
+	}
+	r := <-received
+	b.StopTimer()
+	if elapsed := r.last.Sub(start); elapsed > 0 {
+		b.ReportMetric(float64(r.count)/elapsed.Seconds(), "pps")
+	}
+	b.ReportMetric(float64(b.N+1-r.count)/float64(b.N+1), "loss/op")
+}
@@ -145,7 +145,7 @@ func (c *UdpClient) Connect() error {
 		return nil
 	}
 	c.out.Info("UdpClient.Connect: udp://%s", c.address)
-	conn, err := net.Dial("udp", c.address)
+	conn, err := DialUDPBatch(c.address, 0)
 	if err != nil {
 		return err
 	}
@@ -5,6 +5,12 @@ import (
 	"github.com/luscis/openlan/pkg/network"
 )
 
+// Filter checks frames from an access client before switching.
+type Filter interface {
+	Drop(client string, obj *Access, frame []byte) bool
+	Remove(client string)
+}
+
 type Access struct {
 	UUID     string               `json:"uuid"`
 	Alias    string               `json:"alias"`
@@ -18,6 +24,12 @@ type Access struct {
 	Client   libsock.SocketClient `json:"-"`
 	Device   network.Taper        `json:"-"`
 	System   string               `json:"system"`
+	Posture  *Posture             `json:"posture,omitempty"`
+	// restricted by ACL and zero trust if device isn't compliant.
+	Restricted bool     `json:"restricted"`
+	Violations []string `json:"violations,omitempty"`
+	// resolved by network at login, and checked for every frame.
+	Filters []Filter `json:"-"`
 }
 
 func NewAccess(c libsock.SocketClient, d network.Taper, proto string) (w *Access) {
@@ -53,4 +65,7 @@ func (p *Access) SetUser(user *User) {
 	p.Network = user.Network
 	p.System = user.System
 	p.Alias = user.Alias
+	p.Posture = user.Posture
+	p.Restricted = user.Restricted
+	p.Violations = user.Violations
 }
@@ -1,30 +1,37 @@
 package models
 
 import (
-	"fmt"
-	"os"
 	"time"
 
-	"github.com/luscis/openlan/pkg/libol"
-	"github.com/luscis/openlan/pkg/schema"
+	"github.com/luscis/openlan/pkg/libsock"
 )
 
+// OutputLinker is a link running in the switch process.
+type OutputLinker interface {
+	Device() string
+	Status() string
+	UpTime() int64
+	Statistics() map[string]int64
+	Record() map[string]int64
+}
+
 type Output struct {
-	Network   string
-	Protocol  string
-	Remote    string
-	Segment   int
-	Device    string
-	Secret    string
-	Crypt     string
-	RxBytes   uint64
-	TxBytes   uint64
-	ErrPkt    uint64
-	NewTime   int64
-	Fallback  string
-	StatsFile string
-	PidFile   string
-	uptime    int64
+	Network    string
+	Protocol   string
+	Remote     string
+	Segment    int
+	Device     string
+	Secret     string
+	Crypt      string
+	RxBytes    uint64
+	TxBytes    uint64
+	ErrPkt     uint64
+	Reconnects int64
+	NewTime    int64
+	Fallback   string
+	AllowedIPs []string
+	Linker     OutputLinker
+	uptime     int64
 }
 
 func (o *Output) UpTime() int64 {
@@ -35,29 +42,20 @@ func (o *Output) UpTime() int64 {
 }
 
 func (o *Output) GetState() string {
-	if o.StatsFile != "" {
-		if !o.hasLiveProcess() {
-			o.uptime = 0
-			return "down"
-		}
-		sts := &schema.Access{}
-		_ = libol.UnmarshalLoad(sts, o.StatsFile)
-		o.uptime = sts.AliveTime
-		o.Device = sts.Device
-		return sts.State
+	if o.Linker == nil {
+		return ""
 	}
-	return ""
-}
-
-func (o *Output) hasLiveProcess() bool {
-	if o.PidFile == "" {
-		return true
+	if device := o.Linker.Device(); device != "" {
+		o.Device = device
+	}
+	o.uptime = o.Linker.UpTime()
+	if sts := o.Linker.Statistics(); sts != nil {
+		o.RxBytes = uint64(sts[libsock.CsRecvOkay])
+		o.TxBytes = uint64(sts[libsock.CsSendOkay])
+		o.ErrPkt = uint64(sts[libsock.CsSendError])
 	}
-	data, err := os.ReadFile(o.PidFile)
-	if err != nil {
-		return false
+	if rt := o.Linker.Record(); rt != nil {
+		o.Reconnects = rt["conns"]
 	}
-	pid := 0
-	_, _ = fmt.Sscanf(string(data), "%d", &pid)
-	return pid > 0 && libol.HasProcess(pid)
+	return o.Linker.Status()
 }
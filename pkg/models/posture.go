@@ -0,0 +1,11 @@
+package models
+
+// Posture is state of a device collected by access client at login.
+type Posture struct {
+	System    string   `json:"system"`
+	OsVersion string   `json:"osVersion,omitempty"`
+	Encrypted bool     `json:"encrypted"` // disk encryption
+	Firewall  bool     `json:"firewall"`
+	Agents    []string `json:"agents,omitempty"` // required agents running.
+	Version   string   `json:"version,omitempty"`
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/luscis/openlan/pkg/libol"
+)
+
+var weekdays = map[string][]time.Weekday{
+	"sun":     {time.Sunday},
+	"mon":     {time.Monday},
+	"tue":     {time.Tuesday},
+	"wed":     {time.Wednesday},
+	"thu":     {time.Thursday},
+	"fri":     {time.Friday},
+	"sat":     {time.Saturday},
+	"weekday": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekend": {time.Saturday, time.Sunday},
+}
+
+// Schedule is windows of time in days of week, and a window like
+// 22:00-06:00 crosses midnight into the next day.
+type Schedule struct {
+	Name     string
+	Days     []string // mon, tue ... sun, weekday or weekend, and all if empty.
+	Windows  []string // HH:MM-HH:MM, and whole day if empty.
+	Timezone string   // local if empty.
+	days     map[time.Weekday]bool
+	windows  [][2]int // minutes in day.
+	location *time.Location
+}
+
+func parseMinute(value string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hour, &minute); err != nil {
+		return 0, libol.NewErr("invalid time %q", value)
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, libol.NewErr("invalid time %q", value)
+	}
+	return hour*60 + minute, nil
+}
+
+func NewSchedule(name string, days, windows []string, timezone string) (*Schedule, error) {
+	s := &Schedule{
+		Name:     name,
+		Timezone: timezone,
+		days:     make(map[time.Weekday]bool, 7),
+		location: time.Local,
+	}
+	if name == "" {
+		return nil, libol.NewErr("schedule without name")
+	}
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, libol.NewErr("invalid timezone %q", timezone)
+		}
+		s.location = location
+	}
+	for _, day := range days {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if len(day) > 3 && day != "weekday" && day != "weekend" {
+			day = day[:3] // e.g. monday.
+		}
+		values, ok := weekdays[day]
+		if !ok {
+			return nil, libol.NewErr("invalid day %q", day)
+		}
+		for _, value := range values {
+			s.days[value] = true
+		}
+		s.Days = append(s.Days, day)
+	}
+	if len(s.days) == 0 {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			s.days[day] = true
+		}
+	}
+	for _, window := range windows {
+		window = strings.TrimSpace(window)
+		values := strings.SplitN(window, "-", 2)
+		if len(values) != 2 {
+			return nil, libol.NewErr("invalid window %q", window)
+		}
+		start, err := parseMinute(values[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseMinute(values[1])
+		if err != nil {
+			return nil, err
+		}
+		if start == end || start == 24*60 {
+			return nil, libol.NewErr("invalid window %q", window)
+		}
+		s.windows = append(s.windows, [2]int{start, end})
+		s.Windows = append(s.Windows, window)
+	}
+	return s, nil
+}
+
+func (s *Schedule) Active(now time.Time) bool {
+	now = now.In(s.location)
+	today := now.Weekday()
+	yesterday := (today + 6) % 7
+	if len(s.windows) == 0 {
+		return s.days[today]
+	}
+	minute := now.Hour()*60 + now.Minute()
+	for _, w := range s.windows {
+		if w[0] < w[1] {
+			if s.days[today] && minute >= w[0] && minute < w[1] {
+				return true
+			}
+			continue
+		}
+		// crossed midnight, and started in yesterday.
+		if s.days[today] && minute >= w[0] {
+			return true
+		}
+		if s.days[yesterday] && minute < w[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the time state changed in a week, and zero if never.
+func (s *Schedule) Next(now time.Time) time.Time {
+	now = now.Truncate(time.Minute)
+	state := s.Active(now)
+	for i := 1; i <= 7*24*60; i++ {
+		next := now.Add(time.Duration(i) * time.Minute)
+		if s.Active(next) != state {
+			return next
+		}
+	}
+	return time.Time{}
+}
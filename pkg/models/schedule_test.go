@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleActive(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		// 2024-01-01 is monday.
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	s, err := NewSchedule("office", []string{"weekday"}, []string{"09:00-18:00"}, "UTC")
+	assert.Nil(t, err)
+	assert.Equal(t, true, s.Active(at(1, 9, 0)), "be active.")
+	assert.Equal(t, false, s.Active(at(1, 18, 0)), "be inactive at end.")
+	assert.Equal(t, false, s.Active(at(6, 10, 0)), "be inactive in saturday.")
+	assert.Equal(t, at(1, 18, 0), s.Next(at(1, 12, 30)), "be the same.")
+	assert.Equal(t, at(8, 9, 0), s.Next(at(5, 18, 0)), "be the same.")
+
+	// crossed midnight, and started in friday.
+	s, err = NewSchedule("night", []string{"Friday"}, []string{"22:00-06:00"}, "UTC")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fri"}, s.Days, "be the same.")
+	assert.Equal(t, true, s.Active(at(5, 23, 0)), "be active.")
+	assert.Equal(t, true, s.Active(at(6, 5, 59)), "be active in saturday.")
+	assert.Equal(t, false, s.Active(at(6, 23, 0)), "be inactive.")
+	assert.Equal(t, false, s.Active(at(5, 5, 0)), "be inactive.")
+
+	s, err = NewSchedule("any", nil, nil, "Asia/Shanghai")
+	assert.Nil(t, err)
+	assert.Equal(t, true, s.Active(at(3, 0, 0)), "be active.")
+	assert.Equal(t, time.Time{}, s.Next(at(3, 0, 0)), "be never.")
+
+	for _, windows := range [][]string{{"09:00"}, {"25:00-26:00"}, {"09:00-09:00"}, {"9:60-10:00"}} {
+		_, err := NewSchedule("bad", nil, windows, "")
+		assert.NotNil(t, err, "%v be invalid.", windows)
+	}
+	_, err = NewSchedule("bad", []string{"someday"}, nil, "")
+	assert.NotNil(t, err, "be invalid.")
+	_, err = NewSchedule("bad", nil, nil, "Mars/Base")
+	assert.NotNil(t, err, "be invalid.")
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/luscis/openlan/pkg/libol"
 	"github.com/luscis/openlan/pkg/libsock"
 	"github.com/luscis/openlan/pkg/schema"
@@ -10,20 +12,22 @@ func NewAccessSchema(p *Access) schema.Access {
 	client, dev := p.Client, p.Device
 	sts := client.Statistics()
 	return schema.Access{
-		Uptime:    p.Uptime,
-		UUID:      p.UUID,
-		Alias:     p.Alias,
-		User:      p.User,
-		Protocol:  p.Protocol,
-		Remote:    client.RemoteAddr(),
-		Device:    dev.Name(),
-		RxBytes:   uint64(sts[libsock.CsRecvOkay]),
-		TxBytes:   uint64(sts[libsock.CsSendOkay]),
-		ErrPkt:    uint64(sts[libsock.CsSendError]),
-		State:     client.Status().String(),
-		Network:   p.Network,
-		AliveTime: client.AliveTime(),
-		System:    p.System,
+		Uptime:     p.Uptime,
+		UUID:       p.UUID,
+		Alias:      p.Alias,
+		User:       p.User,
+		Protocol:   p.Protocol,
+		Remote:     client.RemoteAddr(),
+		Device:     dev.Name(),
+		RxBytes:    uint64(sts[libsock.CsRecvOkay]),
+		TxBytes:    uint64(sts[libsock.CsSendOkay]),
+		ErrPkt:     uint64(sts[libsock.CsSendError]),
+		State:      client.Status().String(),
+		Network:    p.Network,
+		AliveTime:  client.AliveTime(),
+		System:     p.System,
+		Restricted: p.Restricted,
+		Violations: p.Violations,
 	}
 }
 
@@ -35,6 +39,7 @@ func NewUserSchema(u *User) schema.User {
 		Network:  u.Network,
 		Role:     u.Role,
 		Lease:    u.Lease.Format(libol.LeaseTime),
+		Groups:   u.Groups,
 	}
 }
 
@@ -47,6 +52,7 @@ func SchemaToUserModel(user *schema.User) *User {
 		Network:  user.Network,
 		Role:     user.Role,
 		Lease:    lease,
+		Groups:   user.Groups,
 	}
 	obj.Update()
 	return obj
@@ -62,17 +68,35 @@ func NewNetworkSchema(n *Network) schema.Network {
 
 func NewOutputSchema(o *Output) schema.Output {
 	return schema.Output{
-		State:     o.GetState(),
-		Network:   o.Network,
-		Protocol:  o.Protocol,
-		Remote:    o.Remote,
-		Fallback:  o.Fallback,
-		Segment:   o.Segment,
-		Device:    o.Device,
-		RxBytes:   o.RxBytes,
-		TxBytes:   o.TxBytes,
-		Secret:    o.Secret,
-		Crypt:     o.Crypt,
-		AliveTime: o.UpTime(),
+		State:      o.GetState(),
+		Network:    o.Network,
+		Protocol:   o.Protocol,
+		Remote:     o.Remote,
+		Fallback:   o.Fallback,
+		AllowedIPs: o.AllowedIPs,
+		Segment:    o.Segment,
+		Device:     o.Device,
+		RxBytes:    o.RxBytes,
+		TxBytes:    o.TxBytes,
+		ErrPkt:     o.ErrPkt,
+		Reconnects: o.Reconnects,
+		Secret:     o.Secret,
+		Crypt:      o.Crypt,
+		AliveTime:  o.UpTime(),
+	}
+}
+
+func NewScheduleSchema(s *Schedule) schema.Schedule {
+	now := time.Now()
+	obj := schema.Schedule{
+		Name:     s.Name,
+		Days:     s.Days,
+		Windows:  s.Windows,
+		Timezone: s.Timezone,
+		Active:   s.Active(now),
+	}
+	if next := s.Next(now); !next.IsZero() {
+		obj.NextAt = next.Unix()
 	}
+	return obj
 }
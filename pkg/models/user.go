@@ -10,16 +10,21 @@ import (
 )
 
 type User struct {
-	Alias    string               `json:"alias"`
-	Name     string               `json:"name"`
-	Network  string               `json:"network"`
-	Password string               `json:"password"`
-	UUID     string               `json:"uuid"`
-	System   string               `json:"system"`
-	Role     string               `json:"type"` // admin , guest or ldap
-	Last     libsock.SocketClient `json:"last"` // lastly accessed by this.
-	Lease    time.Time            `json:"leastTime"`
-	UpdateAt int64
+	Alias    string   `json:"alias"`
+	Name     string   `json:"name"`
+	Network  string   `json:"network"`
+	Password string   `json:"password"`
+	UUID     string   `json:"uuid"`
+	System   string   `json:"system"`
+	Role     string   `json:"type"` // admin , guest or ldap
+	Groups   []string `json:"groups,omitempty"`
+	Posture  *Posture `json:"posture,omitempty"`
+	// restricted by policy of posture, and never sent by client.
+	Restricted bool                 `json:"-"`
+	Violations []string             `json:"-"`
+	Last       libsock.SocketClient `json:"last"` // lastly accessed by this.
+	Lease      time.Time            `json:"leastTime"`
+	UpdateAt   int64
 }
 
 func NewUser(name, network, password string) *User {
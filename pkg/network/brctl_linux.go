@@ -141,3 +141,15 @@ func (p *BrPort) Cost(cost int) error {
 	}
 	return nil
 }
+
+const (
+	BrPortDisabled   = 0
+	BrPortForwarding = 3
+)
+
+func (p *BrPort) State(state int) error {
+	if out, err := libol.Exec("bridge", "link", "set", "dev", p.Name, "state", strconv.Itoa(state)); err != nil {
+		return libol.NewErr("%s: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package network
+
+import (
+	"net"
+	"time"
+
+	"github.com/luscis/openlan/pkg/libol"
+	"golang.org/x/sys/unix"
+)
+
+// Capture reads frames in both directions of a device by a packet socket.
+type Capture struct {
+	name string
+	fd   int
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+func NewCapture(name string) (*Capture, error) {
+	ifi, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	proto := htons(unix.ETH_P_ALL)
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, int(proto))
+	if err != nil {
+		return nil, libol.NewErr("socket %s", err)
+	}
+	addr := &unix.SockaddrLinklayer{Protocol: proto, Ifindex: ifi.Index}
+	if err := unix.Bind(fd, addr); err != nil {
+		unix.Close(fd)
+		return nil, libol.NewErr("bind %s: %s", name, err)
+	}
+	// wake up in time to check the deadline of capture.
+	tv := unix.NsecToTimeval(int64(time.Second))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return nil, libol.NewErr("timeout %s", err)
+	}
+	return &Capture{name: name, fd: fd}, nil
+}
+
+func (c *Capture) Name() string {
+	return c.name
+}
+
+// Read returns zero without error if no frame received in a second.
+func (c *Capture) Read(p []byte) (int, error) {
+	n, _, err := unix.Recvfrom(c.fd, p, 0)
+	if err == unix.EAGAIN || err == unix.EINTR {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (c *Capture) Close() error {
+	return unix.Close(c.fd)
+}
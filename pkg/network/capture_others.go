@@ -0,0 +1,26 @@
+//go:build !linux
+
+package network
+
+import (
+	"github.com/luscis/openlan/pkg/libol"
+)
+
+type Capture struct {
+}
+
+func NewCapture(name string) (*Capture, error) {
+	return nil, libol.NewErr("capture not supported")
+}
+
+func (c *Capture) Name() string {
+	return ""
+}
+
+func (c *Capture) Read(p []byte) (int, error) {
+	return 0, libol.NewErr("capture not supported")
+}
+
+func (c *Capture) Close() error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"fmt"
 	"os/exec"
 	"runtime"
 	"strconv"
@@ -23,6 +24,7 @@ type EBRule struct {
 	Proto      string
 	DstPort    string
 	SrcPort    string
+	IcmpType   string
 	Input      string
 	LogicalIn  string
 	LogicalOut string
@@ -59,6 +61,9 @@ func (ru EBRule) Args() []string {
 	if ru.DstPort != "" {
 		ipArgs = append(ipArgs, "--ip-dport", ru.DstPort)
 	}
+	if ru.IcmpType != "" {
+		ipArgs = append(ipArgs, "--ip-icmp-type", ru.IcmpType)
+	}
 	jump := strings.ToUpper(ru.Jump)
 	if len(ipArgs) > 0 || jump == "ACCEPT" || jump == "DROP" {
 		args = append(args, "-p", "IPv4")
@@ -299,6 +304,81 @@ func (ch *EBFireWallChain) Flush() {
 	ch.rules = make(EBRules, 0, 32)
 }
 
+// size returns number of rules installed in this chain.
+func (ch *EBFireWallChain) size() (int, error) {
+	c := ch.Chain()
+	out, err := exec.Command("ebtables", "-t", c.Table, "-L", c.Name).CombinedOutput()
+	if err != nil {
+		return 0, libol.NewErr("%s", out)
+	}
+	size := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "-") {
+			size++
+		}
+	}
+	return size, nil
+}
+
+// Replace inserts rules ahead of the installed ones and then deletes the
+// older by position. The chain is never empty while replacing, so packets
+// are checked by the older rules until the newer are all ready.
+func (ch *EBFireWallChain) Replace(rules EBRules) error {
+	if runtime.GOOS != "linux" {
+		return libol.NewErr("ebtables notSupport %s", runtime.GOOS)
+	}
+	ch.Prepare()
+	older, err := ch.size()
+	if err != nil {
+		return err
+	}
+	c := ch.Chain()
+	for i, r := range rules {
+		index := strconv.Itoa(i + 1)
+		args := append([]string{"-t", c.Table, "-I", c.Name, index}, r.Args()...)
+		if out, err := exec.Command("ebtables", args...).CombinedOutput(); err != nil {
+			// rollback to older rules.
+			for ; i > 0; i-- {
+				_, _ = exec.Command("ebtables", "-t", c.Table, "-D", c.Name, "1").CombinedOutput()
+			}
+			return libol.NewErr("%s", out)
+		}
+	}
+	index := strconv.Itoa(len(rules) + 1)
+	for i := 0; i < older; i++ {
+		if out, err := exec.Command("ebtables", "-t", c.Table, "-D", c.Name, index).CombinedOutput(); err != nil {
+			return libol.NewErr("%s", out)
+		}
+	}
+	return nil
+}
+
+// Counters returns counters of rules in this chain by order.
+func (ch *EBFireWallChain) Counters() ([]RuleCounter, error) {
+	if runtime.GOOS != "linux" {
+		return nil, libol.NewErr("ebtables notSupport %s", runtime.GOOS)
+	}
+	c := ch.Chain()
+	out, err := exec.Command("ebtables", "-t", c.Table, "-L", c.Name, "--Lc").CombinedOutput()
+	if err != nil {
+		return nil, libol.NewErr("%s", out)
+	}
+	counters := make([]RuleCounter, 0, 32)
+	for _, line := range strings.Split(string(out), "\n") {
+		// -p IPv4 --ip-src 192.168.1.1 -j DROP , pcnt = 0 -- bcnt = 0
+		index := strings.Index(line, "pcnt = ")
+		if index < 0 {
+			continue
+		}
+		var counter RuleCounter
+		if _, err := fmt.Sscanf(line[index:], "pcnt = %d -- bcnt = %d", &counter.Packets, &counter.Bytes); err != nil {
+			continue
+		}
+		counters = append(counters, counter)
+	}
+	return counters, nil
+}
+
 func ItoEBPort(value int) string {
 	return strconv.Itoa(value)
 }
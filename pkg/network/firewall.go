@@ -1,6 +1,11 @@
 package network
 
 import (
+	"bytes"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/luscis/openlan/pkg/config"
@@ -326,6 +331,81 @@ func (ch *FireWallChain) Flush() {
 	}
 }
 
+// restoreText returns input of iptables-restore to replace all rules of
+// a chain.
+func restoreText(table, name string, rules IPRules) string {
+	var buf bytes.Buffer
+	buf.WriteString("*" + table + "\n")
+	buf.WriteString("-F " + name + "\n")
+	for _, r := range rules {
+		buf.WriteString("-A " + name)
+		for _, arg := range r.Args() {
+			if strings.ContainsAny(arg, " \t\"") {
+				arg = strconv.Quote(arg)
+			}
+			buf.WriteString(" " + arg)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("COMMIT\n")
+	return buf.String()
+}
+
+// Replace swaps all rules of this chain in one commit of iptables-restore,
+// so packets never pass the chain with a part of rules.
+func (ch *FireWallChain) Replace(rules IPRules) error {
+	if runtime.GOOS != "linux" {
+		return libol.NewErr("iptables notSupport %s", runtime.GOOS)
+	}
+	ch.Prepare()
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	c := ch.Chain()
+	cmd := exec.Command("iptables-restore", "--noflush")
+	cmd.Stdin = strings.NewReader(restoreText(c.Table, c.Name, rules))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return libol.NewErr("%s: %s", err, out)
+	}
+	return nil
+}
+
+// RuleCounter is packets and bytes matched by a rule.
+type RuleCounter struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// Counters returns counters of rules in this chain by order.
+func (ch *FireWallChain) Counters() ([]RuleCounter, error) {
+	if runtime.GOOS != "linux" {
+		return nil, libol.NewErr("iptables notSupport %s", runtime.GOOS)
+	}
+	c := ch.Chain()
+	out, err := iptables.Raw("-t", c.Table, "-L", c.Name, "-n", "-v", "-x")
+	if err != nil {
+		return nil, err
+	}
+	counters := make([]RuleCounter, 0, 32)
+	lines := strings.Split(string(out), "\n")
+	for i, line := range lines {
+		if i < 2 { // chain and columns.
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		packets, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		bytes, _ := strconv.ParseUint(fields[1], 10, 64)
+		counters = append(counters, RuleCounter{Packets: packets, Bytes: bytes})
+	}
+	return counters, nil
+}
+
 type FireWallFilter struct {
 	name string
 	In   *FireWallChain
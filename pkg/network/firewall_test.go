@@ -65,3 +65,19 @@ func TestFireWallTableRaw(t *testing.T) {
 func TestFireWallCancel(t *testing.T) {
 	firewall.Stop()
 }
+
+func TestFireWallRestoreText(t *testing.T) {
+	rules := IPRules{
+		{Proto: "tcp", DstPort: "80", SrcSet: "TT_a", Jump: "drop"},
+		{Source: "192.168.1.0/24", Comment: "from lan", Jump: "accept"},
+	}
+	text := restoreText(TRaw, "AT_example", rules)
+	expected := "*raw\n" +
+		"-F AT_example\n" +
+		"-A AT_example -m set --match-set TT_a src -p tcp --dport 80 -j DROP\n" +
+		"-A AT_example -s 192.168.1.0/24 -m comment --comment \"from lan\" -j ACCEPT\n" +
+		"COMMIT\n"
+	if text != expected {
+		t.Errorf("restore text %q", text)
+	}
+}
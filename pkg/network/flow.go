@@ -0,0 +1,75 @@
+package network
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// FlowHash returns a hash of the flow of frame, which is same for both
+// directions. Frames of TUN start with IP header, and others with ethernet.
+func FlowHash(frame []byte, tun bool) uint32 {
+	data := frame
+	proto := uint16(0x0800)
+	if !tun {
+		if len(data) < 14 {
+			return 0
+		}
+		proto = binary.BigEndian.Uint16(data[12:14])
+		data = data[14:]
+		if proto == 0x8100 && len(data) >= 4 {
+			proto = binary.BigEndian.Uint16(data[2:4])
+			data = data[4:]
+		}
+	} else if len(data) > 0 && data[0]>>4 == 6 {
+		proto = 0x86dd
+	}
+
+	var src, dst, ports []byte
+	var next byte
+	switch proto {
+	case 0x0800:
+		if len(data) < 20 {
+			break
+		}
+		next = data[9]
+		src, dst = data[12:16], data[16:20]
+		ihl := int(data[0]&0x0f) * 4
+		// ports of first fragment only.
+		if binary.BigEndian.Uint16(data[6:8])&0x1fff == 0 && len(data) >= ihl+4 {
+			ports = data[ihl : ihl+4]
+		}
+	case 0x86dd:
+		if len(data) < 40 {
+			break
+		}
+		next = data[6]
+		src, dst = data[8:24], data[24:40]
+		if len(data) >= 44 {
+			ports = data[40:44]
+		}
+	}
+	if src == nil {
+		if tun || len(frame) < 12 {
+			return 0
+		}
+		src, dst = frame[6:12], frame[0:6]
+	}
+	if next != 6 && next != 17 && next != 132 {
+		ports = nil
+	}
+
+	// ordered by endpoints to be symmetric.
+	a, b := make([]byte, 0, 18), make([]byte, 0, 18)
+	a, b = append(a, src...), append(b, dst...)
+	if ports != nil {
+		a, b = append(a, ports[0:2]...), append(b, ports[2:4]...)
+	}
+	if string(a) > string(b) {
+		a, b = b, a
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte{next})
+	_, _ = h.Write(a)
+	_, _ = h.Write(b)
+	return h.Sum32()
+}
@@ -0,0 +1,46 @@
+package network
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func flowFrame(src, dst string, sport, dport uint16) []byte {
+	frame := make([]byte, 14+20+8)
+	copy(frame[0:6], []byte{0x02, 0, 0, 0, 0, 0x02})
+	copy(frame[6:12], []byte{0x02, 0, 0, 0, 0, 0x01})
+	binary.BigEndian.PutUint16(frame[12:14], 0x0800)
+	ip := frame[14:]
+	ip[0] = 0x45
+	ip[9] = 17
+	copy(ip[12:16], net.ParseIP(src).To4())
+	copy(ip[16:20], net.ParseIP(dst).To4())
+	binary.BigEndian.PutUint16(ip[20:22], sport)
+	binary.BigEndian.PutUint16(ip[22:24], dport)
+	return frame
+}
+
+func TestFlowHash(t *testing.T) {
+	a := flowFrame("192.168.1.1", "192.168.1.2", 1000, 53)
+	b := flowFrame("192.168.1.2", "192.168.1.1", 53, 1000)
+	if FlowHash(a, false) != FlowHash(b, false) {
+		t.Errorf("not symmetric")
+	}
+	if FlowHash(a, false) == FlowHash(flowFrame("192.168.1.1", "192.168.1.2", 1001, 53), false) {
+		t.Errorf("same hash of different ports")
+	}
+	if FlowHash(a[14:], true) != FlowHash(a, false) {
+		t.Errorf("tun differs from tap")
+	}
+	// not ip, hashed by ethernet.
+	arp := make([]byte, 42)
+	copy(arp, a[:12])
+	binary.BigEndian.PutUint16(arp[12:14], 0x0806)
+	if FlowHash(arp, false) == 0 {
+		t.Errorf("zero hash of arp")
+	}
+	if FlowHash(arp[:10], false) != 0 {
+		t.Errorf("short frame")
+	}
+}
@@ -5,9 +5,10 @@ import (
 )
 
 type IPSet struct {
-	Name string
-	Type string // hash:net, hash:ip
-	Sudo bool
+	Name    string
+	Type    string // hash:net, hash:ip or bitmap:port
+	Options []string
+	Sudo    bool
 }
 
 func NewIPSet(name, method string) *IPSet {
@@ -31,7 +32,8 @@ func (i *IPSet) exec(args ...string) (string, error) {
 }
 
 func (i *IPSet) Create() (string, error) {
-	args := []string{"create", i.Name, i.Type, "-!"}
+	args := append([]string{"create", i.Name, i.Type}, i.Options...)
+	args = append(args, "-!")
 	return i.exec(args...)
 }
 
@@ -43,6 +43,9 @@ type IPRule struct {
 	Proto      string
 	DstPort    string
 	SrcPort    string
+	SrcPortSet string
+	DstPortSet string
+	IcmpType   string
 	Input      string
 	Output     string
 	Comment    string
@@ -123,6 +126,15 @@ func (ru IPRule) Args() []string {
 			args = append(args, "--dport", ru.DstPort)
 		}
 	}
+	if ru.SrcPortSet != "" {
+		args = append(args, "-m", "set", "--match-set", ru.SrcPortSet, "src")
+	}
+	if ru.DstPortSet != "" {
+		args = append(args, "-m", "set", "--match-set", ru.DstPortSet, "dst")
+	}
+	if ru.IcmpType != "" {
+		args = append(args, "--icmp-type", ru.IcmpType)
+	}
 	if ru.Input != "" {
 		args = append(args, "-i", ru.Input)
 	}
@@ -10,6 +10,7 @@ import (
 type KernelTap struct {
 	lock   sync.Mutex
 	device *water.Interface
+	queues []*water.Interface // includes device as first.
 	master Bridger
 	tenant string
 	name   string
@@ -21,16 +22,30 @@ func NewKernelTap(tenant string, c TapConfig) (*KernelTap, error) {
 	if c.Mtu == 0 {
 		c.Mtu = 1500
 	}
-	device, err := WaterNew(c)
-	if err != nil {
-		return nil, err
+	var queues []*water.Interface
+	if c.Queues > 1 {
+		devices, err := WaterQueues(c)
+		if err != nil {
+			libol.Warn("NewKernelTap: %d queues %s", c.Queues, err)
+		}
+		queues = devices
 	}
+	if len(queues) == 0 {
+		device, err := WaterNew(c)
+		if err != nil {
+			return nil, err
+		}
+		queues = []*water.Interface{device}
+	}
+	device := queues[0]
 	if c.Name != device.Name() {
 		c.Name = device.Name()
 	}
+	c.Queues = len(queues)
 	tap := &KernelTap{
 		tenant: tenant,
 		device: device,
+		queues: queues,
 		name:   c.Name,
 		config: c,
 		ipMtu:  c.Mtu,
@@ -79,6 +94,22 @@ func (t *KernelTap) Read(p []byte) (int, error) {
 	}
 }
 
+func (t *KernelTap) Queues() int {
+	return t.config.Queues
+}
+
+// ReadQueue reads a frame from the queue of index.
+func (t *KernelTap) ReadQueue(index int, p []byte) (int, error) {
+	t.lock.Lock()
+	if t.device == nil || index >= len(t.queues) {
+		t.lock.Unlock()
+		return 0, libol.NewErr("Closed")
+	}
+	queue := t.queues[index]
+	t.lock.Unlock()
+	return queue.Read(p)
+}
+
 func (t *KernelTap) Write(p []byte) (int, error) {
 	t.lock.Lock()
 	if t.device == nil {
@@ -89,6 +120,19 @@ func (t *KernelTap) Write(p []byte) (int, error) {
 	return t.device.Write(p)
 }
 
+// WriteQueue writes a frame to the queue of index, which is pinned by
+// flows of the writer.
+func (t *KernelTap) WriteQueue(index int, p []byte) (int, error) {
+	t.lock.Lock()
+	if t.device == nil {
+		t.lock.Unlock()
+		return 0, libol.NewErr("Closed")
+	}
+	queue := t.queues[index%len(t.queues)]
+	t.lock.Unlock()
+	return queue.Write(p)
+}
+
 func (t *KernelTap) Recv(p []byte) (int, error) {
 	return t.Read(p)
 }
@@ -108,9 +152,15 @@ func (t *KernelTap) Close() error {
 		_ = t.master.DelSlave(t.name)
 		t.master = nil
 	}
-	err := t.device.Close()
+	var err error
+	for _, queue := range t.queues {
+		if qerr := queue.Close(); qerr != nil && err == nil {
+			err = qerr
+		}
+	}
 	Taps.Del(t.name)
 	t.device = nil
+	t.queues = nil
 	return err
 }
 
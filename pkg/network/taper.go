@@ -42,6 +42,14 @@ type Taper interface {
 	Stats() DeviceInfo
 }
 
+// MultiQueuer is a taper with queues, and frames from kernel are read on
+// every queue in parallel. Frames are written to a queue pinned by writers.
+type MultiQueuer interface {
+	Queues() int
+	ReadQueue(index int, p []byte) (int, error)
+	WriteQueue(index int, p []byte) (int, error)
+}
+
 func NewTaper(tenant string, c TapConfig) (Taper, error) {
 	return NewKernelTap(tenant, c)
 }
@@ -103,6 +111,7 @@ type TapConfig struct {
 	Network  string
 	Name     string
 	Mtu      int
+	Queues   int // number of queues by IFF_MULTI_QUEUE.
 }
 
 func GetName(name string) string {
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"github.com/luscis/openlan/pkg/libol"
 	"github.com/luscis/openlan/pkg/water"
 )
 
@@ -17,3 +18,7 @@ func WaterNew(c TapConfig) (*water.Interface, error) {
 	}
 	return water.New(cfg)
 }
+
+func WaterQueues(c TapConfig) ([]*water.Interface, error) {
+	return nil, libol.NewErr("multi-queue not supported")
+}
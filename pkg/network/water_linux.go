@@ -17,3 +17,14 @@ func WaterNew(c TapConfig) (*water.Interface, error) {
 	}
 	return water.New(cfg)
 }
+
+// WaterQueues creates an interface with queues by IFF_MULTI_QUEUE.
+func WaterQueues(c TapConfig) ([]*water.Interface, error) {
+	deviceType := water.DeviceType(water.TAP)
+	if c.Type == TUN {
+		deviceType = water.TUN
+	}
+	cfg := water.Config{DeviceType: deviceType}
+	cfg.Name = c.Name
+	return water.NewQueues(cfg, c.Queues)
+}
@@ -2,8 +2,15 @@
 
 package network
 
-import "github.com/luscis/openlan/pkg/water"
+import (
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/water"
+)
 
 func WaterNew(c TapConfig) (*water.Interface, error) {
 	return nil, nil
 }
+
+func WaterQueues(c TapConfig) ([]*water.Interface, error) {
+	return nil, libol.NewErr("multi-queue not supported")
+}
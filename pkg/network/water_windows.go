@@ -1,6 +1,7 @@
 package network
 
 import (
+	"github.com/luscis/openlan/pkg/libol"
 	"github.com/luscis/openlan/pkg/water"
 )
 
@@ -32,3 +33,7 @@ func WaterNew(c TapConfig) (dev *water.Interface, err error) {
 	}
 	return nil, err
 }
+
+func WaterQueues(c TapConfig) ([]*water.Interface, error) {
+	return nil, libol.NewErr("multi-queue not supported")
+}
@@ -74,6 +74,7 @@ type HttpProxy struct {
 	requests  map[string]*HttpRecord
 	lock      sync.RWMutex
 	socks     *SocksProxy
+	lockout   *libol.Lockout
 }
 
 var (
@@ -142,6 +143,10 @@ func NewHttpProxy(cfg *co.HttpProxy, px Proxyer) *HttpProxy {
 		requests:  make(map[string]*HttpRecord),
 		proxer:    px,
 		statsFile: cfg.StatsFile,
+		lockout:   libol.NewLockout(),
+	}
+	if lc := cfg.Lockout; lc != nil {
+		h.lockout.Set(lc.MaxFailed, int64(lc.Backoff), int64(lc.MaxBackoff), int64(lc.Window), lc.Block)
 	}
 	h.Initialize()
 	return h
@@ -170,6 +175,9 @@ func (t *HttpProxy) loadUrl() {
 	t.api.HandleFunc("/api/config", t.GetConfig).Methods("GET")
 	t.api.HandleFunc("/api/match/{domain}/to/{backend}", t.AddMatch).Methods("POST")
 	t.api.HandleFunc("/api/match/{domain}/to/{backend}", t.DelMatch).Methods("DELETE")
+	t.api.HandleFunc("/api/lockout", t.GetLockout).Methods("GET")
+	t.api.HandleFunc("/api/lockout", t.ClearLockout).Methods("DELETE")
+	t.api.HandleFunc("/api/lockout/{id}", t.ClearLockout).Methods("DELETE")
 	t.api.HandleFunc("/pac", t.GetPac).Methods("GET")
 
 	t.api.NotFoundHandler = http.HandlerFunc(NotFound)
@@ -294,11 +302,23 @@ func (t *HttpProxy) CheckAuth(w http.ResponseWriter, r *http.Request) bool {
 	}
 	auth := r.Header.Get("Proxy-Authorization")
 	user, password, ok := decodeBasicAuth(auth)
+	if t.lockout != nil {
+		if err := t.lockout.Check(user, r.RemoteAddr); err != nil {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return false
+		}
+	}
 	if !ok || !t.isAuth(user, password) {
+		if ok && t.lockout != nil {
+			t.lockout.Fail(user, r.RemoteAddr)
+		}
 		w.Header().Set("Proxy-Authenticate", "Basic")
 		http.Error(w, "Proxy Authentication Required", http.StatusProxyAuthRequired)
 		return false
 	}
+	if t.lockout != nil {
+		t.lockout.Success(user)
+	}
 	return true
 }
 
@@ -334,7 +354,7 @@ func (t *HttpProxy) toTunnel(w http.ResponseWriter, conn net.Conn, update func(b
 		if n := bio.Reader.Buffered(); n > 0 {
 			n64, err := io.CopyN(conn, bio, int64(n))
 			if n64 != int64(n) || err != nil {
-				t.out.Warn("HttpProxy.tunnel io.CopyN:", n64, err)
+				t.out.Warn("HttpProxy.tunnel io.CopyN: %d %s", n64, err)
 				return
 			}
 		}
@@ -752,6 +772,26 @@ func (t *HttpProxy) DelMatch(w http.ResponseWriter, r *http.Request) {
 	t.Save()
 }
 
+func (t *HttpProxy) GetLockout(w http.ResponseWriter, r *http.Request) {
+	data := t.lockout.List()
+
+	if t.findQuery(r, "format") == "json" {
+		encodeJson(w, data)
+	} else {
+		encodeYaml(w, data)
+	}
+}
+
+func (t *HttpProxy) ClearLockout(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := t.lockout.Clear(vars["id"]); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	encodeYaml(w, "success")
+}
+
 func (t *HttpProxy) GetPac(w http.ResponseWriter, r *http.Request) {
 	data := &struct {
 		Local string
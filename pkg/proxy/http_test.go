@@ -10,6 +10,7 @@ import (
 	"time"
 
 	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
 )
 
 func TestHttpProxyIsAuthShortNameLookup(t *testing.T) {
@@ -235,3 +236,36 @@ func TestHttpProxySaveStatsFile(t *testing.T) {
 		t.Fatalf("expected byte count to be saved, got %s", text)
 	}
 }
+
+func TestHttpProxyCheckAuthLockout(t *testing.T) {
+	lockout := libol.NewLockout()
+	lockout.Set(2, 60, 60, 60, 0)
+	h := &HttpProxy{
+		cfg: &co.HttpProxy{
+			Network: "guest",
+		},
+		pass: map[string]*passAuth{
+			"alice": {
+				Password: "secret",
+			},
+		},
+		lockout: lockout,
+	}
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Proxy-Authorization", encodeBasicAuth("alice:wrong"))
+		if h.CheckAuth(httptest.NewRecorder(), req) {
+			t.Fatalf("expected wrong password to be rejected")
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Proxy-Authorization", encodeBasicAuth("alice:secret"))
+	rec := httptest.NewRecorder()
+	if h.CheckAuth(rec, req) {
+		t.Fatalf("expected locked out user to be rejected")
+	}
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected %d, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+}
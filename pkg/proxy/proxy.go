@@ -86,6 +86,6 @@ func (p *Proxy) Save() {
 func init() {
 	// HTTP/2.0 not support upgrade for Hijacker
 	if err := os.Setenv("GODEBUG", "http2server=0"); err != nil {
-		libol.Warn("proxy.init %s")
+		libol.Warn("proxy.init %s", err)
 	}
 }
@@ -17,4 +17,7 @@ type Access struct {
 	System    string `json:"system,omitempty"`
 	Address   string `json:"address,omitempty"`
 	Fallback  string `json:"fallback,omitempty"`
+	// restricted if device isn't compliant with posture.
+	Restricted bool     `json:"restricted,omitempty"`
+	Violations []string `json:"violations,omitempty"`
 }
@@ -5,12 +5,29 @@ type ACL struct {
 	Rules []ACLRule `json:"rules"`
 }
 
+type ACLGroup struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"` // address, port or service
+	Members []string `json:"members"`
+}
+
 type ACLRule struct {
-	Name    string `json:"name"`
-	SrcIp   string `json:"src"`
-	DstIp   string `json:"dst"`
-	Proto   string `json:"proto"`
-	SrcPort int    `json:"sport"`
-	DstPort int    `json:"dport"`
-	Action  string `json:"action"`
+	Name        string `json:"name"`
+	Priority    int    `json:"priority"`
+	SrcIp       string `json:"src"`
+	DstIp       string `json:"dst"`
+	SrcIdentity string `json:"srcIdentity,omitempty"` // user:NAME, group:NAME or role:NAME
+	DstIdentity string `json:"dstIdentity,omitempty"`
+	Proto       string `json:"proto"`
+	SrcPort     int    `json:"sport"`
+	DstPort     int    `json:"dport"`
+	SrcPorts    string `json:"sports,omitempty"` // port, MIN-MAX or @NAME of a port group
+	DstPorts    string `json:"dports,omitempty"`
+	Service     string `json:"service,omitempty"`
+	IcmpType    string `json:"icmpType,omitempty"`
+	Schedule    string `json:"schedule,omitempty"`
+	Active      bool   `json:"active"` // false if out of windows of schedule.
+	Action      string `json:"action"`
+	Packets     uint64 `json:"packets"`
+	Bytes       uint64 `json:"bytes"`
 }
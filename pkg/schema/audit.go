@@ -0,0 +1,31 @@
+package schema
+
+import "github.com/luscis/openlan/pkg/libol"
+
+type Audit struct {
+	Time    int64  `json:"time"`
+	Type    string `json:"type"`
+	User    string `json:"user,omitempty"`
+	Token   string `json:"token,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Network string `json:"network,omitempty"`
+	Action  string `json:"action"`
+	Params  string `json:"params,omitempty"`
+	Result  string `json:"result,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+func NewAuditSchema(e libol.AuditEvent) Audit {
+	return Audit{
+		Time:    e.Time,
+		Type:    e.Type,
+		User:    e.User,
+		Token:   e.Token,
+		Source:  e.Source,
+		Network: e.Network,
+		Action:  e.Action,
+		Params:  e.Params,
+		Result:  e.Result,
+		Detail:  e.Detail,
+	}
+}
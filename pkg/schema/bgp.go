@@ -1,12 +1,22 @@
 package schema
 
 type BgpNeighbor struct {
-	Address  string   `json:"address"`
-	RemoteAs int      `json:"remoteas"`
-	Password string   `json:"password"`
-	State    string   `json:"state,omitempty" yaml:"state,omitempty"`
-	Advertis []string `json:"advertis"`
-	Receives []string `json:"receives"`
+	Address     string   `json:"address"`
+	RemoteAs    int      `json:"remoteas"`
+	Password    string   `json:"password"`
+	State       string   `json:"state,omitempty" yaml:"state,omitempty"`
+	Uptime      int64    `json:"uptime,omitempty" yaml:"uptime,omitempty"`
+	Received    int      `json:"received,omitempty" yaml:"received,omitempty"`
+	Sent        int      `json:"sent,omitempty" yaml:"sent,omitempty"`
+	BfdState    string   `json:"bfdState,omitempty" yaml:"bfdState,omitempty"`
+	Advertis    []string `json:"advertis"`
+	Receives    []string `json:"receives"`
+	LocalPref   int      `json:"localpref,omitempty" yaml:"localpref,omitempty"`
+	Med         int      `json:"med,omitempty" yaml:"med,omitempty"`
+	Prepend     int      `json:"prepend,omitempty" yaml:"prepend,omitempty"`
+	Communities []string `json:"communities,omitempty" yaml:"communities,omitempty"`
+	Bfd         bool     `json:"bfd,omitempty" yaml:"bfd,omitempty"`
+	Graceful    bool     `json:"graceful,omitempty" yaml:"graceful,omitempty"`
 }
 
 type Bgp struct {
@@ -19,3 +29,12 @@ type BgpPrefix struct {
 	Prefix   string `json:"prefix"`
 	Neighbor string `json:"neighbor"`
 }
+
+type BgpRoute struct {
+	Prefix    string `json:"prefix"`
+	NextHop   string `json:"nexthop"`
+	Path      string `json:"path"`
+	LocalPref int    `json:"localpref"`
+	Med       int    `json:"med"`
+	Best      bool   `json:"best"`
+}
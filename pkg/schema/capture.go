@@ -0,0 +1,23 @@
+package schema
+
+type Capture struct {
+	Network string `json:"network"`
+	Client  string `json:"client,omitempty"` // alias or address of access client.
+	Output  string `json:"output,omitempty"` // device of output link.
+	Filter  string `json:"filter,omitempty"`
+	Count   int    `json:"count,omitempty"`   // stop after packets.
+	Seconds int    `json:"seconds,omitempty"` // stop after seconds.
+	File    string `json:"file,omitempty"`    // saved on the switch.
+}
+
+type CaptureState struct {
+	Id       string `json:"id"`
+	Network  string `json:"network"`
+	Device   string `json:"device"`
+	Filter   string `json:"filter,omitempty"`
+	File     string `json:"file,omitempty"`
+	Count    int    `json:"count"`
+	Seconds  int    `json:"seconds"`
+	Packets  int    `json:"packets"`
+	CreateAt int64  `json:"createAt"`
+}
@@ -0,0 +1,8 @@
+package schema
+
+type Confirm struct {
+	Network  string `json:"network"`
+	Timeout  int    `json:"timeout"`
+	CreateAt int64  `json:"createAt"`
+	Remain   int    `json:"remain"`
+}
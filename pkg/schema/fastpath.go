@@ -0,0 +1,19 @@
+package schema
+
+type FastPath struct {
+	Network   string        `json:"network"`
+	Uplink    string        `json:"uplink"`
+	Ports     int           `json:"ports"`
+	Forwarded uint64        `json:"forwarded"` // unicast between ports.
+	Flooded   uint64        `json:"flooded"`   // broadcast and unknown unicast.
+	ToKernel  uint64        `json:"toKernel"`  // written to uplink.
+	Dropped   uint64        `json:"dropped"`
+	Macs      []FastPathMac `json:"macs,omitempty"`
+}
+
+type FastPathMac struct {
+	Address string `json:"address"`
+	Port    string `json:"port"` // uplink for the kernel bridge.
+	Client  string `json:"client,omitempty"`
+	Age     int64  `json:"age"`
+}
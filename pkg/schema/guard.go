@@ -0,0 +1,11 @@
+package schema
+
+type GuardBinding struct {
+	Network string            `json:"network"`
+	Alias   string            `json:"alias"`
+	Client  string            `json:"client"`
+	Address string            `json:"address"`
+	Mac     string            `json:"mac"`
+	Dropped map[string]uint64 `json:"dropped,omitempty"` // by reason.
+	LastAt  int64             `json:"lastAt,omitempty"`  // last violation.
+}
@@ -1,13 +1,29 @@
 package schema
 
 type IPSecTunnel struct {
-	Left      string `json:"local"`
-	LeftId    string `json:"localid,omitempty"`
-	LeftPort  int    `json:"localport,omitempty"`
-	Right     string `json:"remote"`
-	RightId   string `json:"remoteid,omitempty"`
-	RightPort int    `json:"remoteport,omitempty"`
-	Transport string `json:"protocol"`
-	Secret    string `json:"secret"`
-	State     string `json:"state"`
+	Left      string    `json:"local"`
+	LeftId    string    `json:"localid,omitempty"`
+	LeftPort  int       `json:"localport,omitempty"`
+	Right     string    `json:"remote"`
+	RightId   string    `json:"remoteid,omitempty"`
+	RightPort int       `json:"remoteport,omitempty"`
+	Transport string    `json:"protocol"`
+	Secret    string    `json:"secret"`
+	Cert      string    `json:"cert,omitempty"`
+	Ike       string    `json:"ike,omitempty"`
+	State     string    `json:"state"`
+	Error     string    `json:"error,omitempty"`
+	Sas       []IPSecSA `json:"sas,omitempty"`
+}
+
+type IPSecSA struct {
+	Serial     int    `json:"serial"`
+	Conn       string `json:"conn"`
+	Type       string `json:"type"` // IKE or IPsec
+	State      string `json:"state"`
+	Rekey      int64  `json:"rekey"` // seconds to rekey or replace.
+	InBytes    uint64 `json:"inBytes,omitempty"`
+	OutBytes   uint64 `json:"outBytes,omitempty"`
+	InPackets  uint64 `json:"inPackets,omitempty"`
+	OutPackets uint64 `json:"outPackets,omitempty"`
 }
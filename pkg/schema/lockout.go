@@ -0,0 +1,21 @@
+package schema
+
+import "github.com/luscis/openlan/pkg/libol"
+
+type Lockout struct {
+	Key     string `json:"key"`
+	Failed  int    `json:"failed"`
+	Last    int64  `json:"last"`
+	Until   int64  `json:"until"`
+	Blocked bool   `json:"blocked"`
+}
+
+func NewLockoutSchema(e libol.LockoutEntry) Lockout {
+	return Lockout{
+		Key:     e.Key,
+		Failed:  e.Failed,
+		Last:    e.Last,
+		Until:   e.Until,
+		Blocked: e.Blocked,
+	}
+}
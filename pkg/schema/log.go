@@ -3,13 +3,22 @@ package schema
 import "github.com/luscis/openlan/pkg/libol"
 
 type Log struct {
-	File  string `json:"file"`
+	File    string         `json:"file"`
+	Level   int            `json:"level"`
+	Format  string         `json:"format,omitempty"`
+	Modules map[string]int `json:"modules,omitempty"`
+}
+
+type LogModule struct {
+	Name  string `json:"name"`
 	Level int    `json:"level"`
 }
 
 func NewLogSchema() Log {
 	return Log{
-		File:  libol.Logger.FileName,
-		Level: libol.Logger.Level,
+		File:    libol.Logger.FileName,
+		Level:   libol.Logger.Level,
+		Format:  libol.Logger.Format,
+		Modules: libol.ListModule(),
 	}
 }
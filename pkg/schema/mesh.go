@@ -0,0 +1,18 @@
+package schema
+
+type Mesh struct {
+	Name  string     `json:"name"`
+	Alias string     `json:"alias"`
+	Root  string     `json:"root"`
+	Peers []MeshPeer `json:"peers"`
+}
+
+type MeshPeer struct {
+	Alias     string `json:"alias"`
+	Address   string `json:"address"`
+	Device    string `json:"device"`
+	Direction string `json:"direction"` // in or out
+	State     string `json:"state"`     // forwarding or blocking
+	Status    string `json:"status"`
+	Uptime    int64  `json:"uptime"`
+}
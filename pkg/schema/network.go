@@ -3,11 +3,25 @@ package schema
 import "fmt"
 
 type Lease struct {
-	Address string `json:"address"`
+	Address  string `json:"address"`
+	Alias    string `json:"alias"`
+	Client   string `json:"client"`
+	Type     string `json:"type"` // static, reserved or dynamic.
+	Network  string `json:"network"`
+	State    string `json:"state,omitempty"` // active, released or expired.
+	CreateAt int64  `json:"createAt,omitempty"`
+	UpdateAt int64  `json:"updateAt,omitempty"`
+	ExpireAt int64  `json:"expireAt,omitempty"`
+}
+
+type LeaseEvent struct {
+	Time    int64  `json:"time"`
+	Action  string `json:"action"`
 	Alias   string `json:"alias"`
-	Client  string `json:"client"`
-	Type    string `json:"type"`
+	Address string `json:"address"`
 	Network string `json:"network"`
+	Client  string `json:"client,omitempty"`
+	Reason  string `json:"reason,omitempty"`
 }
 
 type PrefixRoute struct {
@@ -1,18 +1,20 @@
 package schema
 
 type Output struct {
-	Network   string `json:"network"`
-	Protocol  string `json:"protocol"`
-	Remote    string `json:"remote"`
-	DstPort   int    `json:"dstPort,omitempty"`
-	Segment   int    `json:"segment,omitempty"`
-	Secret    string `json:"secret,omitempty"`
-	Crypt     string `json:"crypt,omitempty"`
-	Device    string `json:"device"`
-	RxBytes   uint64 `json:"rxBytes,omitempty"`
-	TxBytes   uint64 `json:"txBytes,omitempty"`
-	ErrPkt    uint64 `json:"errors,omitempty"`
-	AliveTime int64  `json:"aliveTime"`
-	Fallback  string `json:"fallback,omitempty"`
-	State     string `json:"state,omitempty"`
+	Network    string   `json:"network"`
+	Protocol   string   `json:"protocol"`
+	Remote     string   `json:"remote"`
+	DstPort    int      `json:"dstPort,omitempty"`
+	Segment    int      `json:"segment,omitempty"`
+	Secret     string   `json:"secret,omitempty"`
+	Crypt      string   `json:"crypt,omitempty"`
+	Device     string   `json:"device"`
+	RxBytes    uint64   `json:"rxBytes,omitempty"`
+	TxBytes    uint64   `json:"txBytes,omitempty"`
+	ErrPkt     uint64   `json:"errors,omitempty"`
+	Reconnects int64    `json:"reconnects,omitempty"`
+	AliveTime  int64    `json:"aliveTime"`
+	Fallback   string   `json:"fallback,omitempty"`
+	AllowedIPs []string `json:"allowedIps,omitempty"`
+	State      string   `json:"state,omitempty"`
 }
@@ -1,8 +1,21 @@
 package schema
 
 type Qos struct {
-	Name    string  `json:"name"`
-	Device  string  `json:"device"`
-	Ip      string  `json:"ip"`
-	InSpeed float64 `json:"inSpeed"`
+	Name     string  `json:"name"`
+	Device   string  `json:"device"`
+	Ip       string  `json:"ip"`
+	InSpeed  float64 `json:"inSpeed"`
+	InCeil   float64 `json:"inCeil,omitempty"`
+	OutSpeed float64 `json:"outSpeed"`
+	OutCeil  float64 `json:"outCeil,omitempty"`
+	Burst    int     `json:"burst,omitempty"`
+	Schedule string  `json:"schedule,omitempty"`
+	Active   bool    `json:"active"`
+}
+
+type QosPriority struct {
+	Name     string   `json:"name,omitempty"`
+	Dscp     []int    `json:"dscp,omitempty"`
+	Protocol string   `json:"protocol,omitempty"`
+	Ports    []string `json:"ports,omitempty"`
 }
@@ -0,0 +1,10 @@
+package schema
+
+type Schedule struct {
+	Name     string   `json:"name"`
+	Days     []string `json:"days,omitempty"`    // mon, tue ... sun, weekday or weekend
+	Windows  []string `json:"windows,omitempty"` // 09:00-18:00 or 22:00-06:00
+	Timezone string   `json:"timezone,omitempty"`
+	Active   bool     `json:"active"`
+	NextAt   int64    `json:"nextAt,omitempty"` // unix time of state changed.
+}
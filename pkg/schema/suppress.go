@@ -0,0 +1,25 @@
+package schema
+
+type Suppress struct {
+	Network   string           `json:"network"`
+	Neighbors int              `json:"neighbors"`
+	Pruned    uint64           `json:"pruned"` // multicast not sent to unsubscribed ports.
+	Clients   []SuppressClient `json:"clients,omitempty"`
+	Groups    []SuppressGroup  `json:"groups,omitempty"`
+}
+
+type SuppressClient struct {
+	Network     string `json:"network"`
+	Alias       string `json:"alias"`
+	Client      string `json:"client"`
+	Device      string `json:"device"`
+	ArpProxied  uint64 `json:"arpProxied"`
+	NdProxied   uint64 `json:"ndProxied"`
+	RateDropped uint64 `json:"rateDropped"`
+}
+
+type SuppressGroup struct {
+	Group   string   `json:"group"`
+	Mac     string   `json:"mac"`
+	Members []string `json:"members"` // devices subscribed.
+}
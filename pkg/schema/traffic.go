@@ -0,0 +1,24 @@
+package schema
+
+type Traffic struct {
+	User     string `json:"user"` // user@network
+	Network  string `json:"network"`
+	Day      string `json:"day"`
+	Daily    uint64 `json:"daily"`
+	Month    string `json:"month"`
+	Monthly  uint64 `json:"monthly"`
+	RxBytes  uint64 `json:"rxBytes"` // received from the user.
+	TxBytes  uint64 `json:"txBytes"`
+	State    string `json:"state,omitempty"`   // throttled or blocked
+	Restore  *Qos   `json:"restore,omitempty"` // qos before throttled.
+	UpdateAt int64  `json:"updateAt"`
+}
+
+type Quota struct {
+	Network string  `json:"network"`
+	User    string  `json:"user,omitempty"` // empty for all users.
+	Daily   uint64  `json:"daily"`          // MiB
+	Monthly uint64  `json:"monthly"`        // MiB
+	Action  string  `json:"action,omitempty"`
+	Speed   float64 `json:"speed,omitempty"`
+}
@@ -3,6 +3,10 @@ package schema
 type VxLAN struct {
 	Name    string        `json:"name"`
 	Bridge  string        `json:"bridge"`
+	Device  string        `json:"device"`
+	Vni     int           `json:"vni"`
+	Fabric  string        `json:"fabric"`
+	Macs    int           `json:"macs"`
 	Members []VxLANMember `json:"members"`
 }
 
@@ -10,4 +14,9 @@ type VxLANMember struct {
 	Vni    int    `json:"vni"`
 	Local  string `json:"local"`
 	Remote string `json:"remote"`
+	Type   string `json:"type"` // static or evpn
+}
+
+type VxLANPeer struct {
+	Remote string `json:"remote"`
 }
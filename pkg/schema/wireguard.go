@@ -0,0 +1,21 @@
+package schema
+
+type WireGuard struct {
+	Network   string          `json:"network"`
+	Device    string          `json:"device"`
+	Listen    int             `json:"listen"`
+	PublicKey string          `json:"publicKey"`
+	Endpoint  string          `json:"endpoint,omitempty"`
+	Peers     []WireGuardPeer `json:"peers"`
+}
+
+type WireGuardPeer struct {
+	Name       string   `json:"name"`
+	PublicKey  string   `json:"publicKey,omitempty"`
+	Address    string   `json:"address,omitempty"`
+	AllowedIPs []string `json:"allowedIps,omitempty"`
+	Endpoint   string   `json:"endpoint,omitempty"`
+	Handshake  int64    `json:"handshake,omitempty"` // seconds since latest handshake.
+	RxBytes    uint64   `json:"rxBytes,omitempty"`
+	TxBytes    uint64   `json:"txBytes,omitempty"`
+}
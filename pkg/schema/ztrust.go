@@ -1,13 +1,16 @@
 package schema
 
 type ZGuest struct {
-	Network string `json:"network"`
-	Name    string `json:"name"`
-	Device  string `json:"device"`
-	Address string `json:"Address"`
+	Network  string `json:"network"`
+	Name     string `json:"name"`
+	Device   string `json:"device"`
+	Address  string `json:"Address"`
+	Schedule string `json:"schedule,omitempty"`
+	Active   bool   `json:"active"`
 }
 
 type KnockRule struct {
+	Id       string `json:"id,omitempty"`
 	Network  string `json:"network"`
 	Name     string `json:"name"`
 	Dest     string `json:"destination"`
@@ -15,4 +18,30 @@ type KnockRule struct {
 	Port     string `json:"port"`
 	Age      int    `json:"age"`
 	CreateAt int64  `json:"createAt"`
+	State    string `json:"state,omitempty"` // granted or pending.
+	Policy   string `json:"policy,omitempty"`
+}
+
+// ZPolicy allows users or groups to knock services by themselves.
+type ZPolicy struct {
+	Name     string   `json:"name"`
+	Users    []string `json:"users,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	Services []string `json:"services"`
+	MaxAge   int      `json:"maxAge,omitempty"`
+	Approval bool     `json:"approval,omitempty"`
+}
+
+// ZTrustEvent is an audit of requesting, granting and expiring of knocks.
+type ZTrustEvent struct {
+	Time     int64  `json:"time"`
+	Network  string `json:"network"`
+	Name     string `json:"name"`
+	Action   string `json:"action"` // request, grant, reject or expire.
+	Protocol string `json:"protocol"`
+	Dest     string `json:"destination"`
+	Port     string `json:"port"`
+	Age      int    `json:"age,omitempty"`
+	Policy   string `json:"policy,omitempty"`
+	By       string `json:"by,omitempty"`
 }
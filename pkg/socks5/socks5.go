@@ -256,7 +256,7 @@ func (s *Server) toForward(req *Request, local net.Conn, via *co.ForwardTo) erro
 		header := []byte{0, 0}
 		_, err := target.Read(header)
 		if header[0] != socks5Version || header[1] != UserPassAuth {
-			s.config.Logger.Error("Socks.ServeConn CONNECT %s: wrong %s", dstAddr, header[1])
+			s.config.Logger.Error("Socks.ServeConn CONNECT %s: wrong %d", dstAddr, header[1])
 			sendReply(local, serverFailure, nil)
 			return err
 		}
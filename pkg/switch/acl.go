@@ -2,80 +2,235 @@ package cswitch
 
 import (
 	"fmt"
+	"hash/fnv"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/luscis/openlan/pkg/cache"
 	co "github.com/luscis/openlan/pkg/config"
 	"github.com/luscis/openlan/pkg/libol"
 	cn "github.com/luscis/openlan/pkg/network"
 	"github.com/luscis/openlan/pkg/schema"
 )
 
+const (
+	IdentityUser    = "user"
+	IdentityGroup   = "group"
+	IdentityRole    = "role"
+	IdentityPosture = "posture" // restricted or compliant of devices.
+)
+
+// ParseIdentity returns kind and name of an identity like user:NAME,
+// group:NAME, role:NAME or posture:STATE, and it's an user if no kind
+// given.
+func ParseIdentity(value string) (string, string, error) {
+	kind, name := IdentityUser, value
+	if i := strings.Index(value, ":"); i >= 0 {
+		kind, name = value[:i], value[i+1:]
+	}
+	if kind == IdentityUser {
+		name = strings.SplitN(name, "@", 2)[0]
+	}
+	switch kind {
+	case IdentityUser, IdentityGroup, IdentityRole:
+	case IdentityPosture:
+		if name != "restricted" && name != "compliant" {
+			return "", "", libol.NewErr("invalid identity %q", value)
+		}
+	default:
+		return "", "", libol.NewErr("invalid identity %q", value)
+	}
+	if name == "" {
+		return "", "", libol.NewErr("invalid identity %q", value)
+	}
+	return kind, name, nil
+}
+
 type ACLRule struct {
-	SrcIp   string
-	DstIp   string
-	Proto   string // TCP, UDP or ICMP
-	SrcPort int
-	DstPort int
-	Action  string // DROP or ACCEPT
-	ipRule  *cn.IPRule
-	ebRule  *cn.EBRule
+	Priority    int    // matched firstly if lower.
+	SrcIp       string // address, or @NAME of an address group.
+	DstIp       string
+	SrcIdentity string // user:NAME, group:NAME or role:NAME
+	DstIdentity string
+	Proto       string // TCP, UDP or ICMP
+	SrcPort     int
+	DstPort     int
+	SrcPorts    string // port, MIN-MAX, or @NAME of a port group.
+	DstPorts    string
+	Service     string // name of a service group.
+	IcmpType    string
+	Action      string // DROP or ACCEPT
+	Schedule    string // name of a schedule, and always active if empty.
+	seq         int
+	active      bool           // installed in windows of schedule.
+	counter     cn.RuleCounter // counted before reloading.
+}
+
+func NewACLRule(rule *schema.ACLRule) *ACLRule {
+	return &ACLRule{
+		Priority:    rule.Priority,
+		Proto:       rule.Proto,
+		DstIp:       rule.DstIp,
+		SrcIp:       rule.SrcIp,
+		SrcIdentity: rule.SrcIdentity,
+		DstIdentity: rule.DstIdentity,
+		DstPort:     rule.DstPort,
+		SrcPort:     rule.SrcPort,
+		DstPorts:    rule.DstPorts,
+		SrcPorts:    rule.SrcPorts,
+		Service:     rule.Service,
+		IcmpType:    rule.IcmpType,
+		Action:      rule.Action,
+		Schedule:    rule.Schedule,
+	}
 }
 
 func (r *ACLRule) Id() string {
-	return fmt.Sprintf("%s %s:%s:%s:%d:%d", r.Action, r.SrcIp, r.DstIp, r.Proto, r.DstPort, r.SrcPort)
+	id := fmt.Sprintf("%s %s:%s:%s:%s:%s", r.Action, r.SrcIp, r.DstIp, r.Proto, r.DstPorts, r.SrcPorts)
+	if r.Service != "" || r.IcmpType != "" {
+		id += fmt.Sprintf(":%s:%s", r.Service, r.IcmpType)
+	}
+	if r.Identified() {
+		id += fmt.Sprintf(":%s:%s", r.SrcIdentity, r.DstIdentity)
+	}
+	if r.Schedule != "" {
+		id += " at " + r.Schedule
+	}
+	return id
 }
 
-func (r *ACLRule) ToIPRule() cn.IPRule {
-	if r.ipRule == nil {
-		r.ipRule = &cn.IPRule{
-			Dest:   r.DstIp,
-			Source: r.SrcIp,
-			Proto:  r.Proto,
-			Jump:   r.Action,
-			Order:  "-I",
-		}
-		if r.DstPort > 0 {
-			r.ipRule.DstPort = strconv.Itoa(r.DstPort)
-		}
-		if r.SrcPort > 0 {
-			r.ipRule.SrcPort = strconv.Itoa(r.SrcPort)
-		}
+func (r *ACLRule) Identified() bool {
+	return r.SrcIdentity != "" || r.DstIdentity != ""
+}
+
+func isGroup(value string) bool {
+	return strings.HasPrefix(value, "@")
+}
+
+func groupName(value string) string {
+	return strings.TrimPrefix(value, "@")
+}
+
+func correctPorts(ports string, port int) (string, error) {
+	if ports == "" && port > 0 {
+		ports = strconv.Itoa(port)
+	}
+	if ports == "" || isGroup(ports) {
+		return ports, nil
 	}
-	return *r.ipRule
+	return ParsePorts(ports)
+}
+
+// portInt returns the port if only one.
+func portInt(ports string) int {
+	port, _ := strconv.Atoi(ports)
+	return port
 }
 
-func (r *ACLRule) ToEBRule() cn.EBRule {
-	if r.ebRule == nil {
-		r.ebRule = &cn.EBRule{
-			Dest:   r.DstIp,
-			Source: r.SrcIp,
-			Proto:  r.Proto,
-			Jump:   r.Action,
-			Order:  "-I",
+// Correct normalizes identities and ports, and checks it's not mixed.
+func (r *ACLRule) Correct() error {
+	if r.Priority <= 0 {
+		r.Priority = 100
+	}
+	if r.SrcIdentity != "" {
+		if r.SrcIp != "" {
+			return libol.NewErr("source both in address and identity")
+		}
+		kind, name, err := ParseIdentity(r.SrcIdentity)
+		if err != nil {
+			return err
+		}
+		r.SrcIdentity = kind + ":" + name
+	}
+	if r.DstIdentity != "" {
+		if r.DstIp != "" {
+			return libol.NewErr("destination both in address and identity")
+		}
+		kind, name, err := ParseIdentity(r.DstIdentity)
+		if err != nil {
+			return err
 		}
-		if r.DstPort > 0 {
-			r.ebRule.DstPort = strconv.Itoa(r.DstPort)
+		r.DstIdentity = kind + ":" + name
+	}
+	var err error
+	if r.SrcPorts, err = correctPorts(r.SrcPorts, r.SrcPort); err != nil {
+		return err
+	}
+	if r.DstPorts, err = correctPorts(r.DstPorts, r.DstPort); err != nil {
+		return err
+	}
+	r.SrcPort = portInt(r.SrcPorts)
+	r.DstPort = portInt(r.DstPorts)
+	proto := strings.ToLower(r.Proto)
+	if r.Service != "" {
+		if proto != "" || r.SrcPorts != "" || r.DstPorts != "" || r.IcmpType != "" {
+			return libol.NewErr("service with protocol or ports")
 		}
-		if r.SrcPort > 0 {
-			r.ebRule.SrcPort = strconv.Itoa(r.SrcPort)
+		r.Service = groupName(r.Service)
+	}
+	if r.IcmpType != "" {
+		if proto == "" {
+			r.Proto = "icmp"
+		} else if proto != "icmp" {
+			return libol.NewErr("icmp type with %s", r.Proto)
 		}
 	}
-	return *r.ebRule
+	if (r.SrcPorts != "" || r.DstPorts != "") && proto != "tcp" && proto != "udp" {
+		return libol.NewErr("ports without tcp or udp")
+	}
+	return nil
+}
+
+// aclIdentity is an ipset of addresses of online users by an identity.
+type aclIdentity struct {
+	ipset *cn.IPSet
+	addrs map[string]bool
+	refs  int
+}
+
+func (i *aclIdentity) Addrs() []string {
+	addrs := make([]string, 0, len(i.addrs))
+	for addr := range i.addrs {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+type aclUser struct {
+	name       string
+	address    string
+	restricted bool // device not compliant with posture.
 }
 
 type ACL struct {
-	Name    string
-	Rules   map[string]*ACLRule
-	ipchain *cn.FireWallChain
-	ebchain *cn.EBFireWallChain
-	out     *libol.SubLogger
+	lock       sync.Mutex
+	Name       string
+	Rules      map[string]*ACLRule
+	Groups     map[string]*ACLGroup
+	identities map[string]*aclIdentity
+	seq        int
+	ipchain    *cn.FireWallChain
+	ebchain    *cn.EBFireWallChain
+	ipRules    []cn.IPRule // installed, and owners of them by order.
+	ipOwners   []*ACLRule
+	ebRules    []cn.EBRule
+	ebOwners   []*ACLRule
+	out        *libol.SubLogger
+	ticker     libol.Ticker
+	fastpath   bool // rules are bypassed by fastpath.
 }
 
 func NewACL(name string) *ACL {
 	return &ACL{
-		Name:  name,
-		out:   libol.NewSubLogger(name),
-		Rules: make(map[string]*ACLRule, 32),
+		Name:       name,
+		out:        libol.NewSubLogger(name),
+		Rules:      make(map[string]*ACLRule, 32),
+		Groups:     make(map[string]*ACLGroup, 32),
+		identities: make(map[string]*aclIdentity, 32),
 	}
 }
 
@@ -90,26 +245,57 @@ func (a *ACL) Initialize() {
 
 func (a *ACL) Start() {
 	a.out.Info("ACL.Start")
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
 	cfg := co.GetAcl(a.Name)
 	if cfg != nil {
+		for _, group := range cfg.Groups {
+			ag := &ACLGroup{
+				acl:     a.Name,
+				Name:    group.Name,
+				Type:    group.Type,
+				Members: group.Members,
+			}
+			if err := a.addGroup(ag); err != nil {
+				a.out.Warn("ACL.Start %s %s", ag.Name, err)
+			}
+		}
 		for _, rule := range cfg.Rules {
 			ar := &ACLRule{
-				Proto:   rule.Proto,
-				DstIp:   rule.DstIp,
-				SrcIp:   rule.SrcIp,
-				DstPort: rule.DstPort,
-				SrcPort: rule.SrcPort,
-				Action:  rule.Action,
+				Priority:    rule.Priority,
+				Proto:       rule.Proto,
+				DstIp:       rule.DstIp,
+				SrcIp:       rule.SrcIp,
+				SrcIdentity: rule.SrcIdentity,
+				DstIdentity: rule.DstIdentity,
+				DstPort:     rule.DstPort,
+				SrcPort:     rule.SrcPort,
+				DstPorts:    rule.DstPorts,
+				SrcPorts:    rule.SrcPorts,
+				Service:     rule.Service,
+				IcmpType:    rule.IcmpType,
+				Action:      rule.Action,
+				Schedule:    rule.Schedule,
+			}
+			if err := a.check(ar); err != nil {
+				a.out.Warn("ACL.Start %s %s", ar.Id(), err)
+				continue
 			}
 			a.addRule(ar)
 		}
 	}
 	a.ipchain.Install()
 	a.ebchain.Install()
+	a.sync()
+	a.reload()
+
+	a.ticker.Start(5*time.Second, a.Sync)
 }
 
 func (a *ACL) Stop() {
 	a.out.Info("ACL.Stop")
+	a.ticker.Stop()
 	a.ipchain.Cancel()
 	a.ebchain.Cancel()
 }
@@ -162,6 +348,330 @@ func (a *ACL) DelInput(input string) {
 	}
 }
 
+// identity returns the ipset of an identity, and creates it if not
+// existed.
+func (a *ACL) identity(value string) *aclIdentity {
+	if obj, ok := a.identities[value]; ok {
+		obj.refs++
+		return obj
+	}
+	// name of ipset is limited in 31 characters.
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(a.Name + "/" + value))
+	obj := &aclIdentity{
+		ipset: cn.NewIPSet(fmt.Sprintf("ACL_%08x", hash.Sum32()), "hash:ip"),
+		addrs: make(map[string]bool, 8),
+		refs:  1,
+	}
+	if out, err := obj.ipset.Clear(); err != nil {
+		a.out.Warn("ACL.identity %s %s", value, out)
+	}
+	a.identities[value] = obj
+	return obj
+}
+
+func (a *ACL) release(value string) {
+	obj, ok := a.identities[value]
+	if !ok {
+		return
+	}
+	obj.refs--
+	if obj.refs > 0 {
+		return
+	}
+	if out, err := obj.ipset.Destroy(); err != nil {
+		a.out.Warn("ACL.release %s %s", value, out)
+	}
+	delete(a.identities, value)
+}
+
+// check corrects a rule, and checks groups referenced by it.
+func (a *ACL) check(ar *ACLRule) error {
+	if err := ar.Correct(); err != nil {
+		return err
+	}
+	refs := []struct {
+		value string
+		kind  string
+	}{
+		{ar.SrcIp, GroupAddress},
+		{ar.DstIp, GroupAddress},
+		{ar.SrcPorts, GroupPort},
+		{ar.DstPorts, GroupPort},
+	}
+	if ar.Service != "" {
+		refs = append(refs, struct {
+			value string
+			kind  string
+		}{"@" + ar.Service, GroupService})
+	}
+	for _, ref := range refs {
+		if !isGroup(ref.value) {
+			continue
+		}
+		name := groupName(ref.value)
+		group, ok := a.Groups[name]
+		if !ok {
+			return libol.NewErr("group %s notFound", name)
+		}
+		if group.Type != ref.kind {
+			return libol.NewErr("group %s isn't %s", name, ref.kind)
+		}
+	}
+	return nil
+}
+
+// sorted returns rules by priority, and then by added order.
+func (a *ACL) sorted() []*ACLRule {
+	rules := make([]*ACLRule, 0, len(a.Rules))
+	for _, rule := range a.Rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].seq < rules[j].seq
+	})
+	return rules
+}
+
+// toIPRules matches addresses and ports of groups by ipsets, and expands
+// a service group to rules by protocols.
+func (a *ACL) toIPRules(ar *ACLRule) []cn.IPRule {
+	rule := cn.IPRule{
+		Proto:    ar.Proto,
+		IcmpType: ar.IcmpType,
+		Jump:     ar.Action,
+	}
+	if obj, ok := a.identities[ar.SrcIdentity]; ok {
+		rule.SrcSet = obj.ipset.Name
+	} else if group, ok := a.Groups[groupName(ar.SrcIp)]; ok && isGroup(ar.SrcIp) {
+		rule.SrcSet = group.SetName("")
+	} else {
+		rule.Source = ar.SrcIp
+	}
+	if obj, ok := a.identities[ar.DstIdentity]; ok {
+		rule.DestSet = obj.ipset.Name
+	} else if group, ok := a.Groups[groupName(ar.DstIp)]; ok && isGroup(ar.DstIp) {
+		rule.DestSet = group.SetName("")
+	} else {
+		rule.Dest = ar.DstIp
+	}
+	if group, ok := a.Groups[groupName(ar.SrcPorts)]; ok && isGroup(ar.SrcPorts) {
+		rule.SrcPortSet = group.SetName("")
+	} else {
+		rule.SrcPort = tablePorts(ar.SrcPorts)
+	}
+	if group, ok := a.Groups[groupName(ar.DstPorts)]; ok && isGroup(ar.DstPorts) {
+		rule.DstPortSet = group.SetName("")
+	} else {
+		rule.DstPort = tablePorts(ar.DstPorts)
+	}
+	group, ok := a.Groups[ar.Service]
+	if !ok {
+		return []cn.IPRule{rule}
+	}
+	rules := make([]cn.IPRule, 0, 4)
+	for _, key := range group.Keys() {
+		obj := rule
+		obj.Proto = key
+		obj.DstPortSet = group.SetName(key)
+		rules = append(rules, obj)
+	}
+	for _, s := range group.Services() {
+		if s.Proto != "icmp" && s.Port != "" {
+			continue
+		}
+		obj := rule
+		obj.Proto = s.Proto
+		if s.Proto == "icmp" {
+			obj.IcmpType = s.Port
+		}
+		rules = append(rules, obj)
+	}
+	return rules
+}
+
+// ebAddrs returns addresses of an identity or a group, because ebtables
+// can't match an ipset.
+func (a *ACL) ebAddrs(identity, value string) []string {
+	if identity != "" {
+		if obj, ok := a.identities[identity]; ok {
+			return obj.Addrs()
+		}
+		return nil
+	}
+	if isGroup(value) {
+		if group, ok := a.Groups[groupName(value)]; ok {
+			return group.Members
+		}
+		return nil
+	}
+	return []string{value}
+}
+
+func (a *ACL) ebPorts(value string) []string {
+	if isGroup(value) {
+		ports := make([]string, 0, 8)
+		if group, ok := a.Groups[groupName(value)]; ok {
+			for _, member := range group.Members {
+				ports = append(ports, tablePorts(member))
+			}
+		}
+		return ports
+	}
+	return []string{tablePorts(value)}
+}
+
+// toEBRules expands a rule by addresses and ports of identities and groups.
+func (a *ACL) toEBRules(ar *ACLRule) []cn.EBRule {
+	matches := make([]cn.EBRule, 0, 4)
+	if group, ok := a.Groups[ar.Service]; ok {
+		for _, s := range group.Services() {
+			obj := cn.EBRule{Proto: s.Proto}
+			if s.Proto == "icmp" {
+				obj.IcmpType = s.Port
+			} else {
+				obj.DstPort = tablePorts(s.Port)
+			}
+			matches = append(matches, obj)
+		}
+	} else {
+		for _, sport := range a.ebPorts(ar.SrcPorts) {
+			for _, dport := range a.ebPorts(ar.DstPorts) {
+				matches = append(matches, cn.EBRule{
+					Proto:    ar.Proto,
+					SrcPort:  sport,
+					DstPort:  dport,
+					IcmpType: ar.IcmpType,
+				})
+			}
+		}
+	}
+	rules := make([]cn.EBRule, 0, len(matches))
+	for _, source := range a.ebAddrs(ar.SrcIdentity, ar.SrcIp) {
+		for _, dest := range a.ebAddrs(ar.DstIdentity, ar.DstIp) {
+			for _, match := range matches {
+				match.Source = source
+				match.Dest = dest
+				match.Jump = ar.Action
+				rules = append(rules, match)
+			}
+		}
+	}
+	return rules
+}
+
+// count adds counters of rules installed to their owners by order.
+func (a *ACL) count(counters map[*ACLRule]cn.RuleCounter, owners []*ACLRule, values []cn.RuleCounter) {
+	if len(values) != len(owners) {
+		a.out.Debug("ACL.count %d != %d", len(values), len(owners))
+		return
+	}
+	for i, ar := range owners {
+		obj := counters[ar]
+		obj.Packets += values[i].Packets
+		obj.Bytes += values[i].Bytes
+		counters[ar] = obj
+	}
+}
+
+// counters returns counters of rules from iptables and ebtables.
+func (a *ACL) counters() map[*ACLRule]cn.RuleCounter {
+	counters := make(map[*ACLRule]cn.RuleCounter, len(a.Rules))
+	if values, err := a.ipchain.Counters(); err == nil {
+		a.count(counters, a.ipOwners, values)
+	}
+	if values, err := a.ebchain.Counters(); err == nil {
+		a.count(counters, a.ebOwners, values)
+	}
+	return counters
+}
+
+func sameIPRules(older, newer []cn.IPRule) bool {
+	if len(older) != len(newer) {
+		return false
+	}
+	for i := range older {
+		if !older[i].Eq(newer[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameEBRules(older, newer []cn.EBRule) bool {
+	if len(older) != len(newer) {
+		return false
+	}
+	for i := range older {
+		if !older[i].Eq(newer[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// reload renders rules by order, and replaces a chain in one step only if
+// rules of it changed. Counters of rules are kept across replacing, and
+// rules out of windows of their schedule aren't installed.
+func (a *ACL) reload() {
+	ipRules := make([]cn.IPRule, 0, 32)
+	ipOwners := make([]*ACLRule, 0, 32)
+	ebRules := make([]cn.EBRule, 0, 32)
+	ebOwners := make([]*ACLRule, 0, 32)
+	for _, ar := range a.sorted() {
+		ar.active = cache.Schedule.Active(ar.Schedule)
+		if !ar.active {
+			continue
+		}
+		for _, rule := range a.toIPRules(ar) {
+			ipRules = append(ipRules, rule)
+			ipOwners = append(ipOwners, ar)
+		}
+		for _, rule := range a.toEBRules(ar) {
+			ebRules = append(ebRules, rule)
+			ebOwners = append(ebOwners, ar)
+		}
+	}
+
+	if !sameIPRules(a.ipRules, ipRules) {
+		counters := make(map[*ACLRule]cn.RuleCounter, len(a.Rules))
+		if values, err := a.ipchain.Counters(); err == nil {
+			a.count(counters, a.ipOwners, values)
+		}
+		if err := a.ipchain.Replace(ipRules); err != nil {
+			a.out.Warn("ACL.reload %s", err)
+		} else {
+			a.keep(counters)
+			a.ipRules = ipRules
+			a.ipOwners = ipOwners
+		}
+	}
+	if !sameEBRules(a.ebRules, ebRules) {
+		counters := make(map[*ACLRule]cn.RuleCounter, len(a.Rules))
+		if values, err := a.ebchain.Counters(); err == nil {
+			a.count(counters, a.ebOwners, values)
+		}
+		if err := a.ebchain.Replace(ebRules); err != nil {
+			a.out.Warn("ACL.reload.eb %s", err)
+		} else {
+			a.keep(counters)
+			a.ebRules = ebRules
+			a.ebOwners = ebOwners
+		}
+	}
+}
+
+// keep saves counters of rules before they're cleared by replacing.
+func (a *ACL) keep(counters map[*ACLRule]cn.RuleCounter) {
+	for ar, obj := range counters {
+		ar.counter.Packets += obj.Packets
+		ar.counter.Bytes += obj.Bytes
+	}
+}
+
 func (a *ACL) addRule(ar *ACLRule) {
 	a.out.Info("ACL.addRule %s", ar.Id())
 
@@ -169,25 +679,40 @@ func (a *ACL) addRule(ar *ACLRule) {
 		return
 	}
 
-	a.ipchain.AddRuleX(ar.ToIPRule())
-	a.ebchain.AddRuleX(ar.ToEBRule())
+	if ar.SrcIdentity != "" {
+		a.identity(ar.SrcIdentity)
+	}
+	if ar.DstIdentity != "" {
+		a.identity(ar.DstIdentity)
+	}
+	a.seq++
+	ar.seq = a.seq
 	a.Rules[ar.Id()] = ar
 }
 
 func (a *ACL) AddRule(rule *schema.ACLRule) error {
-	ar := &ACLRule{
-		Proto:   rule.Proto,
-		DstIp:   rule.DstIp,
-		SrcIp:   rule.SrcIp,
-		DstPort: rule.DstPort,
-		SrcPort: rule.SrcPort,
-		Action:  rule.Action,
+	if a.fastpath {
+		return libol.NewErr("rules are bypassed by fastpath")
+	}
+	ar := NewACLRule(rule)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if err := a.check(ar); err != nil {
+		return err
+	}
+	if ar.Schedule != "" && cache.Schedule.Get(ar.Schedule) == nil {
+		return libol.NewErr("schedule %s notFound", ar.Schedule)
 	}
 	if _, ok := a.Rules[ar.Id()]; ok {
 		return libol.NewErr("AddRule: already existed")
 	}
 
 	a.addRule(ar)
+	if ar.Identified() {
+		a.sync()
+	}
+	a.reload()
 
 	return nil
 }
@@ -197,68 +722,340 @@ func (a *ACL) delRule(ar *ACLRule) {
 		return
 	}
 
-	if err := a.ipchain.DelRuleX(ar.ToIPRule()); err != nil {
-		a.out.Warn("ACL.DelRule %s", err)
+	delete(a.Rules, ar.Id())
+	a.reload()
+	// ipsets are destroyed after rules referenced removed.
+	if ar.SrcIdentity != "" {
+		a.release(ar.SrcIdentity)
 	}
-	if err := a.ebchain.DelRuleX(ar.ToEBRule()); err != nil {
-		a.out.Warn("ACL.DelRule.eb %s", err)
+	if ar.DstIdentity != "" {
+		a.release(ar.DstIdentity)
 	}
-	delete(a.Rules, ar.Id())
 }
 
 func (a *ACL) DelRule(rule *schema.ACLRule) error {
-	ar := &ACLRule{
-		Proto:   rule.Proto,
-		DstIp:   rule.DstIp,
-		SrcIp:   rule.SrcIp,
-		DstPort: rule.DstPort,
-		SrcPort: rule.SrcPort,
-		Action:  rule.Action,
+	ar := NewACLRule(rule)
+	if err := ar.Correct(); err != nil {
+		return err
 	}
 
-	if _, ok := a.Rules[ar.Id()]; !ok {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	older, ok := a.Rules[ar.Id()]
+	if !ok {
 		return nil
 	}
 
-	a.delRule(ar)
+	a.delRule(older)
 
 	return nil
 }
 
 func (a *ACL) FlushRules() {
 	a.out.Info("ACL.FlushRules")
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
 	a.ipchain.Flush()
 	a.ebchain.Flush()
+	a.ipRules, a.ipOwners = nil, nil
+	a.ebRules, a.ebOwners = nil, nil
+	for value, obj := range a.identities {
+		if out, err := obj.ipset.Destroy(); err != nil {
+			a.out.Warn("ACL.FlushRules %s %s", value, out)
+		}
+	}
+	a.identities = make(map[string]*aclIdentity, 32)
 	a.Rules = make(map[string]*ACLRule, 32)
 }
 
 func (a *ACL) ListRules(call func(obj schema.ACLRule)) {
-	for _, rule := range a.Rules {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	counters := a.counters()
+	for _, rule := range a.sorted() {
+		counter := counters[rule]
 		obj := schema.ACLRule{
-			SrcIp:   rule.SrcIp,
-			DstIp:   rule.DstIp,
-			SrcPort: rule.SrcPort,
-			DstPort: rule.DstPort,
-			Proto:   rule.Proto,
-			Action:  rule.Action,
+			Priority:    rule.Priority,
+			SrcIp:       rule.SrcIp,
+			DstIp:       rule.DstIp,
+			SrcIdentity: rule.SrcIdentity,
+			DstIdentity: rule.DstIdentity,
+			SrcPort:     rule.SrcPort,
+			DstPort:     rule.DstPort,
+			SrcPorts:    rule.SrcPorts,
+			DstPorts:    rule.DstPorts,
+			Service:     rule.Service,
+			IcmpType:    rule.IcmpType,
+			Proto:       rule.Proto,
+			Action:      rule.Action,
+			Schedule:    rule.Schedule,
+			Active:      rule.active,
+			Packets:     rule.counter.Packets + counter.Packets,
+			Bytes:       rule.counter.Bytes + counter.Bytes,
 		}
 		call(obj)
 	}
 }
 
 func (a *ACL) SaveRule() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
 	cfg := co.GetAcl(a.Name)
 	cfg.Rules = nil
-	for _, rule := range a.Rules {
+	for _, rule := range a.sorted() {
 		cr := &co.ACLRule{
-			DstIp:   rule.DstIp,
-			SrcIp:   rule.SrcIp,
-			Proto:   rule.Proto,
-			DstPort: rule.DstPort,
-			SrcPort: rule.SrcPort,
-			Action:  rule.Action,
+			Priority:    rule.Priority,
+			DstIp:       rule.DstIp,
+			SrcIp:       rule.SrcIp,
+			SrcIdentity: rule.SrcIdentity,
+			DstIdentity: rule.DstIdentity,
+			Proto:       rule.Proto,
+			DstPort:     rule.DstPort,
+			SrcPort:     rule.SrcPort,
+			Service:     rule.Service,
+			IcmpType:    rule.IcmpType,
+			Action:      rule.Action,
+			Schedule:    rule.Schedule,
+		}
+		// a single port is saved in sport or dport.
+		if rule.DstPort == 0 {
+			cr.DstPorts = rule.DstPorts
+		}
+		if rule.SrcPort == 0 {
+			cr.SrcPorts = rule.SrcPorts
 		}
 		cfg.Rules = append(cfg.Rules, cr)
 	}
+	cfg.Groups = nil
+	for _, group := range a.Groups {
+		cfg.Groups = append(cfg.Groups, &co.ACLGroup{
+			Name:    group.Name,
+			Type:    group.Type,
+			Members: group.Members,
+		})
+	}
+	sort.Slice(cfg.Groups, func(i, j int) bool {
+		return cfg.Groups[i].Name < cfg.Groups[j].Name
+	})
 	cfg.Save()
 }
+
+func (a *ACL) addGroup(ag *ACLGroup) error {
+	if err := ag.Correct(); err != nil {
+		return err
+	}
+	older, ok := a.Groups[ag.Name]
+	if ok && older.Type != ag.Type {
+		for _, rule := range a.Rules {
+			if a.referred(rule, ag.Name) {
+				return libol.NewErr("group %s in use", ag.Name)
+			}
+		}
+	}
+	if err := ag.Install(); err != nil {
+		return err
+	}
+	a.Groups[ag.Name] = ag
+	if ok {
+		a.reload()
+		if older.Type != ag.Type {
+			older.Uninstall()
+		} else {
+			older.Uninstall(ag.Keys()...)
+		}
+	}
+	return nil
+}
+
+func (a *ACL) referred(ar *ACLRule, name string) bool {
+	for _, value := range []string{ar.SrcIp, ar.DstIp, ar.SrcPorts, ar.DstPorts} {
+		if isGroup(value) && groupName(value) == name {
+			return true
+		}
+	}
+	return ar.Service == name
+}
+
+func (a *ACL) AddGroup(group *schema.ACLGroup) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	ag := &ACLGroup{
+		acl:     a.Name,
+		Name:    group.Name,
+		Type:    group.Type,
+		Members: append([]string{}, group.Members...),
+	}
+	return a.addGroup(ag)
+}
+
+func (a *ACL) DelGroup(name string) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	group, ok := a.Groups[name]
+	if !ok {
+		return libol.NewErr("group %s notFound", name)
+	}
+	for _, rule := range a.Rules {
+		if a.referred(rule, name) {
+			return libol.NewErr("group %s in use", name)
+		}
+	}
+	group.Uninstall()
+	delete(a.Groups, name)
+	return nil
+}
+
+func (a *ACL) ListGroups(call func(obj schema.ACLGroup)) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for _, group := range a.Groups {
+		call(schema.ACLGroup{
+			Name:    group.Name,
+			Type:    group.Type,
+			Members: group.Members,
+		})
+	}
+}
+
+// online returns users connected by access or openvpn, and its address.
+func (a *ACL) online() []aclUser {
+	users := make([]aclUser, 0, 32)
+	for obj := range cache.Access.List() {
+		if obj == nil {
+			break
+		}
+		if obj.Network != a.Name || obj.User == "" {
+			continue
+		}
+		if lease := cache.Network.GetLease(obj.Alias, obj.Network); lease != nil {
+			users = append(users, aclUser{name: obj.User, address: lease.Address, restricted: obj.Restricted})
+		}
+	}
+	for obj := range cache.VPNClient.List(a.Name) {
+		if obj == nil {
+			break
+		}
+		if obj.Address == "" {
+			continue
+		}
+		name := strings.SplitN(obj.Name, "@", 2)[0]
+		users = append(users, aclUser{name: name, address: obj.Address})
+	}
+	return users
+}
+
+// match checks whether an user is in an identity by name, role, groups
+// or posture of its device.
+func (a *ACL) match(value string, online aclUser) bool {
+	kind, target, err := ParseIdentity(value)
+	if err != nil {
+		return false
+	}
+	switch kind {
+	case IdentityUser:
+		return online.name == target
+	case IdentityPosture:
+		return online.restricted == (target == "restricted")
+	}
+	user := cache.User.Get(online.name + "@" + a.Name)
+	if user == nil {
+		return false
+	}
+	if kind == IdentityRole {
+		return user.Role == target
+	}
+	for _, group := range user.Groups {
+		if group == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *ACL) resolve(value string, users []aclUser) map[string]bool {
+	addrs := make(map[string]bool, 8)
+	for _, user := range users {
+		if a.match(value, user) {
+			addrs[user.address] = true
+		}
+	}
+	return addrs
+}
+
+// Sync updates ipsets of identities by online users, and reloads rules
+// if addresses of identities changed or at boundaries of windows. Rules
+// of iptables match ipsets and are only replaced if rendered different.
+func (a *ACL) Sync() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	changed := a.sync()
+	if a.scheduled() {
+		changed = true
+	}
+	if changed {
+		a.reload()
+	}
+}
+
+// Scheduled returns rules referencing a schedule.
+func (a *ACL) Scheduled(name string) []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	refs := make([]string, 0, 4)
+	for _, ar := range a.sorted() {
+		if ar.Schedule == name {
+			refs = append(refs, "acl "+a.Name+" "+ar.Id())
+		}
+	}
+	return refs
+}
+
+// scheduled returns true if state of any rule changed by its schedule.
+func (a *ACL) scheduled() bool {
+	changed := false
+	for _, ar := range a.Rules {
+		if active := cache.Schedule.Active(ar.Schedule); active != ar.active {
+			a.out.Info("ACL.scheduled %s active: %t", ar.Id(), active)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// sync updates ipsets of identities, and returns true if changed.
+func (a *ACL) sync() bool {
+	if len(a.identities) == 0 {
+		return false
+	}
+	changed := false
+	users := a.online()
+	for value, obj := range a.identities {
+		addrs := a.resolve(value, users)
+		for addr := range addrs {
+			if obj.addrs[addr] {
+				continue
+			}
+			changed = true
+			if out, err := obj.ipset.Add(addr); err != nil {
+				a.out.Warn("ACL.sync %s %s", value, out)
+			}
+		}
+		for addr := range obj.addrs {
+			if addrs[addr] {
+				continue
+			}
+			changed = true
+			if out, err := obj.ipset.Del(addr); err != nil {
+				a.out.Warn("ACL.sync %s %s", value, out)
+			}
+		}
+		obj.addrs = addrs
+	}
+	return changed
+}
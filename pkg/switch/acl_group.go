@@ -0,0 +1,217 @@
+package cswitch
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/luscis/openlan/pkg/libol"
+	cn "github.com/luscis/openlan/pkg/network"
+)
+
+const (
+	GroupAddress = "address"
+	GroupPort    = "port"
+	GroupService = "service"
+)
+
+// ParsePorts checks a port or range of MIN-MAX, and returns it in
+// format of ipset.
+func ParsePorts(value string) (string, error) {
+	values := strings.SplitN(strings.Replace(value, ":", "-", 1), "-", 2)
+	ports := make([]int, 0, 2)
+	for _, v := range values {
+		port, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || port < 0 || port > 65535 {
+			return "", libol.NewErr("invalid port %q", value)
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 1 {
+		return strconv.Itoa(ports[0]), nil
+	}
+	if ports[0] > ports[1] {
+		return "", libol.NewErr("invalid port %q", value)
+	}
+	return fmt.Sprintf("%d-%d", ports[0], ports[1]), nil
+}
+
+// tablePorts returns ports in format of iptables and ebtables.
+func tablePorts(value string) string {
+	return strings.Replace(value, "-", ":", 1)
+}
+
+// aclService is a member of service group like tcp:80, udp:1000-2000 or
+// icmp:8, and it's any port or type if not given.
+type aclService struct {
+	Proto string
+	Port  string // port or range for tcp and udp, and type for icmp.
+}
+
+func parseService(value string) (aclService, error) {
+	values := strings.SplitN(strings.ToLower(value), ":", 2)
+	s := aclService{Proto: values[0]}
+	if len(values) > 1 {
+		s.Port = values[1]
+	}
+	switch s.Proto {
+	case "tcp", "udp":
+		if s.Port != "" {
+			port, err := ParsePorts(s.Port)
+			if err != nil {
+				return s, err
+			}
+			s.Port = port
+		}
+	case "icmp":
+	default:
+		return s, libol.NewErr("invalid service %q", value)
+	}
+	return s, nil
+}
+
+// ACLGroup is a reusable object of addresses, ports or services, and
+// referenced by rules in name.
+type ACLGroup struct {
+	acl     string
+	Name    string
+	Type    string // address, port or service.
+	Members []string
+}
+
+func (g *ACLGroup) Correct() error {
+	if g.Name == "" {
+		return libol.NewErr("group without name")
+	}
+	if g.Type == "" {
+		g.Type = GroupAddress
+	}
+	for i, member := range g.Members {
+		member = strings.TrimSpace(member)
+		switch g.Type {
+		case GroupAddress:
+			if net.ParseIP(member) == nil {
+				if _, _, err := net.ParseCIDR(member); err != nil {
+					return libol.NewErr("invalid address %q", member)
+				}
+			}
+		case GroupPort:
+			port, err := ParsePorts(member)
+			if err != nil {
+				return err
+			}
+			member = port
+		case GroupService:
+			s, err := parseService(member)
+			if err != nil {
+				return err
+			}
+			member = s.Proto
+			if s.Port != "" {
+				member += ":" + s.Port
+			}
+		default:
+			return libol.NewErr("invalid group type %q", g.Type)
+		}
+		g.Members[i] = member
+	}
+	return nil
+}
+
+func (g *ACLGroup) Services() []aclService {
+	services := make([]aclService, 0, len(g.Members))
+	if g.Type != GroupService {
+		return services
+	}
+	for _, member := range g.Members {
+		if s, err := parseService(member); err == nil {
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+// Keys returns keys of ipsets, and it's protocols with ports for service.
+func (g *ACLGroup) Keys() []string {
+	if g.Type != GroupService {
+		return []string{""}
+	}
+	keys := make([]string, 0, 2)
+	for _, s := range g.Services() {
+		if s.Proto == "icmp" || s.Port == "" {
+			continue
+		}
+		found := false
+		for _, key := range keys {
+			if key == s.Proto {
+				found = true
+			}
+		}
+		if !found {
+			keys = append(keys, s.Proto)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetName returns name of ipset by key, and it's limited in 31 characters.
+func (g *ACLGroup) SetName(key string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(g.acl + "/" + g.Name + "/" + key))
+	return fmt.Sprintf("TT_ACLG_%08x", hash.Sum32())
+}
+
+func (g *ACLGroup) newSet(key string) *cn.IPSet {
+	set := &cn.IPSet{Name: g.SetName(key), Type: "hash:net"}
+	if g.Type != GroupAddress {
+		set.Type = "bitmap:port"
+		set.Options = []string{"range", "0-65535"}
+	}
+	return set
+}
+
+func (g *ACLGroup) Install() error {
+	for _, key := range g.Keys() {
+		set := g.newSet(key)
+		if out, err := set.Clear(); err != nil {
+			return libol.NewErr("%s %s", set.Name, out)
+		}
+		for _, member := range g.Members {
+			value := member
+			if g.Type == GroupService {
+				s, _ := parseService(member)
+				if s.Proto != key || s.Port == "" {
+					continue
+				}
+				value = s.Port
+			}
+			if out, err := set.Add(value); err != nil {
+				return libol.NewErr("%s %s", set.Name, out)
+			}
+		}
+	}
+	return nil
+}
+
+// Uninstall destroys ipsets not in keys given.
+func (g *ACLGroup) Uninstall(keeps ...string) {
+	for _, key := range g.Keys() {
+		found := false
+		for _, keep := range keeps {
+			if keep == key {
+				found = true
+			}
+		}
+		if found {
+			continue
+		}
+		set := g.newSet(key)
+		if out, err := set.Destroy(); err != nil {
+			libol.Warn("ACLGroup.Uninstall %s %s", set.Name, out)
+		}
+	}
+}
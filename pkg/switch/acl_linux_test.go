@@ -0,0 +1,214 @@
+package cswitch
+
+import (
+	"testing"
+
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/models"
+	cn "github.com/luscis/openlan/pkg/network"
+)
+
+func TestACLIdentity(t *testing.T) {
+	for value, expect := range map[string]string{
+		"alice":              "user:alice",
+		"user:bob@fake":      "user:bob",
+		"group:dev":          "group:dev",
+		"role:admin":         "role:admin",
+		"team:dev":           "",
+		"group:":             "",
+		"role:guest:more":    "role:guest:more",
+		"posture:restricted": "posture:restricted",
+		"posture:bad":        "",
+	} {
+		kind, name, err := ParseIdentity(value)
+		if expect == "" {
+			if err == nil {
+				t.Errorf("identity %s not invalid", value)
+			}
+			continue
+		}
+		if err != nil || kind+":"+name != expect {
+			t.Errorf("identity %s: %s:%s %v", value, kind, name, err)
+		}
+	}
+
+	ar := &ACLRule{SrcIp: "10.0.0.1", SrcIdentity: "alice"}
+	if err := ar.Correct(); err == nil {
+		t.Errorf("mixed source not invalid")
+	}
+	ar = &ACLRule{SrcIdentity: "alice", DstIp: "10.0.0.9", Action: "drop"}
+	if err := ar.Correct(); err != nil || ar.SrcIdentity != "user:alice" {
+		t.Errorf("correct %s %v", ar.SrcIdentity, err)
+	}
+
+	acl := NewACL("fake-acl")
+	acl.identities[ar.SrcIdentity] = &aclIdentity{ipset: cn.NewIPSet("fake", "hash:ip")}
+	if rules := acl.toEBRules(ar); len(rules) != 0 {
+		t.Errorf("rules of offline %v", rules)
+	}
+	acl.identities[ar.SrcIdentity].addrs = map[string]bool{"10.0.0.3": true, "10.0.0.2": true}
+	rules := acl.toEBRules(ar)
+	if len(rules) != 2 || rules[1].Source != "10.0.0.3" || rules[1].Dest != "10.0.0.9" {
+		t.Errorf("rules %v", rules)
+	}
+	ipRules := acl.toIPRules(ar)
+	if len(ipRules) != 1 || ipRules[0].SrcSet != "TT_fake" {
+		t.Errorf("rules %v", ipRules)
+	}
+
+	// addresses changed only replace rules of ebtables.
+	acl.identities[ar.SrcIdentity].addrs = map[string]bool{"10.0.0.2": true}
+	if !sameIPRules(ipRules, acl.toIPRules(ar)) {
+		t.Errorf("ip rules changed by addresses")
+	}
+	if sameEBRules(rules, acl.toEBRules(ar)) {
+		t.Errorf("eb rules not changed by addresses")
+	}
+}
+
+func TestACLGroup(t *testing.T) {
+	for value, expect := range map[string]string{
+		"80":        "80",
+		"1000-2000": "1000-2000",
+		"1000:2000": "1000-2000",
+		"2000-1000": "",
+		"65536":     "",
+		"http":      "",
+	} {
+		ports, err := ParsePorts(value)
+		if (expect == "" && err == nil) || ports != expect {
+			t.Errorf("ports %s: %s %v", value, ports, err)
+		}
+	}
+
+	acl := NewACL("fake-acl")
+	groups := []*ACLGroup{
+		{acl: acl.Name, Name: "office", Members: []string{"10.0.0.0/24", "10.1.0.1"}},
+		{acl: acl.Name, Name: "high", Type: GroupPort, Members: []string{"8000:8080"}},
+		{acl: acl.Name, Name: "web", Type: GroupService, Members: []string{"tcp:80", "TCP:443", "udp:53", "icmp:8", "udp"}},
+	}
+	for _, group := range groups {
+		if err := group.Correct(); err != nil {
+			t.Fatalf("group %s %v", group.Name, err)
+		}
+		acl.Groups[group.Name] = group
+	}
+	if err := (&ACLGroup{Name: "bad", Members: []string{"10.0.0.256"}}).Correct(); err == nil {
+		t.Errorf("bad address not invalid")
+	}
+	if err := (&ACLGroup{Name: "bad", Type: GroupService, Members: []string{"gre"}}).Correct(); err == nil {
+		t.Errorf("bad service not invalid")
+	}
+	if keys := groups[2].Keys(); len(keys) != 2 || keys[0] != "tcp" || keys[1] != "udp" {
+		t.Errorf("keys %v", keys)
+	}
+	if groups[0].SetName("") == groups[2].SetName("tcp") || len(groups[0].SetName("")) > 31 {
+		t.Errorf("set name %s", groups[0].SetName(""))
+	}
+
+	rules := []*ACLRule{
+		{SrcIp: "@office", Service: "@web", Action: "accept"},
+		{DstIp: "@office", Proto: "tcp", DstPorts: "@high", Priority: 10, Action: "drop"},
+		{DstIp: "10.0.0.9", IcmpType: "8", Priority: 10, Action: "drop"},
+	}
+	for _, ar := range rules {
+		if err := acl.check(ar); err != nil {
+			t.Fatalf("check %s %v", ar.Id(), err)
+		}
+		acl.addRule(ar)
+	}
+	for _, ar := range []*ACLRule{
+		{SrcIp: "@web", Action: "drop"},
+		{SrcIp: "@none", Action: "drop"},
+		{Service: "web", Proto: "tcp", Action: "drop"},
+		{DstPorts: "80", Action: "drop"},
+		{Proto: "udp", IcmpType: "8", Action: "drop"},
+	} {
+		if err := acl.check(ar); err == nil {
+			t.Errorf("check %s not invalid", ar.Id())
+		}
+	}
+
+	sorted := acl.sorted()
+	if sorted[0] != rules[1] || sorted[1] != rules[2] || sorted[2] != rules[0] {
+		t.Errorf("sorted %v", sorted)
+	}
+	if rules[2].Proto != "icmp" || rules[0].Priority != 100 {
+		t.Errorf("correct %+v", rules[2])
+	}
+
+	ipRules := acl.toIPRules(rules[0])
+	if len(ipRules) != 4 || ipRules[0].DstPortSet != groups[2].SetName("tcp") || ipRules[2].IcmpType != "8" || ipRules[3].Proto != "udp" {
+		t.Errorf("ip rules %+v", ipRules)
+	}
+	if ipRules[0].SrcSet != groups[0].SetName("") {
+		t.Errorf("ip rules %+v", ipRules[0])
+	}
+	ipRules = acl.toIPRules(rules[1])
+	if len(ipRules) != 1 || ipRules[0].DstPortSet != groups[1].SetName("") || ipRules[0].DestSet != groups[0].SetName("") {
+		t.Errorf("ip rules %+v", ipRules)
+	}
+	// expanded by 2 addresses and 5 services.
+	ebRules := acl.toEBRules(rules[0])
+	if len(ebRules) != 10 || ebRules[0].DstPort != "80" || ebRules[9].Source != "10.1.0.1" {
+		t.Errorf("eb rules %+v", ebRules)
+	}
+	ebRules = acl.toEBRules(rules[1])
+	if len(ebRules) != 2 || ebRules[0].DstPort != "8000:8080" {
+		t.Errorf("eb rules %+v", ebRules)
+	}
+
+	if !acl.referred(rules[1], "high") || acl.referred(rules[2], "office") {
+		t.Errorf("referred")
+	}
+}
+
+func TestACLResolve(t *testing.T) {
+	acl := NewACL("fake-acl")
+	alice := &models.User{Name: "alice", Network: "fake-acl", Role: "admin", Groups: []string{"dev", "ops"}}
+	bob := &models.User{Name: "bob", Network: "fake-acl", Role: "guest", Groups: []string{"dev"}}
+	cache.User.Add(alice)
+	cache.User.Add(bob)
+	defer cache.User.Del(alice.Id())
+	defer cache.User.Del(bob.Id())
+
+	users := []aclUser{
+		{name: "alice", address: "10.0.0.2"},
+		{name: "bob", address: "10.0.0.3"},
+		{name: "carol", address: "10.0.0.4", restricted: true},
+	}
+	for value, expect := range map[string][]string{
+		"user:carol":         {"10.0.0.4"},
+		"group:dev":          {"10.0.0.2", "10.0.0.3"},
+		"group:ops":          {"10.0.0.2"},
+		"role:guest":         {"10.0.0.3"},
+		"role:ldap":          {},
+		"group:sales":        {},
+		"posture:restricted": {"10.0.0.4"},
+		"posture:compliant":  {"10.0.0.2", "10.0.0.3"},
+	} {
+		addrs := acl.resolve(value, users)
+		if len(addrs) != len(expect) {
+			t.Errorf("resolve %s %v", value, addrs)
+			continue
+		}
+		for _, addr := range expect {
+			if !addrs[addr] {
+				t.Errorf("resolve %s %v", value, addrs)
+			}
+		}
+	}
+}
+
+func TestACLScheduled(t *testing.T) {
+	acl := NewACL("fake-acl")
+	acl.addRule(&ACLRule{SrcIp: "10.0.0.1", Action: "DROP", Schedule: "work"})
+	acl.addRule(&ACLRule{SrcIp: "10.0.0.2", Action: "DROP"})
+	refs := acl.Scheduled("work")
+	if len(refs) != 1 || refs[0] != "acl fake-acl "+acl.sorted()[0].Id() {
+		t.Errorf("scheduled %v", refs)
+	}
+	if refs := acl.Scheduled("night"); len(refs) != 0 {
+		t.Errorf("scheduled %v", refs)
+	}
+}
@@ -1,7 +1,10 @@
 package cswitch
 
 import (
+	"io"
+	"net"
 	"os/exec"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -16,6 +19,15 @@ const (
 	BgpEtc = "/etc/frr/frr.conf"
 )
 
+// BgpStatus is the live state of a neighbor from FRR.
+type BgpStatus struct {
+	State    string `json:"state"`
+	Uptime   int64  `json:"uptime"`
+	Received int    `json:"received"`
+	Sent     int    `json:"sent"`
+	Bfd      string `json:"bfd"`
+}
+
 type BgpWorker struct {
 	*WorkerImpl
 	spec *co.BgpSpecifies
@@ -42,6 +54,12 @@ router bgp {{ .LocalAs }}
  {{-  if .Password }}
  neighbor {{ .Address }} password {{ .Password }}
  {{- end }}
+ {{- if .Bfd }}
+ neighbor {{ .Address }} bfd
+ {{- end }}
+ {{- if .Graceful }}
+ neighbor {{ .Address }} graceful-restart
+ {{- end }}
  {{- end }}
  !
  address-family ipv4 unicast
@@ -53,6 +71,15 @@ router bgp {{ .LocalAs }}
   neighbor {{ .Address }} route-map {{ .Address }}-out out
   {{- end }}
  exit-address-family
+ {{- if .Vnis }}
+ !
+ address-family l2vpn evpn
+  {{- range .Neighbors }}
+  neighbor {{ .Address }} activate
+  {{- end }}
+  advertise-all-vni
+ exit-address-family
+ {{- end }}
 !
 
 {{- range $nei := .Neighbors }}
@@ -70,12 +97,24 @@ ip prefix-list {{ $nei.Address }}-in seq 65535 deny any
 {{- range .Neighbors }}
 route-map {{ .Address }}-in permit 10
  match ip address prefix-list {{ .Address }}-in
+ {{- if .LocalPref }}
+ set local-preference {{ .LocalPref }}
+ {{- end }}
 !
 {{- end }}
 
 {{- range .Neighbors }}
 route-map {{ .Address }}-out permit 10
  match ip address prefix-list {{ .Address }}-out
+ {{- if .Med }}
+ set metric {{ .Med }}
+ {{- end }}
+ {{- if .Prepend }}
+ set as-path prepend {{ prepend $.LocalAs .Prepend }}
+ {{- end }}
+ {{- if .Communities }}
+ set community {{ join .Communities " " }} additive
+ {{- end }}
 !
 {{- end }}
 {{- end }}
@@ -87,6 +126,49 @@ func (w *BgpWorker) Initialize() {
 	w.addCache()
 }
 
+// Vnis returns VNIs of networks using this bgp as fabric.
+func (w *BgpWorker) Vnis() []uint32 {
+	var vnis []uint32
+	sw := co.Get()
+	if sw == nil {
+		return vnis
+	}
+	for _, obj := range sw.Network {
+		if obj.VxLAN != nil && obj.VxLAN.Fabric == w.cfg.Name {
+			vnis = append(vnis, obj.VxLAN.Vni)
+		}
+	}
+	return vnis
+}
+
+func (w *BgpWorker) render(out io.Writer) error {
+	maps := template.FuncMap{
+		"inc": func(i int) int {
+			return i + 1
+		},
+		"prepend": func(as, times int) string {
+			values := make([]string, times)
+			for i := range values {
+				values[i] = strconv.Itoa(as)
+			}
+			return strings.Join(values, " ")
+		},
+		"join": strings.Join,
+	}
+	obj, err := template.New("main").Funcs(maps).Parse(BgpTmpl)
+	if err != nil {
+		return err
+	}
+	data := struct {
+		*co.BgpSpecifies
+		Vnis []uint32
+	}{
+		BgpSpecifies: w.spec,
+		Vnis:         w.Vnis(),
+	}
+	return obj.Execute(out, data)
+}
+
 func (w *BgpWorker) save() {
 	file := BgpEtc
 	out, err := libol.CreateFile(file)
@@ -95,17 +177,8 @@ func (w *BgpWorker) save() {
 	}
 	defer out.Close()
 
-	maps := template.FuncMap{
-		"inc": func(i int) int {
-			return i + 1
-		},
-	}
-	if obj, err := template.New("main").Funcs(maps).Parse(BgpTmpl); err != nil {
+	if err := w.render(out); err != nil {
 		w.out.Warn("BgpWorker.save: %s", err)
-	} else {
-		if err := obj.Execute(out, w.spec); err != nil {
-			w.out.Warn("BgpWorker.save: %s", err)
-		}
 	}
 }
 
@@ -146,9 +219,7 @@ func (w *BgpWorker) Get() *schema.Bgp {
 		RouterId: w.spec.RouterId,
 	}
 
-	show := map[string]struct {
-		State string `json:"state"`
-	}{}
+	show := map[string]BgpStatus{}
 	out, err := exec.Command(BgpBin, "--show-neighbors").CombinedOutput()
 	if err == nil {
 		if err := libol.Unmarshal(&show, out); err != nil {
@@ -160,14 +231,24 @@ func (w *BgpWorker) Get() *schema.Bgp {
 
 	for _, nei := range w.spec.Neighbors {
 		obj := schema.BgpNeighbor{
-			Address:  nei.Address,
-			RemoteAs: nei.RemoteAs,
-			Password: nei.Password,
-			Receives: nei.Receives,
-			Advertis: nei.Advertis,
+			Address:     nei.Address,
+			RemoteAs:    nei.RemoteAs,
+			Password:    nei.Password,
+			Receives:    nei.Receives,
+			Advertis:    nei.Advertis,
+			LocalPref:   nei.LocalPref,
+			Med:         nei.Med,
+			Prepend:     nei.Prepend,
+			Communities: nei.Communities,
+			Bfd:         nei.Bfd,
+			Graceful:    nei.Graceful,
 		}
 		if state, ok := show[nei.Address]; ok {
 			obj.State = strings.ToLower(state.State)
+			obj.Uptime = state.Uptime
+			obj.Received = state.Received
+			obj.Sent = state.Sent
+			obj.BfdState = strings.ToLower(state.Bfd)
 		}
 		data.Neighbors = append(data.Neighbors, obj)
 	}
@@ -176,9 +257,15 @@ func (w *BgpWorker) Get() *schema.Bgp {
 
 func (w *BgpWorker) AddNeighbor(data schema.BgpNeighbor) {
 	obj := &co.BgpNeighbor{
-		Address:  data.Address,
-		RemoteAs: data.RemoteAs,
-		Password: data.Password,
+		Address:     data.Address,
+		RemoteAs:    data.RemoteAs,
+		Password:    data.Password,
+		LocalPref:   data.LocalPref,
+		Med:         data.Med,
+		Prepend:     data.Prepend,
+		Communities: data.Communities,
+		Bfd:         data.Bfd,
+		Graceful:    data.Graceful,
 	}
 	obj.Correct()
 	if nei, _ := w.spec.FindNeighbor(obj); nei == nil {
@@ -186,6 +273,12 @@ func (w *BgpWorker) AddNeighbor(data schema.BgpNeighbor) {
 	} else {
 		nei.RemoteAs = data.RemoteAs
 		nei.Password = data.Password
+		nei.LocalPref = data.LocalPref
+		nei.Med = data.Med
+		nei.Prepend = data.Prepend
+		nei.Communities = data.Communities
+		nei.Bfd = data.Bfd
+		nei.Graceful = data.Graceful
 	}
 	w.reload()
 }
@@ -244,3 +337,21 @@ func (w *BgpWorker) DelAdvertis(data schema.BgpPrefix) {
 		}
 	}
 }
+
+func (w *BgpWorker) ListRoutes(neighbor string) ([]schema.BgpRoute, error) {
+	if net.ParseIP(neighbor) == nil {
+		return nil, libol.NewErr("invalid neighbor %s", neighbor)
+	}
+	if nei, _ := w.spec.FindNeighbor(&co.BgpNeighbor{Address: neighbor}); nei == nil {
+		return nil, libol.NewErr("neighbor %s not found", neighbor)
+	}
+	out, err := exec.Command(BgpBin, "--show-routes", neighbor).CombinedOutput()
+	if err != nil {
+		return nil, libol.NewErr("%s: %s", err, out)
+	}
+	routes := make([]schema.BgpRoute, 0, 32)
+	if err := libol.Unmarshal(&routes, out); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
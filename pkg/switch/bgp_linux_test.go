@@ -0,0 +1,101 @@
+package cswitch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	co "github.com/luscis/openlan/pkg/config"
+)
+
+func TestBgpWorkerRenderEvpn(t *testing.T) {
+	sw := &co.Switch{
+		Network: map[string]*co.Network{},
+	}
+	spec := &co.BgpSpecifies{
+		LocalAs:  65001,
+		RouterId: "10.0.0.1",
+		Neighbors: []*co.BgpNeighbor{
+			{Address: "10.0.0.2", RemoteAs: 65001},
+		},
+	}
+	sw.Network["bgp"] = &co.Network{Name: "bgp", Provider: "bgp", Specifies: spec}
+	co.Update(sw)
+	defer co.Update(nil)
+
+	w := NewBgpWorker(sw.Network["bgp"])
+	var out bytes.Buffer
+	if err := w.render(&out); err != nil {
+		t.Fatalf("render: %s", err)
+	}
+	if strings.Contains(out.String(), "l2vpn evpn") {
+		t.Fatalf("unexpected evpn without vxlan networks")
+	}
+
+	sw.Network["net-a"] = &co.Network{
+		Name:  "net-a",
+		VxLAN: &co.VxLANSpecifies{Vni: 100, Fabric: "bgp"},
+	}
+	out.Reset()
+	if err := w.render(&out); err != nil {
+		t.Fatalf("render: %s", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "address-family l2vpn evpn") || !strings.Contains(text, "advertise-all-vni") {
+		t.Fatalf("expected evpn in config:\n%s", text)
+	}
+	if !strings.Contains(text, "neighbor 10.0.0.2 activate") {
+		t.Fatalf("expected neighbor activated:\n%s", text)
+	}
+}
+
+func TestBgpWorkerRenderPolicy(t *testing.T) {
+	sw := &co.Switch{
+		Network: map[string]*co.Network{},
+	}
+	spec := &co.BgpSpecifies{
+		LocalAs:  65001,
+		RouterId: "10.0.0.1",
+		Neighbors: []*co.BgpNeighbor{
+			{
+				Address:     "10.0.0.2",
+				RemoteAs:    65002,
+				LocalPref:   200,
+				Med:         50,
+				Prepend:     2,
+				Communities: []string{"65001:100", "no-export"},
+				Bfd:         true,
+				Graceful:    true,
+			},
+			{Address: "10.0.0.3", RemoteAs: 65003},
+		},
+	}
+	sw.Network["bgp"] = &co.Network{Name: "bgp", Provider: "bgp", Specifies: spec}
+	co.Update(sw)
+	defer co.Update(nil)
+
+	w := NewBgpWorker(sw.Network["bgp"])
+	var out bytes.Buffer
+	if err := w.render(&out); err != nil {
+		t.Fatalf("render: %s", err)
+	}
+	text := out.String()
+	for _, line := range []string{
+		"neighbor 10.0.0.2 bfd",
+		"neighbor 10.0.0.2 graceful-restart",
+		"set local-preference 200",
+		"set metric 50",
+		"set as-path prepend 65001 65001",
+		"set community 65001:100 no-export additive",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("expected %q in config:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "neighbor 10.0.0.3 bfd") {
+		t.Errorf("unexpected bfd for 10.0.0.3:\n%s", text)
+	}
+	if strings.Count(text, "set metric") != 1 {
+		t.Errorf("expected only one metric:\n%s", text)
+	}
+}
@@ -0,0 +1,339 @@
+package cswitch
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luscis/openlan/pkg/api"
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/network"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+const (
+	CaptureDir = "/var/openlan/capture"
+)
+
+const (
+	captureSessions = 4
+	captureCount    = 100
+	captureMaxCount = 100000
+	captureSeconds  = 10
+	captureMaxWait  = 60   // seconds of streaming, less than timeout of http.
+	captureMaxFile  = 3600 // seconds of saving to file.
+)
+
+type captureSource interface {
+	Name() string
+	Read(p []byte) (int, error)
+	Close() error
+}
+
+// fastCapture reads frames of a fastpath port by mirroring, because it has
+// no device in kernel.
+type fastCapture struct {
+	port   *FastPort
+	frames chan []byte
+}
+
+func newFastCapture(port *FastPort) (*fastCapture, error) {
+	c := &fastCapture{
+		port:   port,
+		frames: make(chan []byte, 1024),
+	}
+	mirror := func(data []byte) {
+		select {
+		case c.frames <- append([]byte{}, data...):
+		default: // dropped if reading is slow.
+		}
+	}
+	if !port.SetMirror(mirror) {
+		return nil, libol.NewErr("%s already captured", port.Name())
+	}
+	return c, nil
+}
+
+func (c *fastCapture) Name() string {
+	return c.port.Name()
+}
+
+func (c *fastCapture) Read(p []byte) (int, error) {
+	select {
+	case data := <-c.frames:
+		return copy(p, data), nil
+	case <-time.After(time.Second):
+		return 0, nil
+	}
+}
+
+func (c *fastCapture) Close() error {
+	c.port.SetMirror(nil)
+	return nil
+}
+
+type captureSession struct {
+	lock   sync.Mutex
+	state  schema.CaptureState
+	source captureSource
+	filter *libol.PacketFilter
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func (s *captureSession) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *captureSession) State() schema.CaptureState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state
+}
+
+// run writes frames matched in pcap until count or seconds reached.
+func (s *captureSession) run(w io.Writer) error {
+	defer s.source.Close()
+
+	pcap, err := libol.NewPcapWriter(w, libol.PcapSnapLen)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, libol.PcapSnapLen)
+	deadline := time.Now().Add(time.Duration(s.state.Seconds) * time.Second)
+	for packets := 0; packets < s.state.Count && time.Now().Before(deadline); {
+		select {
+		case <-s.stop:
+			return nil
+		default:
+		}
+		n, err := s.source.Read(buf)
+		if err != nil {
+			return err
+		}
+		if n == 0 || !s.filter.Match(buf[:n]) {
+			continue
+		}
+		if err := pcap.Write(time.Now(), buf[:n]); err != nil {
+			return err
+		}
+		packets++
+		s.lock.Lock()
+		s.state.Packets = packets
+		s.lock.Unlock()
+	}
+	return nil
+}
+
+// Capturer runs bounded captures on bridges, access clients and outputs.
+type Capturer struct {
+	lock     sync.Mutex
+	sessions map[string]*captureSession
+	out      *libol.SubLogger
+}
+
+func NewCapturer() *Capturer {
+	return &Capturer{
+		sessions: make(map[string]*captureSession, captureSessions),
+		out:      libol.NewSubLogger("capture"),
+	}
+}
+
+func (c *Capturer) add(s *captureSession) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.sessions) >= captureSessions {
+		return libol.NewErr("too many captures")
+	}
+	c.sessions[s.state.Id] = s
+	return nil
+}
+
+func (c *Capturer) del(id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.sessions, id)
+}
+
+// Run captures by a session added, and deletes it after finished.
+func (c *Capturer) Run(s *captureSession, w io.Writer) error {
+	defer c.del(s.state.Id)
+
+	c.out.Info("Capturer.Run: %s on %s filter %q", s.state.Id, s.state.Device, s.state.Filter)
+	err := s.run(w)
+	state := s.State()
+	if err != nil {
+		c.out.Warn("Capturer.Run: %s %s", state.Id, err)
+	}
+	c.out.Info("Capturer.Run: %s finished with %d packets", state.Id, state.Packets)
+	return err
+}
+
+func (c *Capturer) Stop(id string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s, ok := c.sessions[id]
+	if !ok {
+		return libol.NewErr("capture %s notFound", id)
+	}
+	s.Stop()
+	return nil
+}
+
+func (c *Capturer) List(call func(obj schema.CaptureState)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, s := range c.sessions {
+		call(s.State())
+	}
+}
+
+func (v *Switch) captureSource(data schema.Capture) (captureSource, error) {
+	w, ok := v.getWorker(data.Network)
+	if !ok {
+		return nil, libol.NewErr("network %s notFound", data.Network)
+	}
+	if data.Client != "" {
+		var device network.Taper
+		for obj := range cache.Access.List() {
+			if obj == nil {
+				break
+			}
+			if obj.Network != data.Network || obj.Device == nil {
+				continue
+			}
+			if obj.Alias == data.Client || (obj.Client != nil && obj.Client.String() == data.Client) {
+				device = obj.Device
+			}
+		}
+		if device == nil {
+			return nil, libol.NewErr("client %s notFound", data.Client)
+		}
+		if port, ok := device.(*FastPort); ok {
+			return newFastCapture(port)
+		}
+		return network.NewCapture(device.Name())
+	}
+	if data.Output != "" {
+		device := ""
+		for obj := range cache.Output.List(data.Network) {
+			if obj == nil {
+				break
+			}
+			state := obj.GetState()
+			if obj.Device != data.Output {
+				continue
+			}
+			if state == "down" {
+				return nil, libol.NewErr("output %s is down", data.Output)
+			}
+			device = obj.Device
+		}
+		if device == "" {
+			return nil, libol.NewErr("output %s notFound", data.Output)
+		}
+		return network.NewCapture(device)
+	}
+	br := w.Bridger()
+	if br == nil {
+		return nil, libol.NewErr("network %s without bridge", data.Network)
+	}
+	return network.NewCapture(br.Name())
+}
+
+// AddCapture writes pcap to w until finished or ctx done if no file given,
+// otherwise saves it to file in background.
+func (v *Switch) AddCapture(ctx context.Context, data schema.Capture, w io.Writer) (schema.CaptureState, error) {
+	maxWait := captureMaxWait
+	if data.File != "" {
+		maxWait = captureMaxFile
+	}
+	if data.Count <= 0 {
+		data.Count = captureCount
+	}
+	if data.Count > captureMaxCount {
+		data.Count = captureMaxCount
+	}
+	if data.Seconds <= 0 {
+		data.Seconds = captureSeconds
+	}
+	if data.Seconds > maxWait {
+		data.Seconds = maxWait
+	}
+	filter, err := libol.NewPacketFilter(data.Filter)
+	if err != nil {
+		return schema.CaptureState{}, libol.NewErr("filter %s", err)
+	}
+	file := ""
+	if data.File != "" {
+		// only saved in the directory of capture.
+		name := filepath.Base(data.File)
+		if !strings.HasSuffix(name, ".pcap") {
+			name += ".pcap"
+		}
+		file = filepath.Join(CaptureDir, name)
+	}
+	source, err := v.captureSource(data)
+	if err != nil {
+		return schema.CaptureState{}, err
+	}
+	s := &captureSession{
+		state: schema.CaptureState{
+			Id:       libol.GenString(8),
+			Network:  data.Network,
+			Device:   source.Name(),
+			Filter:   filter.String(),
+			File:     file,
+			Count:    data.Count,
+			Seconds:  data.Seconds,
+			CreateAt: time.Now().Unix(),
+		},
+		source: source,
+		filter: filter,
+		stop:   make(chan struct{}),
+	}
+	if err := v.capture.add(s); err != nil {
+		source.Close()
+		return schema.CaptureState{}, err
+	}
+	if file == "" {
+		stop := context.AfterFunc(ctx, s.Stop)
+		defer stop()
+		if cw, ok := w.(api.CaptureWriter); ok {
+			cw.Start(s.State())
+		}
+		err := v.capture.Run(s, w)
+		return s.State(), err
+	}
+	if err := os.MkdirAll(CaptureDir, 0700); err != nil {
+		v.capture.del(s.state.Id)
+		source.Close()
+		return schema.CaptureState{}, err
+	}
+	fp, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		v.capture.del(s.state.Id)
+		source.Close()
+		return schema.CaptureState{}, err
+	}
+	libol.Go(func() {
+		defer fp.Close()
+		_ = v.capture.Run(s, fp)
+	})
+	return s.State(), nil
+}
+
+func (v *Switch) DelCapture(id string) error {
+	return v.capture.Stop(id)
+}
+
+func (v *Switch) ListCapture(call func(obj schema.CaptureState)) {
+	v.capture.List(call)
+}
@@ -0,0 +1,92 @@
+package cswitch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/luscis/openlan/pkg/api"
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/libsock"
+	"github.com/luscis/openlan/pkg/models"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+func TestCaptureFastPort(t *testing.T) {
+	fast := NewFastPath("fake-capture")
+	fast.uplink = &fakeUplink{}
+	port := fast.NewPort()
+	port.sendTo = func(f *libsock.FrameMessage) error {
+		return nil
+	}
+
+	source, err := newFastCapture(port)
+	if err != nil {
+		t.Fatalf("capture %s", err)
+	}
+	if _, err := newFastCapture(port); err == nil {
+		t.Errorf("captured twice")
+	}
+	filter, _ := libol.NewPacketFilter("ether src 02:00:00:00:00:01")
+	c := NewCapturer()
+	s := &captureSession{
+		state:  schema.CaptureState{Id: "fake", Count: 2, Seconds: 5},
+		source: source,
+		filter: filter,
+		stop:   make(chan struct{}),
+	}
+	if err := c.add(s); err != nil {
+		t.Fatalf("add %s", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = port.Write(fastFrame(0xff, 0x01))
+		_ = port.output(fastFrame(0x01, 0x02))
+	}
+
+	var buf bytes.Buffer
+	if err := c.Run(s, &buf); err != nil {
+		t.Fatalf("run %s", err)
+	}
+	data := buf.Bytes()
+	if len(data) != 24+2*(16+64) || binary.LittleEndian.Uint32(data[0:4]) != libol.PcapMagic {
+		t.Errorf("pcap %d bytes", len(data))
+	}
+	if state := s.State(); state.Packets != 2 {
+		t.Errorf("packets %d", state.Packets)
+	}
+	count := 0
+	c.List(func(obj schema.CaptureState) {
+		count++
+	})
+	if count != 0 {
+		t.Errorf("sessions %d", count)
+	}
+	// mirror is unset after finished.
+	if _, err := newFastCapture(port); err != nil {
+		t.Errorf("capture again %s", err)
+	}
+}
+
+func TestCaptureOutputDown(t *testing.T) {
+	v := &Switch{
+		worker: map[string]api.NetworkApi{"fake-capture": &WorkerImpl{}},
+	}
+	link := NewNativeLink(&co.Access{Network: "fake-capture"})
+	cache.Output.Add("fake-cap0", &models.Output{
+		Network: "fake-capture",
+		Device:  "fake-cap0",
+		Linker:  link,
+	})
+	defer cache.Output.Del("fake-cap0")
+
+	_, err := v.captureSource(schema.Capture{Network: "fake-capture", Output: "fake-cap0"})
+	if err == nil || !strings.Contains(err.Error(), "is down") {
+		t.Errorf("expected output down, got %v", err)
+	}
+	if _, err := v.captureSource(schema.Capture{Network: "fake-capture", Output: "fake-cap1"}); err == nil {
+		t.Errorf("expected output notFound")
+	}
+}
@@ -0,0 +1,151 @@
+package cswitch
+
+import (
+	"sync"
+	"time"
+
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type Pending struct {
+	snap     *co.Snapshot
+	timeout  int
+	createAt time.Time
+	timer    *time.Timer
+	gen      uint64 // timer of older generation is stale.
+}
+
+func (p *Pending) Remain() int {
+	dt := time.Since(p.createAt) / time.Second
+	if remain := p.timeout - int(dt); remain > 0 {
+		return remain
+	}
+	return 0
+}
+
+type Confirmer struct {
+	lock    sync.Mutex
+	cfg     *co.Switch
+	pending map[string]*Pending
+	restore func(snap *co.Snapshot)
+	out     *libol.SubLogger
+	gen     uint64
+}
+
+func NewConfirmer(cfg *co.Switch, restore func(snap *co.Snapshot)) *Confirmer {
+	return &Confirmer{
+		cfg:     cfg,
+		pending: make(map[string]*Pending, 32),
+		restore: restore,
+		out:     libol.NewSubLogger("confirm"),
+	}
+}
+
+// Prepare takes a snapshot of the network before a change. If a change is
+// already pending, the older snapshot is kept and only the timer restarts.
+func (c *Confirmer) Prepare(network string, timeout int) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if p, ok := c.pending[network]; ok {
+		c.out.Info("Confirmer.Prepare: %s restart timer %ds", network, timeout)
+		p.timer.Stop()
+		p.timeout = timeout
+		p.createAt = time.Now()
+		p.gen = c.next()
+		p.timer = c.newTimer(network, timeout, p.gen)
+		return nil
+	}
+
+	snap, err := c.cfg.Snapshot(network)
+	if err != nil {
+		return err
+	}
+	c.out.Info("Confirmer.Prepare: %s wait %ds", network, timeout)
+	gen := c.next()
+	c.pending[network] = &Pending{
+		snap:     snap,
+		timeout:  timeout,
+		createAt: time.Now(),
+		timer:    c.newTimer(network, timeout, gen),
+		gen:      gen,
+	}
+	return nil
+}
+
+func (c *Confirmer) next() uint64 {
+	c.gen++
+	return c.gen
+}
+
+// newTimer rolls back the change of a generation, and returns if it's
+// committed or prepared again while firing.
+func (c *Confirmer) newTimer(network string, timeout int, gen uint64) *time.Timer {
+	return time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+		if err := c.rollback(network, gen); err != nil {
+			c.out.Warn("Confirmer: %s", err)
+		}
+	})
+}
+
+func (c *Confirmer) Commit(network string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	p, ok := c.pending[network]
+	if !ok {
+		return libol.NewErr("%s has no pending change", network)
+	}
+	p.timer.Stop()
+	delete(c.pending, network)
+	c.out.Info("Confirmer.Commit: %s", network)
+	return nil
+}
+
+func (c *Confirmer) Rollback(network string) error {
+	return c.rollback(network, 0)
+}
+
+// rollback restores the snapshot out of lock, and the pending is checked
+// by generation if not zero.
+func (c *Confirmer) rollback(network string, gen uint64) error {
+	c.lock.Lock()
+	p, ok := c.pending[network]
+	if !ok {
+		c.lock.Unlock()
+		if gen != 0 {
+			return nil
+		}
+		return libol.NewErr("%s has no pending change", network)
+	}
+	if gen != 0 {
+		if p.gen != gen {
+			c.lock.Unlock()
+			return nil
+		}
+		c.out.Warn("Confirmer: %s not confirmed in %ds", network, p.timeout)
+	}
+	p.timer.Stop()
+	delete(c.pending, network)
+	c.lock.Unlock()
+
+	c.out.Info("Confirmer.Rollback: %s", network)
+	c.restore(p.snap)
+	return nil
+}
+
+func (c *Confirmer) ListConfirm(call func(obj schema.Confirm)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for name, p := range c.pending {
+		call(schema.Confirm{
+			Network:  name,
+			Timeout:  p.timeout,
+			CreateAt: p.createAt.Unix(),
+			Remain:   p.Remain(),
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package cswitch
+
+import (
+	"testing"
+	"time"
+
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+func TestConfirmerRollback(t *testing.T) {
+	cfg := &co.Switch{
+		Network: map[string]*co.Network{
+			"fake": {Name: "fake", Provider: "bridge"},
+		},
+	}
+	restored := make(chan string, 4)
+	var c *Confirmer
+	c = NewConfirmer(cfg, func(snap *co.Snapshot) {
+		// restore is called out of lock.
+		c.ListConfirm(func(obj schema.Confirm) {})
+		restored <- snap.Network.Name
+	})
+
+	if err := c.Prepare("fake", 60); err != nil {
+		t.Fatalf("prepare %s", err)
+	}
+	gen := c.pending["fake"].gen
+	if err := c.Commit("fake"); err != nil {
+		t.Errorf("commit %s", err)
+	}
+	if err := c.Prepare("fake", 60); err != nil {
+		t.Fatalf("prepare %s", err)
+	}
+	// timer of the committed fires late.
+	if err := c.rollback("fake", gen); err != nil || len(c.pending) != 1 {
+		t.Errorf("stale rollback %v %d", err, len(c.pending))
+	}
+	if err := c.Rollback("fake"); err != nil {
+		t.Errorf("rollback %s", err)
+	}
+	select {
+	case name := <-restored:
+		if name != "fake" {
+			t.Errorf("restored %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("not restored")
+	}
+	if err := c.Rollback("fake"); err == nil {
+		t.Errorf("rollback without pending")
+	}
+}
@@ -0,0 +1,260 @@
+package cswitch
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	cn "github.com/luscis/openlan/pkg/network"
+	"github.com/luscis/openlan/pkg/schema"
+	nl "github.com/vishvananda/netlink"
+)
+
+var zeroMac = net.HardwareAddr{0, 0, 0, 0, 0, 0}
+
+const (
+	VxLANOverhead = 50
+	DefaultMtu    = 1500
+)
+
+// Fabric is a VxLAN device per VNI on the bridge of network. The remote
+// VTEPs and MACs are learned by EVPN routes from the bgp fabric, or the
+// static peers, and BUM traffic is replicated to all VTEPs by head-end.
+type Fabric struct {
+	cfg    *co.VxLANSpecifies
+	bridge string
+	local  string
+	out    *libol.SubLogger
+	lock   sync.Mutex
+}
+
+func NewFabric(cfg *co.VxLANSpecifies, bridge string) *Fabric {
+	return &Fabric{
+		cfg:    cfg,
+		bridge: bridge,
+		out:    libol.NewSubLogger(cfg.Name),
+	}
+}
+
+func (f *Fabric) Local() string {
+	if f.cfg.Local != "" {
+		return f.cfg.Local
+	}
+	// using router id of bgp fabric as VTEP address.
+	if f.cfg.Fabric != "" {
+		if obj := co.GetNetwork(f.cfg.Fabric); obj != nil {
+			if spec, ok := obj.Specifies.(*co.BgpSpecifies); ok {
+				return spec.RouterId
+			}
+		}
+	}
+	return ""
+}
+
+// underlay returns MTU of the link with local VTEP, or of the default
+// route if the VTEP on a loopback, e.g. router id of bgp.
+func (f *Fabric) underlay() int {
+	if addr := net.ParseIP(f.local); addr != nil {
+		links, _ := nl.LinkList()
+		for _, link := range links {
+			attr := link.Attrs()
+			if attr.Flags&net.FlagLoopback != 0 {
+				continue
+			}
+			addrs, _ := nl.AddrList(link, nl.FAMILY_ALL)
+			for _, obj := range addrs {
+				if obj.IP.Equal(addr) {
+					return attr.MTU
+				}
+			}
+		}
+	}
+	routes, _ := nl.RouteList(nil, nl.FAMILY_V4)
+	for _, rte := range routes {
+		if rte.Dst != nil {
+			continue
+		}
+		if link, err := nl.LinkByIndex(rte.LinkIndex); err == nil {
+			return link.Attrs().MTU
+		}
+	}
+	return DefaultMtu
+}
+
+func (f *Fabric) Mtu() int {
+	if f.cfg.Mtu > 0 {
+		return f.cfg.Mtu
+	}
+	return f.underlay() - VxLANOverhead
+}
+
+func (f *Fabric) Start() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	name := f.cfg.Device()
+	f.local = f.Local()
+	opts := []string{"type", "vxlan",
+		"id", strconv.Itoa(int(f.cfg.Vni)),
+		"dstport", strconv.Itoa(f.cfg.DstPort),
+		"nolearning"}
+	if f.local != "" {
+		opts = append(opts, "local", f.local)
+	}
+	f.out.Info("Fabric.Start: %s %v", name, opts)
+	if out, err := cn.LinkAdd(name, opts...); err != nil {
+		f.out.Warn("Fabric.Start: %s %s", name, out)
+	}
+	if out, err := cn.LinkSet(name, "mtu", strconv.Itoa(f.Mtu())); err != nil {
+		f.out.Warn("Fabric.Start: %s %s", name, out)
+	}
+	br := cn.NewBrCtl(f.bridge, 0)
+	if err := br.AddPort(name); err != nil {
+		f.out.Warn("Fabric.Start: %s", err)
+	}
+	// suppress ARP/ND flooding by learned neighbors from EVPN.
+	if out, err := libol.Exec("bridge", "link", "set", "dev", name,
+		"learning", "off", "neigh_suppress", "on"); err != nil {
+		f.out.Warn("Fabric.Start: %s %s", name, out)
+	}
+	if out, err := cn.LinkUp(name); err != nil {
+		f.out.Warn("Fabric.Start: %s %s", name, out)
+	}
+	for _, peer := range f.cfg.Peers {
+		f.addPeer(peer)
+	}
+}
+
+func (f *Fabric) Stop(kill bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	name := f.cfg.Device()
+	f.out.Info("Fabric.Stop: %s", name)
+	// flooding entries are added again by start.
+	for _, peer := range f.cfg.Peers {
+		f.delPeer(peer)
+	}
+	if kill {
+		if out, err := cn.LinkDel(name); err != nil {
+			f.out.Warn("Fabric.Stop: %s %s", name, out)
+		}
+	} else {
+		if out, err := cn.LinkDown(name); err != nil {
+			f.out.Warn("Fabric.Stop: %s %s", name, out)
+		}
+	}
+}
+
+func (f *Fabric) peerNeigh(remote string) (*nl.Neigh, error) {
+	link, err := nl.LinkByName(f.cfg.Device())
+	if err != nil {
+		return nil, err
+	}
+	addr := net.ParseIP(remote)
+	if addr == nil {
+		return nil, libol.NewErr("invalid remote %s", remote)
+	}
+	return &nl.Neigh{
+		LinkIndex:    link.Attrs().Index,
+		Family:       syscall.AF_BRIDGE,
+		State:        nl.NUD_NOARP | nl.NUD_PERMANENT,
+		Flags:        nl.NTF_SELF,
+		IP:           addr,
+		HardwareAddr: zeroMac,
+	}, nil
+}
+
+func (f *Fabric) addPeer(remote string) {
+	// flooding entry to replicate BUM traffic to the remote.
+	neigh, err := f.peerNeigh(remote)
+	if err == nil {
+		err = nl.NeighAppend(neigh)
+	}
+	if err != nil {
+		f.out.Warn("Fabric.addPeer: %s %s", remote, err)
+	}
+}
+
+func (f *Fabric) delPeer(remote string) {
+	neigh, err := f.peerNeigh(remote)
+	if err == nil {
+		err = nl.NeighDel(neigh)
+	}
+	if err != nil {
+		f.out.Warn("Fabric.delPeer: %s %s", remote, err)
+	}
+}
+
+func (f *Fabric) AddPeer(remote string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if net.ParseIP(remote) == nil {
+		return libol.NewErr("invalid remote %s", remote)
+	}
+	if f.cfg.AddPeer(remote) {
+		f.addPeer(remote)
+	}
+	return nil
+}
+
+func (f *Fabric) DelPeer(remote string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.cfg.DelPeer(remote) {
+		f.delPeer(remote)
+	}
+	return nil
+}
+
+func (f *Fabric) Get() *schema.VxLAN {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	vni := int(f.cfg.Vni)
+	data := &schema.VxLAN{
+		Name:    f.cfg.Name,
+		Bridge:  f.bridge,
+		Device:  f.cfg.Device(),
+		Vni:     vni,
+		Fabric:  f.cfg.Fabric,
+		Members: make([]schema.VxLANMember, 0, 32),
+	}
+	link, err := nl.LinkByName(data.Device)
+	if err != nil {
+		f.out.Warn("Fabric.Get: %s", err)
+		return data
+	}
+	fdb, err := nl.NeighList(link.Attrs().Index, syscall.AF_BRIDGE)
+	if err != nil {
+		f.out.Warn("Fabric.Get: %s", err)
+		return data
+	}
+	for _, neigh := range fdb {
+		if neigh.IP == nil {
+			continue
+		}
+		if !bytes.Equal(neigh.HardwareAddr, zeroMac) {
+			data.Macs++
+			continue
+		}
+		remote := neigh.IP.String()
+		obj := schema.VxLANMember{
+			Vni:    vni,
+			Local:  f.local,
+			Remote: remote,
+			Type:   "evpn",
+		}
+		if f.cfg.FindPeer(remote) != -1 {
+			obj.Type = "static"
+		}
+		data.Members = append(data.Members, obj)
+	}
+	return data
+}
@@ -0,0 +1,24 @@
+package cswitch
+
+import (
+	"testing"
+
+	co "github.com/luscis/openlan/pkg/config"
+)
+
+func TestFabricMtu(t *testing.T) {
+	cfg := &co.VxLANSpecifies{Name: "fake-vx", Vni: 100, Mtu: 1400}
+	f := NewFabric(cfg, "br-fake")
+	if value := f.Mtu(); value != 1400 {
+		t.Errorf("expected 1400, got %d", value)
+	}
+	// derived from the underlay without local VTEP.
+	cfg.Mtu = 0
+	if value := f.Mtu(); value <= 0 || value+VxLANOverhead > 65536 {
+		t.Errorf("unexpected mtu %d", value)
+	}
+	f.local = "203.0.113.1"
+	if value := f.underlay(); value <= 0 {
+		t.Errorf("unexpected underlay mtu %d", value)
+	}
+}
@@ -0,0 +1,462 @@
+package cswitch
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/libsock"
+	"github.com/luscis/openlan/pkg/network"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+const (
+	fastPathAgeing = 300 // seconds
+	fastPathUplink = "uplink"
+)
+
+type fastPathMac struct {
+	port     *FastPort // nil for uplink.
+	updateAt int64
+}
+
+// FastPath switches frames of access clients in userspace by a MAC learning
+// table, and forwards between their sockets directly. Only an uplink tap is
+// attached to the bridge of network for the others.
+type FastPath struct {
+	network   string
+	uplink    network.Taper
+	lock      sync.RWMutex
+	ports     map[string]*FastPort
+	macs      map[[6]byte]*fastPathMac
+	index     int
+	ageing    int64
+	forwarded atomic.Uint64
+	flooded   atomic.Uint64
+	toKernel  atomic.Uint64
+	dropped   atomic.Uint64
+	snoop     *Suppressor // nil if not snooping multicast.
+	out       *libol.SubLogger
+	ticker    libol.Ticker
+}
+
+func NewFastPath(network string) *FastPath {
+	return &FastPath{
+		network: network,
+		ports:   make(map[string]*FastPort, 1024),
+		macs:    make(map[[6]byte]*fastPathMac, 1024),
+		ageing:  fastPathAgeing,
+		out:     libol.NewSubLogger(network),
+	}
+}
+
+func (f *FastPath) Start(br network.Bridger) error {
+	f.out.Info("FastPath.Start")
+	dev, err := network.NewTaper(f.network, network.TapConfig{
+		Provider: br.Type(),
+		Type:     network.TAP,
+	})
+	if err != nil {
+		return err
+	}
+	dev.Up()
+	if err := br.AddSlave(dev.Name()); err != nil {
+		f.out.Warn("FastPath.Start: %s", err)
+	}
+	f.uplink = dev
+	libol.Go(f.readUplink)
+
+	f.ticker.Start(30*time.Second, f.expire)
+	return nil
+}
+
+func (f *FastPath) Stop() {
+	f.out.Info("FastPath.Stop")
+	f.ticker.Stop()
+	f.lock.Lock()
+	ports := make([]*FastPort, 0, len(f.ports))
+	for _, port := range f.ports {
+		ports = append(ports, port)
+	}
+	f.lock.Unlock()
+	for _, port := range ports {
+		_ = port.Close()
+	}
+	if f.uplink != nil {
+		_ = f.uplink.Close()
+	}
+}
+
+func (f *FastPath) readUplink() {
+	dev := f.uplink
+	for {
+		frame := libsock.NewFrameMessage(0)
+		n, err := dev.Read(frame.Frame())
+		if err != nil {
+			f.out.Info("FastPath.readUplink: %s", err)
+			return
+		}
+		frame.SetSize(n)
+		f.Forward(nil, frame.Frame()[:n])
+	}
+}
+
+// NewPort returns a virtual port for an access client.
+func (f *FastPath) NewPort() *FastPort {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.index++
+	name := fmt.Sprintf("%s:%d", f.network, f.index)
+	if f.uplink != nil {
+		name = fmt.Sprintf("%s:%d", f.uplink.Name(), f.index)
+	}
+	port := &FastPort{
+		name: name,
+		fast: f,
+		done: make(chan bool),
+	}
+	f.ports[name] = port
+	return port
+}
+
+func (f *FastPath) delPort(port *FastPort) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.ports, port.name)
+	for mac, entry := range f.macs {
+		if entry.port == port {
+			delete(f.macs, mac)
+		}
+	}
+}
+
+func (f *FastPath) expire() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := time.Now().Unix()
+	for mac, entry := range f.macs {
+		if now-entry.updateAt > f.ageing {
+			delete(f.macs, mac)
+		}
+	}
+}
+
+func (f *FastPath) learn(mac [6]byte, port *FastPort, now int64) {
+	f.lock.RLock()
+	entry, ok := f.macs[mac]
+	if ok && entry.port == port && now == entry.updateAt {
+		f.lock.RUnlock()
+		return
+	}
+	f.lock.RUnlock()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if port != nil {
+		if _, ok := f.ports[port.name]; !ok {
+			return
+		}
+	}
+	f.macs[mac] = &fastPathMac{port: port, updateAt: now}
+}
+
+// lookup returns the destinations of a frame from source, and nil for uplink.
+// Multicast is only flooded to members if it's not nil.
+func (f *FastPath) lookup(dst [6]byte, source *FastPort, now int64, members map[string]bool) ([]*FastPort, bool, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if dst[0]&0x01 == 0 {
+		if entry, ok := f.macs[dst]; ok && now-entry.updateAt <= f.ageing {
+			if entry.port == source {
+				return nil, false, false
+			}
+			if entry.port == nil {
+				return nil, true, false
+			}
+			return []*FastPort{entry.port}, false, false
+		}
+	}
+	ports := make([]*FastPort, 0, len(f.ports))
+	for _, port := range f.ports {
+		if port == source {
+			continue
+		}
+		if members != nil && !members[port.name] {
+			f.snoop.pruned.Add(1)
+			continue
+		}
+		ports = append(ports, port)
+	}
+	return ports, source != nil, true
+}
+
+// Forward switches a frame from source port, and nil is from uplink.
+func (f *FastPath) Forward(source *FastPort, data []byte) {
+	if len(data) < 14 {
+		f.dropped.Add(1)
+		return
+	}
+	var dst, src [6]byte
+	copy(dst[:], data[0:6])
+	copy(src[:], data[6:12])
+	now := time.Now().Unix()
+	if src[0]&0x01 == 0 {
+		f.learn(src, source, now)
+	}
+	var members map[string]bool
+	if f.snoop != nil && dst[0]&0x01 != 0 && dst != [6]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff} {
+		members, _ = f.snoop.Members(dst)
+	}
+	ports, uplink, flood := f.lookup(dst, source, now, members)
+	if !uplink && len(ports) == 0 {
+		if !flood {
+			f.dropped.Add(1)
+		}
+		return
+	}
+	if flood {
+		f.flooded.Add(1)
+	} else if !uplink {
+		f.forwarded.Add(1)
+	}
+	if uplink && f.uplink != nil {
+		if _, err := f.uplink.Write(data); err != nil {
+			f.dropped.Add(1)
+		} else {
+			f.toKernel.Add(1)
+		}
+	}
+	for _, port := range ports {
+		if err := port.output(data); err != nil {
+			f.dropped.Add(1)
+		}
+	}
+}
+
+func (f *FastPath) Get() schema.FastPath {
+	clients := make(map[string]string, 32)
+	for obj := range cache.Access.List() {
+		if obj == nil {
+			break
+		}
+		if obj.Network == f.network && obj.Device != nil {
+			clients[obj.Device.Name()] = obj.Alias
+		}
+	}
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	obj := schema.FastPath{
+		Network:   f.network,
+		Ports:     len(f.ports),
+		Forwarded: f.forwarded.Load(),
+		Flooded:   f.flooded.Load(),
+		ToKernel:  f.toKernel.Load(),
+		Dropped:   f.dropped.Load(),
+		Macs:      make([]schema.FastPathMac, 0, len(f.macs)),
+	}
+	if f.uplink != nil {
+		obj.Uplink = f.uplink.Name()
+	}
+	now := time.Now().Unix()
+	for mac, entry := range f.macs {
+		item := schema.FastPathMac{
+			Address: net.HardwareAddr(mac[:]).String(),
+			Port:    fastPathUplink,
+			Age:     now - entry.updateAt,
+		}
+		if entry.port != nil {
+			item.Port = entry.port.name
+			item.Client = clients[entry.port.name]
+		}
+		obj.Macs = append(obj.Macs, item)
+	}
+	sort.Slice(obj.Macs, func(i, j int) bool {
+		return obj.Macs[i].Address < obj.Macs[j].Address
+	})
+	return obj
+}
+
+// FastPort is a virtual port of FastPath, writing to it switches the frame
+// and frames to it are sent to the socket of access client directly.
+type FastPort struct {
+	name   string
+	fast   *FastPath
+	lock   sync.Mutex
+	wlock  sync.Mutex // serializes writing to socket.
+	sendTo func(f *libsock.FrameMessage) error
+	mirror func(data []byte) // copies frames for capture.
+	closed bool
+	done   chan bool
+	send   atomic.Uint64
+	recv   atomic.Uint64
+	drop   atomic.Uint64
+}
+
+// Attach sets the output of port, and blocks until it's closed.
+func (p *FastPort) Attach(sendTo func(f *libsock.FrameMessage) error) {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return
+	}
+	p.sendTo = sendTo
+	p.lock.Unlock()
+	<-p.done
+}
+
+// SetMirror copies frames sent and received by port to call, and returns
+// false if the port is already mirrored.
+func (p *FastPort) SetMirror(call func(data []byte)) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if call != nil && p.mirror != nil {
+		return false
+	}
+	p.mirror = call
+	return true
+}
+
+func (p *FastPort) output(data []byte) error {
+	p.lock.Lock()
+	sendTo := p.sendTo
+	mirror := p.mirror
+	p.lock.Unlock()
+	if mirror != nil {
+		mirror(data)
+	}
+	if sendTo == nil {
+		p.drop.Add(1)
+		return libol.NewErr("%s not ready", p.name)
+	}
+	// message is encrypted in place, so copy it for every port.
+	frame := libsock.NewFrameMessage(len(data))
+	copy(frame.Frame(), data)
+	frame.SetSize(len(data))
+	p.wlock.Lock()
+	err := sendTo(frame)
+	p.wlock.Unlock()
+	if err != nil {
+		p.drop.Add(1)
+		return err
+	}
+	p.recv.Add(1)
+	return nil
+}
+
+func (p *FastPort) Type() string {
+	return "fastpath"
+}
+
+func (p *FastPort) IsTun() bool {
+	return false
+}
+
+func (p *FastPort) Name() string {
+	return p.name
+}
+
+func (p *FastPort) Read([]byte) (int, error) {
+	<-p.done
+	return 0, libol.NewErr("Closed")
+}
+
+func (p *FastPort) Write(data []byte) (int, error) {
+	p.lock.Lock()
+	closed := p.closed
+	mirror := p.mirror
+	p.lock.Unlock()
+	if closed {
+		return 0, libol.NewErr("Closed")
+	}
+	if mirror != nil {
+		mirror(data)
+	}
+	p.send.Add(1)
+	p.fast.Forward(p, data)
+	return len(data), nil
+}
+
+func (p *FastPort) Send(data []byte) (int, error) {
+	return p.Write(data)
+}
+
+func (p *FastPort) Recv(data []byte) (int, error) {
+	return p.Read(data)
+}
+
+func (p *FastPort) Close() error {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.sendTo = nil
+	close(p.done)
+	p.lock.Unlock()
+	p.fast.delPort(p)
+	return nil
+}
+
+func (p *FastPort) Master() network.Bridger {
+	return nil
+}
+
+func (p *FastPort) SetMaster(dev network.Bridger) error {
+	return libol.NewErr("%s not supported", p.name)
+}
+
+func (p *FastPort) Up() {
+}
+
+func (p *FastPort) Down() {
+}
+
+func (p *FastPort) Tenant() string {
+	return p.fast.network
+}
+
+func (p *FastPort) Mtu() int {
+	return 1500
+}
+
+func (p *FastPort) String() string {
+	return p.name
+}
+
+func (p *FastPort) Has(v uint) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	switch v {
+	case network.UsClose:
+		return p.closed
+	case network.UsUp:
+		return !p.closed
+	}
+	return false
+}
+
+func (p *FastPort) Stats() network.DeviceInfo {
+	state := "up"
+	if p.Has(network.UsClose) {
+		state = "down"
+	}
+	return network.DeviceInfo{
+		Send:  p.send.Load(),
+		Recv:  p.recv.Load(),
+		Drop:  p.drop.Load(),
+		Mtu:   p.Mtu(),
+		State: state,
+	}
+}
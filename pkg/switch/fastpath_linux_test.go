@@ -0,0 +1,94 @@
+package cswitch
+
+import (
+	"testing"
+
+	"github.com/luscis/openlan/pkg/libsock"
+	"github.com/luscis/openlan/pkg/network"
+)
+
+type fakeUplink struct {
+	network.Taper
+	frames int
+}
+
+func (u *fakeUplink) Name() string {
+	return "tap-fake"
+}
+
+func (u *fakeUplink) Write(p []byte) (int, error) {
+	u.frames++
+	return len(p), nil
+}
+
+func fastFrame(dst, src byte) []byte {
+	frame := make([]byte, 64)
+	frame[0], frame[5] = 0x02, dst
+	frame[6], frame[11] = 0x02, src
+	if dst == 0xff {
+		copy(frame[0:6], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	}
+	return frame
+}
+
+func TestFastPathForward(t *testing.T) {
+	uplink := &fakeUplink{}
+	fast := NewFastPath("fake-fast")
+	fast.uplink = uplink
+
+	recv := make(map[string]int, 3)
+	ports := make([]*FastPort, 3)
+	for i := range ports {
+		port := fast.NewPort()
+		name := port.Name()
+		port.sendTo = func(f *libsock.FrameMessage) error {
+			recv[name]++
+			return nil
+		}
+		ports[i] = port
+	}
+
+	// broadcast is flooded to others and uplink.
+	_, _ = ports[0].Write(fastFrame(0xff, 0x01))
+	if recv[ports[0].Name()] != 0 || recv[ports[1].Name()] != 1 || recv[ports[2].Name()] != 1 {
+		t.Errorf("flood %v", recv)
+	}
+	if uplink.frames != 1 {
+		t.Errorf("flood to uplink %d", uplink.frames)
+	}
+
+	// learned 0x01 on port 0, and unicast to it directly.
+	_, _ = ports[1].Write(fastFrame(0x01, 0x02))
+	if recv[ports[0].Name()] != 1 || recv[ports[2].Name()] != 1 || uplink.frames != 1 {
+		t.Errorf("unicast %v %d", recv, uplink.frames)
+	}
+
+	// learned 0x03 from uplink.
+	fast.Forward(nil, fastFrame(0x02, 0x03))
+	if recv[ports[1].Name()] != 2 || uplink.frames != 1 {
+		t.Errorf("from uplink %v %d", recv, uplink.frames)
+	}
+	_, _ = ports[2].Write(fastFrame(0x03, 0x04))
+	if recv[ports[0].Name()] != 1 || recv[ports[1].Name()] != 2 || uplink.frames != 2 {
+		t.Errorf("to uplink %v %d", recv, uplink.frames)
+	}
+
+	obj := fast.Get()
+	if obj.Ports != 3 || len(obj.Macs) != 4 || obj.Forwarded != 2 || obj.Flooded != 1 || obj.ToKernel != 2 {
+		t.Errorf("stats %v", obj)
+	}
+
+	// macs of port are flushed by closing.
+	_ = ports[0].Close()
+	if _, err := ports[0].Write(fastFrame(0xff, 0x01)); err == nil {
+		t.Errorf("write to closed port")
+	}
+	obj = fast.Get()
+	if obj.Ports != 2 || len(obj.Macs) != 3 {
+		t.Errorf("close %v", obj)
+	}
+	_, _ = ports[1].Write(fastFrame(0x01, 0x02))
+	if recv[ports[0].Name()] != 1 || recv[ports[2].Name()] != 2 || uplink.frames != 3 {
+		t.Errorf("unknown %v %d", recv, uplink.frames)
+	}
+}
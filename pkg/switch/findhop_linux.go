@@ -451,7 +451,7 @@ func (pc *PingDriver) Stop() {
 func (pc *PingDriver) ping(ip string, count int) (float64, int, error) {
 	ping, err := exec.LookPath("ping")
 	if err != nil {
-		pc.out.Warn("PingDriver.Ping: ping cmd not found: ", err)
+		pc.out.Warn("PingDriver.Ping: ping cmd not found: %s", err)
 	}
 	output := ""
 	vrf := pc.cfg.Vrf
@@ -461,7 +461,7 @@ func (pc *PingDriver) ping(ip string, count int) (float64, int, error) {
 	} else {
 		ipcmd, err := exec.LookPath("ip")
 		if err != nil {
-			pc.out.Warn("PingDriver.Ping: ip cmd not found: ", err)
+			pc.out.Warn("PingDriver.Ping: ip cmd not found: %s", err)
 		}
 		output, err = libol.Exec(ipcmd, "vrf", "exec", vrf, ping, ip, "-c", cstr)
 	}
@@ -478,7 +478,7 @@ func (pc *PingDriver) ping(ip string, count int) (float64, int, error) {
 	}
 
 	packetLoss := int(LossRate * float64(count) / 100)
-	pc.out.Debug("PingDriver.Ping: ping ip[%s] loss:%.f%", ip, avgLatency, LossRate)
+	pc.out.Debug("PingDriver.Ping: ping ip[%s] latency:%.f loss:%.f%%", ip, avgLatency, LossRate)
 	return avgLatency, packetLoss, nil
 }
 
@@ -0,0 +1,193 @@
+package cswitch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/models"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+const (
+	GuardMac  = "mac"
+	GuardArp  = "arp"
+	GuardIp   = "ip"
+	GuardDhcp = "dhcp"
+	GuardIp6  = "ip6"
+)
+
+// guardBinding is locked by itself, so clients are checked in parallel.
+type guardBinding struct {
+	lock sync.Mutex
+	schema.GuardBinding
+}
+
+// SourceGuard binds each access client to its leased address and the
+// first learned MAC, and drops the frames spoofing others. Leases are
+// IPv4 only, so IPv6 frames are refused all.
+type SourceGuard struct {
+	network  string
+	exempt   string // user of links, e.g. mesh.
+	lock     sync.RWMutex
+	bindings map[string]*guardBinding
+	out      *libol.SubLogger
+}
+
+func NewSourceGuard(network, exempt string) *SourceGuard {
+	return &SourceGuard{
+		network:  network,
+		exempt:   exempt,
+		bindings: make(map[string]*guardBinding, 32),
+		out:      libol.NewSubLogger(network),
+	}
+}
+
+func (g *SourceGuard) binding(client string, obj *models.Access) *guardBinding {
+	g.lock.RLock()
+	b, ok := g.bindings[client]
+	g.lock.RUnlock()
+	if ok {
+		return b
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if b, ok := g.bindings[client]; ok {
+		return b
+	}
+	b = &guardBinding{
+		GuardBinding: schema.GuardBinding{
+			Network: g.network,
+			Alias:   obj.Alias,
+			Client:  client,
+			Dropped: make(map[string]uint64, 4),
+		},
+	}
+	g.bindings[client] = b
+	return b
+}
+
+func (g *SourceGuard) drop(b *guardBinding, reason, detail string) string {
+	b.Dropped[reason]++
+	b.LastAt = time.Now().Unix()
+	if count := b.Dropped[reason]; count == 1 || count%1000 == 0 {
+		g.out.Warn("SourceGuard.Check: %s from %s dropped %d by %s: %s", b.Alias, b.Client, count, reason, detail)
+	}
+	return reason
+}
+
+// Check returns the reason if the frame from client is dropped.
+func (g *SourceGuard) Check(client string, obj *models.Access, frame []byte) string {
+	if g.exempt != "" && obj.User == g.exempt {
+		return ""
+	}
+	b := g.binding(client, obj)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if lease := cache.Network.GetLease(obj.Alias, obj.Network); lease != nil && lease.Client == client {
+		b.Address = lease.Address
+	}
+	eth, err := libol.NewEtherFromFrame(frame)
+	if err != nil {
+		return g.drop(b, GuardMac, err.Error())
+	}
+	src := net.HardwareAddr(eth.Src)
+	if b.Mac == "" {
+		if src[0]&0x01 != 0 || bytes.Equal(src, libol.EthZero) {
+			return g.drop(b, GuardMac, src.String())
+		}
+		b.Mac = src.String()
+		g.out.Info("SourceGuard.Check: %s from %s learned %s", b.Alias, client, b.Mac)
+	} else if b.Mac != src.String() {
+		return g.drop(b, GuardMac, src.String())
+	}
+
+	payload := frame[eth.Len:]
+	proto := eth.Type
+	if eth.IsVlan() && len(payload) >= libol.VlanLen {
+		proto = binary.BigEndian.Uint16(payload[2:4])
+		payload = payload[libol.VlanLen:]
+	}
+	switch proto {
+	case libol.EthArp:
+		arp, err := libol.NewArpFromFrame(payload)
+		if err != nil {
+			return g.drop(b, GuardArp, err.Error())
+		}
+		if !bytes.Equal(arp.SHwAddr, eth.Src) {
+			return g.drop(b, GuardArp, net.HardwareAddr(arp.SHwAddr).String())
+		}
+		sip := net.IP(arp.SIpAddr)
+		// ARP probe has no sender address.
+		if !sip.IsUnspecified() && sip.String() != b.Address {
+			return g.drop(b, GuardArp, sip.String())
+		}
+	case libol.EthIp4:
+		ip4, err := libol.NewIpv4FromFrame(payload)
+		if err != nil {
+			return g.drop(b, GuardIp, err.Error())
+		}
+		isUdp := ip4.Protocol == libol.IpUdp
+		var sport, dport uint16
+		if hlen := int(ip4.HeaderLen) * 4; isUdp && len(payload) >= hlen+libol.UdpLen {
+			sport = binary.BigEndian.Uint16(payload[hlen : hlen+2])
+			dport = binary.BigEndian.Uint16(payload[hlen+2 : hlen+4])
+		}
+		if isUdp && sport == 67 {
+			return g.drop(b, GuardDhcp, net.IP(ip4.Source).String())
+		}
+		sip := net.IP(ip4.Source)
+		if sip.IsUnspecified() {
+			// DHCP client without address.
+			if !isUdp || sport != 68 || dport != 67 {
+				return g.drop(b, GuardIp, sip.String())
+			}
+		} else if sip.String() != b.Address {
+			return g.drop(b, GuardIp, sip.String())
+		}
+	case libol.EthIp6:
+		// neither leased nor learned, and spoofed by ND easily.
+		return g.drop(b, GuardIp6, "")
+	}
+	return ""
+}
+
+// Drop returns true if the frame from client is dropped.
+func (g *SourceGuard) Drop(client string, obj *models.Access, frame []byte) bool {
+	return g.Check(client, obj, frame) != ""
+}
+
+func (g *SourceGuard) Remove(client string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.bindings, client)
+}
+
+func (g *SourceGuard) ListGuard(call func(obj schema.GuardBinding)) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	for _, b := range g.bindings {
+		b.lock.Lock()
+		obj := b.GuardBinding
+		obj.Dropped = make(map[string]uint64, len(b.Dropped))
+		for reason, count := range b.Dropped {
+			obj.Dropped[reason] = count
+		}
+		b.lock.Unlock()
+		call(obj)
+	}
+}
+
+func guardExempt(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	name, _ := SplitCombined(secret)
+	return strings.SplitN(name, "@", 2)[0]
+}
@@ -0,0 +1,111 @@
+package cswitch
+
+import (
+	"net"
+	"testing"
+
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/models"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+func guardArp(mac, hw net.HardwareAddr, sip string) []byte {
+	eth := libol.NewEtherArp()
+	copy(eth.Dst, libol.EthAll)
+	copy(eth.Src, mac)
+	arp := libol.NewArp()
+	copy(arp.SHwAddr, hw)
+	copy(arp.SIpAddr, net.ParseIP(sip).To4())
+	copy(arp.TIpAddr, net.ParseIP("192.168.3.254").To4())
+	return append(eth.Encode(), arp.Encode()...)
+}
+
+func guardUdp(mac net.HardwareAddr, sip string, sport, dport uint16) []byte {
+	eth := libol.NewEtherIP4()
+	copy(eth.Dst, libol.EthAll)
+	copy(eth.Src, mac)
+	ip4 := libol.NewIpv4()
+	ip4.Protocol = libol.IpUdp
+	copy(ip4.Source, net.ParseIP(sip).To4())
+	copy(ip4.Destination, net.ParseIP("255.255.255.255").To4())
+	udp := libol.NewUdp()
+	udp.Source = sport
+	udp.Destination = dport
+	frame := append(eth.Encode(), ip4.Encode()...)
+	return append(frame, udp.Encode()...)
+}
+
+func guardIp6(mac net.HardwareAddr) []byte {
+	eth := libol.NewEtherIP4()
+	eth.Type = libol.EthIp6
+	copy(eth.Dst, libol.EthAll)
+	copy(eth.Src, mac)
+	return append(eth.Encode(), make([]byte, 40)...)
+}
+
+func TestSourceGuardCheck(t *testing.T) {
+	network := "fake-guard"
+	cache.Network.Add(&models.Network{
+		Name:    network,
+		IpStart: "192.168.3.1",
+		IpEnd:   "192.168.3.10",
+	})
+	defer cache.Network.Del(network)
+
+	client := "1.1.1.1:1000"
+	obj := &models.Access{Alias: "fake-aa", Network: network, User: "hi"}
+	lease := cache.Network.AddLease(obj.Alias, "192.168.3.2", network)
+	cache.Network.RenewLease(lease, client)
+
+	mac, _ := net.ParseMAC("02:00:00:00:00:01")
+	fake, _ := net.ParseMAC("02:00:00:00:00:02")
+	g := NewSourceGuard(network, "mesh")
+
+	cases := []struct {
+		name   string
+		frame  []byte
+		reason string
+	}{
+		{"learn mac", guardArp(mac, mac, "192.168.3.2"), ""},
+		{"spoofed mac", guardArp(fake, fake, "192.168.3.2"), GuardMac},
+		{"spoofed arp hw", guardArp(mac, fake, "192.168.3.2"), GuardArp},
+		{"spoofed arp ip", guardArp(mac, mac, "192.168.3.3"), GuardArp},
+		{"arp probe", guardArp(mac, mac, "0.0.0.0"), ""},
+		{"leased ip", guardUdp(mac, "192.168.3.2", 5000, 53), ""},
+		{"spoofed ip", guardUdp(mac, "192.168.3.9", 5000, 53), GuardIp},
+		{"dhcp discover", guardUdp(mac, "0.0.0.0", 68, 67), ""},
+		{"unspecified ip", guardUdp(mac, "0.0.0.0", 5000, 53), GuardIp},
+		{"dhcp server", guardUdp(mac, "192.168.3.2", 67, 68), GuardDhcp},
+		{"ipv6", guardIp6(mac), GuardIp6},
+	}
+	for _, c := range cases {
+		if reason := g.Check(client, obj, c.frame); reason != c.reason {
+			t.Errorf("%s: expected %q, got %q", c.name, c.reason, reason)
+		}
+	}
+
+	count := 0
+	g.ListGuard(func(b schema.GuardBinding) {
+		count++
+		if b.Mac != mac.String() || b.Address != "192.168.3.2" {
+			t.Errorf("unexpected binding: %v", b)
+		}
+		if b.Dropped[GuardArp] != 2 || b.Dropped[GuardIp] != 2 {
+			t.Errorf("unexpected dropped: %v", b.Dropped)
+		}
+	})
+	if count != 1 {
+		t.Errorf("expected 1 binding, got %d", count)
+	}
+
+	// links of mesh are exempt.
+	link := &models.Access{Alias: "fake-bb", Network: network, User: "mesh"}
+	if reason := g.Check("2.2.2.2:1000", link, guardUdp(fake, "10.0.0.1", 5000, 53)); reason != "" {
+		t.Errorf("expected exempt, got %q", reason)
+	}
+	g.Remove(client)
+	if reason := g.Check(client, obj, guardArp(fake, fake, "192.168.3.2")); reason != "" {
+		t.Errorf("expected relearned, got %q", reason)
+	}
+}
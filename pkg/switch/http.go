@@ -1,8 +1,10 @@
 package cswitch
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/pprof"
 	"os"
@@ -97,20 +99,187 @@ func (h *Http) Middleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
+		if err := h.checkLockout(r); err != nil {
+			if event := h.rejected(r); event != nil {
+				event.Result = "failure"
+				event.Detail = err.Error()
+				libol.Audit(*event)
+			}
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		if h.IsAuth(w, r) {
 			latst := time.Now().Unix()
-			next.ServeHTTP(w, r)
+			event := h.audit(r)
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			if network, timeout := api.GetConfirm(r); network != "" {
+				h.ServeConfirm(next, sw, r, network, timeout)
+			} else {
+				next.ServeHTTP(sw, r)
+			}
+			if event != nil {
+				event.Result = "success"
+				if sw.status >= http.StatusBadRequest {
+					event.Result = "failure"
+				}
+				event.Detail = fmt.Sprintf("%d %s", sw.status, http.StatusText(sw.status))
+				libol.Audit(*event)
+			}
 			dt := time.Now().Unix() - latst
 			if dt > 2 {
 				libol.Warn("Http.Middleware %s %s long time %d", r.Method, r.URL.Path, dt)
 			}
 		} else {
+			h.failLockout(r)
+			if event := h.rejected(r); event != nil {
+				event.Result = "failure"
+				event.Detail = "Authorization Required"
+				libol.Audit(*event)
+			}
 			w.Header().Set("WWW-Authenticate", "Basic")
 			http.Error(w, "Authorization Required", http.StatusUnauthorized)
 		}
 	})
 }
 
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusWriter) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusWriter) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// ServeConfirm applies a change that reverts itself when not confirmed
+// in timeout seconds.
+func (h *Http) ServeConfirm(next http.Handler, w http.ResponseWriter, r *http.Request, network string, timeout int) {
+	pending := false
+	h.cs.ListConfirm(func(obj schema.Confirm) {
+		if obj.Network == network {
+			pending = true
+		}
+	})
+	if err := h.cs.Prepare(network, timeout); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(sw, r)
+	if sw.status != http.StatusOK && !pending {
+		// nothing changed, and needn't a rollback.
+		_ = h.cs.Commit(network)
+	}
+}
+
+// caller returns the user of a call, and the user is empty for a token.
+func (h *Http) caller(r *http.Request) (user, pass string) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		user = api.GetQueryOne(r, "token")
+	}
+	if !strings.Contains(user, "@") {
+		return "", pass
+	}
+	return user, pass
+}
+
+func (h *Http) checkLockout(r *http.Request) error {
+	user, _ := h.caller(r)
+	return cache.Lockout.Check(user, r.RemoteAddr)
+}
+
+// failLockout records a failed login with wrong token or password, but a
+// call without token or to a forbidden URL is not failed.
+func (h *Http) failLockout(r *http.Request) {
+	token, _, ok := r.BasicAuth()
+	if !ok {
+		token = api.GetQueryOne(r, "token")
+	}
+	if token == "" {
+		return
+	}
+	user, pass := h.caller(r)
+	if user != "" && api.UserCheck(user, pass) == nil {
+		return
+	}
+	cache.Lockout.Fail(user, r.RemoteAddr)
+}
+
+// maxAuditBody is the max bytes of a body read to be audited.
+const maxAuditBody = 64 << 10
+
+// rejected returns an event for a call to change anything, but only the
+// method, path and source, as the caller isn't authenticated.
+func (h *Http) rejected(r *http.Request) *libol.AuditEvent {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return nil
+	}
+	elements := strings.SplitN(r.URL.Path, "/", 5)
+	if len(elements) < 3 || elements[1] != "api" {
+		return nil
+	}
+	event := &libol.AuditEvent{
+		Type:   "api",
+		Source: r.RemoteAddr,
+		Action: r.Method + " " + r.URL.Path,
+	}
+	if len(elements) > 3 && elements[2] == "network" {
+		event.Network = elements[3]
+	}
+	return event
+}
+
+// audit returns an event for a call to change anything of an authorized
+// caller, and calls to read are not audited.
+func (h *Http) audit(r *http.Request) *libol.AuditEvent {
+	event := h.rejected(r)
+	if event == nil {
+		return nil
+	}
+	user, _, ok := r.BasicAuth()
+	if !ok {
+		user = api.GetQueryOne(r, "token")
+	}
+	if user == h.adminToken {
+		event.User = "admin"
+		event.Token = libol.MaskToken(user)
+	} else if strings.Contains(user, "@") {
+		event.User = user
+	} else if user != "" {
+		event.Token = libol.MaskToken(user)
+	}
+	if r.Body != nil {
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+		if err != nil {
+			libol.Warn("Http.audit: %s", err)
+		}
+		// the rest of a large body is left to the handler.
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+		if len(data) > maxAuditBody {
+			event.Params = "<too large>"
+		} else {
+			event.Params = libol.AuditParams(data)
+		}
+	}
+	query := r.URL.Query()
+	query.Del("token")
+	if len(query) > 0 {
+		event.Params = strings.TrimSpace(query.Encode() + " " + event.Params)
+	}
+	return event
+}
+
 func (h *Http) Router() *mux.Router {
 	if h.router == nil {
 		h.router = mux.NewRouter()
@@ -228,8 +397,8 @@ func (h *Http) IsAuth(w http.ResponseWriter, r *http.Request) bool {
 			}
 			zone := elements[4]
 			if api.UserCheck(user, pass) == nil {
-				// user can URL: /1/2/3/<ovpn|guest>.
-				if zone == "ovpn" || zone == "guest" {
+				// user can URL: /1/2/3/<ovpn|guest|mesh>.
+				if zone == "ovpn" || zone == "guest" || zone == "mesh" {
 					return true
 				}
 			}
@@ -0,0 +1,44 @@
+package cswitch
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpAudit(t *testing.T) {
+	h := &Http{adminToken: "fake-admin-token"}
+
+	r := httptest.NewRequest("POST", "/api/network/fake/acl?token=hi@fake", strings.NewReader(`{"password":"pass"}`))
+	event := h.rejected(r)
+	if event == nil || event.User != "" || event.Params != "" {
+		t.Fatalf("unexpected rejected event %v", event)
+	}
+	if event.Network != "fake" || event.Action != "POST /api/network/fake/acl" {
+		t.Errorf("unexpected rejected event %v", event)
+	}
+
+	event = h.audit(r)
+	if event == nil || event.User != "hi@fake" || event.Params != `{"password":"***"}` {
+		t.Fatalf("unexpected audit event %v", event)
+	}
+	if data, _ := io.ReadAll(r.Body); string(data) != `{"password":"pass"}` {
+		t.Errorf("unexpected body %s", data)
+	}
+
+	large := strings.Repeat("a", maxAuditBody+10)
+	r = httptest.NewRequest("PUT", "/api/user/hi", strings.NewReader(large))
+	event = h.audit(r)
+	if event == nil || event.Params != "<too large>" {
+		t.Fatalf("unexpected audit event %v", event)
+	}
+	if data, _ := io.ReadAll(r.Body); string(data) != large {
+		t.Errorf("unexpected body of %d bytes", len(data))
+	}
+
+	r = httptest.NewRequest("GET", "/api/user", nil)
+	if event := h.audit(r); event != nil {
+		t.Errorf("unexpected audit of get %v", event)
+	}
+}
@@ -26,11 +26,15 @@ type IPSecWorker struct {
 	spec    *co.IPSecSpecifies
 	lock    sync.Mutex
 	running bool
+	sas     map[string][]schema.IPSecSA
+	errors  map[string]string
 }
 
 func NewIPSecWorker(c *co.Network) *IPSecWorker {
 	w := &IPSecWorker{
 		WorkerImpl: NewWorkerApi(c),
+		sas:        make(map[string][]schema.IPSecSA),
+		errors:     make(map[string]string),
 	}
 	api.Call.SetIPSecApi(w)
 
@@ -42,7 +46,7 @@ var ipsecTmpl = map[string]string{
 	"vxlan": `
 conn {{ .Name }}
     keyexchange=ike
-    ikev2=no
+    ikev2={{ if eq .Ike "ikev2" }}yes{{ else }}no{{ end }}
     type=transport
     left={{ .Left }}
 {{- if .LeftPort }}
@@ -52,16 +56,26 @@ conn {{ .Name }}
 {{- if .RightPort }}
     rightikeport={{ .RightPort }}
 {{- end }}
+{{- if .Cert }}
+    authby=rsasig
+    leftcert={{ .Cert }}
+    leftid=%fromcert
+    rightid=%fromcert
+    rightca=%same
+{{- else }}
     authby=secret
+{{- end }}
 
 conn {{ .Name }}-c1
     auto=add
     also={{ .Name }}
+{{- if not .Cert }}
 {{- if .LeftId }}
     leftid=@c1.{{ .LeftId }}.{{ .Transport }}
 {{- end }}
 {{- if .RightId }}
     rightid=@c2.{{ .RightId }}.{{ .Transport }}
+{{- end }}
 {{- end }}
     leftprotoport=udp/8472
     rightprotoport=udp
@@ -69,34 +83,44 @@ conn {{ .Name }}-c1
 conn {{ .Name }}-c2
     auto=add
     also={{ .Name }}
+{{- if not .Cert }}
 {{- if .LeftId }}
     leftid=@c2.{{ .LeftId }}.{{ .Transport }}
 {{- end }}
 {{- if .RightId }}
     rightid=@c1.{{ .RightId }}.{{ .Transport }}
+{{- end }}
 {{- end }}
     leftprotoport=udp
     rightprotoport=udp/8472`,
 	"gre": `
 conn {{ .Name }}-c1
     auto=add
-    ikev2=no
+    ikev2={{ if eq .Ike "ikev2" }}yes{{ else }}no{{ end }}
     type=transport
     left={{ .Left }}
 {{- if .LeftPort }}
     leftikeport={{ .LeftPort }}
 {{- end }}
+    right={{ .Right }}
+{{- if .RightPort }}
+    rightikeport={{ .RightPort }}
+{{- end }}
+{{- if .Cert }}
+    authby=rsasig
+    leftcert={{ .Cert }}
+    leftid=%fromcert
+    rightid=%fromcert
+    rightca=%same
+{{- else }}
 {{- if .LeftId }}
     leftid=@{{ .LeftId }}.{{ .Transport }}
 {{- end }}
-    right={{ .Right }}
 {{- if .RightId }}
     rightid=@{{ .RightId }}.{{ .Transport }}
-{{- end }}
-{{- if .RightPort }}
-    rightikeport={{ .RightPort }}
 {{- end }}
     authby=secret
+{{- end }}
     leftprotoport=gre
     rightprotoport=gre`,
 	"secret": `
@@ -173,7 +197,7 @@ func (w *IPSecWorker) addTunnel(tun *co.IPSecTunnel) error {
 		secTmpl = ipsecTmpl["secret"]
 	}
 
-	if secTmpl != "" {
+	if secTmpl != "" && tun.Cert == "" {
 		if err := w.saveSec(name+".secrets", secTmpl, tun); err != nil {
 			w.out.Error("WorkerImpl.AddTunnel %s", err)
 			return err
@@ -288,6 +312,8 @@ func (w *IPSecWorker) AddTunnel(data schema.IPSecTunnel) {
 		RightPort: data.RightPort,
 		RightId:   data.RightId,
 		Secret:    data.Secret,
+		Cert:      data.Cert,
+		Ike:       data.Ike,
 		Transport: data.Transport,
 	}
 	cfg.Correct()
@@ -308,6 +334,8 @@ func (w *IPSecWorker) DelTunnel(data schema.IPSecTunnel) {
 	cfg.Correct()
 	if _, removed := w.spec.DelTunnel(cfg); removed {
 		w.removeTunnel(cfg)
+		delete(w.sas, cfg.Name)
+		delete(w.errors, cfg.Name)
 	}
 }
 
@@ -338,8 +366,12 @@ func (w *IPSecWorker) ListTunnels(call func(obj schema.IPSecTunnel)) {
 			RightId:   tun.RightId,
 			RightPort: tun.RightPort,
 			Secret:    tun.Secret,
+			Cert:      tun.Cert,
+			Ike:       tun.Ike,
 			Transport: tun.Transport,
 			State:     tun.State,
+			Sas:       w.sas[tun.Name],
+			Error:     w.errors[tun.Name],
 		}
 		call(obj)
 	}
@@ -352,13 +384,52 @@ func (w *IPSecWorker) UpdateState() {
 	w.running = true
 	ticker := time.Tick(2 * time.Second)
 	for range ticker {
+		sas, err := WhackStatus()
+		if err != nil {
+			w.out.Debug("IPSecWorker.UpdateState: %s", err)
+		}
 		w.lock.Lock()
 		status := w.status()
 		for _, tun := range w.spec.Tunnels {
 			if state, ok := status[tun.Name+"-c1"]; ok {
 				tun.State = state
 			}
+			w.updateSas(tun, sas)
 		}
 		w.lock.Unlock()
 	}
 }
+
+func (w *IPSecWorker) conns(tun *co.IPSecTunnel) []string {
+	switch tun.Transport {
+	case "vxlan":
+		return []string{tun.Name + "-c1", tun.Name + "-c2"}
+	case "gre":
+		return []string{tun.Name + "-c1"}
+	}
+	return nil
+}
+
+func (w *IPSecWorker) updateSas(tun *co.IPSecTunnel, sas map[string][]schema.IPSecSA) {
+	values := make([]schema.IPSecSA, 0, 4)
+	established := false
+	for _, conn := range w.conns(tun) {
+		for _, sa := range sas[conn] {
+			if sa.Type == "IPsec" {
+				established = true
+			}
+			values = append(values, sa)
+		}
+	}
+	w.sas[tun.Name] = values
+	if established {
+		delete(w.errors, tun.Name)
+		return
+	}
+	for _, conn := range w.conns(tun) {
+		logFile := fmt.Sprintf("%s/%s.log", IPSecLogDir, conn)
+		if value := lastError(logFile); value != "" {
+			w.errors[tun.Name] = value
+		}
+	}
+}
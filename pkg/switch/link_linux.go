@@ -1,168 +1,146 @@
 package cswitch
 
 import (
-	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
 	"sync"
 
+	"github.com/luscis/openlan/pkg/access"
 	co "github.com/luscis/openlan/pkg/config"
 	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/libsock"
 	nl "github.com/vishvananda/netlink"
 )
 
-const (
-	AccessBin = "openlan-access"
-	AccessDir = "/var/openlan/access"
-)
-
-type Link struct {
-	cfg  *co.Access
-	out  *libol.SubLogger
-	uuid string
-}
-
-func NewLink(cfg *co.Access) *Link {
-	uuid := libol.GenString(13)
-	return &Link{
-		uuid: uuid,
-		cfg:  cfg,
-		out:  libol.NewSubLogger(cfg.Network),
+// nativeAccess is the access worker run by a native link.
+type nativeAccess interface {
+	Initialize()
+	Start()
+	Stop()
+	IfName() string
+	Status() libsock.SocketStatus
+	UpTime() int64
+	Statistics() map[string]int64
+	Record() map[string]int64
+}
+
+// NativeLink runs the access worker inside the switch process.
+type NativeLink struct {
+	cfg       *co.Access
+	access    nativeAccess
+	newAccess func(cfg *co.Access) nativeAccess
+	out       *libol.SubLogger
+	lock      sync.Mutex
+}
+
+func NewNativeLink(cfg *co.Access) *NativeLink {
+	return &NativeLink{
+		cfg: cfg,
+		newAccess: func(cfg *co.Access) nativeAccess {
+			return access.NewAccess(cfg)
+		},
+		out: libol.NewSubLogger(cfg.Network),
 	}
 }
 
-func (l *Link) Initialize() {
-	file := l.ConfFile()
-	l.cfg.Log = co.Log{File: l.LogFile()}
-	l.cfg.StatusFile = l.StatusFile()
-	l.cfg.PidFile = l.PidFile()
-	_ = libol.MarshalSave(l.cfg, file, true)
-}
-
-func (l *Link) Conf() *co.Access {
+func (l *NativeLink) Conf() *co.Access {
 	return l.cfg
 }
 
-func (l *Link) UUID() string {
-	return l.uuid
-}
+func (l *NativeLink) Start() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
 
-func (l *Link) Path() string {
-	return AccessBin
+	if l.access != nil {
+		return nil
+	}
+	l.cfg.Correct()
+	l.out.Info("NativeLink.Start: %s", l.cfg.ID())
+	l.access = l.newAccess(l.cfg)
+	l.access.Initialize()
+	l.access.Start()
+	return nil
 }
 
-func (l *Link) ID() string {
-	return l.cfg.ID()
-}
+func (l *NativeLink) Stop(kill bool) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
 
-func (l *Link) ConfFile() string {
-	return filepath.Join(AccessDir, l.ID()+".json")
+	if l.access == nil {
+		return nil
+	}
+	l.out.Info("NativeLink.Stop: %s", l.cfg.ID())
+	l.access.Stop()
+	l.access = nil
+	return nil
 }
 
-func (l *Link) StatusFile() string {
-	return filepath.Join(AccessDir, l.ID()+".status")
-}
+func (l *NativeLink) Device() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
 
-func (l *Link) PidFile() string {
-	return filepath.Join(AccessDir, l.ID()+".pid")
+	if l.access == nil {
+		return ""
+	}
+	return l.access.IfName()
 }
 
-func (l *Link) LogFile() string {
-	return filepath.Join(AccessDir, l.ID()+".log")
-}
+func (l *NativeLink) Status() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
 
-func (l *Link) Start() error {
-	pid := l.FindPid()
-	l.out.Info("Link.Start: older pid:%d", pid)
-	if pid > 0 {
-		if ok := libol.HasProcess(pid); ok {
-			l.out.Info("OpenVPN.Start: already running")
-			return nil
-		}
+	if l.access == nil {
+		return "down"
 	}
-	libol.Go(func() {
-		file := l.ConfFile()
-		args := []string{
-			"-conf", file,
-		}
-		l.out.Info("%s with %s", l.Path(), args)
-		cmd := exec.Command(l.Path(), args...)
-		if err := cmd.Start(); err != nil {
-			l.out.Error("Link.Start %s: %s", l.uuid, err)
-		}
-		cmd.Wait()
-	})
-	return nil
+	return l.access.Status().String()
 }
 
-func (l *Link) FindPid() int {
-	pid := 0
-	if v, err := os.ReadFile(l.PidFile()); err == nil {
-		fmt.Sscanf(string(v), "%d", &pid)
-	}
-	return pid
-}
+func (l *NativeLink) UpTime() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
 
-func (l *Link) Clean() {
-	files := []string{
-		l.LogFile(), l.StatusFile(), l.PidFile(), l.ConfFile(),
-	}
-	for _, file := range files {
-		if err := libol.FileExist(file); err == nil {
-			if err := os.Remove(file); err != nil {
-				l.out.Warn("Link.Clean %s", err)
-			}
-		}
+	if l.access == nil {
+		return 0
 	}
+	return l.access.UpTime()
 }
 
-func (l *Link) kill() {
-	pid := l.FindPid()
-	if pid == 0 {
-		return
-	}
-	l.out.Info("Link.Kill %d", pid)
-	if proc, err := libol.Kill(pid); err == nil {
-		proc.Wait()
-		if err := os.Remove(l.PidFile()); err != nil {
-			l.out.Warn("Link.Kill: %s", err)
-		}
-	} else {
-		l.out.Warn("Link.Kill: %d %s", pid, err)
+func (l *NativeLink) Statistics() map[string]int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.access == nil {
+		return nil
 	}
+	return l.access.Statistics()
 }
 
-func (l *Link) Stop(kill bool) error {
-	if kill {
-		l.kill()
-	}
-	if pid := l.FindPid(); libol.HasProcess(pid) {
-		l.out.Info("Link.Stop: without stoping: %d", pid)
-	} else {
-		l.Clean()
+func (l *NativeLink) Record() map[string]int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.access == nil {
+		return nil
 	}
-	return nil
+	return l.access.Record()
 }
 
 type Links struct {
 	lock  sync.RWMutex
-	links map[string]*Link
+	links map[string]*NativeLink
 }
 
 func NewLinks() *Links {
 	return &Links{
-		links: make(map[string]*Link),
+		links: make(map[string]*NativeLink),
 	}
 }
 
-func (ls *Links) Add(l *Link) {
+func (ls *Links) Add(l *NativeLink) {
 	ls.lock.Lock()
 	defer ls.lock.Unlock()
 	ls.links[l.cfg.Connection] = l
 }
 
-func (ls *Links) Remove(addr string) *Link {
+func (ls *Links) Remove(addr string) *NativeLink {
 	ls.lock.Lock()
 	defer ls.lock.Unlock()
 	if p, ok := ls.links[addr]; ok {
@@ -0,0 +1,170 @@
+package cswitch
+
+import (
+	"testing"
+
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/libsock"
+	"github.com/luscis/openlan/pkg/models"
+)
+
+type fakeAccess struct {
+	started int
+	stopped int
+	status  libsock.SocketStatus
+}
+
+func (a *fakeAccess) Initialize() {}
+
+func (a *fakeAccess) Start() {
+	a.started++
+	a.status = libsock.ClConnecting
+}
+
+func (a *fakeAccess) Stop() {
+	a.stopped++
+	a.status = libsock.ClClosed
+}
+
+func (a *fakeAccess) IfName() string {
+	return "tap-fake"
+}
+
+func (a *fakeAccess) Status() libsock.SocketStatus {
+	return a.status
+}
+
+func (a *fakeAccess) UpTime() int64 {
+	return 10
+}
+
+func (a *fakeAccess) Statistics() map[string]int64 {
+	return map[string]int64{libsock.CsSendOkay: 1}
+}
+
+func (a *fakeAccess) Record() map[string]int64 {
+	return map[string]int64{"conns": 1}
+}
+
+func newTestLink(accesses *[]*fakeAccess) *NativeLink {
+	link := NewNativeLink(&co.Access{
+		Network:    "fake",
+		Connection: "192.168.1.2",
+		Username:   "hi",
+	})
+	link.newAccess = func(cfg *co.Access) nativeAccess {
+		a := &fakeAccess{}
+		*accesses = append(*accesses, a)
+		return a
+	}
+	return link
+}
+
+func TestNativeLinkLifecycle(t *testing.T) {
+	var accesses []*fakeAccess
+	link := newTestLink(&accesses)
+	if link.Status() != "down" || link.Device() != "" || link.UpTime() != 0 {
+		t.Errorf("expected down before started")
+	}
+	if link.Statistics() != nil || link.Record() != nil {
+		t.Errorf("expected no statistics before started")
+	}
+
+	_ = link.Start()
+	_ = link.Start()
+	if len(accesses) != 1 || accesses[0].started != 1 {
+		t.Fatalf("expected started once, got %d", len(accesses))
+	}
+	if link.Conf().Connection != "192.168.1.2:10002" {
+		t.Errorf("expected corrected connection, got %s", link.Conf().Connection)
+	}
+	if link.Status() != "connecting" || link.Device() != "tap-fake" || link.UpTime() != 10 {
+		t.Errorf("expected running, got %s", link.Status())
+	}
+	if link.Record()["conns"] != 1 || link.Statistics()[libsock.CsSendOkay] != 1 {
+		t.Errorf("expected statistics of access")
+	}
+
+	_ = link.Stop(false)
+	_ = link.Stop(false)
+	if accesses[0].stopped != 1 {
+		t.Errorf("expected stopped once, got %d", accesses[0].stopped)
+	}
+	if link.Status() != "down" || link.Device() != "" {
+		t.Errorf("expected down after stopped")
+	}
+
+	// restarted by a new access worker.
+	_ = link.Start()
+	if len(accesses) != 2 || accesses[1].started != 1 {
+		t.Fatalf("expected restarted, got %d", len(accesses))
+	}
+	links := NewLinks()
+	links.Add(link)
+	if links.Remove("192.168.1.2:10002") != link || accesses[1].stopped != 1 {
+		t.Errorf("expected stopped by remove")
+	}
+	if links.Remove("192.168.1.2:10002") != nil {
+		t.Errorf("expected removed")
+	}
+}
+
+func TestNativeLinkDelOutput(t *testing.T) {
+	var accesses []*fakeAccess
+	link := newTestLink(&accesses)
+	_ = link.Start()
+	w := &WorkerImpl{
+		cfg: &co.Network{Name: "fake"},
+		out: libol.NewSubLogger("fake"),
+	}
+	port := &co.Output{
+		Protocol: "tcp",
+		Link:     "fake-out0",
+		Linker:   link,
+	}
+	cache.Output.Add(port.Link, &models.Output{Network: "fake", Linker: link})
+	w.delOutput("br-fake", port, false)
+	if accesses[0].stopped != 1 || link.Status() != "down" {
+		t.Errorf("expected link stopped by delOutput")
+	}
+	if cache.Output.Get(port.Link) != nil {
+		t.Errorf("expected output deleted")
+	}
+}
+
+func TestNativeLinkOutputAccess(t *testing.T) {
+	w := &WorkerImpl{
+		cfg: &co.Network{Name: "fake", Alias: "sw1"},
+	}
+	ac := w.outputAccess("br-fake", &co.Output{
+		Protocol: "tls",
+		Remote:   "192.168.1.2",
+		DstPort:  10003,
+		Fallback: "192.168.1.3",
+		Secret:   "hi@fake:pass",
+		Crypt:    "aes-128:secret",
+	})
+	if ac.Alias != "sw1" || ac.Network != "fake" || ac.Interface.Bridge != "br-fake" {
+		t.Errorf("unexpected %s %s %s", ac.Alias, ac.Network, ac.Interface.Bridge)
+	}
+	if ac.Connection != "192.168.1.2:10003" || ac.Fallback != "192.168.1.3" || ac.Protocol != "tls" {
+		t.Errorf("unexpected %s %s %s", ac.Connection, ac.Fallback, ac.Protocol)
+	}
+	if ac.Username != "hi@fake" || ac.Password != "pass" || ac.RequestAddr {
+		t.Errorf("unexpected %s %s", ac.Username, ac.Password)
+	}
+	if ac.Crypt == nil || ac.Crypt.Algo != "aes-128" || ac.Crypt.Secret != "secret" {
+		t.Errorf("unexpected crypt %v", ac.Crypt)
+	}
+
+	ac = w.outputAccess("br-fake", &co.Output{
+		Protocol: "udp",
+		Remote:   "192.168.1.2",
+		Secret:   "hi@fake",
+	})
+	if ac.Connection != "192.168.1.2" || ac.Password != "" || ac.Crypt != nil {
+		t.Errorf("unexpected %s %s %v", ac.Connection, ac.Password, ac.Crypt)
+	}
+}
@@ -0,0 +1,104 @@
+package cswitch
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/libol"
+	cn "github.com/luscis/openlan/pkg/network"
+)
+
+// Blocker drops sources blocked by lockout of logins at ports of listeners.
+// The ipset is IPv4 only, as the firewall isn't for IPv6, and IPv6 sources
+// are locked out of logins but not dropped.
+type Blocker struct {
+	ipset  *cn.IPSet
+	out    *libol.SubLogger
+	ticker libol.Ticker
+}
+
+func NewBlocker() *Blocker {
+	return &Blocker{
+		ipset: cn.NewIPSet("LOCKOUT", "hash:ip"),
+		out:   libol.NewSubLogger("blocker"),
+	}
+}
+
+// maxMultiPorts is the max ports of a multiport match.
+const maxMultiPorts = 15
+
+func uniqPorts(ports []string) []string {
+	has := make(map[string]bool, len(ports))
+	values := make([]string, 0, len(ports))
+	for _, port := range ports {
+		if port == "" || has[port] {
+			continue
+		}
+		has[port] = true
+		values = append(values, port)
+	}
+	return values
+}
+
+// Initialize drops blocked sources at ports of listeners, and other ports
+// are not dropped, e.g. ssh of admin.
+func (b *Blocker) Initialize(fire *cn.FireWallGlobal, udp, tcp []string) {
+	if out, err := b.ipset.Clear(); err != nil {
+		b.out.Warn("Blocker.Initialize: %s", out)
+	}
+	for _, proto := range []string{"udp", "tcp"} {
+		ports := udp
+		if proto == "tcp" {
+			ports = tcp
+		}
+		ports = uniqPorts(ports)
+		for len(ports) > 0 {
+			size := len(ports)
+			if size > maxMultiPorts {
+				size = maxMultiPorts
+			}
+			fire.AddRule(cn.IPRule{
+				Table:   cn.TFilter,
+				Chain:   cn.OLCInput,
+				Proto:   proto,
+				Match:   "multiport",
+				DstPort: strings.Join(ports[:size], ","),
+				SrcSet:  b.ipset.Name,
+				Jump:    "DROP",
+				Comment: "Drop Blocked Sources",
+			})
+			ports = ports[size:]
+		}
+	}
+}
+
+func (b *Blocker) Block(addr string) {
+	if ip := net.ParseIP(addr); ip == nil || ip.To4() == nil {
+		b.out.Info("Blocker.Block: %s isn't dropped but locked out", addr)
+		return
+	}
+	if out, err := b.ipset.Add(addr); err != nil {
+		b.out.Warn("Blocker.Block: %s %s", addr, out)
+	}
+}
+
+func (b *Blocker) Unblock(addr string) {
+	if ip := net.ParseIP(addr); ip == nil || ip.To4() == nil {
+		return
+	}
+	if out, err := b.ipset.Del(addr); err != nil {
+		b.out.Warn("Blocker.Unblock: %s %s", addr, out)
+	}
+}
+
+func (b *Blocker) Start() {
+	b.out.Info("Blocker.Start")
+	b.ticker.Start(10*time.Second, cache.Lockout.Expire)
+}
+
+func (b *Blocker) Stop() {
+	b.out.Info("Blocker.Stop")
+	b.ticker.Stop()
+}
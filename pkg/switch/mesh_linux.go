@@ -0,0 +1,444 @@
+package cswitch
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	cn "github.com/luscis/openlan/pkg/network"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+type MeshPort struct {
+	Alias     string
+	Address   string
+	Device    string
+	Direction string
+	Status    string
+	Uptime    int64
+}
+
+// Mesh discovers the switches from seeds, and links to them in process.
+// Every switch computes the same spanning tree from the adjacencies it
+// learned, and disables the bridge ports not in the tree to avoid loops.
+type Mesh struct {
+	cfg     *co.MeshSpecifies
+	alias   string
+	bridge  string
+	links   map[string]*NativeLink // address to outgoing link
+	reports map[string]*schema.Mesh
+	addrs   map[string]string
+	selves  map[string]bool
+	root    string
+	states  map[string]string
+	out     *libol.SubLogger
+	lock    sync.Mutex
+	cancel  context.CancelFunc
+}
+
+func NewMesh(cfg *co.MeshSpecifies, alias, bridge string) *Mesh {
+	return &Mesh{
+		cfg:     cfg,
+		alias:   alias,
+		bridge:  bridge,
+		links:   make(map[string]*NativeLink),
+		reports: make(map[string]*schema.Mesh),
+		addrs:   make(map[string]string),
+		selves:  make(map[string]bool),
+		states:  make(map[string]string),
+		out:     libol.NewSubLogger(cfg.Name),
+	}
+}
+
+func (m *Mesh) Start() {
+	m.out.Info("Mesh.Start: %s with %v", m.alias, m.cfg.Seeds)
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(time.Duration(m.cfg.Interval) * time.Second)
+	m.lock.Lock()
+	m.cancel = cancel
+	m.lock.Unlock()
+	libol.Go(func() {
+		defer ticker.Stop()
+		m.Discover(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Discover(ctx)
+			}
+		}
+	})
+}
+
+func (m *Mesh) Stop() {
+	m.out.Info("Mesh.Stop")
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.cancel == nil {
+		return
+	}
+	// cancel the fetches in discovering, and it never connects after.
+	m.cancel()
+	m.cancel = nil
+	for addr, link := range m.links {
+		link.Stop(true)
+		delete(m.links, addr)
+	}
+}
+
+func (m *Mesh) user() (string, string) {
+	name, pass := SplitCombined(m.cfg.Secret)
+	return name, pass
+}
+
+// cert verifies peers by the CA of this switch, unless insecure is
+// configured.
+func (m *Mesh) cert() *co.Cert {
+	cert := &co.Cert{Insecure: m.cfg.Insecure}
+	if sw := co.Get(); sw != nil && sw.Cert != nil {
+		cert.CaFile = sw.Cert.CaFile
+	}
+	return cert
+}
+
+func (m *Mesh) fetch(ctx context.Context, host string) (*schema.Mesh, error) {
+	name, pass := m.user()
+	cert := m.cert()
+	config := &tls.Config{InsecureSkipVerify: cert.Insecure}
+	if !cert.Insecure {
+		config.RootCAs = cert.GetCertPool()
+	}
+	client := libol.HttpClient{
+		Url: fmt.Sprintf("https://%s:%d/api/network/%s/mesh", host, m.cfg.ApiPort, m.cfg.Name),
+		Auth: libol.Auth{
+			Type:     "basic",
+			Username: name,
+			Password: pass,
+		},
+		TlsConfig: config,
+		Timeout:   10 * time.Second,
+		Context:   ctx,
+	}
+	resp, err := client.Do()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	defer client.Close()
+	if resp.StatusCode != 200 {
+		return nil, libol.NewErr("%s: %s", host, resp.Status)
+	}
+	data := &schema.Mesh{}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (m *Mesh) isSeed(host string) bool {
+	for _, seed := range m.cfg.Seeds {
+		if m.cfg.Host(seed) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// Discover fetches the peers of the known switches, links to the new
+// peers and recomputes the forwarding tree.
+func (m *Mesh) Discover(ctx context.Context) {
+	hosts := make(map[string]bool)
+	for _, seed := range m.cfg.Seeds {
+		hosts[m.cfg.Host(seed)] = true
+	}
+	m.lock.Lock()
+	for _, addr := range m.addrs {
+		hosts[addr] = true
+	}
+	for _, report := range m.reports {
+		for _, peer := range report.Peers {
+			if peer.Alias != m.alias && peer.Address != "" {
+				hosts[peer.Address] = true
+			}
+		}
+	}
+	m.lock.Unlock()
+
+	reports := make(map[string]*schema.Mesh)
+	addrs := make(map[string]string)
+	for host := range hosts {
+		if m.selves[host] {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		report, err := m.fetch(ctx, host)
+		if err != nil {
+			m.out.Debug("Mesh.Discover: %s", err)
+			continue
+		}
+		if report.Alias == m.alias {
+			// seed is myself, never link to it.
+			m.out.Warn("Mesh.Discover: %s is a loop to myself", host)
+			m.selves[host] = true
+			continue
+		}
+		reports[report.Alias] = report
+		addrs[report.Alias] = host
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.reports = reports
+	for alias, addr := range addrs {
+		m.addrs[alias] = addr
+	}
+	m.connect()
+	m.compute()
+}
+
+func (m *Mesh) newLink(addr string) *NativeLink {
+	name, pass := m.user()
+	algo, secret := SplitCombined(m.cfg.Crypt)
+	ac := &co.Access{
+		Alias:       m.alias,
+		Network:     m.cfg.Name,
+		RequestAddr: false,
+		Interface: co.Interface{
+			Bridge: m.bridge,
+		},
+		Connection: addr,
+		Protocol:   m.cfg.Protocol,
+		Username:   name,
+		Password:   pass,
+	}
+	if m.cfg.Protocol == "tls" || m.cfg.Protocol == "wss" {
+		ac.Cert = m.cert()
+	}
+	if secret != "" {
+		ac.Crypt = &co.Crypt{
+			Algo:   algo,
+			Secret: secret,
+		}
+	}
+	return NewNativeLink(ac)
+}
+
+func (m *Mesh) connect() {
+	inbound := make(map[string]bool)
+	for _, port := range m.inbound() {
+		inbound[port.Alias] = true
+	}
+	for alias, addr := range m.addrs {
+		if _, ok := m.links[addr]; ok {
+			continue
+		}
+		if inbound[alias] {
+			continue
+		}
+		// the lower switch dials the higher, except the seeds which may
+		// not known me.
+		if m.alias > alias && !m.isSeed(addr) {
+			continue
+		}
+		m.out.Info("Mesh.connect: %s on %s", alias, addr)
+		link := m.newLink(addr)
+		if err := link.Start(); err != nil {
+			m.out.Warn("Mesh.connect: %s %s", addr, err)
+			continue
+		}
+		m.links[addr] = link
+	}
+}
+
+func (m *Mesh) inbound() []MeshPort {
+	user, _ := m.user()
+	user = strings.SplitN(user, "@", 2)[0]
+	ports := make([]MeshPort, 0, 8)
+	for obj := range cache.Access.List() {
+		if obj == nil {
+			break
+		}
+		if obj.Network != m.cfg.Name || obj.User != user || obj.Alias == m.alias {
+			continue
+		}
+		port := MeshPort{
+			Alias:     obj.Alias,
+			Direction: "in",
+			Status:    "online",
+			Uptime:    obj.Uptime,
+		}
+		if obj.Client != nil {
+			port.Address = strings.SplitN(obj.Client.String(), ":", 2)[0]
+		}
+		if obj.Device != nil {
+			port.Device = obj.Device.Name()
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+func (m *Mesh) outbound() []MeshPort {
+	ports := make([]MeshPort, 0, 8)
+	for addr, link := range m.links {
+		port := MeshPort{
+			Address:   addr,
+			Device:    link.Device(),
+			Direction: "out",
+			Status:    link.Status(),
+			Uptime:    link.UpTime(),
+		}
+		for alias, value := range m.addrs {
+			if value == addr {
+				port.Alias = alias
+			}
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+func (m *Mesh) ports() []MeshPort {
+	return append(m.outbound(), m.inbound()...)
+}
+
+func (p *MeshPort) IsUp() bool {
+	return p.Status == "online" || p.Status == "authenticated"
+}
+
+func meshEdge(a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
+
+// meshTree returns the edges of a spanning tree by kruskal with sorted
+// edges, so all switches get the same tree from the same adjacencies.
+func meshTree(edges []string) map[string]bool {
+	sort.Strings(edges)
+	parent := make(map[string]string)
+	var find func(x string) string
+	find = func(x string) string {
+		if p, ok := parent[x]; ok && p != x {
+			parent[x] = find(p)
+			return parent[x]
+		}
+		parent[x] = x
+		return x
+	}
+	tree := make(map[string]bool)
+	for _, edge := range edges {
+		values := strings.SplitN(edge, "|", 2)
+		if len(values) != 2 {
+			continue
+		}
+		ra, rb := find(values[0]), find(values[1])
+		if ra == rb {
+			continue
+		}
+		parent[rb] = ra
+		tree[edge] = true
+	}
+	return tree
+}
+
+func (m *Mesh) compute() {
+	ports := m.ports()
+	edges := make(map[string]bool)
+	m.root = m.alias
+	for _, port := range ports {
+		if port.Alias != "" && port.IsUp() {
+			edges[meshEdge(m.alias, port.Alias)] = true
+		}
+	}
+	for alias, report := range m.reports {
+		if alias < m.root {
+			m.root = alias
+		}
+		for _, peer := range report.Peers {
+			if peer.Alias == "" || (peer.Status != "online" && peer.Status != "authenticated") {
+				continue
+			}
+			edges[meshEdge(alias, peer.Alias)] = true
+		}
+	}
+	keys := make([]string, 0, len(edges))
+	for edge := range edges {
+		keys = append(keys, edge)
+	}
+	tree := meshTree(keys)
+
+	// both ends see the two links between a pair, and keep the one dialed
+	// by the lower switch.
+	dialer := make(map[string]string)
+	for _, port := range ports {
+		if !port.IsUp() {
+			continue
+		}
+		if _, ok := dialer[port.Alias]; !ok || (m.alias < port.Alias) == (port.Direction == "out") {
+			dialer[port.Alias] = port.Direction
+		}
+	}
+	for _, port := range ports {
+		if port.Device == "" {
+			continue
+		}
+		state := "blocking"
+		if tree[meshEdge(m.alias, port.Alias)] && dialer[port.Alias] == port.Direction {
+			state = "forwarding"
+		}
+		if m.states[port.Device] == state {
+			continue
+		}
+		value := cn.BrPortDisabled
+		if state == "forwarding" {
+			value = cn.BrPortForwarding
+		}
+		m.out.Info("Mesh.compute: %s to %s is %s", port.Device, port.Alias, state)
+		if err := cn.NewBrPort(port.Device).State(value); err != nil {
+			m.out.Warn("Mesh.compute: %s", err)
+			continue
+		}
+		m.states[port.Device] = state
+	}
+}
+
+func (m *Mesh) Get() *schema.Mesh {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	data := &schema.Mesh{
+		Name:  m.cfg.Name,
+		Alias: m.alias,
+		Root:  m.root,
+		Peers: make([]schema.MeshPeer, 0, 8),
+	}
+	for _, port := range m.ports() {
+		data.Peers = append(data.Peers, schema.MeshPeer{
+			Alias:     port.Alias,
+			Address:   port.Address,
+			Device:    port.Device,
+			Direction: port.Direction,
+			State:     m.states[port.Device],
+			Status:    port.Status,
+			Uptime:    port.Uptime,
+		})
+	}
+	return data
+}
@@ -0,0 +1,35 @@
+package cswitch
+
+import "testing"
+
+func TestMeshTreeLoopFree(t *testing.T) {
+	// a triangle of a, b and c with a tail of d.
+	edges := []string{
+		meshEdge("c", "a"),
+		meshEdge("b", "c"),
+		meshEdge("a", "b"),
+		meshEdge("d", "c"),
+	}
+	tree := meshTree(edges)
+	if len(tree) != 3 {
+		t.Errorf("expected 3 edges, got %v", tree)
+	}
+	if tree["b|c"] {
+		t.Errorf("b|c should be blocked, got %v", tree)
+	}
+	for _, edge := range []string{"a|b", "a|c", "c|d"} {
+		if !tree[edge] {
+			t.Errorf("%s should be forwarding, got %v", edge, tree)
+		}
+	}
+}
+
+func TestMeshTreeSameOrder(t *testing.T) {
+	a := meshTree([]string{"x|y", "y|z", "x|z"})
+	b := meshTree([]string{"x|z", "x|y", "y|z"})
+	for edge := range a {
+		if !b[edge] {
+			t.Errorf("trees differ %v and %v", a, b)
+		}
+	}
+}
@@ -45,22 +45,28 @@ func SplitCombined(value string) (string, string) {
 }
 
 type WorkerImpl struct {
-	uuid    string
-	cfg     *co.Network
-	out     *libol.SubLogger
-	dhcp    *Dhcp
-	fire    *cn.FireWallTable
-	ipser   *cn.IPSet
-	vpn     *OpenVPN
-	ztrust  *ZTrust
-	qos     *QosCtrl
-	vrf     *cn.VRF
-	table   int
-	br      cn.Bridger
-	acl     *ACL
-	findhop *FindHop
-	snat    *cn.FireWallChain
-	dnat    *cn.FireWallChain
+	uuid     string
+	cfg      *co.Network
+	out      *libol.SubLogger
+	dhcp     *Dhcp
+	fire     *cn.FireWallTable
+	ipser    *cn.IPSet
+	vpn      *OpenVPN
+	ztrust   *ZTrust
+	qos      *QosCtrl
+	vrf      *cn.VRF
+	table    int
+	br       cn.Bridger
+	acl      *ACL
+	findhop  *FindHop
+	snat     *cn.FireWallChain
+	dnat     *cn.FireWallChain
+	fabric   *Fabric
+	mesh     *Mesh
+	wg       *WireGuard
+	guard    *SourceGuard
+	fast     *FastPath
+	suppress *Suppressor
 }
 
 func NewWorkerApi(c *co.Network) *WorkerImpl {
@@ -132,6 +138,7 @@ func (w *WorkerImpl) Initialize() {
 
 	w.ztrust = NewZTrust(cfg.Name, 30)
 	w.ztrust.Initialize()
+	w.ztrust.loadPolicy(cfg.ZPolicy)
 
 	w.qos = NewQosCtrl(cfg.Name)
 	w.qos.Initialize()
@@ -139,6 +146,38 @@ func (w *WorkerImpl) Initialize() {
 	if cfg.Dhcp == "enable" && cfg.Bridge != nil {
 		w.dhcp = w.newDHCP()
 	}
+	if cfg.VxLAN != nil && cfg.Bridge != nil {
+		w.fabric = NewFabric(cfg.VxLAN, cfg.Bridge.Name)
+	}
+	if cfg.Mesh != nil && cfg.Bridge != nil {
+		w.mesh = NewMesh(cfg.Mesh, cfg.Alias, cfg.Bridge.Name)
+	}
+	if cfg.WireGuard != nil && cfg.Bridge != nil {
+		w.wg = NewWireGuard(cfg.WireGuard, cfg.Bridge.Name, w.table)
+		w.wg.Initialize()
+	}
+	if cfg.Guard == "enable" && cfg.Bridge != nil {
+		exempt := ""
+		if cfg.Mesh != nil {
+			exempt = guardExempt(cfg.Mesh.Secret)
+		}
+		w.guard = NewSourceGuard(cfg.Name, exempt)
+	}
+	if cfg.Fastpath == "enable" && cfg.Bridge != nil {
+		if policies := cfg.FastpathBypass(co.GetAcl(cfg.Name), co.GetQos(cfg.Name)); len(policies) > 0 {
+			w.out.Warn("WorkerImpl.Initialize: fastpath disabled by %v", policies)
+		} else {
+			w.fast = NewFastPath(cfg.Name)
+			w.acl.fastpath = true
+			w.qos.fastpath = true
+		}
+	}
+	if cfg.Suppress != nil && cfg.Bridge != nil {
+		w.suppress = NewSuppressor(cfg.Name, cfg.Suppress)
+		if w.fast != nil && cfg.Suppress.Snooping == "enable" {
+			w.fast.snoop = w.suppress
+		}
+	}
 
 	w.toSubnet()
 	w.toVPN()
@@ -241,36 +280,28 @@ func (w *WorkerImpl) addOutput(bridge string, port *co.Output) {
 		cn.LinkSet(port.Link, "mtu", strconv.Itoa(mtu))
 		port.Linker = link
 	case "tcp", "udp", "tls", "wss":
-		name, pass := SplitCombined(port.Secret)
-		algo, secret := SplitCombined(port.Crypt)
-		ac := co.Access{
-			Alias:       w.cfg.Alias,
-			Network:     w.cfg.Name,
-			RequestAddr: false,
-			Interface: co.Interface{
-				Bridge: bridge,
-			},
-			Connection: port.Remote,
-			Fallback:   port.Fallback,
-			Protocol:   port.Protocol,
-			Username:   name,
-			Password:   pass,
+		link := NewNativeLink(w.outputAccess(bridge, port))
+		if err := link.Start(); err != nil {
+			w.out.Warn("WorkerImpl.LinkStart %s %s", port.Id(), err)
 		}
-		if port.DstPort != 0 {
-			ac.Connection = fmt.Sprintf("%s:%d", port.Remote, port.DstPort)
+		port.Linker = link
+		out.Linker = link
+	case "wireguard":
+		if w.wg == nil {
+			w.out.Error("WorkerImpl.addOutput %s wireguard disabled", port.Id())
+			return
 		}
-		if secret != "" {
-			ac.Crypt = &co.Crypt{
-				Algo:   algo,
-				Secret: secret,
-			}
+		if port.Segment > 0 || len(port.AllowedIPs) == 0 {
+			// bridged by gretap over wireguard is not supported.
+			w.out.Error("WorkerImpl.addOutput %s routed by allowedIps only", port.Id())
+			return
+		}
+		link := NewWireGuardLink(w.wg, port)
+		if err := link.Start(); err != nil {
+			w.out.Warn("WorkerImpl.LinkStart %s %s", port.Id(), err)
 		}
-		link := NewLink(&ac)
-		link.Initialize()
-		link.Start()
 		port.Linker = link
-		out.StatsFile = link.StatusFile()
-		out.PidFile = link.PidFile()
+		out.Linker = link
 	default:
 		link, err := nl.LinkByName(port.Remote)
 		if link == nil {
@@ -311,6 +342,7 @@ func (w *WorkerImpl) addOutput(bridge string, port *co.Output) {
 	out.Secret = port.Secret
 	out.Crypt = port.Crypt
 	out.Fallback = port.Fallback
+	out.AllowedIPs = port.AllowedIPs
 	cache.Output.Add(port.Link, out)
 
 	w.out.Info("WorkerImpl.addOutput %s %s", port.Link, port.Id())
@@ -653,6 +685,9 @@ func (w *WorkerImpl) leftTrust() {
 
 func (w *WorkerImpl) DoZTrust() error {
 	cfg, vpn := w.GetCfgs()
+	if w.fast != nil {
+		return libol.NewErr("ztrust is bypassed by fastpath")
+	}
 	if cfg.ZTrust != "enable" {
 		cfg.ZTrust = "enable"
 		if vpn != nil {
@@ -707,20 +742,11 @@ func (w *WorkerImpl) setVPN2VRF() {
 
 func (w *WorkerImpl) toVPNQoS() {
 	_, vpn := w.GetCfgs()
-	w.fire.Mangle.In.AddRuleX(cn.IPRule{
-		Input:   vpn.Device,
-		Jump:    w.qos.ChainIn(),
-		Comment: "Goto Qos ChainIn",
-	})
+	w.qos.SetDevice(vpn.Device)
 }
 
 func (w *WorkerImpl) leftVPNQoS() {
-	_, vpn := w.GetCfgs()
-	w.fire.Mangle.In.DelRuleX(cn.IPRule{
-		Input:   vpn.Device,
-		Jump:    w.qos.ChainIn(),
-		Comment: "Goto Qos ChainIn",
-	})
+	w.qos.SetDevice("")
 }
 
 func (w *WorkerImpl) Start(v api.SwitchApi) {
@@ -736,9 +762,26 @@ func (w *WorkerImpl) Start(v api.SwitchApi) {
 	if w.br != nil {
 		w.toACL(w.br.L3Name())
 		w.toBridgeACL(w.br.Name())
+		if w.wg != nil {
+			w.wg.Start()
+		}
 		for _, output := range cfg.Outputs {
 			w.addOutput(cfg.Bridge.Name, output)
 		}
+		if w.fabric != nil {
+			w.fabric.Start()
+		}
+		if w.mesh != nil {
+			w.mesh.Start()
+		}
+		if w.fast != nil {
+			if err := w.fast.Start(w.br); err != nil {
+				w.out.Error("WorkerImpl.Start: fastpath %s", err)
+			}
+		}
+		if w.suppress != nil {
+			w.suppress.Start(w.br)
+		}
 	}
 
 	if !(w.vpn == nil) {
@@ -747,8 +790,8 @@ func (w *WorkerImpl) Start(v api.SwitchApi) {
 			w.setVPN2VRF()
 		}
 		w.toVPNQoS()
-		w.qos.Start()
 	}
+	w.qos.Start()
 	w.ztrust.Start()
 
 	w.fire.Start()
@@ -783,6 +826,35 @@ func (w *WorkerImpl) DelPhysical(bridge string, output string) {
 	}
 }
 
+// outputAccess returns config of the access worker to an output.
+func (w *WorkerImpl) outputAccess(bridge string, port *co.Output) *co.Access {
+	name, pass := SplitCombined(port.Secret)
+	algo, secret := SplitCombined(port.Crypt)
+	ac := co.Access{
+		Alias:       w.cfg.Alias,
+		Network:     w.cfg.Name,
+		RequestAddr: false,
+		Interface: co.Interface{
+			Bridge: bridge,
+		},
+		Connection: port.Remote,
+		Fallback:   port.Fallback,
+		Protocol:   port.Protocol,
+		Username:   name,
+		Password:   pass,
+	}
+	if port.DstPort != 0 {
+		ac.Connection = fmt.Sprintf("%s:%d", port.Remote, port.DstPort)
+	}
+	if secret != "" {
+		ac.Crypt = &co.Crypt{
+			Algo:   algo,
+			Secret: secret,
+		}
+	}
+	return &ac
+}
+
 func (w *WorkerImpl) delOutput(bridge string, port *co.Output, kill bool) {
 	w.out.Info("WorkerImpl.delOutput %s", port.Link)
 
@@ -946,9 +1018,9 @@ func (w *WorkerImpl) Stop(kill bool) {
 		w.leftRoutes()
 	}
 
+	w.qos.Stop()
 	if !(w.vpn == nil) {
 		w.ztrust.Stop()
-		w.qos.Stop()
 		w.vpn.Stop(kill)
 	}
 	if !(w.dhcp == nil) {
@@ -962,6 +1034,21 @@ func (w *WorkerImpl) Stop(kill bool) {
 			w.delOutput(cfg.Bridge.Name, output, kill)
 		}
 	}
+	if w.fabric != nil {
+		w.fabric.Stop(kill)
+	}
+	if w.fast != nil {
+		w.fast.Stop()
+	}
+	if w.suppress != nil {
+		w.suppress.Stop()
+	}
+	if w.mesh != nil {
+		w.mesh.Stop()
+	}
+	if w.wg != nil {
+		w.wg.Stop(kill)
+	}
 	if kill {
 		w.ipser.Destroy()
 	}
@@ -1068,7 +1155,7 @@ func (w *WorkerImpl) closePort(protocol, port, comment string) {
 }
 
 func (w *WorkerImpl) toForward_i(input, dSet, comment string) {
-	w.out.Debug("WorkerImpl.toForward %s %s:%s", input, dSet)
+	w.out.Debug("WorkerImpl.toForward %s %s", input, dSet)
 	// Allowed forward between source and prefix.
 	w.fire.Filter.For.AddRuleX(cn.IPRule{
 		Input:   input,
@@ -1078,7 +1165,7 @@ func (w *WorkerImpl) toForward_i(input, dSet, comment string) {
 }
 
 func (w *WorkerImpl) toForward_r(input, source, dSet, comment string) {
-	w.out.Debug("WorkerImpl.toForward %s:%s %s:%s", input, source, dSet)
+	w.out.Debug("WorkerImpl.toForward %s:%s %s", input, source, dSet)
 	// Allowed forward between source and prefix.
 	w.fire.Filter.For.AddRuleX(cn.IPRule{
 		Input:   input,
@@ -1089,7 +1176,7 @@ func (w *WorkerImpl) toForward_r(input, source, dSet, comment string) {
 }
 
 func (w *WorkerImpl) leftForward_r(input, source, dSet, comment string) {
-	w.out.Debug("WorkerImpl.leftForward %s:%s %s:%s", input, source, dSet)
+	w.out.Debug("WorkerImpl.leftForward %s:%s %s", input, source, dSet)
 	// Allowed forward between source and prefix.
 	w.fire.Filter.For.DelRuleX(cn.IPRule{
 		Input:   input,
@@ -1100,7 +1187,7 @@ func (w *WorkerImpl) leftForward_r(input, source, dSet, comment string) {
 }
 
 func (w *WorkerImpl) toForward_s(input, sSet, prefix, comment string) {
-	w.out.Debug("WorkerImpl.toForward %s:%s %s:%s", input, sSet, prefix)
+	w.out.Debug("WorkerImpl.toForward %s:%s %s", input, sSet, prefix)
 	// Allowed forward between source and prefix.
 	w.fire.Filter.For.AddRuleX(cn.IPRule{
 		Input:   input,
@@ -1477,13 +1564,14 @@ func (w *WorkerImpl) IfAddr() string {
 
 func (w *WorkerImpl) AddOutput(data schema.Output) {
 	output := &co.Output{
-		Segment:  data.Segment,
-		Protocol: data.Protocol,
-		Remote:   data.Remote,
-		DstPort:  data.DstPort,
-		Secret:   data.Secret,
-		Crypt:    data.Crypt,
-		Fallback: data.Fallback,
+		Segment:    data.Segment,
+		Protocol:   data.Protocol,
+		Remote:     data.Remote,
+		DstPort:    data.DstPort,
+		Secret:     data.Secret,
+		Crypt:      data.Crypt,
+		Fallback:   data.Fallback,
+		AllowedIPs: data.AllowedIPs,
 	}
 	if !w.cfg.AddOutput(output) {
 		w.out.Info("WorkerImple.AddOutput %s already existed", output.Id())
@@ -1522,6 +1610,48 @@ func (w *WorkerImpl) FindHoper() api.FindHopApi {
 	return w.findhop
 }
 
+func (w *WorkerImpl) Fabricer() api.FabricApi {
+	if w.fabric == nil {
+		return nil
+	}
+	return w.fabric
+}
+
+func (w *WorkerImpl) WireGuarder() api.WireGuardApi {
+	if w.wg == nil {
+		return nil
+	}
+	return w.wg
+}
+
+func (w *WorkerImpl) Guarder() api.GuardApi {
+	if w.guard == nil {
+		return nil
+	}
+	return w.guard
+}
+
+func (w *WorkerImpl) FastPather() api.FastPathApi {
+	if w.fast == nil {
+		return nil
+	}
+	return w.fast
+}
+
+func (w *WorkerImpl) Suppressor() api.SuppressApi {
+	if w.suppress == nil {
+		return nil
+	}
+	return w.suppress
+}
+
+func (w *WorkerImpl) Mesher() api.MeshApi {
+	if w.mesh == nil {
+		return nil
+	}
+	return w.mesh
+}
+
 func (w *WorkerImpl) AddAddress(value string) {
 	if w.br != nil {
 		w.br.Open(value)
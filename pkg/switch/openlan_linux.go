@@ -38,11 +38,7 @@ func (w *OpenLANWorker) Initialize() {
 	w.br = cn.NewBridger(brCfg.Provider, brCfg.Name, brCfg.IPMtu)
 
 	for _, ht := range w.cfg.Hosts {
-		lease := cache.Network.AddLease(ht.Hostname, ht.Address, name)
-		if lease != nil {
-			lease.Type = "static"
-			lease.Network = name
-		}
+		cache.Network.AddStatic(ht.Hostname, ht.Address, name)
 	}
 
 	w.WorkerImpl.Initialize()
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/luscis/openlan/pkg/api"
+	"github.com/luscis/openlan/pkg/cache"
 	"github.com/luscis/openlan/pkg/libol"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -55,6 +56,46 @@ var (
 		Name: "node_client_received_bytes_total",
 		Help: "Current client received bytes total",
 	}, []string{"node", "name", "scope", "address"})
+	// Traffic
+	trafficDaily = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_traffic_daily_bytes",
+		Help: "Current traffic of users today",
+	}, []string{"node", "name", "scope", "state"})
+	trafficMonthly = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_traffic_monthly_bytes",
+		Help: "Current traffic of users this month",
+	}, []string{"node", "name", "scope", "state"})
+	// Guard
+	guardDropped = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_guard_dropped_total",
+		Help: "Current spoofed frames dropped by source guard",
+	}, []string{"node", "network", "alias", "reason"})
+	// Suppress
+	suppressed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_suppressed_total",
+		Help: "Current broadcast and multicast frames suppressed",
+	}, []string{"node", "network", "alias", "reason"})
+	// IPSec
+	ipsecUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_ipsec_tunnel_up",
+		Help: "Current ipsec tunnel has established IPsec SA",
+	}, []string{"node", "name"})
+	ipsecSent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_ipsec_transmit_bytes_total",
+		Help: "Current ipsec SA transmit bytes total",
+	}, []string{"node", "name", "conn"})
+	ipsecRecv = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_ipsec_received_bytes_total",
+		Help: "Current ipsec SA received bytes total",
+	}, []string{"node", "name", "conn"})
+	ipsecSentPkts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_ipsec_transmit_packets_total",
+		Help: "Current ipsec SA transmit packets total",
+	}, []string{"node", "name", "conn"})
+	ipsecRecvPkts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "node_ipsec_received_packets_total",
+		Help: "Current ipsec SA received packets total",
+	}, []string{"node", "name", "conn"})
 )
 
 func nodeName() string {
@@ -106,12 +147,103 @@ func updateClients() {
 	}
 }
 
+func updateTraffic() {
+	items := cache.Traffic.List("", "")
+	trafficDaily.Reset()
+	trafficMonthly.Reset()
+	for _, t := range items {
+		labels := prometheus.Labels{
+			"node":  nodeName(),
+			"name":  strings.SplitN(t.User, "@", 2)[0],
+			"scope": t.Network,
+			"state": t.State,
+		}
+		trafficDaily.With(labels).Set(float64(t.Daily))
+		trafficMonthly.With(labels).Set(float64(t.Monthly))
+	}
+}
+
+func updateGuard() {
+	bindings := api.ListGuards()
+	guardDropped.Reset()
+	for _, b := range bindings {
+		for reason, count := range b.Dropped {
+			guardDropped.With(prometheus.Labels{
+				"node":    nodeName(),
+				"network": b.Network,
+				"alias":   b.Alias,
+				"reason":  reason,
+			}).Add(float64(count))
+		}
+	}
+}
+
+func updateSuppress() {
+	items := api.ListSuppress()
+	suppressed.Reset()
+	for _, item := range items {
+		for _, c := range item.Clients {
+			for reason, count := range map[string]uint64{
+				"arp":  c.ArpProxied,
+				"nd":   c.NdProxied,
+				"rate": c.RateDropped,
+			} {
+				suppressed.With(prometheus.Labels{
+					"node":    nodeName(),
+					"network": item.Network,
+					"alias":   c.Alias,
+					"reason":  reason,
+				}).Add(float64(count))
+			}
+		}
+		suppressed.With(prometheus.Labels{
+			"node":    nodeName(),
+			"network": item.Network,
+			"alias":   "",
+			"reason":  "snooping",
+		}).Add(float64(item.Pruned))
+	}
+}
+
+func updateIPSec() {
+	tunnels := api.ListIPSecTunnels()
+	ipsecUp.Reset()
+	ipsecSent.Reset()
+	ipsecRecv.Reset()
+	ipsecSentPkts.Reset()
+	ipsecRecvPkts.Reset()
+	for _, t := range tunnels {
+		name := t.Right + "-" + t.Transport
+		up := 0
+		for _, sa := range t.Sas {
+			if sa.Type != "IPsec" {
+				continue
+			}
+			up = 1
+			labels := prometheus.Labels{
+				"node": nodeName(),
+				"name": name,
+				"conn": sa.Conn,
+			}
+			ipsecSent.With(labels).Add(float64(sa.OutBytes))
+			ipsecRecv.With(labels).Add(float64(sa.InBytes))
+			ipsecSentPkts.With(labels).Add(float64(sa.OutPackets))
+			ipsecRecvPkts.With(labels).Add(float64(sa.InPackets))
+		}
+		ipsecUp.With(prometheus.Labels{"node": nodeName(), "name": name}).Set(float64(up))
+	}
+}
+
 func recordMetrics() {
 	libol.Go(func() {
 		for {
 			updateUsage()
 			updateDevices()
 			updateClients()
+			updateIPSec()
+			updateGuard()
+			updateSuppress()
+			updateTraffic()
 			time.Sleep(2 * time.Second)
 		}
 	})
@@ -129,4 +261,13 @@ func init() {
 	metrics.MustRegister(deviceRecv)
 	metrics.MustRegister(clientSent)
 	metrics.MustRegister(clientRecv)
+	metrics.MustRegister(trafficDaily)
+	metrics.MustRegister(trafficMonthly)
+	metrics.MustRegister(guardDropped)
+	metrics.MustRegister(suppressed)
+	metrics.MustRegister(ipsecUp)
+	metrics.MustRegister(ipsecSent)
+	metrics.MustRegister(ipsecRecv)
+	metrics.MustRegister(ipsecSentPkts)
+	metrics.MustRegister(ipsecRecvPkts)
 }
@@ -1,336 +1,438 @@
 package cswitch
 
 import (
-	"strconv"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/luscis/openlan/pkg/cache"
-	"github.com/luscis/openlan/pkg/config"
+	co "github.com/luscis/openlan/pkg/config"
 	"github.com/luscis/openlan/pkg/libol"
-	cn "github.com/luscis/openlan/pkg/network"
 	"github.com/luscis/openlan/pkg/schema"
 )
 
-//125000 ~ 1Mb/s
-
+// QosUser is the speed of an user, and shaped on the device it's online.
 type QosUser struct {
-	QosChainName string
-	InSpeed      float64 // Mbit
-	Name         string
-	Ip           string
-	Device       string
-	uniqueName   string
-	qosChainIn   *cn.FireWallChain
-	out          *libol.SubLogger
-}
-
-func (qr *QosUser) RuleName(dir string) string {
-	return "Qos_" + qr.QosChainName + "-" + dir + "-" + qr.uniqueName
-}
-
-func (qr *QosUser) InLimitPacket() string {
-	//Mbit * 125000 / mtu
-	return strconv.Itoa(int((qr.InSpeed * 125000) / 1300))
-}
-
-func (qr *QosUser) InLimitStr() string {
-	return qr.InLimitPacket() + "/s"
-}
-
-func (qr *QosUser) InLimitRule() cn.IPRule {
-	return cn.IPRule{
-		Limit:      qr.InLimitStr(),
-		LimitBurst: "100",
-		Comment:    "Qos Limit In " + qr.Name,
-		Jump:       "ACCEPT",
-	}
+	Name   string
+	Limit  co.QosLimit
+	Ip     string
+	Device string
+	active bool // shaped in windows of schedule.
 }
 
-func (qr *QosUser) BuildChainIn(chain *cn.FireWallChain) {
-	if qr.InSpeed > 0 {
-		qr.qosChainIn = cn.NewFireWallChain(qr.RuleName("in"), cn.TMangle, "")
-		qr.qosChainIn.AddRule(qr.InLimitRule())
-		qr.qosChainIn.AddRule(cn.IPRule{
-			Comment: "Qos Default Drop",
-			Jump:    "DROP",
-		})
-		qr.qosChainIn.Install()
-		qr.BuildChainInJump(chain)
+func (qr *QosUser) In() ShapeClass {
+	return ShapeClass{
+		Rate:  qr.Limit.InSpeed,
+		Ceil:  qr.Limit.InCeil,
+		Burst: qr.Limit.Burst,
 	}
 }
 
-func (qr *QosUser) BuildChainInJump(chain *cn.FireWallChain) {
-	if qr.Ip != "" && qr.InSpeed > 0 {
-		if err := chain.AddRuleX(cn.IPRule{
-			Comment: "Qos Jump",
-			Jump:    qr.RuleName("in"),
-			Source:  qr.Ip,
-		}); err != nil {
-			qr.out.Warn("Qos.Add In Rule: %s", err)
-		}
+func (qr *QosUser) Out() ShapeClass {
+	return ShapeClass{
+		Rate:  qr.Limit.OutSpeed,
+		Ceil:  qr.Limit.OutCeil,
+		Burst: qr.Limit.Burst,
 	}
 }
 
-func (qr *QosUser) ClearChainInJump(chain *cn.FireWallChain) {
-	if qr.Ip != "" && qr.InSpeed > 0 {
-		qr.out.Debug("ClearChainInJump: %s", qr.Ip)
-		if err := chain.DelRuleX(cn.IPRule{
-			Comment: "Qos Jump",
-			Jump:    qr.RuleName("in"),
-			Source:  qr.Ip,
-		}); err != nil {
-			qr.out.Warn("Qos.Del In Rule: %s", err)
-		}
-	}
+type qosSession struct {
+	device string
+	ip     string // empty for tap of access.
 }
 
-func (qr *QosUser) Start(chainIn *cn.FireWallChain) {
-	qr.BuildChainIn(chainIn)
+type qosShaper struct {
+	device  string
+	ingress bool
+	users   map[string]ShapeClass
 }
 
-func (qr *QosUser) ReBuild(chainIn *cn.FireWallChain) {
-	qr.Clear(chainIn)
-	qr.Start(chainIn)
+type QosCtrl struct {
+	Name     string
+	Rules    map[string]*QosUser
+	Priority []*co.QosPriority
+	device   string             // tun of openvpn.
+	shapers  map[string]*Shaper // by device and direction.
+	out      *libol.SubLogger
+	lock     sync.Mutex
+	ticker   libol.Ticker
+	fastpath bool // users are not shaped on fastpath.
 }
 
-func (qr *QosUser) ClearChainIn(chain *cn.FireWallChain) {
-	if qr.qosChainIn != nil {
-		qr.out.Debug("qos chain ClearChainIn start")
-		if qr.Ip != "" {
-			qr.ClearChainInJump(chain)
-		}
-		qr.qosChainIn.Cancel()
-		qr.qosChainIn = nil
+func NewQosCtrl(name string) *QosCtrl {
+	return &QosCtrl{
+		Name:    name,
+		Rules:   make(map[string]*QosUser, 1024),
+		shapers: make(map[string]*Shaper, 32),
+		out:     libol.NewSubLogger("Qos").With("network", name),
 	}
 }
 
-func (qr *QosUser) Clear(chainIn *cn.FireWallChain) {
-	qr.ClearChainIn(chainIn)
-}
-
-func (qr *QosUser) Update(chainIn *cn.FireWallChain, inSpeed float64, device string, ip string) {
-	qr.Device = device
-
-	ipChanged := qr.Ip != ip
-	speedChanged := qr.InSpeed != inSpeed
-
-	if speedChanged {
-		// speed will rebuild jump & limit
-		qr.ClearChainIn(chainIn)
-		qr.InSpeed = inSpeed
-		qr.Ip = ip
-		qr.BuildChainIn(chainIn)
+func (q *QosCtrl) Initialize() {
+	qosCfg := co.GetQos(q.Name)
+	if qosCfg == nil {
 		return
 	}
-
-	if ipChanged {
-		qr.ClearChainInJump(chainIn)
-		qr.Ip = ip
-		qr.BuildChainInJump(chainIn)
-	} else {
-		//ignored
+	for name, limit := range qosCfg.Config {
+		q.Rules[name] = &QosUser{
+			Name:  name,
+			Limit: *limit,
+		}
 	}
-
+	q.Priority = qosCfg.Priority
 }
 
-type QosCtrl struct {
-	Name    string
-	Rules   map[string]*QosUser
-	chainIn *cn.FireWallChain
-	out     *libol.SubLogger
-	lock    sync.Mutex
-}
+// SetDevice sets the tun of openvpn, and empty to clear it.
+func (q *QosCtrl) SetDevice(device string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
 
-func NewQosCtrl(name string) *QosCtrl {
-	return &QosCtrl{
-		Name:  name,
-		Rules: make(map[string]*QosUser, 1024),
-		out:   libol.NewSubLogger("Qos"),
+	if q.device == device {
+		return
 	}
-}
-
-func (q *QosCtrl) ChainIn() string {
-	return "Qos_" + q.Name + "-in"
-}
-
-func (q *QosCtrl) Initialize() {
-	q.chainIn = cn.NewFireWallChain(q.ChainIn(), cn.TMangle, "")
-
-	qosCfg := config.GetQos(q.Name)
-	if qosCfg != nil && len(qosCfg.Config) > 0 {
-		for name, limit := range qosCfg.Config {
-			qr := &QosUser{
-				QosChainName: q.Name,
-				Name:         name,
-				InSpeed:      limit.InSpeed,
-				Ip:           "",
-				uniqueName:   libol.GenString(7),
-				out:          libol.NewSubLogger("Qos_" + name),
-			}
-			q.Rules[name] = qr
+	for key, s := range q.shapers {
+		if s.Device == q.device {
+			s.Stop()
+			delete(q.shapers, key)
 		}
 	}
-	q.chainIn.Install()
+	q.device = device
 }
 
 func (q *QosCtrl) Start() {
 	q.out.Info("Qos.Start")
-	if len(q.Rules) > 0 {
-		for _, rule := range q.Rules {
-			rule.Start(q.chainIn)
-		}
-	}
-	libol.Go(q.Update)
+	libol.Go(q.ClientUpdate)
+	q.ticker.Start(5*time.Second, q.ClientUpdate)
 }
 
 func (q *QosCtrl) Stop() {
 	q.out.Info("Qos.Stop")
-	if len(q.Rules) != 0 {
-		for _, rule := range q.Rules {
-			rule.Clear(q.chainIn)
-		}
-	}
-	q.chainIn.Cancel()
-}
+	q.ticker.Stop()
 
-func (q *QosCtrl) DelUserRule(name string) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	if rule, ok := q.Rules[name]; ok {
-		rule.Clear(q.chainIn)
-		delete(q.Rules, name)
-	}
+	q.resetShapers()
 }
 
-func (q *QosCtrl) FindClient(name string) *schema.VPNClient {
-	for n := range cache.Network.List() {
-		if n == nil {
-			break
-		}
-		for client := range cache.VPNClient.List(n.Name) {
-			if client == nil {
-				break
-			}
-			if client.Name == name {
-				return client
-			}
-		}
+func (q *QosCtrl) resetShapers() {
+	for key, s := range q.shapers {
+		s.Stop()
+		delete(q.shapers, key)
 	}
-
-	return nil
 }
 
-func (q *QosCtrl) AddOrUpdateQos(name string, inSpeed float64) {
-	q.lock.Lock()
-	defer q.lock.Unlock()
-	client := q.FindClient(name)
-	device := ""
-	var ip = ""
-	if client != nil {
-		device = client.Device
-		ip = client.Address
+func shaperKey(device string, ingress bool) string {
+	if ingress {
+		return device + "/in"
 	}
+	return device + "/out"
+}
 
-	if rule, ok := q.Rules[name]; ok {
-
-		rule.Update(q.chainIn, inSpeed, device, ip)
-	} else {
+func (q *QosCtrl) shaper(device string, ingress bool) *Shaper {
+	key := shaperKey(device, ingress)
+	if s, ok := q.shapers[key]; ok {
+		return s
+	}
+	s := NewShaper(device, ingress, q.Priority)
+	if err := s.Start(); err != nil {
+		q.out.Warn("Qos.shaper: %s", err)
+		s.Stop()
+		return nil
+	}
+	q.shapers[key] = s
+	return s
+}
 
-		rule = &QosUser{
-			QosChainName: q.Name,
-			Name:         name,
-			InSpeed:      inSpeed,
-			Ip:           ip,
-			uniqueName:   libol.GenString(7),
-			out:          libol.NewSubLogger("Qos_" + name),
+// online returns sessions of users in this network.
+func (q *QosCtrl) online() map[string][]qosSession {
+	sessions := make(map[string][]qosSession, 32)
+	for client := range cache.VPNClient.List(q.Name) {
+		if client == nil {
+			break
 		}
-		rule.Start(q.chainIn)
+		if q.device == "" || client.Address == "" {
+			continue
+		}
+		name := vpnUser(client)
+		sessions[name] = append(sessions[name], qosSession{
+			device: q.device,
+			ip:     client.Address,
+		})
+	}
+	for obj := range cache.Access.List() {
+		if obj == nil {
+			break
+		}
+		if obj.Network != q.Name || obj.IfName == "" {
+			continue
+		}
+		if _, ok := obj.Device.(*FastPort); ok {
+			// no device to be shaped on fastpath.
+			continue
+		}
+		name := obj.User + "@" + obj.Network
+		sessions[name] = append(sessions[name], qosSession{
+			device: obj.IfName,
+		})
+	}
+	return sessions
+}
 
-		q.Rules[name] = rule
+// sessions returns sessions of a rule, which is named by an user or by
+// name@network.
+func (q *QosCtrl) sessions(online map[string][]qosSession, name string) []qosSession {
+	if strings.Contains(name, "@") {
+		return online[name]
 	}
+	return append(online[name], online[name+"@"+q.Name]...)
 }
 
+// ClientUpdate shapes the users on the devices they're online, and clears
+// the others.
 func (q *QosCtrl) ClientUpdate() {
 	q.lock.Lock()
 	defer q.lock.Unlock()
-	clients := make([]schema.VPNClient, 0, 1024)
-	for n := range cache.Network.List() {
-		if n == nil {
-			break
+
+	online := q.online()
+	desired := make(map[string]*qosShaper, 32)
+	add := func(device string, ingress bool, name string, class ShapeClass) {
+		key := shaperKey(device, ingress)
+		if _, ok := desired[key]; !ok {
+			desired[key] = &qosShaper{
+				device:  device,
+				ingress: ingress,
+				users:   make(map[string]ShapeClass, 32),
+			}
 		}
-		for client := range cache.VPNClient.List(n.Name) {
-			if client == nil {
-				break
+		desired[key].users[name] = class
+	}
+	for name, rule := range q.Rules {
+		rule.Device = ""
+		rule.Ip = ""
+		active := cache.Schedule.Active(rule.Limit.Schedule)
+		if active != rule.active {
+			q.out.Info("Qos.ClientUpdate: %s active: %t", name, active)
+			rule.active = active
+		}
+		for _, sess := range q.sessions(online, name) {
+			rule.Device = sess.device
+			rule.Ip = sess.ip
+			if !active {
+				continue
+			}
+			if rule.Limit.InSpeed > 0 {
+				class := rule.In()
+				class.Ip = sess.ip
+				add(sess.device, true, name, class)
+			}
+			if rule.Limit.OutSpeed > 0 {
+				class := rule.Out()
+				class.Ip = sess.ip
+				add(sess.device, false, name, class)
 			}
-			clients = append(clients, *client)
 		}
 	}
-	for _, rule := range q.Rules {
-		var existClient *schema.VPNClient
-		for _, client := range clients {
-			if client.Name == rule.Name {
-				existClient = &client
-				break
+
+	for key, s := range q.shapers {
+		var users map[string]ShapeClass
+		if obj, ok := desired[key]; ok {
+			users = obj.users
+		}
+		for name := range s.users {
+			if _, ok := users[name]; !ok {
+				s.Del(name)
 			}
 		}
-		if existClient != nil {
-			rule.Update(q.chainIn, rule.InSpeed, existClient.Device, existClient.Address)
-		} else {
-			rule.ClearChainInJump(q.chainIn)
-			rule.Ip = ""
+		// shapers of openvpn are kept.
+		if len(users) == 0 && s.Device != q.device {
+			s.Stop()
+			delete(q.shapers, key)
+		}
+	}
+	for _, obj := range desired {
+		s := q.shaper(obj.device, obj.ingress)
+		if s == nil {
+			continue
+		}
+		for name, class := range obj.users {
+			if err := s.Set(name, class); err != nil {
+				q.out.Warn("Qos.ClientUpdate: %s %s", name, err)
+			}
 		}
 	}
-
 }
 
-func (q *QosCtrl) Update() {
-	for {
-		q.ClientUpdate()
-
-		time.Sleep(time.Second * 5)
+// Scheduled returns limits of users referencing a schedule.
+func (q *QosCtrl) Scheduled(name string) []string {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	refs := make([]string, 0, 4)
+	for user, rule := range q.Rules {
+		if rule.Limit.Schedule == name {
+			refs = append(refs, "qos "+q.Name+" "+user)
+		}
 	}
+	sort.Strings(refs)
+	return refs
 }
 
 func (q *QosCtrl) SaveQos() {
-	cfg := config.GetQos(q.Name)
-	cfg.Config = make(map[string]*config.QosLimit, 1024)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	cfg := co.GetQos(q.Name)
+	if cfg == nil {
+		return
+	}
+	cfg.Config = make(map[string]*co.QosLimit, 1024)
 	for _, rule := range q.Rules {
-		ql := &config.QosLimit{
-			InSpeed: rule.InSpeed,
-		}
-		cfg.Config[rule.Name] = ql
+		limit := rule.Limit
+		cfg.Config[rule.Name] = &limit
 	}
+	cfg.Priority = q.Priority
 	cfg.Save()
 }
 
-func (q *QosCtrl) AddQos(name string, inSpeed float64) error {
+func (q *QosCtrl) AddQos(data schema.Qos) error {
+	if q.fastpath {
+		return libol.NewErr("qos is bypassed by fastpath")
+	}
+	if data.Name == "" {
+		return libol.NewErr("invalid name")
+	}
+	if data.InSpeed <= 0 && data.OutSpeed <= 0 {
+		return libol.NewErr("inSpeed or outSpeed is required")
+	}
+	limit := co.QosLimit{
+		InSpeed:  data.InSpeed,
+		InCeil:   data.InCeil,
+		OutSpeed: data.OutSpeed,
+		OutCeil:  data.OutCeil,
+		Burst:    data.Burst,
+		Schedule: data.Schedule,
+	}
+	limit.Correct()
+	if limit.Schedule != "" && cache.Schedule.Get(limit.Schedule) == nil {
+		return libol.NewErr("schedule %s notFound", limit.Schedule)
+	}
 
-	q.AddOrUpdateQos(name, inSpeed)
+	q.lock.Lock()
+	if rule, ok := q.Rules[data.Name]; ok {
+		rule.Limit = limit
+	} else {
+		q.Rules[data.Name] = &QosUser{
+			Name:  data.Name,
+			Limit: limit,
+		}
+	}
+	q.lock.Unlock()
 
+	q.ClientUpdate()
 	return nil
 }
-func (q *QosCtrl) UpdateQos(name string, inSpeed float64) error {
-
-	q.AddOrUpdateQos(name, inSpeed)
 
-	return nil
+func (q *QosCtrl) UpdateQos(data schema.Qos) error {
+	return q.AddQos(data)
 }
+
 func (q *QosCtrl) DelQos(name string) error {
+	q.lock.Lock()
+	delete(q.Rules, name)
+	q.lock.Unlock()
 
-	q.DelUserRule(name)
+	q.ClientUpdate()
 	return nil
 }
 
 func (q *QosCtrl) ListQos(call func(obj schema.Qos)) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
 
 	for _, rule := range q.Rules {
 		obj := schema.Qos{
-			Name:    rule.Name,
-			Device:  rule.Device,
-			InSpeed: rule.InSpeed,
-			Ip:      rule.Ip,
+			Name:     rule.Name,
+			Device:   rule.Device,
+			Ip:       rule.Ip,
+			InSpeed:  rule.Limit.InSpeed,
+			InCeil:   rule.Limit.InCeil,
+			OutSpeed: rule.Limit.OutSpeed,
+			OutCeil:  rule.Limit.OutCeil,
+			Burst:    rule.Limit.Burst,
+			Schedule: rule.Limit.Schedule,
+			Active:   rule.active,
 		}
 		call(obj)
 	}
 }
+
+func (q *QosCtrl) AddPriority(data schema.QosPriority) error {
+	if data.Name == "" {
+		return libol.NewErr("invalid name")
+	}
+	if len(data.Dscp) == 0 && len(data.Ports) == 0 {
+		return libol.NewErr("dscp or ports is required")
+	}
+	for _, dscp := range data.Dscp {
+		if dscp < 0 || dscp > 63 {
+			return libol.NewErr("invalid dscp %d", dscp)
+		}
+	}
+	obj := &co.QosPriority{
+		Name:     data.Name,
+		Dscp:     data.Dscp,
+		Protocol: data.Protocol,
+		Ports:    data.Ports,
+	}
+	obj.Correct()
+	switch obj.Protocol {
+	case "", "udp", "tcp":
+	default:
+		return libol.NewErr("invalid protocol %s", obj.Protocol)
+	}
+
+	q.lock.Lock()
+	priority := make([]*co.QosPriority, 0, len(q.Priority)+1)
+	for _, rule := range q.Priority {
+		if rule.Name != obj.Name {
+			priority = append(priority, rule)
+		}
+	}
+	q.Priority = append(priority, obj)
+	// rebuild all classes with new filters.
+	q.resetShapers()
+	q.lock.Unlock()
+
+	q.ClientUpdate()
+	return nil
+}
+
+func (q *QosCtrl) DelPriority(name string) error {
+	q.lock.Lock()
+	priority := make([]*co.QosPriority, 0, len(q.Priority))
+	for _, rule := range q.Priority {
+		if rule.Name != name {
+			priority = append(priority, rule)
+		}
+	}
+	q.Priority = priority
+	q.resetShapers()
+	q.lock.Unlock()
+
+	q.ClientUpdate()
+	return nil
+}
+
+func (q *QosCtrl) ListPriority(call func(obj schema.QosPriority)) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for _, rule := range q.Priority {
+		call(schema.QosPriority{
+			Name:     rule.Name,
+			Dscp:     rule.Dscp,
+			Protocol: rule.Protocol,
+			Ports:    rule.Ports,
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package cswitch
+
+import "testing"
+
+func TestQosSessions(t *testing.T) {
+	q := NewQosCtrl("fake")
+	online := map[string][]qosSession{
+		"hi@fake":    {{device: "tun0", ip: "10.0.0.2"}},
+		"hello@fake": {{device: "tap0"}},
+	}
+	if sess := q.sessions(online, "hi"); len(sess) != 1 || sess[0].ip != "10.0.0.2" {
+		t.Errorf("expected session of hi, got %v", sess)
+	}
+	if sess := q.sessions(online, "hello@fake"); len(sess) != 1 || sess[0].device != "tap0" {
+		t.Errorf("expected session of hello@fake, got %v", sess)
+	}
+	if sess := q.sessions(online, "hi@other"); len(sess) != 0 {
+		t.Errorf("expected no session, got %v", sess)
+	}
+}
@@ -0,0 +1,98 @@
+package cswitch
+
+import (
+	"strings"
+
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/models"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+func (v *Switch) loadSchedule() {
+	for name, obj := range v.cfg.Schedule {
+		s, err := models.NewSchedule(name, obj.Days, obj.Windows, obj.Timezone)
+		if err != nil {
+			v.out.Warn("Switch.loadSchedule: %s %s", name, err)
+			continue
+		}
+		cache.Schedule.Add(s)
+	}
+}
+
+// scheduled returns objects referencing a schedule in all networks.
+func (v *Switch) scheduled(name string) []string {
+	refs := make([]string, 0, 4)
+	for _, w := range v.workers() {
+		if acl, ok := w.ACLer().(*ACL); ok {
+			refs = append(refs, acl.Scheduled(name)...)
+		}
+		if qos, ok := w.Qoser().(*QosCtrl); ok {
+			refs = append(refs, qos.Scheduled(name)...)
+		}
+		if ztrust, ok := w.ZTruster().(*ZTrust); ok {
+			refs = append(refs, ztrust.Scheduled(name)...)
+		}
+	}
+	return refs
+}
+
+// reschedule checks objects by their schedules at once, and not waits
+// for next tick of them.
+func (v *Switch) reschedule() {
+	for _, w := range v.workers() {
+		if acl, ok := w.ACLer().(*ACL); ok {
+			acl.Sync()
+		}
+		if qos, ok := w.Qoser().(*QosCtrl); ok {
+			qos.ClientUpdate()
+		}
+		if ztrust, ok := w.ZTruster().(*ZTrust); ok {
+			ztrust.Reschedule()
+		}
+	}
+}
+
+func (v *Switch) AddSchedule(data schema.Schedule) error {
+	s, err := models.NewSchedule(data.Name, data.Days, data.Windows, data.Timezone)
+	if err != nil {
+		return err
+	}
+	v.lock.Lock()
+	cache.Schedule.Add(s)
+	v.cfg.Schedule[s.Name] = &co.Schedule{
+		Name:     s.Name,
+		Days:     s.Days,
+		Windows:  s.Windows,
+		Timezone: s.Timezone,
+	}
+	v.lock.Unlock()
+	v.out.Info("Switch.AddSchedule: %s", s.Name)
+	v.reschedule()
+	return nil
+}
+
+func (v *Switch) DelSchedule(name string) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if cache.Schedule.Get(name) == nil {
+		return libol.NewErr("schedule %s notFound", name)
+	}
+	if refs := v.scheduled(name); len(refs) > 0 {
+		return libol.NewErr("schedule %s referenced by %s", name, strings.Join(refs, ", "))
+	}
+	cache.Schedule.Del(name)
+	delete(v.cfg.Schedule, name)
+	v.out.Info("Switch.DelSchedule: %s", name)
+	return nil
+}
+
+func (v *Switch) ListSchedule(call func(obj schema.Schedule)) {
+	for s := range cache.Schedule.List() {
+		if s == nil {
+			break
+		}
+		call(models.NewScheduleSchema(s))
+	}
+}
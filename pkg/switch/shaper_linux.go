@@ -0,0 +1,299 @@
+package cswitch
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+)
+
+const (
+	shapeMaxIndex   = 0x3ffe // classid of an user is index * 4.
+	shapeMaxFilters = 64
+)
+
+// ShapeClass is the HTB class of an user.
+type ShapeClass struct {
+	Ip    string  // empty to match all, e.g. tap of an user.
+	Rate  float64 // Mbit
+	Ceil  float64 // Mbit
+	Burst int     // KiB
+}
+
+type shapeFilter struct {
+	handle uint32
+	proto  string
+	prio   string
+}
+
+type shapeUser struct {
+	index   int
+	class   ShapeClass
+	filters []shapeFilter
+}
+
+// Shaper shapes egress of a device by HTB classes of users, and ingress
+// is redirected to an ifb and shaped at its egress. Every class of user has
+// a preferred leaf for the priority traffic and a bulk leaf, and both are
+// fq_codel.
+type Shaper struct {
+	Device   string
+	Ingress  bool
+	priority []*co.QosPriority
+	users    map[string]*shapeUser
+	run      func(args ...string) error
+	out      *libol.SubLogger
+}
+
+func NewShaper(device string, ingress bool, priority []*co.QosPriority) *Shaper {
+	s := &Shaper{
+		Device:   device,
+		Ingress:  ingress,
+		priority: priority,
+		users:    make(map[string]*shapeUser, 32),
+		out:      libol.NewSubLogger("shaper"),
+	}
+	s.run = s.exec
+	return s
+}
+
+func (s *Shaper) exec(args ...string) error {
+	bin := "tc"
+	if args[0] == "link" {
+		bin = "ip"
+	}
+	if out, err := libol.Exec(bin, args...); err != nil {
+		return libol.NewErr("%s %s: %s", bin, strings.Join(args, " "), strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// Ifb returns name of the ifb for ingress, and a hash of the device if
+// too long, so devices with same prefix aren't collided.
+func (s *Shaper) Ifb() string {
+	name := "ifb-" + s.Device
+	if len(name) > 15 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(s.Device))
+		name = fmt.Sprintf("ifb-%08x", h.Sum32())
+	}
+	return name
+}
+
+// dev returns the device to be shaped by qdisc.
+func (s *Shaper) dev() string {
+	if s.Ingress {
+		return s.Ifb()
+	}
+	return s.Device
+}
+
+func mbit(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64) + "mbit"
+}
+
+func (s *Shaper) Start() error {
+	s.out.Info("Shaper.Start: %s ingress %t", s.Device, s.Ingress)
+	dev := s.dev()
+	if s.Ingress {
+		_ = s.run("link", "add", dev, "type", "ifb")
+		if err := s.run("link", "set", dev, "up"); err != nil {
+			return err
+		}
+		if err := s.run("qdisc", "replace", "dev", s.Device, "handle", "ffff:", "ingress"); err != nil {
+			return err
+		}
+		if err := s.run("filter", "replace", "dev", s.Device, "parent", "ffff:", "protocol", "all",
+			"prio", "1", "u32", "match", "u32", "0", "0",
+			"action", "mirred", "egress", "redirect", "dev", dev); err != nil {
+			return err
+		}
+	}
+	cmds := [][]string{
+		{"qdisc", "replace", "dev", dev, "root", "handle", "1:", "htb", "default", "ffff"},
+		{"class", "replace", "dev", dev, "parent", "1:", "classid", "1:1", "htb", "rate", "10gbit"},
+		{"class", "replace", "dev", dev, "parent", "1:1", "classid", "1:ffff", "htb", "rate", "10gbit"},
+		{"qdisc", "replace", "dev", dev, "parent", "1:ffff", "fq_codel"},
+	}
+	for _, args := range cmds {
+		if err := s.run(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Shaper) Stop() {
+	s.out.Info("Shaper.Stop: %s ingress %t", s.Device, s.Ingress)
+	_ = s.run("qdisc", "del", "dev", s.dev(), "root")
+	if s.Ingress {
+		_ = s.run("qdisc", "del", "dev", s.Device, "ingress")
+		_ = s.run("link", "del", s.Ifb())
+	}
+	s.users = make(map[string]*shapeUser, 32)
+}
+
+func (s *Shaper) index() int {
+	used := make(map[int]bool, len(s.users))
+	for _, u := range s.users {
+		used[u.index] = true
+	}
+	for i := 1; i <= shapeMaxIndex; i++ {
+		if !used[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// matches returns flower keys of the priority traffic.
+func (s *Shaper) matches() [][]string {
+	port := "dst_port"
+	if !s.Ingress {
+		port = "src_port"
+	}
+	keys := make([][]string, 0, 8)
+	for _, p := range s.priority {
+		for _, dscp := range p.Dscp {
+			keys = append(keys, []string{"ip_tos", fmt.Sprintf("0x%x/0xfc", dscp<<2)})
+		}
+		protos := []string{p.Protocol}
+		if p.Protocol == "" {
+			protos = []string{"udp", "tcp"}
+		}
+		for _, value := range p.Ports {
+			for _, proto := range protos {
+				keys = append(keys, []string{"ip_proto", proto, port, value})
+			}
+		}
+	}
+	return keys
+}
+
+// Set adds or updates the class of an user.
+func (s *Shaper) Set(name string, class ShapeClass) error {
+	u, ok := s.users[name]
+	if ok && u.class == class {
+		return nil
+	}
+	if ok {
+		s.delFilters(u)
+	} else {
+		index := s.index()
+		if index == -1 {
+			return libol.NewErr("too many classes on %s", s.dev())
+		}
+		u = &shapeUser{index: index}
+		s.users[name] = u
+	}
+	u.class = class
+	if class.Ceil < class.Rate {
+		class.Ceil = class.Rate
+	}
+
+	dev := s.dev()
+	minor := u.index * 4
+	parent := fmt.Sprintf("1:%x", minor)
+	rates := []string{"rate", mbit(class.Rate), "ceil", mbit(class.Ceil)}
+	if class.Burst > 0 {
+		rates = append(rates, "burst", fmt.Sprintf("%dk", class.Burst))
+	}
+	args := append([]string{"class", "replace", "dev", dev, "parent", "1:1", "classid", parent, "htb"}, rates...)
+	if err := s.run(args...); err != nil {
+		return err
+	}
+	for i, prio := range []string{"0", "1"} {
+		leaf := minor + 1 + i
+		args := append([]string{"class", "replace", "dev", dev, "parent", parent,
+			"classid", fmt.Sprintf("1:%x", leaf), "htb"}, rates...)
+		args = append(args, "prio", prio)
+		if err := s.run(args...); err != nil {
+			return err
+		}
+		if err := s.run("qdisc", "replace", "dev", dev, "parent", fmt.Sprintf("1:%x", leaf),
+			"handle", fmt.Sprintf("%x:", leaf), "fq_codel"); err != nil {
+			return err
+		}
+	}
+
+	addr := "dst_ip"
+	if s.Ingress {
+		addr = "src_ip"
+	}
+	// addresses of an user on IPv6 aren't known, so only a class without
+	// address matches IPv6.
+	protos := []string{"ip", "ipv6"}
+	if class.Ip != "" {
+		protos = []string{"ip"}
+		if strings.Contains(class.Ip, ":") {
+			protos = []string{"ipv6"}
+		}
+	}
+	base := uint32(u.index) * shapeMaxFilters
+	filter := func(proto, prio string, leaf int, keys []string) error {
+		handle := base + uint32(len(u.filters))
+		args := []string{"filter", "replace", "dev", dev, "parent", "1:", "protocol", proto,
+			"prio", prio, "handle", fmt.Sprintf("0x%x", handle), "flower"}
+		if class.Ip != "" {
+			args = append(args, addr, class.Ip)
+		}
+		args = append(args, keys...)
+		args = append(args, "classid", fmt.Sprintf("1:%x", leaf))
+		u.filters = append(u.filters, shapeFilter{handle: handle, proto: proto, prio: prio})
+		return s.run(args...)
+	}
+	// filters of a protocol are in its own priorities.
+	limit := shapeMaxFilters / len(protos)
+	for i, proto := range protos {
+		first := len(u.filters)
+		for _, keys := range s.matches() {
+			if len(u.filters)-first >= limit-1 {
+				s.out.Warn("Shaper.Set: %s too many priority filters", name)
+				break
+			}
+			if err := filter(proto, strconv.Itoa(2*i+1), minor+1, keys); err != nil {
+				return err
+			}
+		}
+		if err := filter(proto, strconv.Itoa(2*i+2), minor+2, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Shaper) delFilters(u *shapeUser) {
+	dev := s.dev()
+	for _, f := range u.filters {
+		_ = s.run("filter", "del", "dev", dev, "parent", "1:", "protocol", f.proto,
+			"prio", f.prio, "handle", fmt.Sprintf("0x%x", f.handle), "flower")
+	}
+	u.filters = nil
+}
+
+// Del removes the class of an user.
+func (s *Shaper) Del(name string) {
+	u, ok := s.users[name]
+	if !ok {
+		return
+	}
+	s.delFilters(u)
+	dev := s.dev()
+	minor := u.index * 4
+	for _, id := range []int{minor + 1, minor + 2, minor} {
+		_ = s.run("class", "del", "dev", dev, "classid", fmt.Sprintf("1:%x", id))
+	}
+	delete(s.users, name)
+}
+
+func (s *Shaper) Has(name string) bool {
+	_, ok := s.users[name]
+	return ok
+}
+
+func (s *Shaper) Len() int {
+	return len(s.users)
+}
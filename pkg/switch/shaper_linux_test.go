@@ -0,0 +1,131 @@
+package cswitch
+
+import (
+	"strings"
+	"testing"
+
+	co "github.com/luscis/openlan/pkg/config"
+)
+
+func newTestShaper(ingress bool, cmds *[]string) *Shaper {
+	priority := []*co.QosPriority{
+		{Name: "voice", Dscp: []int{46}, Protocol: "udp", Ports: []string{"5060"}},
+	}
+	s := NewShaper("tun0", ingress, priority)
+	s.run = func(args ...string) error {
+		*cmds = append(*cmds, strings.Join(args, " "))
+		return nil
+	}
+	return s
+}
+
+func hasCmd(cmds []string, value string) bool {
+	for _, cmd := range cmds {
+		if cmd == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestShaperEgress(t *testing.T) {
+	var cmds []string
+	s := newTestShaper(false, &cmds)
+	if err := s.Start(); err != nil {
+		t.Fatalf("start: %s", err)
+	}
+	class := ShapeClass{Ip: "10.0.0.2", Rate: 2, Ceil: 4, Burst: 32}
+	if err := s.Set("hi@fake", class); err != nil {
+		t.Fatalf("set: %s", err)
+	}
+	expected := []string{
+		"qdisc replace dev tun0 root handle 1: htb default ffff",
+		"class replace dev tun0 parent 1:1 classid 1:4 htb rate 2mbit ceil 4mbit burst 32k",
+		"class replace dev tun0 parent 1:4 classid 1:5 htb rate 2mbit ceil 4mbit burst 32k prio 0",
+		"qdisc replace dev tun0 parent 1:6 handle 6: fq_codel",
+		"filter replace dev tun0 parent 1: protocol ip prio 1 handle 0x40 flower dst_ip 10.0.0.2 ip_tos 0xb8/0xfc classid 1:5",
+		"filter replace dev tun0 parent 1: protocol ip prio 1 handle 0x41 flower dst_ip 10.0.0.2 ip_proto udp src_port 5060 classid 1:5",
+		"filter replace dev tun0 parent 1: protocol ip prio 2 handle 0x42 flower dst_ip 10.0.0.2 classid 1:6",
+	}
+	for _, cmd := range expected {
+		if !hasCmd(cmds, cmd) {
+			t.Errorf("expected %q in %v", cmd, cmds)
+		}
+	}
+
+	// unchanged class isn't applied again.
+	cmds = nil
+	_ = s.Set("hi@fake", class)
+	if len(cmds) != 0 {
+		t.Errorf("unexpected %v", cmds)
+	}
+
+	cmds = nil
+	s.Del("hi@fake")
+	for _, cmd := range []string{
+		"filter del dev tun0 parent 1: protocol ip prio 2 handle 0x42 flower",
+		"class del dev tun0 classid 1:4",
+	} {
+		if !hasCmd(cmds, cmd) {
+			t.Errorf("expected %q in %v", cmd, cmds)
+		}
+	}
+	if s.Len() != 0 {
+		t.Errorf("expected no class, got %d", s.Len())
+	}
+}
+
+func TestShaperIngress(t *testing.T) {
+	var cmds []string
+	s := newTestShaper(true, &cmds)
+	if err := s.Start(); err != nil {
+		t.Fatalf("start: %s", err)
+	}
+	_ = s.Set("hi@fake", ShapeClass{Ip: "10.0.0.2", Rate: 1})
+	_ = s.Set("hello@fake", ShapeClass{Rate: 1})
+	for _, cmd := range []string{
+		"link add ifb-tun0 type ifb",
+		"filter replace dev tun0 parent ffff: protocol all prio 1 u32 match u32 0 0 action mirred egress redirect dev ifb-tun0",
+		"class replace dev ifb-tun0 parent 1:1 classid 1:4 htb rate 1mbit ceil 1mbit",
+		"filter replace dev ifb-tun0 parent 1: protocol ip prio 1 handle 0x41 flower src_ip 10.0.0.2 ip_proto udp dst_port 5060 classid 1:5",
+		"filter replace dev ifb-tun0 parent 1: protocol ip prio 2 handle 0x82 flower classid 1:a",
+		"filter replace dev ifb-tun0 parent 1: protocol ipv6 prio 3 handle 0x84 flower ip_proto udp dst_port 5060 classid 1:9",
+		"filter replace dev ifb-tun0 parent 1: protocol ipv6 prio 4 handle 0x85 flower classid 1:a",
+	} {
+		if !hasCmd(cmds, cmd) {
+			t.Errorf("expected %q in %v", cmd, cmds)
+		}
+	}
+	for _, cmd := range cmds {
+		if strings.Contains(cmd, "protocol ipv6") && strings.Contains(cmd, "10.0.0.2") {
+			t.Errorf("unexpected %q", cmd)
+		}
+	}
+	cmds = nil
+	s.Del("hello@fake")
+	if !hasCmd(cmds, "filter del dev ifb-tun0 parent 1: protocol ipv6 prio 4 handle 0x85 flower") {
+		t.Errorf("expected ipv6 filter deleted in %v", cmds)
+	}
+	cmds = nil
+	s.Stop()
+	if !hasCmd(cmds, "link del ifb-tun0") {
+		t.Errorf("expected ifb deleted in %v", cmds)
+	}
+}
+
+func TestShaperIfb(t *testing.T) {
+	if name := NewShaper("tun0", true, nil).Ifb(); name != "ifb-tun0" {
+		t.Errorf("expected ifb-tun0, got %s", name)
+	}
+	aa := NewShaper("vnet-default-aa", true, nil).Ifb()
+	bb := NewShaper("vnet-default-bb", true, nil).Ifb()
+	if len(aa) > 15 || len(bb) > 15 {
+		t.Errorf("too long name %s %s", aa, bb)
+	}
+	if aa == bb {
+		t.Errorf("expected different ifb, got %s", aa)
+	}
+	if value := NewShaper("vnet-default-aa", true, nil).Ifb(); value != aa {
+		t.Errorf("expected %s, got %s", aa, value)
+	}
+}
@@ -0,0 +1,574 @@
+package cswitch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/luscis/openlan/pkg/cache"
+	"github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/libsock"
+	"github.com/luscis/openlan/pkg/models"
+	"github.com/luscis/openlan/pkg/network"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+const (
+	suppressAgeing = 300 // seconds of learned neighbors.
+	suppressMember = 260 // seconds of group membership.
+)
+
+const (
+	icmp6Proto  = 58
+	icmp6MldV1  = 131
+	icmp6MldEnd = 132
+	icmp6NS     = 135
+	icmp6NA     = 136
+	icmp6MldV2  = 143
+)
+
+const (
+	igmpV1Report = 0x12
+	igmpV2Report = 0x16
+	igmpV2Leave  = 0x17
+	igmpV3Report = 0x22
+)
+
+type suppressClient struct {
+	stats  schema.SuppressClient
+	mac    net.HardwareAddr
+	tokens float64
+	last   time.Time
+}
+
+type suppressNeighbor struct {
+	mac      net.HardwareAddr
+	client   string
+	updateAt int64
+}
+
+type suppressGroup struct {
+	mac     [6]byte
+	members map[string]int64 // device to update time.
+}
+
+// Suppressor reduces broadcast and multicast of access clients: ARP and
+// ND requests are answered by the lease table and learned neighbors, the
+// others are limited by rate, and multicast is only sent to the ports
+// subscribed by IGMP/MLD when fastpath is enabled.
+type Suppressor struct {
+	network   string
+	cfg       *config.Suppress
+	lock      sync.Mutex
+	clients   map[string]*suppressClient
+	neighbors map[string]*suppressNeighbor
+	groups    map[string]*suppressGroup
+	pruned    atomic.Uint64
+	out       *libol.SubLogger
+	ticker    libol.Ticker
+}
+
+func NewSuppressor(network string, cfg *config.Suppress) *Suppressor {
+	return &Suppressor{
+		network:   network,
+		cfg:       cfg,
+		clients:   make(map[string]*suppressClient, 32),
+		neighbors: make(map[string]*suppressNeighbor, 128),
+		groups:    make(map[string]*suppressGroup, 32),
+		out:       libol.NewSubLogger(network),
+	}
+}
+
+func (s *Suppressor) Start(br network.Bridger) {
+	s.out.Info("Suppressor.Start")
+	if s.cfg.Snooping == "enable" && br != nil && br.Type() == "linux" {
+		// let kernel bridge snoop for the others.
+		for _, name := range []string{"multicast_snooping", "multicast_querier"} {
+			file := "/sys/class/net/" + br.Name() + "/bridge/" + name
+			if err := os.WriteFile(file, []byte("1"), 0644); err != nil {
+				s.out.Warn("Suppressor.Start: %s", err)
+			}
+		}
+	}
+	s.ticker.Start(30*time.Second, s.expire)
+}
+
+func (s *Suppressor) Stop() {
+	s.out.Info("Suppressor.Stop")
+	s.ticker.Stop()
+}
+
+func (s *Suppressor) expire() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now().Unix()
+	for addr, n := range s.neighbors {
+		if now-n.updateAt > suppressAgeing {
+			delete(s.neighbors, addr)
+		}
+	}
+	for name, g := range s.groups {
+		for dev, updateAt := range g.members {
+			if now-updateAt > suppressMember {
+				delete(g.members, dev)
+			}
+		}
+		if len(g.members) == 0 {
+			delete(s.groups, name)
+		}
+	}
+}
+
+func (s *Suppressor) client(client string, obj *models.Access) *suppressClient {
+	c, ok := s.clients[client]
+	if !ok {
+		c = &suppressClient{
+			stats: schema.SuppressClient{
+				Network: s.network,
+				Alias:   obj.Alias,
+				Client:  client,
+				Device:  obj.Device.Name(),
+			},
+		}
+		s.clients[client] = c
+	}
+	return c
+}
+
+func (s *Suppressor) learn(addr net.IP, mac []byte, client string, now int64) {
+	if addr.IsUnspecified() || mac[0]&0x01 != 0 {
+		return
+	}
+	key := addr.String()
+	n, ok := s.neighbors[key]
+	if !ok {
+		n = &suppressNeighbor{}
+		s.neighbors[key] = n
+	}
+	if !bytes.Equal(n.mac, mac) {
+		n.mac = append(net.HardwareAddr{}, mac...)
+	}
+	n.client = client
+	n.updateAt = now
+}
+
+// resolve returns the MAC of addr not owned by requester.
+func (s *Suppressor) resolve(addr net.IP, requester string, now int64) net.HardwareAddr {
+	key := addr.String()
+	if lease := cache.Network.GetLeaseByAddr(key, s.network); lease != nil {
+		if c, ok := s.clients[lease.Client]; ok && c.mac != nil {
+			if lease.Client == requester {
+				return nil
+			}
+			return c.mac
+		}
+	}
+	if n, ok := s.neighbors[key]; ok && now-n.updateAt <= suppressAgeing {
+		if n.client == requester {
+			return nil
+		}
+		return n.mac
+	}
+	return nil
+}
+
+func (s *Suppressor) allow(c *suppressClient, now time.Time) bool {
+	burst := float64(s.cfg.Burst)
+	if c.last.IsZero() {
+		c.tokens = burst
+	} else {
+		c.tokens += now.Sub(c.last).Seconds() * float64(s.cfg.Rate)
+		if c.tokens > burst {
+			c.tokens = burst
+		}
+	}
+	c.last = now
+	if c.tokens < 1 {
+		return false
+	}
+	c.tokens--
+	return true
+}
+
+// Check returns true if the frame from client is suppressed.
+func (s *Suppressor) Check(client string, obj *models.Access, frame []byte) bool {
+	eth, err := libol.NewEtherFromFrame(frame)
+	if err != nil || eth.IsVlan() {
+		return false
+	}
+	reply := s.check(client, obj, eth, frame[eth.Len:])
+	if reply == nil {
+		return !s.permit(client, eth)
+	}
+	if err := s.reply(obj, reply); err != nil {
+		s.out.Warn("Suppressor.Check: %s %s", client, err)
+		return false
+	}
+	return true
+}
+
+func (s *Suppressor) permit(client string, eth *libol.Ether) bool {
+	if eth.Dst[0]&0x01 == 0 {
+		return true
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	c, ok := s.clients[client]
+	if !ok || s.allow(c, time.Now()) {
+		return true
+	}
+	c.stats.RateDropped++
+	if count := c.stats.RateDropped; count == 1 || count%1000 == 0 {
+		s.out.Warn("Suppressor.Check: %s from %s dropped %d by rate", c.stats.Alias, client, count)
+	}
+	return false
+}
+
+func (s *Suppressor) check(client string, obj *models.Access, eth *libol.Ether, payload []byte) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	c := s.client(client, obj)
+	if eth.Src[0]&0x01 == 0 && !bytes.Equal(c.mac, eth.Src) {
+		c.mac = append(net.HardwareAddr{}, eth.Src...)
+	}
+	now := time.Now().Unix()
+	switch eth.Type {
+	case libol.EthArp:
+		return s.arp(c, payload, now)
+	case libol.EthIp4:
+		s.ip4(c, payload, now)
+	case libol.EthIp6:
+		return s.ip6(c, eth, payload, now)
+	}
+	return nil
+}
+
+func (s *Suppressor) reply(obj *models.Access, data []byte) error {
+	if port, ok := obj.Device.(*FastPort); ok {
+		return port.output(data)
+	}
+	frame := libsock.NewFrameMessage(len(data))
+	copy(frame.Frame(), data)
+	frame.SetSize(len(data))
+	return obj.Client.WriteMsg(frame)
+}
+
+func (s *Suppressor) arp(c *suppressClient, payload []byte, now int64) []byte {
+	arp, err := libol.NewArpFromFrame(payload)
+	if err != nil || !arp.IsIP4() {
+		return nil
+	}
+	sip := net.IP(arp.SIpAddr)
+	tip := net.IP(arp.TIpAddr)
+	s.learn(sip, arp.SHwAddr, c.stats.Client, now)
+	if s.cfg.Proxy != "enable" || !arp.IsRequest() {
+		return nil
+	}
+	// ARP probe and gratuitous ARP are for the others.
+	if sip.IsUnspecified() || sip.Equal(tip) {
+		return nil
+	}
+	mac := s.resolve(tip, c.stats.Client, now)
+	if mac == nil {
+		return nil
+	}
+	eth := libol.NewEtherArp()
+	copy(eth.Dst, arp.SHwAddr)
+	copy(eth.Src, mac)
+	reply := libol.NewArp()
+	reply.OpCode = libol.ArpReply
+	copy(reply.SHwAddr, mac)
+	copy(reply.SIpAddr, arp.TIpAddr)
+	copy(reply.THwAddr, arp.SHwAddr)
+	copy(reply.TIpAddr, arp.SIpAddr)
+	c.stats.ArpProxied++
+	return append(eth.Encode(), reply.Encode()...)
+}
+
+func (s *Suppressor) ip4(c *suppressClient, payload []byte, now int64) {
+	if s.cfg.Snooping != "enable" {
+		return
+	}
+	ip4, err := libol.NewIpv4FromFrame(payload)
+	if err != nil || ip4.Protocol != libol.IpIgmp {
+		return
+	}
+	hlen := int(ip4.HeaderLen) * 4
+	if len(payload) < hlen+8 {
+		return
+	}
+	data := payload[hlen:]
+	dev := c.stats.Device
+	switch data[0] {
+	case igmpV1Report, igmpV2Report:
+		s.join(net.IP(data[4:8]), dev, now)
+	case igmpV2Leave:
+		s.leave(net.IP(data[4:8]), dev)
+	case igmpV3Report:
+		count := int(binary.BigEndian.Uint16(data[6:8]))
+		off := 8
+		for i := 0; i < count && len(data) >= off+8; i++ {
+			sources := int(binary.BigEndian.Uint16(data[off+2 : off+4]))
+			s.record(data[off], sources, net.IP(data[off+4:off+8]), dev, now)
+			off += 8 + 4*sources + 4*int(data[off+1])
+		}
+	}
+}
+
+func (s *Suppressor) ip6(c *suppressClient, eth *libol.Ether, payload []byte, now int64) []byte {
+	if len(payload) < 40 {
+		return nil
+	}
+	next := payload[6]
+	off := 40
+	if next == 0 && len(payload) >= off+8 {
+		// hop-by-hop options with router alert of MLD.
+		next = payload[off]
+		off += (int(payload[off+1]) + 1) * 8
+	}
+	if next != icmp6Proto || len(payload) < off+8 {
+		return nil
+	}
+	sip := net.IP(payload[8:24])
+	data := payload[off:]
+	dev := c.stats.Device
+	switch data[0] {
+	case icmp6NS:
+		if len(data) < 24 || sip.IsUnspecified() {
+			return nil // duplicate address detection.
+		}
+		s.learn(sip, eth.Src, c.stats.Client, now)
+		if s.cfg.Proxy != "enable" {
+			return nil
+		}
+		target := net.IP(data[8:24])
+		mac := s.resolve(target, c.stats.Client, now)
+		if mac == nil {
+			return nil
+		}
+		c.stats.NdProxied++
+		return ndAdvert(mac, eth.Src, target, sip)
+	case icmp6NA:
+		if len(data) >= 24 {
+			s.learn(net.IP(data[8:24]), eth.Src, c.stats.Client, now)
+		}
+	case icmp6MldV1:
+		if s.cfg.Snooping == "enable" && len(data) >= 24 {
+			s.join(net.IP(data[8:24]), dev, now)
+		}
+	case icmp6MldEnd:
+		if s.cfg.Snooping == "enable" && len(data) >= 24 {
+			s.leave(net.IP(data[8:24]), dev)
+		}
+	case icmp6MldV2:
+		if s.cfg.Snooping != "enable" {
+			return nil
+		}
+		count := int(binary.BigEndian.Uint16(data[6:8]))
+		off := 8
+		for i := 0; i < count && len(data) >= off+20; i++ {
+			sources := int(binary.BigEndian.Uint16(data[off+2 : off+4]))
+			s.record(data[off], sources, net.IP(data[off+4:off+20]), dev, now)
+			off += 20 + 16*sources + 4*int(data[off+1])
+		}
+	}
+	return nil
+}
+
+// record processes a group record of IGMPv3 or MLDv2.
+func (s *Suppressor) record(kind byte, sources int, group net.IP, dev string, now int64) {
+	switch kind {
+	case 1, 3: // MODE_IS_INCLUDE and CHANGE_TO_INCLUDE
+		if sources == 0 {
+			s.leave(group, dev)
+		} else {
+			s.join(group, dev, now)
+		}
+	case 2, 4, 5: // MODE_IS_EXCLUDE, CHANGE_TO_EXCLUDE and ALLOW
+		s.join(group, dev, now)
+	}
+}
+
+// groupMac returns the MAC of a multicast group, and false if it's always
+// flooded, e.g. 224.0.0.x and ff02::1.
+func groupMac(group net.IP) ([6]byte, bool) {
+	var mac [6]byte
+	if ip4 := group.To4(); ip4 != nil {
+		if !ip4.IsMulticast() || (ip4[0] == 224 && ip4[1] == 0 && ip4[2] == 0) {
+			return mac, false
+		}
+		mac = [6]byte{0x01, 0x00, 0x5e, ip4[1] & 0x7f, ip4[2], ip4[3]}
+		return mac, true
+	}
+	if !group.IsMulticast() || group.Equal(net.IPv6linklocalallnodes) {
+		return mac, false
+	}
+	mac = [6]byte{0x33, 0x33, group[12], group[13], group[14], group[15]}
+	return mac, true
+}
+
+func (s *Suppressor) join(group net.IP, dev string, now int64) {
+	mac, ok := groupMac(group)
+	if !ok {
+		return
+	}
+	key := group.String()
+	g, ok := s.groups[key]
+	if !ok {
+		g = &suppressGroup{mac: mac, members: make(map[string]int64, 4)}
+		s.groups[key] = g
+		s.out.Info("Suppressor.join: %s by %s", key, dev)
+	}
+	g.members[dev] = now
+}
+
+func (s *Suppressor) leave(group net.IP, dev string) {
+	key := group.String()
+	if g, ok := s.groups[key]; ok {
+		delete(g.members, dev)
+		if len(g.members) == 0 {
+			delete(s.groups, key)
+		}
+	}
+}
+
+// Members returns the devices subscribed the multicast MAC, and false if
+// the group is unknown and should be flooded.
+func (s *Suppressor) Members(dst [6]byte) (map[string]bool, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var members map[string]bool
+	now := time.Now().Unix()
+	for _, g := range s.groups {
+		if g.mac != dst {
+			continue
+		}
+		for dev, updateAt := range g.members {
+			if now-updateAt > suppressMember {
+				continue
+			}
+			if members == nil {
+				members = make(map[string]bool, 4)
+			}
+			members[dev] = true
+		}
+	}
+	return members, members != nil
+}
+
+// Drop returns true if the frame from client is suppressed.
+func (s *Suppressor) Drop(client string, obj *models.Access, frame []byte) bool {
+	return s.Check(client, obj, frame)
+}
+
+func (s *Suppressor) Remove(client string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	c, ok := s.clients[client]
+	if !ok {
+		return
+	}
+	delete(s.clients, client)
+	for addr, n := range s.neighbors {
+		if n.client == client {
+			delete(s.neighbors, addr)
+		}
+	}
+	for key, g := range s.groups {
+		delete(g.members, c.stats.Device)
+		if len(g.members) == 0 {
+			delete(s.groups, key)
+		}
+	}
+}
+
+func (s *Suppressor) Get() schema.Suppress {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	obj := schema.Suppress{
+		Network:   s.network,
+		Neighbors: len(s.neighbors),
+		Pruned:    s.pruned.Load(),
+		Clients:   make([]schema.SuppressClient, 0, len(s.clients)),
+		Groups:    make([]schema.SuppressGroup, 0, len(s.groups)),
+	}
+	for _, c := range s.clients {
+		obj.Clients = append(obj.Clients, c.stats)
+	}
+	sort.Slice(obj.Clients, func(i, j int) bool {
+		return obj.Clients[i].Client < obj.Clients[j].Client
+	})
+	for key, g := range s.groups {
+		item := schema.SuppressGroup{
+			Group:   key,
+			Mac:     net.HardwareAddr(g.mac[:]).String(),
+			Members: make([]string, 0, len(g.members)),
+		}
+		for dev := range g.members {
+			item.Members = append(item.Members, dev)
+		}
+		sort.Strings(item.Members)
+		obj.Groups = append(obj.Groups, item)
+	}
+	sort.Slice(obj.Groups, func(i, j int) bool {
+		return obj.Groups[i].Group < obj.Groups[j].Group
+	})
+	return obj
+}
+
+// ndAdvert returns a solicited neighbor advertisement of target at mac.
+func ndAdvert(mac, dstMac net.HardwareAddr, target, dst net.IP) []byte {
+	frame := make([]byte, libol.EtherLen+40+32)
+	copy(frame[0:6], dstMac)
+	copy(frame[6:12], mac)
+	binary.BigEndian.PutUint16(frame[12:14], libol.EthIp6)
+
+	ip6 := frame[libol.EtherLen : libol.EtherLen+40]
+	ip6[0] = 0x60
+	binary.BigEndian.PutUint16(ip6[4:6], 32)
+	ip6[6] = icmp6Proto
+	ip6[7] = 255
+	copy(ip6[8:24], target.To16())
+	copy(ip6[24:40], dst.To16())
+
+	icmp := frame[libol.EtherLen+40:]
+	icmp[0] = icmp6NA
+	icmp[4] = 0x60 // solicited and override.
+	copy(icmp[8:24], target.To16())
+	icmp[24] = 2 // target link-layer address.
+	icmp[25] = 1
+	copy(icmp[26:32], mac)
+	binary.BigEndian.PutUint16(icmp[2:4], icmp6Checksum(ip6[8:24], ip6[24:40], icmp))
+	return frame
+}
+
+func icmp6Checksum(src, dst, data []byte) uint16 {
+	var sum uint32
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(b[i])<<8 | uint32(b[i+1])
+		}
+		if len(b)%2 == 1 {
+			sum += uint32(b[len(b)-1]) << 8
+		}
+	}
+	add(src)
+	add(dst)
+	sum += uint32(len(data)) + icmp6Proto
+	add(data)
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
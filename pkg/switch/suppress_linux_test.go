@@ -0,0 +1,164 @@
+package cswitch
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/libsock"
+	"github.com/luscis/openlan/pkg/models"
+)
+
+func suppressArp(op uint16, mac net.HardwareAddr, sip, tip string) []byte {
+	eth := libol.NewEtherArp()
+	copy(eth.Dst, libol.EthAll)
+	copy(eth.Src, mac)
+	arp := libol.NewArp()
+	arp.OpCode = op
+	copy(arp.SHwAddr, mac)
+	copy(arp.SIpAddr, net.ParseIP(sip).To4())
+	copy(arp.TIpAddr, net.ParseIP(tip).To4())
+	return append(eth.Encode(), arp.Encode()...)
+}
+
+func suppressNS(mac net.HardwareAddr, sip, target string) []byte {
+	frame := make([]byte, libol.EtherLen+40+24)
+	copy(frame[0:6], []byte{0x33, 0x33, 0xff, 0x00, 0x00, 0x01})
+	copy(frame[6:12], mac)
+	binary.BigEndian.PutUint16(frame[12:14], libol.EthIp6)
+	ip6 := frame[libol.EtherLen:]
+	ip6[0] = 0x60
+	ip6[6] = icmp6Proto
+	copy(ip6[8:24], net.ParseIP(sip).To16())
+	copy(ip6[24:40], net.ParseIP("ff02::1:ff00:1").To16())
+	icmp := ip6[40:]
+	icmp[0] = icmp6NS
+	copy(icmp[8:24], net.ParseIP(target).To16())
+	return frame
+}
+
+func suppressIgmp(mac net.HardwareAddr, group string) []byte {
+	eth := libol.NewEtherIP4()
+	copy(eth.Dst, []byte{0x01, 0x00, 0x5e, 0x01, 0x02, 0x03})
+	copy(eth.Src, mac)
+	ip4 := libol.NewIpv4()
+	ip4.Protocol = libol.IpIgmp
+	copy(ip4.Destination, net.ParseIP(group).To4())
+	igmp := make([]byte, 8)
+	igmp[0] = igmpV2Report
+	copy(igmp[4:8], net.ParseIP(group).To4())
+	frame := append(eth.Encode(), ip4.Encode()...)
+	return append(frame, igmp...)
+}
+
+func TestSuppressor(t *testing.T) {
+	cfg := &config.Suppress{Rate: 1, Burst: 2}
+	cfg.Correct()
+	s := NewSuppressor("fake-suppress", cfg)
+	fast := NewFastPath("fake-suppress")
+	fast.uplink = &fakeUplink{}
+	fast.snoop = s
+
+	recv := make(map[string][][]byte, 3)
+	objs := make([]*models.Access, 3)
+	macs := make([]net.HardwareAddr, 3)
+	for i := range objs {
+		port := fast.NewPort()
+		name := port.Name()
+		port.sendTo = func(f *libsock.FrameMessage) error {
+			recv[name] = append(recv[name], append([]byte{}, f.Frame()...))
+			return nil
+		}
+		objs[i] = &models.Access{Alias: name, Network: "fake-suppress", Device: port}
+		macs[i] = net.HardwareAddr{0x02, 0, 0, 0, 0, byte(i + 1)}
+	}
+	client := func(i int) string {
+		return objs[i].Alias
+	}
+
+	// learned 10.0.0.1 from gratuitous ARP, and it's not proxied.
+	if s.Check(client(0), objs[0], suppressArp(libol.ArpRequest, macs[0], "10.0.0.1", "10.0.0.1")) {
+		t.Errorf("gratuitous arp suppressed")
+	}
+	if !s.Check(client(1), objs[1], suppressArp(libol.ArpRequest, macs[1], "10.0.0.2", "10.0.0.1")) {
+		t.Fatalf("arp not proxied")
+	}
+	replies := recv[objs[1].Device.Name()]
+	if len(replies) != 1 {
+		t.Fatalf("arp replies %d", len(replies))
+	}
+	arp, err := libol.NewArpFromFrame(replies[0][libol.EtherLen:])
+	if err != nil || !arp.IsReply() {
+		t.Fatalf("arp reply %v", err)
+	}
+	if net.HardwareAddr(arp.SHwAddr).String() != macs[0].String() || net.IP(arp.TIpAddr).String() != "10.0.0.2" {
+		t.Errorf("arp reply %s %s", net.HardwareAddr(arp.SHwAddr), net.IP(arp.TIpAddr))
+	}
+	// unknown target is flooded.
+	if s.Check(client(1), objs[1], suppressArp(libol.ArpRequest, macs[1], "10.0.0.2", "10.0.0.9")) {
+		t.Errorf("unknown arp suppressed")
+	}
+
+	// learned fd00::1 from NS, and answered NS of others.
+	_ = s.Check(client(0), objs[0], suppressNS(macs[0], "fd00::1", "fd00::9"))
+	if !s.Check(client(2), objs[2], suppressNS(macs[2], "fd00::3", "fd00::1")) {
+		t.Fatalf("ns not proxied")
+	}
+	replies = recv[objs[2].Device.Name()]
+	if len(replies) != 1 {
+		t.Fatalf("nd replies %d", len(replies))
+	}
+	ip6 := replies[0][libol.EtherLen:]
+	if ip6[40] != icmp6NA || net.HardwareAddr(ip6[66:72]).String() != macs[0].String() {
+		t.Errorf("nd reply %x", ip6)
+	}
+	if sum := icmp6Checksum(ip6[8:24], ip6[24:40], ip6[40:72]); sum != 0 {
+		t.Errorf("nd checksum %x", sum)
+	}
+
+	// multicast is only sent to subscribed port and uplink.
+	_ = s.Check(client(2), objs[2], suppressIgmp(macs[2], "239.1.2.3"))
+	recv = make(map[string][][]byte, 3)
+	data := suppressIgmp(macs[0], "239.1.2.3")
+	data[len(data)-8] = 0x00
+	fast.Forward(objs[0].Device.(*FastPort), data)
+	if len(recv[objs[1].Device.Name()]) != 0 || len(recv[objs[2].Device.Name()]) != 1 {
+		t.Errorf("snooping %v", recv)
+	}
+
+	// broadcast is limited by rate.
+	arp1 := suppressArp(libol.ArpRequest, macs[1], "10.0.0.2", "10.0.0.8")
+	dropped := 0
+	for i := 0; i < 5; i++ {
+		if s.Check(client(1), objs[1], arp1) {
+			dropped++
+		}
+	}
+	if dropped == 0 {
+		t.Errorf("rate not limited")
+	}
+
+	obj := s.Get()
+	if obj.Neighbors != 4 || obj.Pruned != 1 || len(obj.Groups) != 1 || len(obj.Clients) != 3 {
+		t.Errorf("get %+v", obj)
+	}
+	for _, c := range obj.Clients {
+		switch c.Client {
+		case client(1):
+			if c.ArpProxied != 1 || c.RateDropped == 0 {
+				t.Errorf("client %+v", c)
+			}
+		case client(2):
+			if c.NdProxied != 1 {
+				t.Errorf("client %+v", c)
+			}
+		}
+	}
+
+	s.Remove(client(2))
+	if obj := s.Get(); len(obj.Groups) != 0 || len(obj.Clients) != 2 {
+		t.Errorf("remove %+v", obj)
+	}
+}
@@ -137,9 +137,15 @@ type Switch struct {
 	http    *Http
 	server  libsock.SocketServer
 	worker  map[string]api.NetworkApi
+	wlock   sync.RWMutex // guards worker.
 	uuid    string
 	newTime int64
 	out     *libol.SubLogger
+	confirm *Confirmer
+	acct    *Accountant
+	blocker *Blocker
+	capture *Capturer
+	leaser  libol.Ticker // expires and saves leases.
 }
 
 func NewSwitch(c *co.Switch) *Switch {
@@ -153,6 +159,10 @@ func NewSwitch(c *co.Switch) *Switch {
 		hooks:   make([]Hook, 0, 64),
 		out:     libol.NewSubLogger(c.Alias),
 	}
+	v.confirm = NewConfirmer(c, v.restoreNetwork)
+	v.acct = NewAccountant(v)
+	v.blocker = NewBlocker()
+	v.capture = NewCapturer()
 	return v
 }
 
@@ -176,31 +186,79 @@ func (v *Switch) openPort(protocol, port string) {
 	})
 }
 
+func (v *Switch) getWorker(network string) (api.NetworkApi, bool) {
+	v.wlock.RLock()
+	defer v.wlock.RUnlock()
+	w, ok := v.worker[network]
+	return w, ok
+}
+
+func (v *Switch) setWorker(network string, w api.NetworkApi) {
+	v.wlock.Lock()
+	defer v.wlock.Unlock()
+	if w == nil {
+		delete(v.worker, network)
+	} else {
+		v.worker[network] = w
+	}
+}
+
+func (v *Switch) workers() []api.NetworkApi {
+	v.wlock.RLock()
+	defer v.wlock.RUnlock()
+	items := make([]api.NetworkApi, 0, len(v.worker))
+	for _, w := range v.worker {
+		items = append(items, w)
+	}
+	return items
+}
+
 func (v *Switch) AddNetwork(network string) {
 	for _, nCfg := range v.cfg.Network {
 		name := nCfg.Name
 		if name == network {
 			w := NewNetworker(nCfg)
-			v.worker[name] = w
+			v.setWorker(name, w)
 			w.Initialize()
 			w.Start(v)
+			v.reloadFabric(nCfg)
 		}
 	}
 }
 
 func (v *Switch) DelNetwork(network string) {
-	worker := v.worker[network]
+	worker, ok := v.getWorker(network)
+	if !ok {
+		return
+	}
 	file := worker.Config().File
 
 	worker.Stop(true)
 	cache.Network.Del(network)
-	delete(v.worker, network)
+	v.setWorker(network, nil)
+	cfg := v.cfg.Network[network]
 	delete(v.cfg.Network, network)
+	if cfg != nil {
+		v.reloadFabric(cfg)
+	}
 	if err := os.Remove(file); err != nil {
 		v.out.Error("Error removing file: %s, err: %s", file, err)
 	}
 }
 
+// reloadFabric renders the bgp fabric of a vxlan network again, so the
+// VNI is advertised or withdrawn by evpn.
+func (v *Switch) reloadFabric(cfg *co.Network) {
+	if cfg.VxLAN == nil || cfg.VxLAN.Fabric == "" {
+		return
+	}
+	if w, ok := v.getWorker(cfg.VxLAN.Fabric); ok {
+		if bgp, ok := w.(*BgpWorker); ok {
+			bgp.reload()
+		}
+	}
+}
+
 func (v *Switch) SaveNetwork(network string) {
 	if network == "" {
 		for _, obj := range v.cfg.Network {
@@ -217,7 +275,7 @@ func (v *Switch) preNetwork() {
 	for _, nCfg := range v.cfg.Network {
 		name := nCfg.Name
 		w := NewNetworker(nCfg)
-		v.worker[name] = w
+		v.setWorker(name, w)
 	}
 }
 
@@ -252,6 +310,19 @@ func (v *Switch) openPorts() {
 	if v.cfg.Http != nil {
 		TcpPorts = append(TcpPorts, v.GetPort(v.cfg.Http.Listen))
 	}
+	// drop blocked sources before opened, and at ports of openvpn.
+	blockUdp := []string{port}
+	blockTcp := append([]string{}, TcpPorts...)
+	for _, nCfg := range v.cfg.Network {
+		if vpn := nCfg.OpenVPN; vpn != nil {
+			if strings.HasPrefix(vpn.Protocol, "udp") {
+				blockUdp = append(blockUdp, v.GetPort(vpn.Listen))
+			} else {
+				blockTcp = append(blockTcp, v.GetPort(vpn.Listen))
+			}
+		}
+	}
+	v.blocker.Initialize(v.fire, blockUdp, blockTcp)
 	v.openPort("udp", strings.Join(UdpPorts, ","))
 	v.openPort("tcp", strings.Join(TcpPorts, ","))
 }
@@ -261,6 +332,12 @@ func (v *Switch) Initialize() {
 	defer v.lock.Unlock()
 
 	v.openPorts()
+	// Lock out failed logins, and block sources by ipset
+	if lc := v.cfg.Lockout; lc != nil {
+		cache.Lockout.Set(lc.MaxFailed, int64(lc.Backoff), int64(lc.MaxBackoff), int64(lc.Window), lc.Block)
+	}
+	cache.Lockout.OnBlock = v.blocker.Block
+	cache.Lockout.OnUnblock = v.blocker.Unblock
 	v.preApplication()
 	if v.cfg.Http != nil {
 		v.http = NewHttp(v)
@@ -268,9 +345,16 @@ func (v *Switch) Initialize() {
 	v.preNetwork()
 	// Load global firewall
 	v.fire.Initialize()
-	for _, w := range v.worker {
+	// Load schedules before rules of networks referenced
+	v.loadSchedule()
+	for _, w := range v.workers() {
 		w.Initialize()
 	}
+	// Load leases after static hosts of networks
+	cache.Network.SetFile(v.cfg.LeaseFile)
+	cache.Network.Load()
+	cache.Traffic.SetFile(v.cfg.TrafficFile)
+	cache.Traffic.Load()
 	// Load password for guest access
 	cache.User.SetFile(v.cfg.PassFile)
 	cache.User.Load()
@@ -452,6 +536,55 @@ func client2Access(client libsock.SocketClient) (*models.Access, error) {
 	}
 }
 
+func (v *Switch) guard(network string) *SourceGuard {
+	if w, ok := v.getWorker(network); ok {
+		if guard, ok := w.Guarder().(*SourceGuard); ok {
+			return guard
+		}
+	}
+	return nil
+}
+
+func (v *Switch) acl(network string) *ACL {
+	if w, ok := v.getWorker(network); ok {
+		if acl, ok := w.ACLer().(*ACL); ok {
+			return acl
+		}
+	}
+	return nil
+}
+
+func (v *Switch) fastPath(network string) *FastPath {
+	if w, ok := v.getWorker(network); ok {
+		if fast, ok := w.FastPather().(*FastPath); ok {
+			return fast
+		}
+	}
+	return nil
+}
+
+func (v *Switch) suppress(network string) *Suppressor {
+	if w, ok := v.getWorker(network); ok {
+		if suppress, ok := w.Suppressor().(*Suppressor); ok {
+			return suppress
+		}
+	}
+	return nil
+}
+
+// Filters returns the source guard and suppressor of network, which are
+// resolved once at login of an access client.
+func (v *Switch) Filters(network string) []models.Filter {
+	var filters []models.Filter
+	if guard := v.guard(network); guard != nil {
+		filters = append(filters, guard)
+	}
+	if suppress := v.suppress(network); suppress != nil {
+		filters = append(filters, suppress)
+	}
+	return filters
+}
+
 func (v *Switch) ReadClient(client libsock.SocketClient, frame *libsock.FrameMessage) error {
 	addr := client.String()
 	if v.out.Has(libol.LOG) {
@@ -476,6 +609,11 @@ func (v *Switch) ReadClient(client libsock.SocketClient, frame *libsock.FrameMes
 		if device == nil {
 			return libol.NewErr("Tap devices is nil")
 		}
+		for _, filter := range obj.Filters {
+			if filter.Drop(addr, obj, frame.Frame()) {
+				return nil
+			}
+		}
 		if _, err := device.Write(frame.Frame()); err != nil {
 			v.out.Error("Switch.ReadClient: %s", err)
 			return err
@@ -490,7 +628,15 @@ func (v *Switch) OnClose(client libsock.SocketClient) error {
 	addr := client.String()
 	v.out.Info("Switch.OnClose: %s", addr)
 	if obj, err := client2Access(client); err == nil {
-		cache.Network.DelLease(obj.Alias, obj.Network)
+		cache.Network.ReleaseLease(obj.Alias, obj.Network)
+		for _, filter := range obj.Filters {
+			filter.Remove(addr)
+		}
+		cache.Access.Del(addr)
+		if acl := v.acl(obj.Network); acl != nil {
+			libol.Go(acl.Sync)
+		}
+		return nil
 	}
 	cache.Access.Del(addr)
 	return nil
@@ -502,7 +648,7 @@ func (v *Switch) Start() {
 
 	v.fire.Start()
 	// firstly, start network.
-	for _, w := range v.worker {
+	for _, w := range v.workers() {
 		w.Start(v)
 	}
 	// start server for accessing
@@ -516,6 +662,14 @@ func (v *Switch) Start() {
 	if v.http != nil {
 		libol.Go(v.http.Start)
 	}
+	v.acct.Start()
+	v.blocker.Start()
+	v.leaser.Start(5*time.Second, v.updateLease)
+}
+
+func (v *Switch) updateLease() {
+	cache.Network.Expire()
+	cache.Network.Save()
 }
 
 func (v *Switch) Stop() {
@@ -524,11 +678,16 @@ func (v *Switch) Stop() {
 
 	v.out.Info("Switch.Stop")
 
+	v.acct.Stop()
+	v.blocker.Stop()
+	if v.leaser.Stop() {
+		cache.Network.Save()
+	}
 	if v.http != nil {
 		v.http.Shutdown()
 	}
 	// stop network.
-	for _, w := range v.worker {
+	for _, w := range v.workers() {
 		w.Stop(false)
 	}
 	v.out.Info("Switch.Stop left access")
@@ -556,7 +715,7 @@ func (v *Switch) Server() libsock.SocketServer {
 }
 
 func (v *Switch) GetBridge(tenant string) (network.Bridger, error) {
-	w, ok := v.worker[tenant]
+	w, ok := v.getWorker(tenant)
 	if !ok {
 		return nil, libol.NewErr("bridge %s notFound", tenant)
 	}
@@ -568,6 +727,11 @@ func (v *Switch) NewTap(tenant string) (network.Taper, error) {
 	defer v.lock.Unlock()
 	v.out.Debug("Switch.NewTap")
 
+	if fast := v.fastPath(tenant); fast != nil {
+		port := fast.NewPort()
+		v.out.Info("Switch.NewTap: %s on %s", port.Name(), tenant)
+		return port, nil
+	}
 	// already not need support free list for device.
 	// dropped firstly packages during 15s because of forwarding delay.
 	br, err := v.GetBridge(tenant)
@@ -578,6 +742,7 @@ func (v *Switch) NewTap(tenant string) (network.Taper, error) {
 	dev, err := network.NewTaper(tenant, network.TapConfig{
 		Provider: br.Type(),
 		Type:     network.TAP,
+		Queues:   v.cfg.Queue.TapQus,
 	})
 	if err != nil {
 		v.out.Error("Switch.NewTap: %s", err)
@@ -596,7 +761,7 @@ func (v *Switch) FreeTap(dev network.Taper) error {
 	name := dev.Name()
 	tenant := dev.Tenant()
 	v.out.Debug("Switch.FreeTap %s", name)
-	w, ok := v.worker[tenant]
+	w, ok := v.getWorker(tenant)
 	if !ok {
 		return libol.NewErr("bridge %s notFound", tenant)
 	}
@@ -616,24 +781,43 @@ func (v *Switch) UUID() string {
 func (v *Switch) ReadTap(device network.Taper, readAt func(f *libsock.FrameMessage) error) {
 	name := device.Name()
 	v.out.Info("Switch.ReadTap: %s", name)
-	done := make(chan bool, 2)
+	if port, ok := device.(*FastPort); ok {
+		// frames are sent by fastpath directly without reading.
+		port.Attach(readAt)
+		return
+	}
+	queues := 1
+	mq, multi := device.(network.MultiQueuer)
+	if multi && mq.Queues() > 1 {
+		queues = mq.Queues()
+	}
+	done := make(chan bool, queues+1)
 	queue := make(chan *libsock.FrameMessage, v.cfg.Queue.TapWr)
-	libol.Go(func() {
-		for {
-			frame := libsock.NewFrameMessage(0)
-			n, err := device.Read(frame.Frame())
-			if err != nil {
-				v.out.Error("Switch.ReadTap: %s", err)
-				done <- true
-				break
+	for i := 0; i < queues; i++ {
+		read := device.Read
+		if queues > 1 {
+			index := i
+			read = func(p []byte) (int, error) {
+				return mq.ReadQueue(index, p)
 			}
-			frame.SetSize(n)
-			if v.out.Has(libol.LOG) {
-				v.out.Log("Switch.ReadTap: %x\n", frame.Frame()[:n])
-			}
-			queue <- frame
 		}
-	})
+		libol.Go(func() {
+			for {
+				frame := libsock.NewFrameMessage(0)
+				n, err := read(frame.Frame())
+				if err != nil {
+					v.out.Error("Switch.ReadTap: %s", err)
+					done <- true
+					break
+				}
+				frame.SetSize(n)
+				if v.out.Has(libol.LOG) {
+					v.out.Log("Switch.ReadTap: %x\n", frame.Frame()[:n])
+				}
+				queue <- frame
+			}
+		})
+	}
 	defer device.Close()
 	for {
 		select {
@@ -741,6 +925,22 @@ func (v *Switch) DelRate(device string) error {
 	return v.clearRate(device)
 }
 
+func (v *Switch) ResetTraffic(user string) error {
+	return v.acct.ResetTraffic(user)
+}
+
+func (v *Switch) AddQuota(data schema.Quota) error {
+	return v.acct.AddQuota(data)
+}
+
+func (v *Switch) DelQuota(data schema.Quota) error {
+	return v.acct.DelQuota(data)
+}
+
+func (v *Switch) ListQuota(network string, call func(obj schema.Quota)) error {
+	return v.acct.ListQuota(network, call)
+}
+
 func (v *Switch) AddLDAP(value schema.LDAP) error {
 	v.cfg.Ldap = &co.LDAP{
 		Server:    value.Server,
@@ -769,3 +969,46 @@ func (v *Switch) DelLDAP() {
 	v.cfg.Ldap = nil
 	cache.User.ClearLDAP()
 }
+
+func (v *Switch) Prepare(network string, timeout int) error {
+	if _, ok := v.getWorker(network); !ok {
+		return libol.NewErr("network %s notFound", network)
+	}
+	return v.confirm.Prepare(network, timeout)
+}
+
+func (v *Switch) Commit(network string) error {
+	return v.confirm.Commit(network)
+}
+
+func (v *Switch) Rollback(network string) error {
+	return v.confirm.Rollback(network)
+}
+
+func (v *Switch) ListConfirm(call func(obj schema.Confirm)) {
+	v.confirm.ListConfirm(call)
+}
+
+func (v *Switch) restoreNetwork(snap *co.Snapshot) {
+	name := snap.Network.Name
+	v.out.Info("Switch.restoreNetwork: %s", name)
+
+	if w, ok := v.getWorker(name); ok {
+		w.Stop(true)
+		v.setWorker(name, nil)
+	}
+	// access clients lost their tap on bridge, and let them login again.
+	for p := range cache.Access.List() {
+		if p == nil {
+			break
+		}
+		if p.Network == name {
+			v.OffClient(p.Client)
+		}
+	}
+	v.lock.Lock()
+	v.cfg.Restore(snap)
+	v.lock.Unlock()
+	snap.Save()
+	v.AddNetwork(name)
+}
@@ -0,0 +1,337 @@
+package cswitch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luscis/openlan/pkg/api"
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/libsock"
+	"github.com/luscis/openlan/pkg/models"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+const (
+	TrafficThrottled = "throttled"
+	TrafficBlocked   = "blocked"
+)
+
+// trafficOnline is the online sessions of an user.
+type trafficOnline struct {
+	access []*models.Access
+	vpn    []string
+}
+
+// Accountant accounts traffic of access and openvpn clients by users, and
+// enforces the quota of networks.
+type Accountant struct {
+	sw     *Switch
+	lock   sync.Mutex
+	out    *libol.SubLogger
+	ticker libol.Ticker
+}
+
+func NewAccountant(sw *Switch) *Accountant {
+	return &Accountant{
+		sw:  sw,
+		out: libol.NewSubLogger("accountant"),
+	}
+}
+
+func (a *Accountant) Start() {
+	a.out.Info("Accountant.Start")
+	a.ticker.Start(10*time.Second, a.Update)
+}
+
+func (a *Accountant) Stop() {
+	a.out.Info("Accountant.Stop")
+	if !a.ticker.Stop() {
+		return
+	}
+	a.Update()
+}
+
+func vpnUser(client *schema.VPNClient) string {
+	if strings.Contains(client.Name, "@") {
+		return client.Name
+	}
+	return client.Name + "@" + client.Network
+}
+
+func (a *Accountant) collect() map[string]*trafficOnline {
+	users := make(map[string]*trafficOnline, 32)
+	online := func(user string) *trafficOnline {
+		if _, ok := users[user]; !ok {
+			users[user] = &trafficOnline{}
+		}
+		return users[user]
+	}
+	alive := make(map[string]bool, 32)
+	for obj := range cache.Access.List() {
+		if obj == nil {
+			break
+		}
+		if obj.User == "" || obj.Client == nil {
+			continue
+		}
+		user := obj.User + "@" + obj.Network
+		session := "access:" + obj.Client.String()
+		stats := obj.Client.Statistics()
+		rx := uint64(stats[libsock.CsRecvOkay])
+		tx := uint64(stats[libsock.CsSendOkay])
+		cache.Traffic.Account(session, user, obj.Network, rx, tx)
+		alive[session] = true
+		o := online(user)
+		o.access = append(o.access, obj)
+	}
+	for n := range cache.Network.List() {
+		if n == nil {
+			break
+		}
+		for client := range cache.VPNClient.List(n.Name) {
+			if client == nil {
+				break
+			}
+			user := vpnUser(client)
+			session := fmt.Sprintf("openvpn:%s:%s:%d", client.Network, client.Remote, client.Uptime)
+			cache.Traffic.Account(session, user, client.Network, client.RxBytes, client.TxBytes)
+			alive[session] = true
+			o := online(user)
+			o.vpn = append(o.vpn, client.Name)
+		}
+	}
+	cache.Traffic.Sweep(alive)
+	return users
+}
+
+// exceeded returns the quota of the user, and whether it is out of quota.
+func (a *Accountant) exceeded(obj schema.Traffic) (*co.Quota, bool) {
+	w := api.Call.GetWorker(obj.Network)
+	if w == nil {
+		return nil, false
+	}
+	quota := w.Config().Quota
+	if quota == nil {
+		return nil, false
+	}
+	name := strings.SplitN(obj.User, "@", 2)[0]
+	if _, ok := quota.Users[name]; !ok {
+		// admin isn't limited by quota of network, e.g. links.
+		if u := cache.User.Get(obj.User); u != nil && u.Role == "admin" {
+			return quota, false
+		}
+	}
+	daily, monthly := quota.Limit(name)
+	if daily > 0 && obj.Daily >= daily {
+		return quota, true
+	}
+	if monthly > 0 && obj.Monthly >= monthly {
+		return quota, true
+	}
+	return quota, false
+}
+
+// throttle limits the user by qos, which shapes both openvpn and access.
+func (a *Accountant) throttle(obj schema.Traffic, speed float64) {
+	if obj.State == TrafficThrottled {
+		return
+	}
+	var restore *schema.Qos
+	if w := api.Call.GetWorker(obj.Network); w != nil {
+		qos := w.Qoser()
+		qos.ListQos(func(rule schema.Qos) {
+			if rule.Name == obj.User {
+				restore = &rule
+			}
+		})
+		data := schema.Qos{
+			Name:     obj.User,
+			InSpeed:  speed,
+			OutSpeed: speed,
+		}
+		if err := qos.AddQos(data); err != nil {
+			a.out.Warn("Accountant.throttle: %s %s", obj.User, err)
+		}
+	}
+	a.out.Info("Accountant.throttle: %s out of quota with %.2fMbit", obj.User, speed)
+	cache.Traffic.SetState(obj.User, TrafficThrottled, restore)
+}
+
+func (a *Accountant) disconnect(obj schema.Traffic, online *trafficOnline) {
+	if obj.State != TrafficBlocked {
+		a.out.Info("Accountant.disconnect: %s out of quota", obj.User)
+		cache.Traffic.SetState(obj.User, TrafficBlocked, nil)
+	}
+	if online == nil {
+		return
+	}
+	for _, client := range online.access {
+		a.sw.OffClient(client.Client)
+	}
+	if w := api.Call.GetWorker(obj.Network); w != nil {
+		for _, name := range online.vpn {
+			if err := w.KillVPNClient(name); err != nil {
+				a.out.Warn("Accountant.disconnect: %s %s", name, err)
+			}
+		}
+	}
+}
+
+func (a *Accountant) restore(obj schema.Traffic) {
+	if obj.State == TrafficThrottled {
+		if w := api.Call.GetWorker(obj.Network); w != nil {
+			qos := w.Qoser()
+			if obj.Restore != nil {
+				_ = qos.UpdateQos(*obj.Restore)
+			} else {
+				_ = qos.DelQos(obj.User)
+			}
+		}
+	}
+	a.out.Info("Accountant.restore: %s from %s", obj.User, obj.State)
+	cache.Traffic.SetState(obj.User, "", nil)
+}
+
+func (a *Accountant) enforce(obj schema.Traffic, online *trafficOnline) {
+	quota, exceeded := a.exceeded(obj)
+	switch {
+	case exceeded && quota.Action == "throttle":
+		if obj.State == TrafficBlocked {
+			a.restore(obj)
+			obj.State = ""
+		}
+		a.throttle(obj, quota.Speed)
+	case exceeded:
+		if obj.State == TrafficThrottled {
+			a.restore(obj)
+			obj.State = ""
+		}
+		a.disconnect(obj, online)
+	case obj.State != "":
+		a.restore(obj)
+	}
+}
+
+func (a *Accountant) Update() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	users := a.collect()
+	cache.Traffic.Rollover()
+	for _, obj := range cache.Traffic.List("", "") {
+		a.enforce(obj, users[obj.User])
+	}
+	cache.Traffic.Save()
+}
+
+func (a *Accountant) ResetTraffic(user string) error {
+	if !cache.Traffic.Reset(user) {
+		return libol.NewErr("traffic of %s not found", user)
+	}
+	libol.Go(a.Update)
+	return nil
+}
+
+func (a *Accountant) AddQuota(data schema.Quota) error {
+	w := api.Call.GetWorker(data.Network)
+	if w == nil {
+		return libol.NewErr("network %s not found", data.Network)
+	}
+	switch data.Action {
+	case "", "throttle", "disconnect":
+	default:
+		return libol.NewErr("invalid action %s", data.Action)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	cfg := w.Config()
+	if cfg.Quota == nil {
+		cfg.Quota = &co.Quota{}
+	}
+	quota := cfg.Quota
+	limit := co.QuotaLimit{
+		Daily:   data.Daily,
+		Monthly: data.Monthly,
+	}
+	if data.User == "" {
+		quota.QuotaLimit = limit
+		if data.Action != "" {
+			quota.Action = data.Action
+		}
+		if data.Speed > 0 {
+			quota.Speed = data.Speed
+		}
+	} else {
+		if quota.Users == nil {
+			quota.Users = make(map[string]*co.QuotaLimit, 32)
+		}
+		quota.Users[data.User] = &limit
+	}
+	quota.Correct()
+	return nil
+}
+
+func (a *Accountant) DelQuota(data schema.Quota) error {
+	w := api.Call.GetWorker(data.Network)
+	if w == nil {
+		return libol.NewErr("network %s not found", data.Network)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	cfg := w.Config()
+	if cfg.Quota == nil {
+		return nil
+	}
+	if data.User == "" {
+		cfg.Quota = nil
+	} else {
+		delete(cfg.Quota.Users, data.User)
+	}
+	return nil
+}
+
+func (a *Accountant) ListQuota(network string, call func(obj schema.Quota)) error {
+	w := api.Call.GetWorker(network)
+	if w == nil {
+		return libol.NewErr("network %s not found", network)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	quota := w.Config().Quota
+	if quota == nil {
+		return nil
+	}
+	call(schema.Quota{
+		Network: network,
+		Daily:   quota.Daily,
+		Monthly: quota.Monthly,
+		Action:  quota.Action,
+		Speed:   quota.Speed,
+	})
+	users := make([]string, 0, len(quota.Users))
+	for user := range quota.Users {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	for _, user := range users {
+		limit := quota.Users[user]
+		call(schema.Quota{
+			Network: network,
+			User:    user,
+			Daily:   limit.Daily,
+			Monthly: limit.Monthly,
+		})
+	}
+	return nil
+}
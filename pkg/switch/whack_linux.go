@@ -0,0 +1,193 @@
+package cswitch
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+// WhackSA is a state of pluto with SPIs of its IPsec SA.
+type WhackSA struct {
+	schema.IPSecSA
+	InSpi  uint32
+	OutSpi uint32
+}
+
+var (
+	// #2: "conn":4500 STATE_QUICK_I2 (sent QI2, IPsec SA established); EVENT_SA_REPLACE in 27889s; ...
+	whackState = regexp.MustCompile(`#(\d+): "([^"]+)"(?:\[\d+\])?(?::\d+)? (\S+) \(([^)]*)\);(.*)`)
+	// #2: "conn" esp.5b3c0a1@10.0.0.2 esp.c2d1a8e@10.0.0.1 ...
+	whackSpi = regexp.MustCompile(`#(\d+): "[^"]+"(?:\[\d+\])?(?::\d+)? esp\.([0-9a-f]+)@\S+ esp\.([0-9a-f]+)@\S+`)
+	// EVENT_SA_REPLACE in 27889s, REKEY in 2000s or REPLACE in 2000s
+	whackRekey = regexp.MustCompile(`(?:EVENT_SA_REPLACE|EVENT_v[12]_REPLACE|REKEY|REPLACE|EXPIRE) in (-?\d+)s`)
+	// #2: "conn", type=ESP, add_time=1700000000, inBytes=1234, outBytes=5678, ...
+	whackTraffic = regexp.MustCompile(`#(\d+): "[^"]+"(?:\[\d+\])?,.*inBytes=(\d+), outBytes=(\d+)`)
+)
+
+func parseSpi(value string) uint32 {
+	spi, _ := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 32)
+	return uint32(spi)
+}
+
+// ParseWhackStatus returns states from `ipsec whack --status`.
+func ParseWhackStatus(out string) []*WhackSA {
+	sas := make([]*WhackSA, 0, 32)
+	serials := make(map[int]*WhackSA)
+	for _, line := range strings.Split(out, "\n") {
+		if m := whackState.FindStringSubmatch(line); m != nil {
+			serial, _ := strconv.Atoi(m[1])
+			sa := &WhackSA{
+				IPSecSA: schema.IPSecSA{
+					Serial: serial,
+					Conn:   m[2],
+					Type:   "IKE",
+					State:  m[3],
+				},
+			}
+			desc := m[4]
+			if strings.Contains(desc, "IPsec SA") || strings.Contains(desc, "Child SA") ||
+				strings.Contains(m[3], "CHILD") || strings.Contains(m[3], "QUICK") {
+				sa.Type = "IPsec"
+			}
+			if r := whackRekey.FindStringSubmatch(m[5]); r != nil {
+				sa.Rekey, _ = strconv.ParseInt(r[1], 10, 64)
+			}
+			sas = append(sas, sa)
+			serials[serial] = sa
+			continue
+		}
+		if m := whackSpi.FindStringSubmatch(line); m != nil {
+			serial, _ := strconv.Atoi(m[1])
+			if sa, ok := serials[serial]; ok {
+				sa.OutSpi = parseSpi(m[2])
+				sa.InSpi = parseSpi(m[3])
+			}
+		}
+	}
+	return sas
+}
+
+// ParseTrafficStatus returns in and out bytes by serial from `ipsec
+// whack --trafficstatus`.
+func ParseTrafficStatus(out string) map[int][2]uint64 {
+	data := make(map[int][2]uint64)
+	for _, line := range strings.Split(out, "\n") {
+		m := whackTraffic.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		serial, _ := strconv.Atoi(m[1])
+		in, _ := strconv.ParseUint(m[2], 10, 64)
+		out, _ := strconv.ParseUint(m[3], 10, 64)
+		data[serial] = [2]uint64{in, out}
+	}
+	return data
+}
+
+// ParseXfrmState returns packets by SPI from `ip -s xfrm state`.
+func ParseXfrmState(out string) map[uint32]uint64 {
+	data := make(map[uint32]uint64)
+	spi := uint32(0)
+	current := false
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		values := strings.Fields(line)
+		if len(values) >= 4 && values[0] == "proto" && values[2] == "spi" {
+			spi = parseSpi(values[3])
+			continue
+		}
+		if line == "lifetime current:" {
+			current = true
+			continue
+		}
+		if current {
+			current = false
+			// 1234(bytes), 12(packets)
+			if len(values) == 2 && strings.HasSuffix(values[1], "(packets)") {
+				value := strings.TrimSuffix(values[1], "(packets)")
+				data[spi], _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+	}
+	return data
+}
+
+// whackLogTail is size of the log read for the last error, as the log of a
+// connection keeps growing.
+const whackLogTail = 64 << 10
+
+// lastError returns the last failure of a connection from tail of its log.
+func lastError(file string) string {
+	fp, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer fp.Close()
+	info, err := fp.Stat()
+	if err != nil {
+		return ""
+	}
+	partial := false
+	if offset := info.Size() - whackLogTail; offset > 0 {
+		if _, err := fp.Seek(offset, io.SeekStart); err != nil {
+			return ""
+		}
+		partial = true
+	}
+	last := ""
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		if partial {
+			// the first line may be cut by seeking.
+			partial = false
+			continue
+		}
+		line := strings.TrimSpace(scanner.Text())
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "fail") || strings.Contains(lower, "error") ||
+			strings.Contains(lower, "timeout") || strings.Contains(lower, "no acceptable") {
+			last = line
+		}
+	}
+	return last
+}
+
+// WhackStatus queries pluto for SAs with counters, and groups by
+// connection name.
+func WhackStatus() (map[string][]schema.IPSecSA, error) {
+	out, err := libol.Exec(IPSecBin, "whack", "--status")
+	if err != nil {
+		return nil, libol.NewErr("%s: %s", err, out)
+	}
+	sas := ParseWhackStatus(out)
+	traffic := make(map[int][2]uint64)
+	if out, err := libol.Exec(IPSecBin, "whack", "--trafficstatus"); err == nil {
+		traffic = ParseTrafficStatus(out)
+	}
+	packets := make(map[uint32]uint64)
+	if out, err := libol.Exec("ip", "-s", "xfrm", "state"); err == nil {
+		packets = ParseXfrmState(out)
+	}
+	data := make(map[string][]schema.IPSecSA)
+	for _, sa := range sas {
+		if value, ok := traffic[sa.Serial]; ok {
+			sa.InBytes = value[0]
+			sa.OutBytes = value[1]
+		}
+		if sa.InSpi > 0 {
+			sa.InPackets = packets[sa.InSpi]
+		}
+		if sa.OutSpi > 0 {
+			sa.OutPackets = packets[sa.OutSpi]
+		}
+		data[sa.Conn] = append(data[sa.Conn], sa.IPSecSA)
+	}
+	return data, nil
+}
@@ -0,0 +1,87 @@
+package cswitch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const whackStatusOut = `000 State Information: DDoS cookies not required, Accepting new IKE connections
+000 #1: "10.0.0.2-vxlan-c1":500 STATE_MAIN_I4 (ISAKMP SA established); EVENT_SA_REPLACE in 2620s; newest ISAKMP; lastdpd=-1s(seq in:0 out:0); idle;
+000 #2: "10.0.0.2-vxlan-c1":500 STATE_QUICK_I2 (sent QI2, IPsec SA established); EVENT_SA_REPLACE in 27889s; newest IPSEC; eroute owner; isakmp#1; idle;
+000 #2: "10.0.0.2-vxlan-c1" esp.5b3c0a1@10.0.0.2 esp.c2d1a8e@10.0.0.1 tun.0@10.0.0.2 tun.0@10.0.0.1 Traffic: ESPin=1KB ESPout=2KB! ESPmax=4194303B
+000 #3: "10.0.0.3-gre-c1":500 STATE_PARENT_I2 (sent v2I2, expected v2R2); EVENT_RETRANSMIT in 10s; idle;
+`
+
+const whackTrafficOut = `006 #2: "10.0.0.2-vxlan-c1", type=ESP, add_time=1700000000, inBytes=1234, outBytes=5678, id='@10.0.0.2.vxlan'
+`
+
+const xfrmStateOut = `src 10.0.0.1 dst 10.0.0.2
+	proto esp spi 0x05b3c0a1 reqid 16389 mode transport
+	replay-window 32 seq 0x00000000 flag af-unspec (0x00100000)
+	lifetime current:
+	  5678(bytes), 56(packets)
+src 10.0.0.2 dst 10.0.0.1
+	proto esp spi 0x0c2d1a8e reqid 16389 mode transport
+	replay-window 32 seq 0x00000000 flag af-unspec (0x00100000)
+	lifetime current:
+	  1234(bytes), 12(packets)
+`
+
+func TestParseWhackStatus(t *testing.T) {
+	sas := ParseWhackStatus(whackStatusOut)
+	if len(sas) != 3 {
+		t.Fatalf("expected 3 states, got %d", len(sas))
+	}
+	if sas[0].Type != "IKE" || sas[0].State != "STATE_MAIN_I4" || sas[0].Rekey != 2620 {
+		t.Errorf("unexpected ike state: %+v", sas[0])
+	}
+	if sas[1].Type != "IPsec" || sas[1].Conn != "10.0.0.2-vxlan-c1" || sas[1].Rekey != 27889 {
+		t.Errorf("unexpected ipsec state: %+v", sas[1])
+	}
+	if sas[1].OutSpi != 0x05b3c0a1 || sas[1].InSpi != 0x0c2d1a8e {
+		t.Errorf("unexpected spi: %x %x", sas[1].OutSpi, sas[1].InSpi)
+	}
+	if sas[2].Type != "IKE" || sas[2].Conn != "10.0.0.3-gre-c1" {
+		t.Errorf("unexpected ike state: %+v", sas[2])
+	}
+}
+
+func TestParseTrafficStatus(t *testing.T) {
+	data := ParseTrafficStatus(whackTrafficOut)
+	if value, ok := data[2]; !ok || value[0] != 1234 || value[1] != 5678 {
+		t.Errorf("unexpected traffic: %v", data)
+	}
+}
+
+func TestParseXfrmState(t *testing.T) {
+	data := ParseXfrmState(xfrmStateOut)
+	if data[0x05b3c0a1] != 56 || data[0x0c2d1a8e] != 12 {
+		t.Errorf("unexpected packets: %v", data)
+	}
+}
+
+func TestWhackLastError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "conn.log")
+	data := "initiating Main Mode\nIKE SA failed: timeout\n" +
+		strings.Repeat("retransmitting\n", whackLogTail/len("retransmitting\n")) +
+		"STATE_MAIN_I1: no acceptable response\nestablished\n"
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatalf("write %s", err)
+	}
+	if value := lastError(file); value != "STATE_MAIN_I1: no acceptable response" {
+		t.Errorf("last error %q", value)
+	}
+	// the failure out of tail isn't read.
+	data = "IKE SA failed: timeout\n" + strings.Repeat("retransmitting\n", whackLogTail/len("retransmitting\n"))
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatalf("write %s", err)
+	}
+	if value := lastError(file); value != "" {
+		t.Errorf("last error %q", value)
+	}
+	if value := lastError(file + ".none"); value != "" {
+		t.Errorf("last error %q", value)
+	}
+}
@@ -0,0 +1,488 @@
+package cswitch
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/libsock"
+	cn "github.com/luscis/openlan/pkg/network"
+	"github.com/luscis/openlan/pkg/schema"
+	nl "github.com/vishvananda/netlink"
+)
+
+const (
+	WgBin = "wg"
+	WgDir = "/var/openlan/wireguard"
+)
+
+func WgGenKey() (string, string, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	priv := base64.StdEncoding.EncodeToString(key.Bytes())
+	pub := base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+	return priv, pub, nil
+}
+
+func WgPubKey(priv string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(priv)
+	if err != nil {
+		return "", err
+	}
+	key, err := ecdh.X25519().NewPrivateKey(data)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// WireGuard is a kernel wg device of network. The clients are routed to
+// the bridge by proxy ARP, and get addresses from the lease pool.
+type WireGuard struct {
+	cfg    *co.WireGuard
+	bridge string
+	table  int
+	pubKey string
+	out    *libol.SubLogger
+	lock   sync.Mutex
+}
+
+func NewWireGuard(cfg *co.WireGuard, bridge string, table int) *WireGuard {
+	return &WireGuard{
+		cfg:    cfg,
+		bridge: bridge,
+		table:  table,
+		out:    libol.NewSubLogger(cfg.Network),
+	}
+}
+
+func (w *WireGuard) KeyFile() string {
+	return WgDir + "/" + w.cfg.Network + ".key"
+}
+
+// saveKey writes the private key to disk at once, so the key generated
+// is kept even if the configuration not saved.
+func (w *WireGuard) saveKey() error {
+	if err := os.MkdirAll(WgDir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(w.KeyFile(), []byte(w.cfg.PrivateKey), 0600)
+}
+
+func (w *WireGuard) Initialize() {
+	if w.cfg.PrivateKey == "" {
+		if data, err := os.ReadFile(w.KeyFile()); err == nil {
+			w.cfg.PrivateKey = strings.TrimSpace(string(data))
+		}
+	}
+	if w.cfg.PrivateKey == "" {
+		priv, _, err := WgGenKey()
+		if err != nil {
+			w.out.Error("WireGuard.Initialize: %s", err)
+			return
+		}
+		w.cfg.PrivateKey = priv
+	}
+	if err := w.saveKey(); err != nil {
+		w.out.Warn("WireGuard.Initialize: %s", err)
+	}
+	pub, err := WgPubKey(w.cfg.PrivateKey)
+	if err != nil {
+		w.out.Error("WireGuard.Initialize: %s", err)
+		return
+	}
+	w.pubKey = pub
+}
+
+func (w *WireGuard) exec(args ...string) error {
+	w.out.Debug("WireGuard.exec: %v", args)
+	if out, err := libol.Exec(WgBin, args...); err != nil {
+		return libol.NewErr("%s: %s", err, out)
+	}
+	return nil
+}
+
+func (w *WireGuard) Start() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	name := w.cfg.Device()
+	w.out.Info("WireGuard.Start: %s on %d", name, w.cfg.Listen)
+	if out, err := cn.LinkAdd(name, "type", "wireguard"); err != nil {
+		w.out.Warn("WireGuard.Start: %s %s", name, out)
+	}
+	if err := w.exec("set", name,
+		"listen-port", strconv.Itoa(w.cfg.Listen),
+		"private-key", w.KeyFile()); err != nil {
+		w.out.Warn("WireGuard.Start: %s", err)
+	}
+	if out, err := cn.LinkUp(name); err != nil {
+		w.out.Warn("WireGuard.Start: %s %s", name, out)
+	}
+	// answer ARP of clients on the bridge.
+	file := "/proc/sys/net/ipv4/conf/" + w.bridge + "/proxy_arp"
+	if err := os.WriteFile(file, []byte("1"), 0644); err != nil {
+		w.out.Warn("WireGuard.Start: %s", err)
+	}
+	for _, peer := range w.cfg.Peers {
+		w.addPeer(peer)
+	}
+}
+
+func (w *WireGuard) Stop(kill bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	name := w.cfg.Device()
+	w.out.Info("WireGuard.Stop: %s", name)
+	if out, err := cn.LinkDel(name); err != nil {
+		w.out.Warn("WireGuard.Stop: %s %s", name, out)
+	}
+	if kill {
+		// leases are kept for peers restarted.
+		for _, peer := range w.cfg.Peers {
+			cache.Network.DelLease(w.alias(peer), w.cfg.Network)
+		}
+		_ = os.Remove(w.KeyFile())
+	}
+}
+
+func (w *WireGuard) alias(peer *co.WireGuardPeer) string {
+	return "wg:" + peer.Name
+}
+
+func (w *WireGuard) lease(peer *co.WireGuardPeer) error {
+	alias := w.alias(peer)
+	network := w.cfg.Network
+	if peer.Address == "" {
+		lease := cache.Network.NewLease(alias, network)
+		if lease == nil {
+			return libol.NewErr("no address for %s", peer.Name)
+		}
+		peer.Address = lease.Address
+		return nil
+	}
+	if has := cache.Network.GetLeaseByAddr(peer.Address, network); has != nil && has.Alias != alias {
+		return libol.NewErr("%s already leased to %s", peer.Address, has.Alias)
+	}
+	cache.Network.AddLease(alias, peer.Address, network)
+	return nil
+}
+
+func (w *WireGuard) route(prefix string, add bool) {
+	dst, err := libol.ParseNet(prefix)
+	if err != nil {
+		w.out.Warn("WireGuard.route: %s", err)
+		return
+	}
+	link, err := nl.LinkByName(w.cfg.Device())
+	if err != nil {
+		w.out.Warn("WireGuard.route: %s", err)
+		return
+	}
+	nlr := &nl.Route{
+		Dst:       dst,
+		LinkIndex: link.Attrs().Index,
+		Table:     w.table,
+	}
+	if add {
+		err = nl.RouteReplace(nlr)
+	} else {
+		err = nl.RouteDel(nlr)
+	}
+	if err != nil {
+		w.out.Warn("WireGuard.route: %s %s", prefix, err)
+	}
+}
+
+func (w *WireGuard) allowed(peer *co.WireGuardPeer) []string {
+	ips := make([]string, 0, 4)
+	if peer.Address != "" {
+		ips = append(ips, peer.Address+"/32")
+	}
+	return append(ips, peer.AllowedIPs...)
+}
+
+func (w *WireGuard) addPeer(peer *co.WireGuardPeer) {
+	if err := w.lease(peer); err != nil {
+		w.out.Warn("WireGuard.addPeer: %s", err)
+	}
+	ips := w.allowed(peer)
+	if err := w.exec("set", w.cfg.Device(),
+		"peer", peer.PublicKey,
+		"allowed-ips", strings.Join(ips, ",")); err != nil {
+		w.out.Warn("WireGuard.addPeer: %s %s", peer.Name, err)
+		return
+	}
+	for _, prefix := range ips {
+		w.route(prefix, true)
+	}
+	w.out.Info("WireGuard.addPeer: %s %v", peer.Name, ips)
+}
+
+func (w *WireGuard) delPeer(peer *co.WireGuardPeer) {
+	for _, prefix := range w.allowed(peer) {
+		w.route(prefix, false)
+	}
+	if err := w.exec("set", w.cfg.Device(), "peer", peer.PublicKey, "remove"); err != nil {
+		w.out.Warn("WireGuard.delPeer: %s %s", peer.Name, err)
+	}
+	cache.Network.DelLease(w.alias(peer), w.cfg.Network)
+	w.out.Info("WireGuard.delPeer: %s", peer.Name)
+}
+
+func (w *WireGuard) AddPeer(data schema.WireGuardPeer) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if data.Name == "" {
+		return libol.NewErr("invalid name")
+	}
+	if _, find := w.cfg.FindPeer(data.Name); find != -1 {
+		return libol.NewErr("%s already existed", data.Name)
+	}
+	if data.Address != "" && net.ParseIP(data.Address) == nil {
+		return libol.NewErr("invalid address %s", data.Address)
+	}
+	peer := &co.WireGuardPeer{
+		Name:       data.Name,
+		PublicKey:  data.PublicKey,
+		Address:    data.Address,
+		AllowedIPs: data.AllowedIPs,
+	}
+	if peer.PublicKey == "" {
+		priv, pub, err := WgGenKey()
+		if err != nil {
+			return err
+		}
+		peer.PrivateKey = priv
+		peer.PublicKey = pub
+	}
+	if err := w.lease(peer); err != nil {
+		return err
+	}
+	w.cfg.AddPeer(peer)
+	w.addPeer(peer)
+	return nil
+}
+
+func (w *WireGuard) DelPeer(name string) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	peer, ok := w.cfg.DelPeer(name)
+	if !ok {
+		return libol.NewErr("%s not found", name)
+	}
+	w.delPeer(peer)
+	return nil
+}
+
+type WgDump struct {
+	Endpoint  string
+	Handshake int64
+	RxBytes   uint64
+	TxBytes   uint64
+}
+
+// dump returns status of peers by public key from `wg show <dev> dump`.
+func (w *WireGuard) dump() map[string]WgDump {
+	data := make(map[string]WgDump)
+	out, err := libol.Exec(WgBin, "show", w.cfg.Device(), "dump")
+	if err != nil {
+		w.out.Debug("WireGuard.dump: %s", err)
+		return data
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	for _, line := range lines[1:] {
+		// public-key preshared-key endpoint allowed-ips handshake rx tx keepalive
+		values := strings.Split(line, "\t")
+		if len(values) < 7 {
+			continue
+		}
+		obj := WgDump{Endpoint: values[2]}
+		obj.Handshake, _ = strconv.ParseInt(values[4], 10, 64)
+		obj.RxBytes, _ = strconv.ParseUint(values[5], 10, 64)
+		obj.TxBytes, _ = strconv.ParseUint(values[6], 10, 64)
+		data[values[0]] = obj
+	}
+	return data
+}
+
+func (w *WireGuard) Get() *schema.WireGuard {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	data := &schema.WireGuard{
+		Network:   w.cfg.Network,
+		Device:    w.cfg.Device(),
+		Listen:    w.cfg.Listen,
+		PublicKey: w.pubKey,
+		Endpoint:  w.cfg.Endpoint,
+		Peers:     make([]schema.WireGuardPeer, 0, len(w.cfg.Peers)),
+	}
+	status := w.dump()
+	for _, peer := range w.cfg.Peers {
+		obj := schema.WireGuardPeer{
+			Name:       peer.Name,
+			PublicKey:  peer.PublicKey,
+			Address:    peer.Address,
+			AllowedIPs: peer.AllowedIPs,
+		}
+		if sts, ok := status[peer.PublicKey]; ok {
+			obj.Endpoint = sts.Endpoint
+			if sts.Handshake > 0 {
+				obj.Handshake = time.Now().Unix() - sts.Handshake
+			}
+			obj.RxBytes = sts.RxBytes
+			obj.TxBytes = sts.TxBytes
+		}
+		data.Peers = append(data.Peers, obj)
+	}
+	return data
+}
+
+// Profile exports the configuration of a client which keys generated by
+// the switch.
+func (w *WireGuard) Profile(name, server string) (string, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	peer, find := w.cfg.FindPeer(name)
+	if find == -1 {
+		return "", libol.NewErr("%s not found", name)
+	}
+	if peer.PrivateKey == "" {
+		return "", libol.NewErr("%s has its own private key", name)
+	}
+	prefix := 32
+	allowed := make([]string, 0, 4)
+	if network := cache.Network.Get(w.cfg.Network); network != nil && network.Netmask != "" {
+		prefix = libol.Netmask2Len(network.Netmask)
+		addr := fmt.Sprintf("%s/%d", network.Address, prefix)
+		if _, dest, err := net.ParseCIDR(addr); err == nil {
+			allowed = append(allowed, dest.String())
+		}
+	}
+	endpoint := w.cfg.Endpoint
+	if endpoint == "" {
+		endpoint = server
+	}
+	if !strings.Contains(endpoint, ":") {
+		endpoint = fmt.Sprintf("%s:%d", endpoint, w.cfg.Listen)
+	}
+	var sb strings.Builder
+	sb.WriteString("[Interface]\n")
+	sb.WriteString("PrivateKey = " + peer.PrivateKey + "\n")
+	sb.WriteString(fmt.Sprintf("Address = %s/%d\n", peer.Address, prefix))
+	sb.WriteString("\n[Peer]\n")
+	sb.WriteString("PublicKey = " + w.pubKey + "\n")
+	sb.WriteString("Endpoint = " + endpoint + "\n")
+	if len(allowed) > 0 {
+		sb.WriteString("AllowedIPs = " + strings.Join(allowed, ", ") + "\n")
+	}
+	sb.WriteString("PersistentKeepalive = 25\n")
+	return sb.String(), nil
+}
+
+// WireGuardLink is a site peer of wg device for the wireguard output.
+type WireGuardLink struct {
+	wg   *WireGuard
+	port *co.Output
+	peer *co.WireGuardPeer
+}
+
+func NewWireGuardLink(wg *WireGuard, port *co.Output) *WireGuardLink {
+	return &WireGuardLink{
+		wg:   wg,
+		port: port,
+		peer: &co.WireGuardPeer{
+			Name:       port.Link,
+			PublicKey:  port.Secret,
+			AllowedIPs: port.AllowedIPs,
+		},
+	}
+}
+
+func (l *WireGuardLink) endpoint() string {
+	port := l.port.DstPort
+	if port == 0 {
+		port = 51820
+	}
+	return fmt.Sprintf("%s:%d", l.port.Remote, port)
+}
+
+func (l *WireGuardLink) Start() error {
+	l.wg.lock.Lock()
+	defer l.wg.lock.Unlock()
+
+	ips := strings.Join(l.peer.AllowedIPs, ",")
+	if err := l.wg.exec("set", l.wg.cfg.Device(),
+		"peer", l.peer.PublicKey,
+		"endpoint", l.endpoint(),
+		"persistent-keepalive", "25",
+		"allowed-ips", ips); err != nil {
+		return err
+	}
+	for _, prefix := range l.peer.AllowedIPs {
+		l.wg.route(prefix, true)
+	}
+	return nil
+}
+
+func (l *WireGuardLink) Stop(kill bool) error {
+	l.wg.lock.Lock()
+	defer l.wg.lock.Unlock()
+
+	for _, prefix := range l.peer.AllowedIPs {
+		l.wg.route(prefix, false)
+	}
+	return l.wg.exec("set", l.wg.cfg.Device(), "peer", l.peer.PublicKey, "remove")
+}
+
+func (l *WireGuardLink) status() WgDump {
+	l.wg.lock.Lock()
+	defer l.wg.lock.Unlock()
+	return l.wg.dump()[l.peer.PublicKey]
+}
+
+func (l *WireGuardLink) Device() string {
+	return l.wg.cfg.Device()
+}
+
+func (l *WireGuardLink) Status() string {
+	sts := l.status()
+	// handshake is renewed every two minutes.
+	if sts.Handshake > 0 && time.Now().Unix()-sts.Handshake < 180 {
+		return "connected"
+	}
+	return "connecting"
+}
+
+func (l *WireGuardLink) UpTime() int64 {
+	return 0
+}
+
+func (l *WireGuardLink) Statistics() map[string]int64 {
+	sts := l.status()
+	return map[string]int64{
+		libsock.CsRecvOkay: int64(sts.RxBytes),
+		libsock.CsSendOkay: int64(sts.TxBytes),
+	}
+}
+
+func (l *WireGuardLink) Record() map[string]int64 {
+	return nil
+}
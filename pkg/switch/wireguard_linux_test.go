@@ -0,0 +1,146 @@
+package cswitch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/models"
+)
+
+func TestWgGenKey(t *testing.T) {
+	priv, pub, err := WgGenKey()
+	if err != nil {
+		t.Fatalf("genkey: %s", err)
+	}
+	if len(priv) != 44 || len(pub) != 44 {
+		t.Errorf("unexpected key length: %q %q", priv, pub)
+	}
+	value, err := WgPubKey(priv)
+	if err != nil {
+		t.Fatalf("pubkey: %s", err)
+	}
+	if value != pub {
+		t.Errorf("expected %s, got %s", pub, value)
+	}
+}
+
+func TestWgPubKeyInvalid(t *testing.T) {
+	if _, err := WgPubKey("invalid"); err == nil {
+		t.Errorf("expected error for invalid key")
+	}
+}
+
+func fakeWireGuard(network string) *WireGuard {
+	cfg := &co.WireGuard{}
+	cfg.Correct(network)
+	return NewWireGuard(cfg, "br-"+network, 0)
+}
+
+func TestWireGuardLease(t *testing.T) {
+	network := "fake-wg"
+	cache.Network.Add(&models.Network{
+		Name:    network,
+		Address: "192.168.4.1",
+		Netmask: "255.255.255.0",
+		IpStart: "192.168.4.10",
+		IpEnd:   "192.168.4.11",
+	})
+	defer cache.Network.Del(network)
+
+	w := fakeWireGuard(network)
+	aa := &co.WireGuardPeer{Name: "aa"}
+	if err := w.lease(aa); err != nil {
+		t.Fatalf("lease: %s", err)
+	}
+	if aa.Address != "192.168.4.10" {
+		t.Errorf("expected 192.168.4.10, got %s", aa.Address)
+	}
+	defer cache.Network.DelLease(w.alias(aa), network)
+	// sticky lease of the same peer.
+	address := aa.Address
+	if err := w.lease(aa); err != nil || aa.Address != address {
+		t.Errorf("expected %s, got %s %v", address, aa.Address, err)
+	}
+
+	bb := &co.WireGuardPeer{Name: "bb", Address: "192.168.4.10"}
+	if err := w.lease(bb); err == nil {
+		t.Errorf("expected error for address leased to aa")
+	}
+	cc := &co.WireGuardPeer{Name: "cc", Address: "192.168.4.20"}
+	if err := w.lease(cc); err != nil {
+		t.Fatalf("lease: %s", err)
+	}
+	defer cache.Network.DelLease(w.alias(cc), network)
+	if lease := cache.Network.GetLeaseByAddr("192.168.4.20", network); lease == nil || lease.Alias != "wg:cc" {
+		t.Errorf("expected lease of wg:cc, got %v", lease)
+	}
+
+	dd := &co.WireGuardPeer{Name: "dd"}
+	if err := w.lease(dd); err != nil {
+		t.Fatalf("lease: %s", err)
+	}
+	defer cache.Network.DelLease(w.alias(dd), network)
+	ee := &co.WireGuardPeer{Name: "ee"}
+	if err := w.lease(ee); err == nil {
+		t.Errorf("expected error for pool exhausted")
+	}
+}
+
+func TestWireGuardProfile(t *testing.T) {
+	network := "fake-wgp"
+	cache.Network.Add(&models.Network{
+		Name:    network,
+		Address: "192.168.5.1",
+		Netmask: "255.255.255.0",
+	})
+	defer cache.Network.Del(network)
+
+	priv, pub, _ := WgGenKey()
+	w := fakeWireGuard(network)
+	w.pubKey = "server-public-key"
+	w.cfg.Endpoint = "vpn.example.com"
+	w.cfg.AddPeer(&co.WireGuardPeer{
+		Name:       "aa",
+		PrivateKey: priv,
+		PublicKey:  pub,
+		Address:    "192.168.5.10",
+	})
+	w.cfg.AddPeer(&co.WireGuardPeer{
+		Name:      "bb",
+		PublicKey: pub,
+		Address:   "192.168.5.11",
+	})
+
+	data, err := w.Profile("aa", "1.1.1.1")
+	if err != nil {
+		t.Fatalf("profile: %s", err)
+	}
+	expected := []string{
+		"[Interface]",
+		"PrivateKey = " + priv,
+		"Address = 192.168.5.10/24",
+		"",
+		"[Peer]",
+		"PublicKey = server-public-key",
+		"Endpoint = vpn.example.com:51820",
+		"AllowedIPs = 192.168.5.0/24",
+		"PersistentKeepalive = 25",
+	}
+	if value := strings.TrimSpace(data); value != strings.Join(expected, "\n") {
+		t.Errorf("unexpected profile:\n%s", data)
+	}
+
+	w.cfg.Endpoint = ""
+	data, _ = w.Profile("aa", "1.1.1.1")
+	if !strings.Contains(data, "Endpoint = 1.1.1.1:51820\n") {
+		t.Errorf("expected endpoint of server:\n%s", data)
+	}
+	if _, err := w.Profile("bb", "1.1.1.1"); err == nil {
+		t.Errorf("expected error for peer with its own key")
+	}
+	if _, err := w.Profile("cc", "1.1.1.1"); err == nil {
+		t.Errorf("expected error for peer not found")
+	}
+}
@@ -2,9 +2,14 @@ package cswitch
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
 	"github.com/luscis/openlan/pkg/libol"
 	cn "github.com/luscis/openlan/pkg/network"
 	"github.com/luscis/openlan/pkg/schema"
@@ -16,6 +21,7 @@ type KnockRule struct {
 	protocol    string
 	destination string
 	port        string
+	policy      string // requested by an user if not empty.
 	rule        *cn.IPRule
 }
 
@@ -31,6 +37,30 @@ func (r *KnockRule) Expire() bool {
 	return false
 }
 
+func (r *KnockRule) Schema(network, name string) schema.KnockRule {
+	return schema.KnockRule{
+		Network:  network,
+		Name:     name,
+		Protocol: r.protocol,
+		Dest:     r.destination,
+		Port:     r.port,
+		CreateAt: r.createAt.Unix(),
+		Age:      int(r.age + r.createAt.Unix() - time.Now().Unix()),
+		Policy:   r.policy,
+	}
+}
+
+func newKnockRule(obj schema.KnockRule) *KnockRule {
+	return &KnockRule{
+		createAt:    time.Unix(obj.CreateAt, 0),
+		age:         int64(obj.Age),
+		protocol:    obj.Protocol,
+		destination: obj.Dest,
+		port:        obj.Port,
+		policy:      obj.Policy,
+	}
+}
+
 func (r *KnockRule) Rule() cn.IPRule {
 	if r.rule == nil {
 		r.rule = &cn.IPRule{
@@ -47,6 +77,8 @@ type ZGuest struct {
 	network  string
 	username string
 	source   string
+	schedule string
+	active   bool // jumped to chain in windows of schedule.
 	rules    map[string]*KnockRule
 	chain    *cn.FireWallChain
 	out      *libol.SubLogger
@@ -115,7 +147,8 @@ func (g *ZGuest) Stop() {
 	g.chain.Cancel()
 }
 
-func (g *ZGuest) Clear() {
+// Clear removes rules expired, and returns them.
+func (g *ZGuest) Clear() []*KnockRule {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
@@ -130,6 +163,18 @@ func (g *ZGuest) Clear() {
 		delete(g.rules, rule.Id())
 		g.delRuleX(rule.Rule())
 	}
+	return removed
+}
+
+func (g *ZGuest) List() []*KnockRule {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	rules := make([]*KnockRule, 0, len(g.rules))
+	for _, rule := range g.rules {
+		rules = append(rules, rule)
+	}
+	return rules
 }
 
 func (g *ZGuest) flush() {
@@ -145,20 +190,63 @@ func (g *ZGuest) Flush() {
 	g.flush()
 }
 
+const (
+	ZTrustDir = "/var/openlan/ztrust"
+)
+
+const (
+	zMaxHistory = 1024
+	zPendingAge = 3600 // seconds of a request waiting for approval.
+	zMaxPending = 8    // requests of an user waiting for approval.
+)
+
+// zRequest is a knock requested by an user, and waiting for approval.
+type zRequest struct {
+	id   string
+	name string
+	rule *KnockRule
+}
+
+func (r *zRequest) Schema(network string) schema.KnockRule {
+	obj := r.rule.Schema(network, r.name)
+	obj.Id = r.id
+	obj.Age = int(r.rule.age)
+	obj.State = "pending"
+	return obj
+}
+
+// zState is saved to keep grants and audits across restarts.
+type zState struct {
+	Grants  []schema.KnockRule   `json:"grants"`
+	Pending []schema.KnockRule   `json:"pending"`
+	History []schema.ZTrustEvent `json:"history"`
+}
+
 type ZTrust struct {
-	network string
-	expire  int
-	guests  map[string]*ZGuest
-	chain   *cn.FireWallChain
-	out     *libol.SubLogger
+	lock      sync.Mutex
+	network   string
+	expire    int
+	guests    map[string]*ZGuest
+	schedules map[string]string // by name of guest, and set by admin.
+	policies  []*ZPolicy
+	pending   map[string]*zRequest
+	restored  map[string][]*KnockRule // grants of guests offline after restarted.
+	history   []schema.ZTrustEvent
+	file      string
+	chain     *cn.FireWallChain
+	out       *libol.SubLogger
 }
 
 func NewZTrust(network string, expire int) *ZTrust {
 	return &ZTrust{
-		network: network,
-		expire:  expire,
-		out:     libol.NewSubLogger(network),
-		guests:  make(map[string]*ZGuest, 32),
+		network:   network,
+		expire:    expire,
+		out:       libol.NewSubLogger(network),
+		guests:    make(map[string]*ZGuest, 32),
+		schedules: make(map[string]string, 32),
+		pending:   make(map[string]*zRequest, 32),
+		restored:  make(map[string][]*KnockRule, 32),
+		file:      filepath.Join(ZTrustDir, network+".json"),
 	}
 }
 
@@ -178,11 +266,115 @@ func (z *ZTrust) Initialize() {
 	})
 }
 
-func (z *ZTrust) Knock(name string, protocol, dest, port string, age int) error {
+func (z *ZTrust) loadPolicy(cfgs []*co.ZPolicy) {
+	for _, cfg := range cfgs {
+		p, err := NewZPolicy(cfg)
+		if err != nil {
+			z.out.Warn("ZTrust.loadPolicy: %s", err)
+			continue
+		}
+		z.policies = append(z.policies, p)
+	}
+}
+
+// record appends an audit of a knock, and kept in max history.
+func (z *ZTrust) record(action, name string, rule *KnockRule, by string) {
+	z.out.Info("ZTrust.record: %s %s %s by %s", action, name, rule.Id(), by)
+	if len(z.history) >= zMaxHistory {
+		z.history = z.history[1:]
+	}
+	z.history = append(z.history, schema.ZTrustEvent{
+		Time:     time.Now().Unix(),
+		Network:  z.network,
+		Name:     name,
+		Action:   action,
+		Protocol: rule.protocol,
+		Dest:     rule.destination,
+		Port:     rule.port,
+		Age:      int(rule.age),
+		Policy:   rule.policy,
+		By:       by,
+	})
+	libol.Audit(libol.AuditEvent{
+		Type:    "ztrust",
+		User:    name,
+		Network: z.network,
+		Action:  action,
+		Params:  rule.Id(),
+		Detail:  "by " + by,
+	})
+}
+
+func (z *ZTrust) load() {
+	state := &zState{}
+	if err := libol.UnmarshalLoad(state, z.file); err != nil {
+		z.out.Debug("ZTrust.load: %s", err)
+		return
+	}
+	z.history = state.History
+	for _, obj := range state.Grants {
+		rule := newKnockRule(obj)
+		if rule.Expire() {
+			z.record("expire", obj.Name, rule, "system")
+			continue
+		}
+		z.restored[obj.Name] = append(z.restored[obj.Name], rule)
+	}
+	for _, obj := range state.Pending {
+		z.pending[obj.Id] = &zRequest{
+			id:   obj.Id,
+			name: obj.Name,
+			rule: newKnockRule(obj),
+		}
+	}
+}
+
+func (z *ZTrust) save() {
+	state := &zState{
+		History: z.history,
+	}
+	grant := func(name string, rule *KnockRule) {
+		obj := rule.Schema(z.network, name)
+		obj.Age = int(rule.age) // in total to check expired.
+		state.Grants = append(state.Grants, obj)
+	}
+	for name, guest := range z.guests {
+		for _, rule := range guest.List() {
+			grant(name, rule)
+		}
+	}
+	for name, rules := range z.restored {
+		for _, rule := range rules {
+			grant(name, rule)
+		}
+	}
+	for _, req := range z.pending {
+		state.Pending = append(state.Pending, req.Schema(z.network))
+	}
+	if err := os.MkdirAll(filepath.Dir(z.file), 0700); err != nil {
+		z.out.Warn("ZTrust.save: %s", err)
+		return
+	}
+	if err := libol.MarshalSave(state, z.file, true); err != nil {
+		z.out.Warn("ZTrust.save: %s", err)
+	}
+}
+
+func (z *ZTrust) knock(name string, rule *KnockRule, by string) error {
 	guest, ok := z.guests[name]
 	if !ok {
 		return libol.NewErr("Knock: not found %s", name)
 	}
+	guest.AddRule(rule)
+	z.record("grant", name, rule, by)
+	return nil
+}
+
+// Knock opens a destination for a guest by admin.
+func (z *ZTrust) Knock(name string, protocol, dest, port string, age int) error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
 	rule := &KnockRule{
 		protocol:    protocol,
 		destination: dest,
@@ -191,15 +383,260 @@ func (z *ZTrust) Knock(name string, protocol, dest, port string, age int) error
 		age:         int64(age),
 	}
 	z.out.Info("Knock: %s %s", name, rule.Id())
-	guest.AddRule(rule)
+	if err := z.knock(name, rule, "admin"); err != nil {
+		return err
+	}
+	z.save()
+	return nil
+}
+
+// Request opens a destination for a guest by itself if a policy allowed,
+// and it's pending if the policy needs approval.
+func (z *ZTrust) Request(name string, protocol, dest, port string, age int) (schema.KnockRule, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	rule := &KnockRule{
+		protocol:    protocol,
+		destination: dest,
+		port:        port,
+		createAt:    time.Now(),
+		age:         int64(age),
+	}
+	if _, ok := z.guests[name]; !ok {
+		return schema.KnockRule{}, libol.NewErr("Request: not found %s", name)
+	}
+	if z.restricted(name) {
+		z.deny(name, rule)
+		return schema.KnockRule{}, libol.NewErr("Request: device of %s not compliant", name)
+	}
+	p := z.policy(name, protocol, dest, port)
+	if p == nil {
+		z.deny(name, rule)
+		return schema.KnockRule{}, libol.NewErr("Request: %s not allowed", rule.Id())
+	}
+	rule.policy = p.Name
+	if age <= 0 || age > p.MaxAge {
+		rule.age = int64(p.MaxAge)
+	}
+	if p.Approval {
+		pending := 0
+		for _, req := range z.pending {
+			if req.name != name {
+				continue
+			}
+			// same request is waiting already.
+			if req.rule.Id() == rule.Id() {
+				return req.Schema(z.network), nil
+			}
+			pending++
+		}
+		if pending >= zMaxPending {
+			return schema.KnockRule{}, libol.NewErr("Request: too many pending of %s", name)
+		}
+		defer z.save()
+		req := &zRequest{
+			id:   libol.GenString(8),
+			name: name,
+			rule: rule,
+		}
+		z.pending[req.id] = req
+		z.record("request", name, rule, name)
+		return req.Schema(z.network), nil
+	}
+	if err := z.knock(name, rule, name); err != nil {
+		return schema.KnockRule{}, err
+	}
+	z.save()
+	obj := rule.Schema(z.network, name)
+	obj.State = "granted"
+	return obj, nil
+}
+
+// deny audits a request denied, and it's not kept in history to avoid
+// flooding by an user.
+func (z *ZTrust) deny(name string, rule *KnockRule) {
+	z.out.Info("ZTrust.deny: %s %s", name, rule.Id())
+	libol.Audit(libol.AuditEvent{
+		Type:    "ztrust",
+		User:    name,
+		Network: z.network,
+		Action:  "deny",
+		Params:  rule.Id(),
+		Detail:  "by " + name,
+	})
+}
+
+// restricted checks whether a device of the guest isn't compliant with
+// posture, and it's knocked only by admin.
+func (z *ZTrust) restricted(name string) bool {
+	for obj := range cache.Access.List() {
+		if obj == nil {
+			break
+		}
+		if obj.Network == z.network && obj.User == name && obj.Restricted {
+			return true
+		}
+	}
+	return false
+}
+
+func (z *ZTrust) Approve(id string) error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	req, ok := z.pending[id]
+	if !ok {
+		return libol.NewErr("request %s notFound", id)
+	}
+	req.rule.createAt = time.Now()
+	if err := z.knock(req.name, req.rule, "admin"); err != nil {
+		return err
+	}
+	delete(z.pending, id)
+	z.save()
+	return nil
+}
+
+func (z *ZTrust) Reject(id string) error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	req, ok := z.pending[id]
+	if !ok {
+		return libol.NewErr("request %s notFound", id)
+	}
+	delete(z.pending, id)
+	z.record("reject", req.name, req.rule, "admin")
+	z.save()
 	return nil
 }
 
+func (z *ZTrust) ListRequest(call func(obj schema.KnockRule)) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	for _, req := range z.pending {
+		call(req.Schema(z.network))
+	}
+}
+
+func (z *ZTrust) ListAudit(call func(obj schema.ZTrustEvent)) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	for _, obj := range z.history {
+		call(obj)
+	}
+}
+
+func (z *ZTrust) savePolicy() {
+	cfg := co.GetNetwork(z.network)
+	if cfg == nil {
+		return
+	}
+	cfg.ZPolicy = nil
+	for _, p := range z.policies {
+		cfg.ZPolicy = append(cfg.ZPolicy, p.Config())
+	}
+}
+
+// AddPolicy adds a policy, or updates it if existed.
+func (z *ZTrust) AddPolicy(data schema.ZPolicy) error {
+	p, err := NewZPolicy(&co.ZPolicy{
+		Name:     data.Name,
+		Users:    data.Users,
+		Groups:   data.Groups,
+		Services: data.Services,
+		MaxAge:   data.MaxAge,
+		Approval: data.Approval,
+	})
+	if err != nil {
+		return err
+	}
+
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	z.out.Info("ZTrust.AddPolicy: %s", p.Name)
+	for i, older := range z.policies {
+		if older.Name == p.Name {
+			z.policies[i] = p
+			z.savePolicy()
+			return nil
+		}
+	}
+	z.policies = append(z.policies, p)
+	z.savePolicy()
+	return nil
+}
+
+func (z *ZTrust) DelPolicy(name string) error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	for i, p := range z.policies {
+		if p.Name == name {
+			z.out.Info("ZTrust.DelPolicy: %s", name)
+			z.policies = append(z.policies[:i], z.policies[i+1:]...)
+			z.savePolicy()
+			return nil
+		}
+	}
+	return libol.NewErr("policy %s notFound", name)
+}
+
+func (z *ZTrust) ListPolicy(call func(obj schema.ZPolicy)) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	for _, p := range z.policies {
+		call(p.Schema())
+	}
+}
+
+// clear removes grants and requests expired, and returns true if any.
+func (z *ZTrust) clear() bool {
+	changed := false
+	for name, guest := range z.guests {
+		for _, rule := range guest.Clear() {
+			z.record("expire", name, rule, "system")
+			changed = true
+		}
+		z.schedule(guest)
+	}
+	for name, rules := range z.restored {
+		alive := make([]*KnockRule, 0, len(rules))
+		for _, rule := range rules {
+			if rule.Expire() {
+				z.record("expire", name, rule, "system")
+				changed = true
+				continue
+			}
+			alive = append(alive, rule)
+		}
+		z.restored[name] = alive
+		if len(alive) == 0 {
+			delete(z.restored, name)
+		}
+	}
+	now := time.Now().Unix()
+	for id, req := range z.pending {
+		if req.rule.createAt.Unix()+zPendingAge < now {
+			delete(z.pending, id)
+			z.record("expire", req.name, req.rule, "system")
+			changed = true
+		}
+	}
+	return changed
+}
+
 func (z *ZTrust) Update() {
 	for {
-		for _, guest := range z.guests {
-			guest.Clear()
+		z.lock.Lock()
+		if z.clear() {
+			z.save()
 		}
+		z.lock.Unlock()
 		time.Sleep(time.Second * 3)
 	}
 }
@@ -221,25 +658,104 @@ func (z *ZTrust) AddGuest(name, source string) error {
 	if source == "" {
 		return libol.NewErr("AddGuest: invalid source")
 	}
+
+	z.lock.Lock()
+	defer z.lock.Unlock()
 	guest, ok := z.guests[name]
 	if ok {
 		return nil
 	}
 
 	guest = NewZGuest(z.network, name, source)
+	guest.schedule = z.schedules[name]
 	guest.Start()
-	z.addRuleX(cn.IPRule{
+	z.schedule(guest)
+	z.guests[name] = guest
+	// grants saved before restarted.
+	for _, rule := range z.restored[name] {
+		if !rule.Expire() {
+			guest.AddRule(rule)
+		}
+	}
+	delete(z.restored, name)
+
+	return nil
+}
+
+func (z *ZTrust) guestRule(guest *ZGuest) cn.IPRule {
+	return cn.IPRule{
 		Source:  guest.source,
 		Comment: "User " + guest.username + "@" + guest.network,
 		Jump:    guest.Chain(),
-		Order:   "-I",
-	})
-	z.guests[name] = guest
+	}
+}
+
+// schedule jumps a guest to its chain in windows of schedule, otherwise
+// it's denied by default.
+func (z *ZTrust) schedule(guest *ZGuest) {
+	active := cache.Schedule.Active(guest.schedule)
+	if active == guest.active {
+		return
+	}
+	z.out.Info("ZTrust.schedule: %s active: %t", guest.username, active)
+	rule := z.guestRule(guest)
+	if active {
+		rule.Order = "-I"
+		z.addRuleX(rule)
+	} else {
+		z.delRuleX(rule)
+	}
+	guest.active = active
+}
+
+// Scheduled returns guests referencing a schedule.
+func (z *ZTrust) Scheduled(name string) []string {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	refs := make([]string, 0, 4)
+	for guest, schedule := range z.schedules {
+		if schedule == name {
+			refs = append(refs, "ztrust "+z.network+" "+guest)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
 
+// Reschedule checks guests by their schedules at once.
+func (z *ZTrust) Reschedule() {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	for _, guest := range z.guests {
+		z.schedule(guest)
+	}
+}
+
+// SetSchedule limits a guest in windows of a schedule, and clears it
+// if empty.
+func (z *ZTrust) SetSchedule(name, schedule string) error {
+	if schedule != "" && cache.Schedule.Get(schedule) == nil {
+		return libol.NewErr("schedule %s notFound", schedule)
+	}
+
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	z.out.Info("ZTrust.SetSchedule: %s %s", name, schedule)
+	if schedule == "" {
+		delete(z.schedules, name)
+	} else {
+		z.schedules[name] = schedule
+	}
+	if guest, ok := z.guests[name]; ok {
+		guest.schedule = schedule
+		z.schedule(guest)
+	}
 	return nil
 }
 
 func (z *ZTrust) DelGuest(name, source string) error {
+	z.lock.Lock()
+	defer z.lock.Unlock()
 	guest, ok := z.guests[name]
 	if !ok {
 		return nil
@@ -247,24 +763,33 @@ func (z *ZTrust) DelGuest(name, source string) error {
 
 	z.out.Info("ZTrust.DelGuest: %s %s", name, source)
 
-	z.delRuleX(cn.IPRule{
-		Source:  guest.source,
-		Comment: "User " + guest.username + "@" + guest.network,
-		Jump:    guest.Chain(),
-	})
+	if guest.active {
+		z.delRuleX(z.guestRule(guest))
+	}
+	for _, rule := range guest.List() {
+		z.record("revoke", name, rule, "system")
+	}
 	guest.Stop()
 	delete(z.guests, name)
+	z.save()
 
 	return nil
 }
 
 func (z *ZTrust) Start() {
 	z.out.Info("ZTrust.Start")
+	z.lock.Lock()
+	z.load()
+	z.lock.Unlock()
 	libol.Go(z.Update)
 }
 
 func (z *ZTrust) Stop() {
 	z.out.Info("ZTrust.Stop")
+	z.lock.Lock()
+	defer z.lock.Unlock()
+	// grants are kept for restarting.
+	z.save()
 	z.chain.Cancel()
 	for _, guest := range z.guests {
 		guest.Stop()
@@ -272,34 +797,36 @@ func (z *ZTrust) Stop() {
 }
 
 func (z *ZTrust) ListGuest(call func(obj schema.ZGuest)) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
 	for _, guest := range z.guests {
 		obj := schema.ZGuest{
-			Name:    guest.username,
-			Network: guest.network,
-			Address: guest.source,
+			Name:     guest.username,
+			Network:  guest.network,
+			Address:  guest.source,
+			Schedule: guest.schedule,
+			Active:   guest.active,
 		}
 		call(obj)
 	}
 }
 
+// ListKnock returns rules granted and requests pending of a guest.
 func (z *ZTrust) ListKnock(name string, call func(obj schema.KnockRule)) {
-	guest, ok := z.guests[name]
-	if !ok {
-		return
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if guest, ok := z.guests[name]; ok {
+		for _, rule := range guest.List() {
+			obj := rule.Schema(z.network, name)
+			obj.State = "granted"
+			call(obj)
+		}
 	}
-
-	now := time.Now()
-	for _, rule := range guest.rules {
-		createAt := rule.createAt
-		obj := schema.KnockRule{
-			Name:     name,
-			Network:  z.network,
-			Protocol: rule.protocol,
-			Dest:     rule.destination,
-			Port:     rule.port,
-			CreateAt: createAt.Unix(),
-			Age:      int(rule.age + createAt.Unix() - now.Unix()),
+	for _, req := range z.pending {
+		if req.name == name {
+			call(req.Schema(z.network))
 		}
-		call(obj)
 	}
 }
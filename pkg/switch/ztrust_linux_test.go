@@ -0,0 +1,120 @@
+package cswitch
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+func TestZTrustPolicy(t *testing.T) {
+	for _, value := range []string{"tcp", "gre:10.0.0.1", "tcp:10.0.0.256", "icmp:10.0.0.1:8", "tcp:10.0.0.1:70000"} {
+		if _, err := parseZService(value); err == nil {
+			t.Errorf("service %s not invalid", value)
+		}
+	}
+	p, err := NewZPolicy(&co.ZPolicy{
+		Name:     "ssh",
+		Groups:   []string{"dev"},
+		Services: []string{"TCP:10.0.0.0/24:22", "udp:10.0.1.1:1000-2000", "any:10.0.2.1"},
+	})
+	if err != nil {
+		t.Fatalf("policy %s", err)
+	}
+	if p.MaxAge != 3600 {
+		t.Errorf("max age %d", p.MaxAge)
+	}
+	if !p.Allow("alice@fake", []string{"ops", "dev"}) || p.Allow("bob@fake", []string{"ops"}) {
+		t.Errorf("allow by groups")
+	}
+	p.Users = []string{"bob@fake"}
+	if !p.Allow("bob@fake", nil) || p.Allow("bob@other", nil) {
+		t.Errorf("allow by users")
+	}
+	for _, knock := range [][4]string{
+		{"tcp", "10.0.0.9", "22", "yes"},
+		{"tcp", "10.0.0.9", "23", ""},
+		{"tcp", "10.0.0.9", "", ""},
+		{"udp", "10.0.1.1", "1500", "yes"},
+		{"udp", "10.0.1.2", "1500", ""},
+		{"icmp", "10.0.2.1", "", "yes"},
+	} {
+		if p.Match(knock[0], knock[1], knock[2]) != (knock[3] == "yes") {
+			t.Errorf("match %v", knock)
+		}
+	}
+	if _, err := NewZPolicy(&co.ZPolicy{Name: "none"}); err == nil {
+		t.Errorf("policy without services not invalid")
+	}
+}
+
+func TestZTrustRequest(t *testing.T) {
+	z := NewZTrust("fake-zt", 30)
+	z.file = filepath.Join(t.TempDir(), "fake-zt.json")
+	z.loadPolicy([]*co.ZPolicy{
+		{Name: "web", Users: []string{"alice@fake-zt"}, Services: []string{"tcp:10.0.0.1:80"}, Approval: true, MaxAge: 600},
+	})
+	z.guests["alice"] = NewZGuest(z.network, "alice", "10.0.0.2")
+
+	if _, err := z.Request("alice", "tcp", "10.0.0.1", "22", 60); err == nil {
+		t.Errorf("request not denied")
+	}
+	if _, err := z.Request("bob", "tcp", "10.0.0.1", "80", 60); err == nil {
+		t.Errorf("request of offline not denied")
+	}
+	obj, err := z.Request("alice", "tcp", "10.0.0.1", "80", 6000)
+	if err != nil || obj.State != "pending" || obj.Age != 600 || obj.Policy != "web" {
+		t.Fatalf("request %+v %v", obj, err)
+	}
+	knocks := 0
+	z.ListKnock("alice", func(obj schema.KnockRule) {
+		knocks++
+	})
+	if knocks != 1 {
+		t.Errorf("knocks %d", knocks)
+	}
+	// same request is merged, and pending of an user is limited.
+	if same, err := z.Request("alice", "tcp", "10.0.0.1", "80", 60); err != nil || same.Id != obj.Id {
+		t.Errorf("request not merged %+v %v", same, err)
+	}
+	z.loadPolicy([]*co.ZPolicy{
+		{Name: "web", Users: []string{"alice@fake-zt"}, Services: []string{"tcp:10.0.0.1:80", "tcp:10.0.1.1"}, Approval: true, MaxAge: 600},
+	})
+	for i := 1; i < zMaxPending; i++ {
+		if _, err := z.Request("alice", "tcp", "10.0.1.1", strconv.Itoa(i), 60); err != nil {
+			t.Errorf("request %d %v", i, err)
+		}
+	}
+	if _, err := z.Request("alice", "tcp", "10.0.1.1", "100", 60); err == nil {
+		t.Errorf("too many pending not denied")
+	}
+	for id := range z.pending {
+		if id != obj.Id {
+			_ = z.Reject(id)
+		}
+	}
+	if err := z.Reject(obj.Id); err != nil {
+		t.Errorf("reject %s", err)
+	}
+	if err := z.Approve(obj.Id); err == nil {
+		t.Errorf("approve rejected")
+	}
+	actions := ""
+	z.ListAudit(func(obj schema.ZTrustEvent) {
+		actions += obj.Action + " "
+	})
+	if !strings.HasPrefix(actions, "request ") || !strings.HasSuffix(actions, " reject ") || strings.Contains(actions, "deny") {
+		t.Errorf("audits %s", actions)
+	}
+
+	// pending and audits are loaded after restarted.
+	restarted := NewZTrust("fake-zt", 30)
+	restarted.file = z.file
+	restarted.load()
+	if len(restarted.history) != len(z.history) {
+		t.Errorf("history %v", restarted.history)
+	}
+}
@@ -0,0 +1,189 @@
+package cswitch
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/luscis/openlan/pkg/cache"
+	co "github.com/luscis/openlan/pkg/config"
+	"github.com/luscis/openlan/pkg/libol"
+	"github.com/luscis/openlan/pkg/schema"
+)
+
+// zService is a service allowed by policy like tcp:10.0.0.0/24:22,
+// udp:10.0.0.1:1000-2000 or any:10.0.0.1.
+type zService struct {
+	proto string // tcp, udp, icmp or any.
+	dest  *net.IPNet
+	ports [2]int // any port if zero.
+}
+
+func parseZService(value string) (*zService, error) {
+	values := strings.SplitN(strings.ToLower(value), ":", 3)
+	if len(values) < 2 {
+		return nil, libol.NewErr("invalid service %q", value)
+	}
+	s := &zService{proto: values[0]}
+	switch s.proto {
+	case "tcp", "udp", "icmp", "any":
+	default:
+		return nil, libol.NewErr("invalid service %q", value)
+	}
+	dest := values[1]
+	if !strings.Contains(dest, "/") {
+		dest += "/32"
+	}
+	_, ipnet, err := net.ParseCIDR(dest)
+	if err != nil {
+		return nil, libol.NewErr("invalid service %q", value)
+	}
+	s.dest = ipnet
+	if len(values) == 3 {
+		if s.proto != "tcp" && s.proto != "udp" {
+			return nil, libol.NewErr("invalid service %q", value)
+		}
+		ports, err := ParsePorts(values[2])
+		if err != nil {
+			return nil, err
+		}
+		bounds := strings.SplitN(ports, "-", 2)
+		s.ports[0], _ = strconv.Atoi(bounds[0])
+		s.ports[1] = s.ports[0]
+		if len(bounds) == 2 {
+			s.ports[1], _ = strconv.Atoi(bounds[1])
+		}
+	}
+	return s, nil
+}
+
+// Match checks a knock, and a knock of any port is matched only if the
+// service has no port.
+func (s *zService) Match(proto, dest, port string) bool {
+	if s.proto != "any" && s.proto != strings.ToLower(proto) {
+		return false
+	}
+	addr := net.ParseIP(dest)
+	if addr == nil || !s.dest.Contains(addr) {
+		return false
+	}
+	if s.ports[1] == 0 {
+		return true
+	}
+	value, err := strconv.Atoi(port)
+	if err != nil {
+		return false
+	}
+	return value >= s.ports[0] && value <= s.ports[1]
+}
+
+type ZPolicy struct {
+	Name     string
+	Users    []string
+	Groups   []string
+	Services []string
+	MaxAge   int
+	Approval bool
+	services []*zService
+}
+
+func NewZPolicy(cfg *co.ZPolicy) (*ZPolicy, error) {
+	if cfg.Name == "" {
+		return nil, libol.NewErr("policy without name")
+	}
+	cfg.Correct()
+	p := &ZPolicy{
+		Name:     cfg.Name,
+		Users:    cfg.Users,
+		Groups:   cfg.Groups,
+		Services: cfg.Services,
+		MaxAge:   cfg.MaxAge,
+		Approval: cfg.Approval,
+	}
+	if len(p.Services) == 0 {
+		return nil, libol.NewErr("policy %s without services", p.Name)
+	}
+	for _, value := range p.Services {
+		s, err := parseZService(value)
+		if err != nil {
+			return nil, err
+		}
+		p.services = append(p.services, s)
+	}
+	return p, nil
+}
+
+// Allow checks an user by name@network or groups, and all users if neither
+// given.
+func (p *ZPolicy) Allow(name string, groups []string) bool {
+	if len(p.Users) == 0 && len(p.Groups) == 0 {
+		return true
+	}
+	for _, user := range p.Users {
+		if user == name {
+			return true
+		}
+	}
+	for _, group := range p.Groups {
+		for _, value := range groups {
+			if value == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *ZPolicy) Match(proto, dest, port string) bool {
+	for _, s := range p.services {
+		if s.Match(proto, dest, port) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ZPolicy) Config() *co.ZPolicy {
+	return &co.ZPolicy{
+		Name:     p.Name,
+		Users:    p.Users,
+		Groups:   p.Groups,
+		Services: p.Services,
+		MaxAge:   p.MaxAge,
+		Approval: p.Approval,
+	}
+}
+
+func (p *ZPolicy) Schema() schema.ZPolicy {
+	return schema.ZPolicy{
+		Name:     p.Name,
+		Users:    p.Users,
+		Groups:   p.Groups,
+		Services: p.Services,
+		MaxAge:   p.MaxAge,
+		Approval: p.Approval,
+	}
+}
+
+// policy returns the first policy allowing an user to knock, and prefers
+// one without approval.
+func (z *ZTrust) policy(name, proto, dest, port string) *ZPolicy {
+	var groups []string
+	uuid := name + "@" + z.network
+	if user := cache.User.Get(uuid); user != nil {
+		groups = user.Groups
+	}
+	var found *ZPolicy
+	for _, p := range z.policies {
+		if !p.Allow(uuid, groups) || !p.Match(proto, dest, port) {
+			continue
+		}
+		if !p.Approval {
+			return p
+		}
+		if found == nil {
+			found = p
+		}
+	}
+	return found
+}
@@ -28,3 +28,27 @@ func NewTUN(ifName string) (ifce *Interface, err error) {
 	config.Name = ifName
 	return openDev(config)
 }
+
+// NewQueues creates a TUN/TAP interface with multiple queues by MultiQueue,
+// and each queue is returned as an interface of same name. All of them should
+// be closed to destroy the interface.
+func NewQueues(config Config, queues int) ([]*Interface, error) {
+	if queues < 1 {
+		queues = 1
+	}
+	config.MultiQueue = true
+	ifces := make([]*Interface, 0, queues)
+	for i := 0; i < queues; i++ {
+		ifce, err := New(config)
+		if err != nil {
+			for _, obj := range ifces {
+				_ = obj.Close()
+			}
+			return nil, err
+		}
+		// attach other queues to the interface created firstly.
+		config.Name = ifce.Name()
+		ifces = append(ifces, ifce)
+	}
+	return ifces, nil
+}